@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/Kappeh/RecordBot/compat"
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// compatFormats are the formats a compatibility matrix can be exported as
+var compatFormats = []string{"csv", "markdown"}
+
+// compatBuild is a row of the json output of a compatibility matrix
+type compatBuild struct {
+	ID       string            `json:"id"`
+	Name     string            `json:"name"`
+	Summary  string            `json:"summary"`
+	Statuses map[string]string `json:"statuses"`
+	// Inferred is only set with -infer and is kept apart from
+	// the statuses, which are the ones that have been entered
+	Inferred map[string]compatInferred `json:"inferred,omitempty"`
+}
+
+// compatInferred is an inferred status in the json output
+// of a compatibility matrix
+type compatInferred struct {
+	Status     string `json:"status"`
+	Confidence string `json:"confidence"`
+}
+
+// compatReport is the json output of a compatibility matrix
+type compatReport struct {
+	EditionID string        `json:"edition_id"`
+	Versions  []string      `json:"versions"`
+	Builds    []compatBuild `json:"builds"`
+	Conflicts []string      `json:"conflicts,omitempty"`
+}
+
+// runCompat exports the compatibility matrix of a set of builds
+func runCompat(path string, p printer, args []string) error {
+	fs := flag.NewFlagSet("compat", flag.ContinueOnError)
+	var f compat.Filter
+	fs.StringVar(&f.EditionID, "edition", "", "id of the edition of the builds")
+	fs.StringVar(&f.BuildClassID, "class", "", "id of the build class of the builds")
+	fs.StringVar(&f.Name, "name", "", "text the names of the builds contain")
+	fs.StringVar(&f.From, "from", "", "first version of the matrix")
+	fs.StringVar(&f.To, "to", "", "last version of the matrix")
+	fs.BoolVar(&f.IncludeUnverified, "unverified", false, "include builds that haven't been verified")
+	builds := fs.String("builds", "", "comma separated ids of the builds to include")
+	format := fs.String("format", "csv", "output format: "+strings.Join(compatFormats, ", "))
+	summary := fs.Bool("summary", false, "output a summary of each build instead of every version")
+	infer := fs.Bool("infer", false, "include the likely statuses of versions the builds haven't been tested in")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return &usageError{fmt.Sprintf("unexpected argument %q", fs.Arg(0))}
+	}
+	if f.EditionID == "" {
+		return &usageError{"missing -edition"}
+	}
+	if *format != "csv" && *format != "markdown" {
+		return &usageError{fmt.Sprintf("unknown format %q", *format)}
+	}
+	for _, id := range strings.Split(*builds, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			f.BuildIDs = append(f.BuildIDs, id)
+		}
+	}
+
+	db, err := database.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to open database")
+	}
+	defer db.Close()
+
+	m, err := compat.Build(db, f)
+	if err != nil {
+		return err
+	}
+	inf := m.Infer()
+	if p.json {
+		report := compatReport{EditionID: m.Edition.ID, Versions: []string{}, Builds: []compatBuild{}}
+		for _, v := range m.Versions {
+			report.Versions = append(report.Versions, v.Number().String())
+		}
+		for i, b := range m.Builds {
+			cb := compatBuild{ID: b.ID, Name: b.Name, Summary: m.Summary(i), Statuses: map[string]string{}}
+			for j, c := range m.Cells[i] {
+				cb.Statuses[report.Versions[j]] = c.Name()
+				if ic := inf.Cells[i][j]; *infer && ic.Inferred {
+					if cb.Inferred == nil {
+						cb.Inferred = map[string]compatInferred{}
+					}
+					cb.Inferred[report.Versions[j]] = compatInferred{ic.Status.Name, ic.Confidence.String()}
+				}
+			}
+			report.Builds = append(report.Builds, cb)
+		}
+		if *infer {
+			for _, c := range inf.Conflicts {
+				report.Conflicts = append(report.Conflicts, m.Builds[c.Build].Name+": "+m.DescribeConflict(c))
+			}
+		}
+		return p.encode(report)
+	}
+	switch {
+	case *format == "csv" && *summary:
+		return m.WriteSummaryCSV(p.w)
+	case *format == "csv" && *infer:
+		return m.WriteInferredCSV(p.w, inf)
+	case *format == "csv":
+		return m.WriteCSV(p.w)
+	case *summary:
+		return m.WriteSummaryMarkdown(p.w)
+	case *infer:
+		return m.WriteInferredMarkdown(p.w, inf)
+	default:
+		return m.WriteMarkdown(p.w)
+	}
+}
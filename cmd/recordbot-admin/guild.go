@@ -0,0 +1,163 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// plannedChannel is a channel planned by onboarding a guild
+type plannedChannel struct {
+	Purpose      string `json:"purpose"`
+	Name         string `json:"name"`
+	Category     bool   `json:"category"`
+	Setting      string `json:"setting,omitempty"`
+	RecordTypeID string `json:"record_type_id,omitempty"`
+}
+
+// cells gets the values shown in table output
+func (c plannedChannel) cells() []string {
+	kind := "text"
+	if c.Category {
+		kind = "category"
+	}
+	target := c.Setting
+	if target == "" {
+		target = "record type " + c.RecordTypeID
+	}
+	return []string{c.Purpose, c.Name, kind, target}
+}
+
+// plannedChannelColumns are the headings of the onboard report
+var plannedChannelColumns = []string{"PURPOSE", "NAME", "TYPE", "STORED IN"}
+
+// onboardReport is the json output of onboarding a guild
+type onboardReport struct {
+	GuildID         string           `json:"guild_id"`
+	SettingsCreated bool             `json:"settings_created"`
+	Channels        []plannedChannel `json:"channels"`
+}
+
+// removedRows is the number of rows removed from a table
+type removedRows struct {
+	Table   string `json:"table"`
+	Removed int    `json:"removed"`
+}
+
+// cells gets the values shown in table output
+func (r removedRows) cells() []string {
+	return []string{r.Table, fmt.Sprint(r.Removed)}
+}
+
+// removedRowsColumns are the headings of the offboard report
+var removedRowsColumns = []string{"TABLE", "REMOVED"}
+
+// offboardReport is the json output of offboarding a guild
+type offboardReport struct {
+	GuildID   string        `json:"guild_id"`
+	Mode      string        `json:"mode"`
+	ArchiveID string        `json:"archive_id,omitempty"`
+	Removed   []removedRows `json:"removed"`
+}
+
+// runGuild onboards or offboards a guild
+func runGuild(path string, p printer, args []string) error {
+	if len(args) == 0 {
+		return &usageError{"missing action"}
+	}
+	action, args := args[0], args[1:]
+	fs := flag.NewFlagSet("guild "+action, flag.ContinueOnError)
+	purge := false
+	switch action {
+	case "onboard":
+	case "offboard":
+		fs.BoolVar(&purge, "purge", false, "remove the guild's rows without archiving them")
+	default:
+		return &usageError{fmt.Sprintf("unknown action %q", action)}
+	}
+	// The id may be given before or after the flags
+	id := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		id, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if id == "" && fs.NArg() > 0 {
+		id = fs.Arg(0)
+	} else if fs.NArg() > 0 {
+		return &usageError{fmt.Sprintf("unexpected argument %q", fs.Arg(0))}
+	}
+	if id == "" {
+		return &usageError{"missing guild id"}
+	}
+
+	db, err := database.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to open database")
+	}
+	defer db.Close()
+
+	if action == "onboard" {
+		report, err := db.OnboardGuild(id)
+		if err != nil {
+			return err
+		}
+		channels := []plannedChannel{}
+		rows := []row{}
+		for _, pc := range report.Channels {
+			c := plannedChannel{
+				Purpose:      pc.Purpose.String(),
+				Name:         pc.Name,
+				Category:     pc.Category,
+				Setting:      pc.SettingKey,
+				RecordTypeID: pc.RecordTypeID,
+			}
+			channels = append(channels, c)
+			rows = append(rows, c)
+		}
+		if p.json {
+			return p.encode(onboardReport{report.GuildID, report.SettingsCreated, channels})
+		}
+		if report.SettingsCreated {
+			fmt.Fprintf(p.w, "created settings for guild %s\n", id)
+		} else {
+			fmt.Fprintf(p.w, "guild %s was already onboarded\n", id)
+		}
+		return p.rows(plannedChannelColumns, rows)
+	}
+
+	mode := database.OffboardArchive
+	if purge {
+		mode = database.OffboardPurge
+	}
+	report, err := db.OffboardGuild(id, mode)
+	if err != nil {
+		return err
+	}
+	tables := []string{}
+	for table := range report.Removed {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	removed := []removedRows{}
+	rows := []row{}
+	for _, table := range tables {
+		r := removedRows{table, report.Removed[table]}
+		removed = append(removed, r)
+		rows = append(rows, r)
+	}
+	if p.json {
+		return p.encode(offboardReport{report.GuildID, report.Mode.String(), report.ArchiveID, removed})
+	}
+	if report.ArchiveID != "" {
+		fmt.Fprintf(p.w, "archived %d rows of guild %s as archive %s\n", report.Total(), id, report.ArchiveID)
+	} else {
+		fmt.Fprintf(p.w, "purged %d rows of guild %s\n", report.Total(), id)
+	}
+	return p.rows(removedRowsColumns, rows)
+}
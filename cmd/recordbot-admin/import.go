@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// errDryRun rolls back the import transaction of a dry run
+var errDryRun = errors.New("dry run")
+
+// importResult is the outcome of importing one kind of row
+type importResult struct {
+	Kind    string `json:"kind"`
+	Created int    `json:"created"`
+	Skipped int    `json:"skipped"`
+}
+
+// cells gets the values shown in table output
+func (r importResult) cells() []string {
+	return []string{r.Kind, fmt.Sprint(r.Created), fmt.Sprint(r.Skipped)}
+}
+
+// importColumns are the headings of the import report
+var importColumns = []string{"KIND", "CREATED", "SKIPPED"}
+
+// readImport reads an import file, or standard input if path is -
+// The file is a json object with a list of rows for each kind, e.g.
+//
+//	{
+//		"editions": [{"name": "Java Edition"}],
+//		"versions": [{"edition": "Java Edition", "major": 1, "minor": 14, "released": "2019-04-23"}]
+//	}
+func readImport(path string) (map[string][]fields, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read import file")
+	}
+	raw := map[string][]json.RawMessage{}
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return nil, errors.Wrap(err, "failed to parse import file")
+	}
+	result := map[string][]fields{}
+	for name, items := range raw {
+		k, ok := findKind(name)
+		if !ok {
+			return nil, errors.Errorf("unknown kind %q in import file", name)
+		}
+		for i, item := range items {
+			d := json.NewDecoder(bytes.NewReader(item))
+			d.DisallowUnknownFields()
+			var f fields
+			if err = d.Decode(&f); err != nil {
+				return nil, errors.Wrapf(err, "failed to parse %s %d", k.singular, i+1)
+			}
+			result[k.name] = append(result[k.name], f)
+		}
+	}
+	return result, nil
+}
+
+// importAll creates the rows of an import file in a single transaction
+// Rows that already exist are skipped so a file can be imported
+// more than once. Nothing is changed if any row fails
+func importAll(db *database.Database, items map[string][]fields, dryRun bool) ([]row, error) {
+	results := []row{}
+	err := db.Transaction(func(tx *database.Database) error {
+		for _, k := range kinds {
+			result := importResult{Kind: k.name}
+			for i, f := range items[k.name] {
+				if _, ok, err := k.find(tx, f); err != nil {
+					return errors.Wrapf(err, "failed to import %s %d", k.singular, i+1)
+				} else if ok {
+					result.Skipped++
+					continue
+				}
+				if _, err := k.create(tx, f); err != nil {
+					return errors.Wrapf(err, "failed to import %s %d", k.singular, i+1)
+				}
+				result.Created++
+			}
+			results = append(results, result)
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && err != errDryRun {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,516 @@
+package main
+
+import (
+	"flag"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// dateLayout is the layout of version release dates
+const dateLayout = "2006-01-02"
+
+// colourPattern matches embed colours such as #00ff00
+var colourPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// fields are the values given when creating or editing a row
+// They're read from command line flags or from an import file
+type fields struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	EmbedColour string `json:"embed_colour"`
+	Rule        string `json:"rule"`
+	Edition     string `json:"edition"`
+	Major       int    `json:"major"`
+	Minor       int    `json:"minor"`
+	Patch       int    `json:"patch"`
+	Revision    int    `json:"revision"`
+	Type        string `json:"type"`
+	PreRelease  int    `json:"pre_release"`
+	Snapshot    string `json:"snapshot"`
+	Released    string `json:"released"`
+
+	// set contains the names of the flags that were given
+	set map[string]bool
+}
+
+// has indicates whether a field was given
+func (f fields) has(name string) bool {
+	return f.set[name]
+}
+
+// row is a row of reference data to be output
+type row interface {
+	// cells gets the values shown in table output
+	cells() []string
+}
+
+// kind is a type of reference data that can be managed
+type kind struct {
+	// name is the plural name used on the command line
+	name string
+	// singular is the name of a single row
+	singular string
+	// flags are the names of the fields that can be given
+	flags []string
+	// columns are the headings of table output
+	columns []string
+
+	list   func(db *database.Database) ([]row, error)
+	create func(db *database.Database, f fields) (row, error)
+	edit   func(db *database.Database, id string, f fields) (row, bool, error)
+	delete func(db *database.Database, id string) (row, bool, error)
+	// find finds an existing row matching the fields so that
+	// importing the same file twice doesn't create duplicates
+	find func(db *database.Database, f fields) (row, bool, error)
+}
+
+// kinds are the types of reference data in the order they're imported
+// Editions come before versions so that versions can refer to them
+var kinds = []*kind{
+	{
+		name:     "editions",
+		singular: "edition",
+		flags:    []string{"name", "description"},
+		columns:  []string{"ID", "NAME", "DESCRIPTION"},
+		list: func(db *database.Database) ([]row, error) {
+			es, err := db.Editions()
+			rows := []row{}
+			for _, e := range es {
+				rows = append(rows, newEditionRow(e))
+			}
+			return rows, errors.Wrap(err, "failed to get editions")
+		},
+		create: func(db *database.Database, f fields) (row, error) {
+			if err := requireName(f); err != nil {
+				return nil, err
+			}
+			e, err := db.EditionCreate(f.Name, f.Description)
+			return newEditionRow(e), errors.Wrap(err, "failed to create edition")
+		},
+		edit: func(db *database.Database, id string, f fields) (row, bool, error) {
+			e, ok, err := db.Edition(id)
+			if err != nil || !ok {
+				return nil, false, errors.Wrap(err, "failed to get edition")
+			}
+			setString(&e.Name, f, "name", f.Name)
+			setString(&e.Description, f, "description", f.Description)
+			e, ok, err = db.EditionEdit(id, e.Name, e.Description)
+			return newEditionRow(e), ok, errors.Wrap(err, "failed to edit edition")
+		},
+		delete: func(db *database.Database, id string) (row, bool, error) {
+			e, ok, err := db.EditionDelete(id)
+			return newEditionRow(e), ok, errors.Wrap(err, "failed to delete edition")
+		},
+		find: func(db *database.Database, f fields) (row, bool, error) {
+			es, err := db.Editions()
+			if err != nil {
+				return nil, false, errors.Wrap(err, "failed to get editions")
+			}
+			for _, e := range es {
+				if strings.EqualFold(e.Name, f.Name) {
+					return newEditionRow(e), true, nil
+				}
+			}
+			return nil, false, nil
+		},
+	},
+	{
+		name:     "classes",
+		singular: "build class",
+		flags:    []string{"name", "description", "colour", "rule"},
+		columns:  []string{"ID", "NAME", "COLOUR", "RULE", "DESCRIPTION"},
+		list: func(db *database.Database) ([]row, error) {
+			bcs, err := db.BuildClasses()
+			rows := []row{}
+			for _, bc := range bcs {
+				rows = append(rows, newBuildClassRow(bc))
+			}
+			return rows, errors.Wrap(err, "failed to get build classes")
+		},
+		create: func(db *database.Database, f fields) (row, error) {
+			if err := requireName(f); err != nil {
+				return nil, err
+			}
+			if err := checkColour(f.EmbedColour); err != nil {
+				return nil, err
+			}
+			bc, err := db.BuildClassCreate(f.Name, f.Description, f.EmbedColour, f.Rule)
+			return newBuildClassRow(bc), errors.Wrap(err, "failed to create build class")
+		},
+		edit: func(db *database.Database, id string, f fields) (row, bool, error) {
+			bc, ok, err := db.BuildClass(id)
+			if err != nil || !ok {
+				return nil, false, errors.Wrap(err, "failed to get build class")
+			}
+			setString(&bc.Name, f, "name", f.Name)
+			setString(&bc.Description, f, "description", f.Description)
+			setString(&bc.EmbedColour, f, "colour", f.EmbedColour)
+			setString(&bc.Rule, f, "rule", f.Rule)
+			if err = checkColour(bc.EmbedColour); err != nil {
+				return nil, false, err
+			}
+			bc, ok, err = db.BuildClassEdit(id, bc.Name, bc.Description, bc.EmbedColour, bc.Rule)
+			return newBuildClassRow(bc), ok, errors.Wrap(err, "failed to edit build class")
+		},
+		delete: func(db *database.Database, id string) (row, bool, error) {
+			bc, ok, err := db.BuildClassDelete(id)
+			return newBuildClassRow(bc), ok, errors.Wrap(err, "failed to delete build class")
+		},
+		find: func(db *database.Database, f fields) (row, bool, error) {
+			bcs, err := db.BuildClasses()
+			if err != nil {
+				return nil, false, errors.Wrap(err, "failed to get build classes")
+			}
+			for _, bc := range bcs {
+				if strings.EqualFold(bc.Name, f.Name) {
+					return newBuildClassRow(bc), true, nil
+				}
+			}
+			return nil, false, nil
+		},
+	},
+	{
+		name:     "recordtypes",
+		singular: "record type",
+		flags:    []string{"name", "description"},
+		columns:  []string{"ID", "NAME", "DESCRIPTION"},
+		list: func(db *database.Database) ([]row, error) {
+			rts, err := db.RecordTypes()
+			rows := []row{}
+			for _, rt := range rts {
+				rows = append(rows, newRecordTypeRow(rt))
+			}
+			return rows, errors.Wrap(err, "failed to get record types")
+		},
+		create: func(db *database.Database, f fields) (row, error) {
+			if err := requireName(f); err != nil {
+				return nil, err
+			}
+			rt, err := db.RecordTypeCreate(f.Name, f.Description)
+			return newRecordTypeRow(rt), errors.Wrap(err, "failed to create record type")
+		},
+		edit: func(db *database.Database, id string, f fields) (row, bool, error) {
+			rt, ok, err := db.RecordType(id)
+			if err != nil || !ok {
+				return nil, false, errors.Wrap(err, "failed to get record type")
+			}
+			setString(&rt.Name, f, "name", f.Name)
+			setString(&rt.Description, f, "description", f.Description)
+			rt, ok, err = db.RecordTypeEdit(id, rt.Name, rt.Description)
+			return newRecordTypeRow(rt), ok, errors.Wrap(err, "failed to edit record type")
+		},
+		delete: func(db *database.Database, id string) (row, bool, error) {
+			rt, ok, err := db.RecordTypeDelete(id)
+			return newRecordTypeRow(rt), ok, errors.Wrap(err, "failed to delete record type")
+		},
+		find: func(db *database.Database, f fields) (row, bool, error) {
+			rts, err := db.RecordTypes()
+			if err != nil {
+				return nil, false, errors.Wrap(err, "failed to get record types")
+			}
+			for _, rt := range rts {
+				if strings.EqualFold(rt.Name, f.Name) {
+					return newRecordTypeRow(rt), true, nil
+				}
+			}
+			return nil, false, nil
+		},
+	},
+	{
+		name:     "statuses",
+		singular: "status",
+		flags:    []string{"name", "description"},
+		columns:  []string{"ID", "NAME", "DESCRIPTION"},
+		list: func(db *database.Database) ([]row, error) {
+			ss, err := db.Statuses()
+			rows := []row{}
+			for _, s := range ss {
+				rows = append(rows, newStatusRow(s))
+			}
+			return rows, errors.Wrap(err, "failed to get statuses")
+		},
+		create: func(db *database.Database, f fields) (row, error) {
+			if err := requireName(f); err != nil {
+				return nil, err
+			}
+			s, err := db.StatusCreate(f.Name, f.Description)
+			return newStatusRow(s), errors.Wrap(err, "failed to create status")
+		},
+		edit: func(db *database.Database, id string, f fields) (row, bool, error) {
+			s, ok, err := db.Status(id)
+			if err != nil || !ok {
+				return nil, false, errors.Wrap(err, "failed to get status")
+			}
+			setString(&s.Name, f, "name", f.Name)
+			setString(&s.Description, f, "description", f.Description)
+			s, ok, err = db.StatusEdit(id, s.Name, s.Description)
+			return newStatusRow(s), ok, errors.Wrap(err, "failed to edit status")
+		},
+		delete: func(db *database.Database, id string) (row, bool, error) {
+			s, ok, err := db.StatusDelete(id)
+			return newStatusRow(s), ok, errors.Wrap(err, "failed to delete status")
+		},
+		find: func(db *database.Database, f fields) (row, bool, error) {
+			ss, err := db.Statuses()
+			if err != nil {
+				return nil, false, errors.Wrap(err, "failed to get statuses")
+			}
+			for _, s := range ss {
+				if strings.EqualFold(s.Name, f.Name) {
+					return newStatusRow(s), true, nil
+				}
+			}
+			return nil, false, nil
+		},
+	},
+	{
+		name:     "versions",
+		singular: "version",
+		flags:    []string{"edition", "major", "minor", "patch", "revision", "type", "pre-release", "snapshot", "name", "description", "released"},
+		columns:  []string{"ID", "EDITION", "VERSION", "TYPE", "NAME", "RELEASED"},
+		list: func(db *database.Database) ([]row, error) {
+			vs, err := db.Versions()
+			rows := []row{}
+			for _, v := range vs {
+				rows = append(rows, newVersionRow(v))
+			}
+			return rows, errors.Wrap(err, "failed to get versions")
+		},
+		create: func(db *database.Database, f fields) (row, error) {
+			v := database.Version{}
+			if err := applyVersion(db, &v, f, true); err != nil {
+				return nil, err
+			}
+			v, err := db.VersionCreate(v)
+			return newVersionRow(v), errors.Wrap(err, "failed to create version")
+		},
+		edit: func(db *database.Database, id string, f fields) (row, bool, error) {
+			v, ok, err := db.Version(id)
+			if err != nil || !ok {
+				return nil, false, errors.Wrap(err, "failed to get version")
+			}
+			if err = applyVersion(db, &v, f, false); err != nil {
+				return nil, false, err
+			}
+			v, ok, err = db.VersionEdit(id, v)
+			return newVersionRow(v), ok, errors.Wrap(err, "failed to edit version")
+		},
+		delete: func(db *database.Database, id string) (row, bool, error) {
+			v, ok, err := db.VersionDelete(id)
+			return newVersionRow(v), ok, errors.Wrap(err, "failed to delete version")
+		},
+		find: func(db *database.Database, f fields) (row, bool, error) {
+			editionID, err := resolveEdition(db, f.Edition)
+			if err != nil {
+				return nil, false, err
+			}
+			vs, err := db.Versions()
+			if err != nil {
+				return nil, false, errors.Wrap(err, "failed to get versions")
+			}
+			want := database.Version{}
+			if err = applyNumber(&want, f, true); err != nil {
+				return nil, false, err
+			}
+			for _, v := range vs {
+				if v.EditionID == editionID && v.Compare(want) == 0 {
+					return newVersionRow(v), true, nil
+				}
+			}
+			return nil, false, nil
+		},
+	},
+}
+
+// findKind gets a kind by its name
+// Singular names and build-classes are accepted too
+func findKind(name string) (*kind, bool) {
+	name = strings.ToLower(name)
+	for _, k := range kinds {
+		if name == k.name || name == strings.Replace(k.singular, " ", "", -1) {
+			return k, true
+		}
+	}
+	switch name {
+	case "buildclasses", "buildclass":
+		return kinds[1], true
+	}
+	return nil, false
+}
+
+// bind adds the flags of a kind to a flag set
+// The returned function gets the values once the flags are parsed
+func (k *kind) bind(fs *flag.FlagSet) func() fields {
+	f := &fields{}
+	for _, name := range k.flags {
+		switch name {
+		case "name":
+			fs.StringVar(&f.Name, name, "", "the name of the "+k.singular)
+		case "description":
+			fs.StringVar(&f.Description, name, "", "the description of the "+k.singular)
+		case "colour":
+			fs.StringVar(&f.EmbedColour, name, "", "the embed colour, such as #00ff00")
+		case "rule":
+			fs.StringVar(&f.Rule, name, "", "the rule its builds follow, "+database.BuildClassRuleDoor+" or "+database.BuildClassRuleExtender+", or none if empty")
+		case "edition":
+			fs.StringVar(&f.Edition, name, "", "the id or name of the edition")
+		case "major":
+			fs.IntVar(&f.Major, name, 0, "the major version number")
+		case "minor":
+			fs.IntVar(&f.Minor, name, 0, "the minor version number")
+		case "patch":
+			fs.IntVar(&f.Patch, name, 0, "the patch version number")
+		case "revision":
+			fs.IntVar(&f.Revision, name, 0, "the fourth version number, such as 56 in 1.16.100.56")
+		case "type":
+			fs.StringVar(&f.Type, name, "release", "the type of version, one of release, snapshot, pre-release, release-candidate or preview")
+		case "pre-release":
+			fs.IntVar(&f.PreRelease, name, 0, "the number of a pre-release or release candidate, such as 3 in 1.16-pre3")
+		case "snapshot":
+			fs.StringVar(&f.Snapshot, name, "", "the name of a snapshot, such as 20w14a")
+		case "released":
+			fs.StringVar(&f.Released, name, "", "the release date as "+dateLayout)
+		}
+	}
+	return func() fields {
+		f.set = map[string]bool{}
+		fs.Visit(func(fl *flag.Flag) {
+			f.set[fl.Name] = true
+		})
+		return *f
+	}
+}
+
+// requireName checks that a name was given
+func requireName(f fields) error {
+	if strings.TrimSpace(f.Name) == "" {
+		return errors.New("a name is required")
+	}
+	return nil
+}
+
+// checkColour checks that an embed colour is valid
+// An empty colour is allowed
+func checkColour(colour string) error {
+	if colour != "" && !colourPattern.MatchString(colour) {
+		return errors.Errorf("colour %q must be like #00ff00", colour)
+	}
+	return nil
+}
+
+// setString sets a value if its field was given
+func setString(dst *string, f fields, name, value string) {
+	if f.has(name) {
+		*dst = value
+	}
+}
+
+// applyVersion applies fields to a version
+// When creating, every field is applied and an edition is required
+func applyVersion(db *database.Database, v *database.Version, f fields, create bool) error {
+	if create || f.has("edition") {
+		editionID, err := resolveEdition(db, f.Edition)
+		if err != nil {
+			return err
+		}
+		v.EditionID = editionID
+	}
+	if err := applyNumber(v, f, create); err != nil {
+		return err
+	}
+	if create || f.has("name") {
+		v.Name = f.Name
+	}
+	if create || f.has("description") {
+		v.Description = f.Description
+	}
+	if (create || f.has("released")) && f.Released != "" {
+		t, err := time.Parse(dateLayout, f.Released)
+		if err != nil {
+			return errors.Errorf("release date %q must be like %s", f.Released, dateLayout)
+		}
+		v.VersionTimestamp = database.Timestamp(t)
+	}
+	return nil
+}
+
+// applyNumber applies the version number fields to a version
+// and checks that they describe a version
+// Snapshots are given by name along with the numbers of the
+// release they lead up to
+func applyNumber(v *database.Version, f fields, create bool) error {
+	if create || f.has("major") {
+		v.MajorVersion = f.Major
+	}
+	if create || f.has("minor") {
+		v.MinorVersion = f.Minor
+	}
+	if create || f.has("patch") {
+		v.Patch = f.Patch
+	}
+	if create || f.has("revision") {
+		v.Revision = f.Revision
+	}
+	if create || f.has("type") {
+		t, err := database.ParseVersionType(f.Type)
+		if err != nil {
+			return err
+		}
+		v.Type = t
+	}
+	if create || f.has("pre-release") {
+		v.PreRelease = f.PreRelease
+	}
+	if create || f.has("snapshot") {
+		v.Snapshot = strings.ToLower(f.Snapshot)
+	}
+	if v.MajorVersion < 0 || v.MinorVersion < 0 || v.Patch < 0 || v.Revision < 0 {
+		return errors.New("version numbers can't be negative")
+	}
+	switch v.Type {
+	case database.VersionSnapshot:
+		if n, err := database.ParseVersionNumber(v.Snapshot); err != nil || n.Type != database.VersionSnapshot {
+			return errors.Errorf("snapshot %q must be named like 20w14a", v.Snapshot)
+		}
+	case database.VersionPreRelease, database.VersionReleaseCandidate:
+		if v.PreRelease < 1 {
+			return errors.Errorf("a %s needs a pre-release number of at least 1", v.Type)
+		}
+	}
+	if v.Type != database.VersionSnapshot && v.Snapshot != "" {
+		return errors.New("only snapshots have a snapshot name")
+	}
+	if v.Type != database.VersionPreRelease && v.Type != database.VersionReleaseCandidate && v.PreRelease != 0 {
+		return errors.New("only pre-releases and release candidates have a pre-release number")
+	}
+	return nil
+}
+
+// resolveEdition gets the id of an edition from its id or name
+func resolveEdition(db *database.Database, s string) (string, error) {
+	if s == "" {
+		return "", errors.New("an edition is required")
+	}
+	if _, err := strconv.Atoi(s); err == nil {
+		if _, ok, err := db.Edition(s); err != nil {
+			return "", errors.Wrap(err, "failed to get edition")
+		} else if ok {
+			return s, nil
+		}
+	}
+	es, err := db.Editions()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get editions")
+	}
+	for _, e := range es {
+		if strings.EqualFold(e.Name, s) {
+			return e.ID, nil
+		}
+	}
+	return "", errors.Errorf("edition %q doesn't exist", s)
+}
@@ -0,0 +1,254 @@
+// Command recordbot-admin manages the reference data of the bot's
+// database: editions, build classes, record types, statuses and versions
+//
+// Usage:
+//
+//	recordbot-admin [-db path | -config path] [-json] <kind> list
+//	recordbot-admin [-db path | -config path] [-json] [-untested] <kind> create [flags]
+//	recordbot-admin [-db path | -config path] [-json] <kind> edit <id> [flags]
+//	recordbot-admin [-db path | -config path] [-json] <kind> delete <id>
+//	recordbot-admin [-db path | -config path] [-json] [-untested] import [-dry-run] <file>
+//	recordbot-admin [-db path | -config path] [-json] guild onboard <guild id>
+//	recordbot-admin [-db path | -config path] [-json] guild offboard [-purge] <guild id>
+//	recordbot-admin [-db path | -config path] [-json] compat -edition id [flags]
+//	recordbot-admin [-db path | -config path] [-json] retest [-edition id]
+//	recordbot-admin [-db path | -config path] [-json] [-untested] manifest -edition id [-format java|bedrock] [-dry-run] [-releases] <file>
+//	recordbot-admin [-db path | -config path] [-json] violations
+//
+// The kinds are editions, classes, recordtypes, statuses and versions.
+// Use -h after an action to see the flags of a kind
+//
+// With -untested, every verified build is given the untested status
+// in the versions that are created, so that they can be re-tested
+//
+// manifest imports the versions of Mojang's version_manifest.json or
+// of a Bedrock version list, which can be imported more than once
+//
+// violations lists build versions and build records which link rows of
+// different editions or build classes
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Kappeh/RecordBot/config"
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// usageError is an error caused by invalid arguments
+type usageError struct {
+	message string
+}
+
+// Error gets the message followed by the usage
+func (e *usageError) Error() string {
+	return e.message + "\n" + usage()
+}
+
+// usage describes how to use the command
+func usage() string {
+	names := []string{}
+	for _, k := range kinds {
+		names = append(names, k.name)
+	}
+	return fmt.Sprintf(`usage:
+  recordbot-admin [-db path | -config path] [-json] <kind> list
+  recordbot-admin [-db path | -config path] [-json] [-untested] <kind> create [flags]
+  recordbot-admin [-db path | -config path] [-json] <kind> edit <id> [flags]
+  recordbot-admin [-db path | -config path] [-json] <kind> delete <id>
+  recordbot-admin [-db path | -config path] [-json] [-untested] import [-dry-run] <file>
+  recordbot-admin [-db path | -config path] [-json] guild onboard <guild id>
+  recordbot-admin [-db path | -config path] [-json] guild offboard [-purge] <guild id>
+  recordbot-admin [-db path | -config path] [-json] compat -edition id [flags]
+  recordbot-admin [-db path | -config path] [-json] retest [-edition id]
+  recordbot-admin [-db path | -config path] [-json] [-untested] manifest -edition id [-format java|bedrock] [-dry-run] [-releases] <file>
+  recordbot-admin [-db path | -config path] [-json] violations
+kinds: %s`, strings.Join(names, ", "))
+}
+
+// run runs the command with the given arguments
+func run(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("recordbot-admin", flag.ContinueOnError)
+	dbPath := fs.String("db", "", "path to the database file")
+	configPath := fs.String("config", "", "path to a bot config file to read the database path from")
+	asJSON := fs.Bool("json", false, "output json instead of a table")
+	untested := fs.Bool("untested", false, "give verified builds the untested status in versions that are created")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), usage())
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+	if len(args) == 0 {
+		return &usageError{"missing kind"}
+	}
+	path, err := databasePath(*dbPath, *configPath)
+	if err != nil {
+		return err
+	}
+	p := printer{w: out, json: *asJSON}
+
+	if args[0] == "import" {
+		return runImport(path, p, *untested, args[1:])
+	}
+	if args[0] == "guild" {
+		return runGuild(path, p, args[1:])
+	}
+	if args[0] == "compat" {
+		return runCompat(path, p, args[1:])
+	}
+	if args[0] == "retest" {
+		return runRetest(path, p, args[1:])
+	}
+	if args[0] == "manifest" {
+		return runManifest(path, p, *untested, args[1:])
+	}
+	if args[0] == "violations" {
+		return runViolations(path, p, args[1:])
+	}
+	k, ok := findKind(args[0])
+	if !ok {
+		return &usageError{fmt.Sprintf("unknown kind %q", args[0])}
+	}
+	if len(args) < 2 {
+		return &usageError{"missing action"}
+	}
+	action, args := args[1], args[2:]
+	afs := flag.NewFlagSet(k.name+" "+action, flag.ContinueOnError)
+	var get func() fields
+	switch action {
+	case "create", "edit":
+		get = k.bind(afs)
+	case "list", "delete":
+	default:
+		return &usageError{fmt.Sprintf("unknown action %q", action)}
+	}
+	// The id may be given before or after the flags
+	id := ""
+	if (action == "edit" || action == "delete") && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		id, args = args[0], args[1:]
+	}
+	if err = afs.Parse(args); err != nil {
+		return err
+	}
+	if (action == "edit" || action == "delete") && id == "" {
+		if afs.NArg() == 0 {
+			return &usageError{"missing id"}
+		}
+		id = afs.Arg(0)
+	} else if afs.NArg() > 0 {
+		return &usageError{fmt.Sprintf("unexpected argument %q", afs.Arg(0))}
+	}
+
+	db, err := database.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to open database")
+	}
+	defer db.Close()
+	if *untested {
+		db.OnVersionCreate(database.UntestedHook)
+	}
+
+	switch action {
+	case "list":
+		rows, err := k.list(db)
+		if err != nil {
+			return err
+		}
+		return p.rows(k.columns, rows)
+	case "create":
+		r, err := k.create(db, get())
+		if err != nil {
+			return err
+		}
+		return p.row(k.columns, r)
+	case "edit":
+		f := get()
+		if len(f.set) == 0 {
+			return &usageError{"nothing to edit"}
+		}
+		r, ok, err := k.edit(db, id, f)
+		if err != nil {
+			return err
+		} else if !ok {
+			return errors.Errorf("%s %s doesn't exist", k.singular, id)
+		}
+		return p.row(k.columns, r)
+	default:
+		r, ok, err := k.delete(db, id)
+		if err != nil {
+			return err
+		} else if !ok {
+			return errors.Errorf("%s %s doesn't exist", k.singular, id)
+		}
+		return p.row(k.columns, r)
+	}
+}
+
+// runImport imports rows from a file
+// Verified builds are given the untested status in
+// imported versions if untested is true
+func runImport(path string, p printer, untested bool, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "report what would be imported without changing the database")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return &usageError{"import takes one file"}
+	}
+	items, err := readImport(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	db, err := database.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to open database")
+	}
+	defer db.Close()
+	if untested {
+		db.OnVersionCreate(database.UntestedHook)
+	}
+	results, err := importAll(db, items, *dryRun)
+	if err != nil {
+		return err
+	}
+	return p.rows(importColumns, results)
+}
+
+// databasePath gets the path of the database from the flags
+// A config file is only read for its database path, so the
+// rest of it doesn't need to be valid
+func databasePath(dbPath, configPath string) (string, error) {
+	if dbPath != "" && configPath != "" {
+		return "", &usageError{"-db and -config can't both be given"}
+	}
+	if dbPath != "" {
+		return dbPath, nil
+	}
+	if configPath != "" {
+		c, err := config.Load(configPath)
+		if err != nil {
+			return "", err
+		}
+		return c.DatabasePath, nil
+	}
+	if path, ok := os.LookupEnv(config.EnvPrefix + "DATABASE_PATH"); ok {
+		return path, nil
+	}
+	return database.DefaultPath, nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/Kappeh/RecordBot/manifest"
+	"github.com/pkg/errors"
+)
+
+// manifestRow is a listed version in the manifest import report
+type manifestRow struct {
+	Result  string `json:"result"`
+	ID      string `json:"id,omitempty"`
+	Version string `json:"version"`
+	Type    string `json:"type,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// cells gets the values shown in table output
+func (r manifestRow) cells() []string {
+	return []string{r.Result, r.ID, r.Version, r.Type, r.Reason}
+}
+
+// manifestColumns are the headings of the manifest import report
+var manifestColumns = []string{"RESULT", "ID", "VERSION", "TYPE", "REASON"}
+
+// manifestReport is the json output of importing a version manifest
+type manifestReport struct {
+	EditionID string        `json:"edition_id"`
+	DryRun    bool          `json:"dry_run"`
+	Added     int           `json:"added"`
+	Updated   int           `json:"updated"`
+	Unchanged int           `json:"unchanged"`
+	Skipped   int           `json:"skipped"`
+	Versions  []manifestRow `json:"versions"`
+}
+
+// runManifest imports the versions of a version manifest file
+func runManifest(path string, p printer, untested bool, args []string) error {
+	fs := flag.NewFlagSet("manifest", flag.ContinueOnError)
+	editionID := fs.String("edition", "", "id of the edition to import the versions into")
+	formatName := fs.String("format", "java", "format of the file: java or bedrock")
+	dryRun := fs.Bool("dry-run", false, "report what would be imported without changing the database")
+	releasesOnly := fs.Bool("releases", false, "only import releases")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return &usageError{"manifest takes one file"}
+	}
+	if *editionID == "" {
+		return &usageError{"missing -edition"}
+	}
+	format, ok := manifest.Formats[strings.ToLower(*formatName)]
+	if !ok {
+		return &usageError{fmt.Sprintf("unknown format %q", *formatName)}
+	}
+	f := os.Stdin
+	if fs.Arg(0) != "-" {
+		var err error
+		if f, err = os.Open(fs.Arg(0)); err != nil {
+			return errors.Wrap(err, "failed to open manifest")
+		}
+		defer f.Close()
+	}
+	entries, err := manifest.Read(f, format)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to open database")
+	}
+	defer db.Close()
+	if untested {
+		db.OnVersionCreate(database.UntestedHook)
+	}
+	report, err := manifest.Import(db, *editionID, entries, manifest.Options{DryRun: *dryRun, ReleasesOnly: *releasesOnly})
+	if err != nil {
+		return err
+	}
+
+	versions := []manifestRow{}
+	for _, v := range report.Added {
+		r := manifestRow{Result: "added", ID: v.ID, Version: v.Number().String(), Type: v.Type.String()}
+		if *dryRun {
+			// The version was rolled back, so its id isn't used
+			r.ID = ""
+		}
+		versions = append(versions, r)
+	}
+	for _, v := range report.Updated {
+		versions = append(versions, manifestRow{Result: "updated", ID: v.ID, Version: v.Number().String(), Type: v.Type.String(), Reason: "release time was missing"})
+	}
+	skipped := append([]manifest.Skipped{}, report.Skipped...)
+	sort.SliceStable(skipped, func(i, j int) bool { return skipped[i].Reason < skipped[j].Reason })
+	for _, s := range skipped {
+		versions = append(versions, manifestRow{Result: "skipped", Version: s.ID, Reason: s.Reason})
+	}
+	if p.json {
+		return p.encode(manifestReport{
+			EditionID: *editionID,
+			DryRun:    *dryRun,
+			Added:     len(report.Added),
+			Updated:   len(report.Updated),
+			Unchanged: report.Unchanged,
+			Skipped:   len(report.Skipped),
+			Versions:  versions,
+		})
+	}
+	verb := "added"
+	if *dryRun {
+		verb = "would add"
+	}
+	fmt.Fprintf(p.w, "%s %d versions, updated %d, %d unchanged, %d skipped\n",
+		verb, len(report.Added), len(report.Updated), report.Unchanged, len(report.Skipped))
+	rows := []row{}
+	for _, r := range versions {
+		rows = append(rows, r)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return p.rows(manifestColumns, rows)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// printer writes rows as a table or as json
+type printer struct {
+	w    io.Writer
+	json bool
+}
+
+// rows writes a list of rows
+func (p printer) rows(columns []string, rows []row) error {
+	if p.json {
+		return p.encode(rows)
+	}
+	cells := make([][]string, len(rows))
+	for i, r := range rows {
+		cells[i] = r.cells()
+	}
+	return p.table(columns, cells)
+}
+
+// row writes a single row
+func (p printer) row(columns []string, r row) error {
+	if p.json {
+		return p.encode(r)
+	}
+	return p.table(columns, [][]string{r.cells()})
+}
+
+// table writes cells as aligned columns under a heading
+func (p printer) table(columns []string, cells [][]string) error {
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	if _, err := io.WriteString(tw, strings.Join(columns, "\t")+"\n"); err != nil {
+		return err
+	}
+	for _, line := range cells {
+		for i := range line {
+			// Tabs and new lines would break the alignment
+			line[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(line[i])
+		}
+		if _, err := io.WriteString(tw, strings.Join(line, "\t")+"\n"); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// encode writes a value as indented json
+func (p printer) encode(v interface{}) error {
+	e := json.NewEncoder(p.w)
+	e.SetIndent("", "  ")
+	e.SetEscapeHTML(false)
+	return e.Encode(v)
+}
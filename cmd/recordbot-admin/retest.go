@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// retestRow is a build waiting to be re-tested in a version
+type retestRow struct {
+	BuildID   string `json:"build_id"`
+	Build     string `json:"build"`
+	VersionID string `json:"version_id"`
+	Version   string `json:"version"`
+	Records   int    `json:"records"`
+}
+
+// cells gets the values shown in table output
+func (r retestRow) cells() []string {
+	return []string{r.BuildID, r.Build, r.Version, fmt.Sprint(r.Records)}
+}
+
+// retestColumns are the headings of the re-test queue
+var retestColumns = []string{"BUILD ID", "BUILD", "VERSION", "RECORDS"}
+
+// runRetest lists the builds waiting to be re-tested
+// in new versions, record holders first
+func runRetest(path string, p printer, args []string) error {
+	fs := flag.NewFlagSet("retest", flag.ContinueOnError)
+	editionID := fs.String("edition", "", "id of the edition of the versions")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return &usageError{fmt.Sprintf("unexpected argument %q", fs.Arg(0))}
+	}
+	db, err := database.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to open database")
+	}
+	defer db.Close()
+	queue, err := db.RetestQueue(*editionID)
+	if err != nil {
+		return err
+	}
+	rows := []row{}
+	for _, item := range queue {
+		rows = append(rows, retestRow{
+			BuildID:   item.Build.ID,
+			Build:     item.Build.Name,
+			VersionID: item.Version.ID,
+			Version:   item.Version.Number().String(),
+			Records:   len(item.Records),
+		})
+	}
+	return p.rows(retestColumns, rows)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"time"
+
+	"github.com/Kappeh/RecordBot/database"
+)
+
+// timestamp formats a database timestamp for output
+// Zero timestamps are left empty
+func timestamp(t database.Timestamp) string {
+	if time.Time(t).IsZero() {
+		return ""
+	}
+	return time.Time(t).Format(time.RFC3339)
+}
+
+// editionRow is an edition as it's output
+type editionRow struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Created     string `json:"created"`
+	Edited      string `json:"edited"`
+}
+
+// newEditionRow converts a edition for output
+func newEditionRow(e database.Edition) row {
+	return editionRow{e.ID, e.Name, e.Description, timestamp(e.Timestamp), timestamp(e.EditedTimestamp)}
+}
+
+// cells gets the values shown in table output
+func (r editionRow) cells() []string {
+	return []string{r.ID, r.Name, r.Description}
+}
+
+// buildClassRow is a build class as it's output
+type buildClassRow struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	EmbedColour string `json:"embed_colour"`
+	Rule        string `json:"rule"`
+	Created     string `json:"created"`
+	Edited      string `json:"edited"`
+}
+
+// newBuildClassRow converts a build class for output
+func newBuildClassRow(bc database.BuildClass) row {
+	return buildClassRow{bc.ID, bc.Name, bc.Description, bc.EmbedColour, bc.Rule, timestamp(bc.Timestamp), timestamp(bc.EditedTimestamp)}
+}
+
+// cells gets the values shown in table output
+func (r buildClassRow) cells() []string {
+	return []string{r.ID, r.Name, r.EmbedColour, r.Rule, r.Description}
+}
+
+// recordTypeRow is a record type as it's output
+type recordTypeRow struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Created     string `json:"created"`
+	Edited      string `json:"edited"`
+}
+
+// newRecordTypeRow converts a record type for output
+func newRecordTypeRow(rt database.RecordType) row {
+	return recordTypeRow{rt.ID, rt.Name, rt.Description, timestamp(rt.Timestamp), timestamp(rt.EditedTimestamp)}
+}
+
+// cells gets the values shown in table output
+func (r recordTypeRow) cells() []string {
+	return []string{r.ID, r.Name, r.Description}
+}
+
+// statusRow is a status as it's output
+type statusRow struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Created     string `json:"created"`
+	Edited      string `json:"edited"`
+}
+
+// newStatusRow converts a status for output
+func newStatusRow(s database.Status) row {
+	return statusRow{s.ID, s.Name, s.Description, timestamp(s.Timestamp), timestamp(s.EditedTimestamp)}
+}
+
+// cells gets the values shown in table output
+func (r statusRow) cells() []string {
+	return []string{r.ID, r.Name, r.Description}
+}
+
+// versionRow is a version as it's output
+type versionRow struct {
+	ID          string `json:"id"`
+	EditionID   string `json:"edition_id"`
+	Version     string `json:"version"`
+	Major       int    `json:"major"`
+	Minor       int    `json:"minor"`
+	Patch       int    `json:"patch"`
+	Revision    int    `json:"revision"`
+	Type        string `json:"type"`
+	PreRelease  int    `json:"pre_release"`
+	Snapshot    string `json:"snapshot"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Released    string `json:"released"`
+	Created     string `json:"created"`
+	Edited      string `json:"edited"`
+}
+
+// newVersionRow converts a version for output
+func newVersionRow(v database.Version) row {
+	r := versionRow{
+		ID:          v.ID,
+		EditionID:   v.EditionID,
+		Version:     v.Number().String(),
+		Major:       v.MajorVersion,
+		Minor:       v.MinorVersion,
+		Patch:       v.Patch,
+		Revision:    v.Revision,
+		Type:        v.Type.String(),
+		PreRelease:  v.PreRelease,
+		Snapshot:    v.Snapshot,
+		Name:        v.Name,
+		Description: v.Description,
+		Created:     timestamp(v.Timestamp),
+		Edited:      timestamp(v.EditedTimestamp),
+	}
+	if t := time.Time(v.VersionTimestamp); !t.IsZero() && t.Year() > 1 {
+		r.Released = t.Format(dateLayout)
+	}
+	return r
+}
+
+// cells gets the values shown in table output
+func (r versionRow) cells() []string {
+	return []string{r.ID, r.EditionID, r.Version, r.Type, r.Name, r.Released}
+}
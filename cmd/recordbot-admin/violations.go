@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// violationRow is a row which links rows of different
+// editions or build classes
+type violationRow struct {
+	Table         string `json:"table"`
+	BuildID       string `json:"build_id"`
+	VersionID     string `json:"version_id,omitempty"`
+	BuildRecordID string `json:"build_record_id,omitempty"`
+	RecordID      string `json:"record_id,omitempty"`
+	Problem       string `json:"problem"`
+}
+
+// cells gets the values shown in table output
+func (r violationRow) cells() []string {
+	key := "build record " + r.BuildRecordID
+	if r.Table == "BuildVersions" {
+		key = fmt.Sprintf("build %s, version %s", r.BuildID, r.VersionID)
+	}
+	return []string{r.Table, key, r.Problem}
+}
+
+// violationColumns are the headings of the violation scan
+var violationColumns = []string{"TABLE", "ROW", "PROBLEM"}
+
+// runViolations lists the rows which link rows of
+// different editions or build classes
+func runViolations(path string, p printer, args []string) error {
+	if len(args) > 0 {
+		return &usageError{fmt.Sprintf("unexpected argument %q", args[0])}
+	}
+	db, err := database.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to open database")
+	}
+	defer db.Close()
+	violations, err := db.Violations()
+	if err != nil {
+		return err
+	}
+	rows := []row{}
+	for _, v := range violations {
+		rows = append(rows, violationRow{
+			Table:         v.Table,
+			BuildID:       v.BuildID,
+			VersionID:     v.VersionID,
+			BuildRecordID: v.BuildRecordID,
+			RecordID:      v.RecordID,
+			Problem:       v.Err.Error(),
+		})
+	}
+	return p.rows(violationColumns, rows)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/Kappeh/RecordBot/config"
+)
+
+// Log levels from most to least verbose, in the same order as config.LogLevels
+const (
+	levelDebug = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+// levelNames are the names of the log levels as used in the config
+var levelNames = config.LogLevels
+
+// logger writes messages that are at least as severe as its level
+type logger struct {
+	level int
+	out   *log.Logger
+}
+
+// newLogger creates a logger for a log level named in the config
+// Unknown levels are treated as info
+func newLogger(w io.Writer, level string) *logger {
+	l := &logger{level: levelInfo, out: log.New(w, "", log.LstdFlags)}
+	for i, name := range levelNames {
+		if name == level {
+			l.level = i
+		}
+	}
+	return l
+}
+
+// logf writes a message if level is severe enough
+func (l *logger) logf(level int, format string, args ...interface{}) {
+	if level < l.level {
+		return
+	}
+	l.out.Printf("[%s] %s", levelNames[level], fmt.Sprintf(format, args...))
+}
+
+// Debugf writes a debug message
+func (l *logger) Debugf(format string, args ...interface{}) { l.logf(levelDebug, format, args...) }
+
+// Infof writes an info message
+func (l *logger) Infof(format string, args ...interface{}) { l.logf(levelInfo, format, args...) }
+
+// Warnf writes a warning message
+func (l *logger) Warnf(format string, args ...interface{}) { l.logf(levelWarn, format, args...) }
+
+// Errorf writes an error message
+func (l *logger) Errorf(format string, args ...interface{}) { l.logf(levelError, format, args...) }
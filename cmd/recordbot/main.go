@@ -0,0 +1,209 @@
+// Command recordbot runs the bot
+//
+// The bot receives slash commands through an http interactions endpoint
+// and posts builds and records to guilds by draining the outbox. Records
+// that existed before a guild configured its channels are backfilled
+//
+// Usage:
+//
+//	recordbot [-config path] [-check-config]
+//
+// The config file may be .toml, .yaml, .yml or .json and any setting can
+// be overridden with an environment variable such as RECORDBOT_TOKEN
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Kappeh/RecordBot/command"
+	"github.com/Kappeh/RecordBot/config"
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/Kappeh/RecordBot/discord"
+	"github.com/Kappeh/RecordBot/interaction"
+	"github.com/Kappeh/RecordBot/messagesync"
+	"github.com/Kappeh/RecordBot/outbox"
+	"github.com/pkg/errors"
+)
+
+// shutdownTimeout is how long requests being handled are given
+// to finish when the bot is stopped
+const shutdownTimeout = 10 * time.Second
+
+func main() {
+	configPath := flag.String("config", "recordbot.toml", "path to the config file")
+	checkConfig := flag.Bool("check-config", false, "check the config and database schema then exit")
+	flag.Parse()
+
+	c, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if *checkConfig {
+		if err = check(c); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("config is valid")
+		return
+	}
+	l := newLogger(os.Stderr, c.LogLevel)
+	if err = run(c, l); err != nil {
+		l.Errorf("%v", err)
+		os.Exit(1)
+	}
+}
+
+// loadConfig loads the config file, applies environment
+// variable overrides and validates the result
+func loadConfig(path string) (config.Config, error) {
+	c, err := config.Load(path)
+	if err != nil {
+		return config.Config{}, err
+	}
+	if err = c.ApplyEnv(os.LookupEnv); err != nil {
+		return config.Config{}, err
+	}
+	if err = c.Validate(); err != nil {
+		return config.Config{}, err
+	}
+	return c, nil
+}
+
+// check checks that the database schema matches the one expected
+// A database that doesn't exist yet is fine as it's created on start up
+func check(c config.Config) error {
+	if _, err := os.Stat(c.DatabasePath); os.IsNotExist(err) {
+		fmt.Printf("database %s doesn't exist and will be created\n", c.DatabasePath)
+		return nil
+	}
+	return errors.Wrap(database.CheckSchema(c.DatabasePath), "invalid database")
+}
+
+// run runs the bot until it receives an interrupt or terminate signal
+func run(c config.Config, l *logger) error {
+	publicKey, err := hex.DecodeString(c.PublicKey)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode public key")
+	}
+	db, err := database.Open(c.DatabasePath)
+	if err != nil {
+		return errors.Wrap(err, "failed to open database")
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			l.Errorf("failed to close database: %v", err)
+		}
+	}()
+	l.Infof("opened database %s", c.DatabasePath)
+
+	// Every request to discord goes through the dispatcher so that
+	// syncing messages waits behind replies to commands
+	dispatcher := discord.NewDispatcher(http.DefaultClient)
+	client := discord.NewClient(c.Token)
+	client.Doer = dispatcher
+	background := discord.NewClient(c.Token)
+	background.Doer = dispatcher.WithPriority(discord.PriorityBackground)
+	session := &discord.Session{Client: client, OwnerIDs: c.OwnerIDs}
+	// Commands are only received as slash commands so
+	// usage is shown with a slash
+	router, err := command.NewDefaultRouter("/")
+	if err != nil {
+		return errors.Wrap(err, "failed to create command router")
+	}
+	router.HomeGuildID = c.HomeGuildID
+	registrar := interaction.NewRegistrar(c.Token, c.ApplicationID)
+	registrar.Client = dispatcher
+	if err = registrar.RegisterGuilds(db, interaction.Schemas(router)); err != nil {
+		return errors.Wrap(err, "failed to register slash commands")
+	}
+	l.Infof("registered slash commands")
+
+	// Outbox worker
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	messenger := &discord.Messenger{Client: background}
+	worker := outbox.NewWorker(db, outbox.SyncHandler(db, messenger))
+	workerDone := make(chan error, 1)
+	go func() {
+		workerDone <- worker.Run(ctx)
+	}()
+
+	// Record backfill
+	backfiller := messagesync.NewBackfiller(db, messenger)
+	backfiller.OnError = func(guildID string, err error) {
+		l.Warnf("failed to backfill records of guild %s: %v", guildID, err)
+	}
+	backfillDone := make(chan error, 1)
+	go func() {
+		backfillDone <- backfiller.Run(ctx)
+	}()
+
+	// Interactions endpoint
+	handler := &interaction.Handler{
+		Router:    router,
+		DB:        db,
+		Session:   session,
+		PublicKey: ed25519.PublicKey(publicKey),
+		OnError: func(err error) {
+			l.Errorf("failed to handle interaction: %v", err)
+		},
+	}
+	server := &http.Server{Addr: c.ListenAddress, Handler: handler}
+	serverDone := make(chan error, 1)
+	go func() {
+		serverDone <- server.ListenAndServe()
+	}()
+	l.Infof("listening for interactions on %s", c.ListenAddress)
+
+	// Wait for a signal or for something to stop
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	var result error
+	select {
+	case sig := <-signals:
+		l.Infof("received %s, shutting down", sig)
+	case err := <-serverDone:
+		result = errors.Wrap(err, "interactions endpoint stopped")
+		serverDone <- nil
+	case err := <-workerDone:
+		result = errors.Wrap(err, "outbox worker stopped")
+		workerDone <- nil
+	case err := <-backfillDone:
+		result = errors.Wrap(err, "record backfill stopped")
+		backfillDone <- nil
+	}
+
+	// Let requests being handled finish before stopping the workers
+	// so that outbox entries they add are kept for the next start
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer shutdownCancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		l.Warnf("failed to shut down interactions endpoint: %v", err)
+	}
+	if err := <-serverDone; err != nil && err != http.ErrServerClosed {
+		l.Warnf("interactions endpoint stopped: %v", err)
+	}
+	cancel()
+	if err := <-workerDone; err != nil {
+		l.Warnf("outbox worker stopped: %v", err)
+	}
+	if err := <-backfillDone; err != nil {
+		l.Warnf("record backfill stopped: %v", err)
+	}
+	m := dispatcher.Metrics()
+	l.Infof("sent %d requests to discord, %d rate limited, %d globally rate limited, %d retried",
+		m.Sent, m.RateLimited, m.GlobalRateLimited, m.Retried)
+	l.Infof("stopped")
+	return result
+}
@@ -0,0 +1,278 @@
+package command
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Kappeh/RecordBot/database"
+)
+
+// ArgType is the type of value an argument takes
+type ArgType int
+
+const (
+	// ArgString is a single word or a quoted string
+	ArgString ArgType = iota
+	// ArgID is an id or a mention of a user, role or channel
+	ArgID
+	// ArgUser is the id or mention of a user
+	ArgUser
+	// ArgRole is the id or mention of a role
+	ArgRole
+	// ArgInt is a whole number
+	ArgInt
+	// ArgDuration is a duration in game ticks
+	// It's given in game ticks, such as 27 or 27gt, redstone
+	// ticks, such as 13.5rt, or seconds, such as 1.35s
+	ArgDuration
+	// ArgDimensions is the size of a build such as 3x4x5
+	ArgDimensions
+	// ArgText is the rest of the input
+	ArgText
+)
+
+// String gets the name of the argument type
+func (t ArgType) String() string {
+	switch t {
+	case ArgString:
+		return "string"
+	case ArgID:
+		return "id"
+	case ArgUser:
+		return "user"
+	case ArgRole:
+		return "role"
+	case ArgInt:
+		return "number"
+	case ArgDuration:
+		return "duration"
+	case ArgDimensions:
+		return "dimensions"
+	case ArgText:
+		return "text"
+	}
+	return "unknown"
+}
+
+// Completion is the kind of value that an argument can be
+// autocompleted with
+type Completion int
+
+const (
+	// CompleteNone doesn't autocomplete the argument
+	CompleteNone Completion = iota
+	// CompleteBuild autocompletes the argument with build ids
+	CompleteBuild
+	// CompleteRecord autocompletes the argument with record ids
+	CompleteRecord
+	// CompleteEdition autocompletes the argument with edition names
+	CompleteEdition
+	// CompleteBuildClass autocompletes the argument with build class names
+	CompleteBuildClass
+	// CompleteRecordType autocompletes the argument with record type names
+	CompleteRecordType
+)
+
+// Arg is an argument of a command
+type Arg struct {
+	// Name is the name of the argument shown in usages
+	Name string
+	// Description is a short description of the argument
+	Description string
+	// Type is the type of value the argument takes
+	Type ArgType
+	// Optional indicates whether the argument can be left out
+	Optional bool
+	// Choices are the values the argument can take
+	// Any value is allowed if there are no choices
+	Choices []string
+	// Complete is the kind of value the argument is autocompleted with
+	// where autocompletion is available
+	Complete Completion
+}
+
+// Args are the parsed arguments of a command by name
+// Arguments that were left out aren't present
+type Args map[string]interface{}
+
+// Has indicates whether an argument was given
+func (a Args) Has(name string) bool {
+	_, ok := a[name]
+	return ok
+}
+
+// String gets a string or text argument
+func (a Args) String(name string) string {
+	s, _ := a[name].(string)
+	return s
+}
+
+// ID gets an id or user argument
+func (a Args) ID(name string) string {
+	return a.String(name)
+}
+
+// Int gets a number argument
+func (a Args) Int(name string) int {
+	i, _ := a[name].(int)
+	return i
+}
+
+// Duration gets a duration argument
+func (a Args) Duration(name string) database.Ticks {
+	t, _ := a[name].(database.Ticks)
+	return t
+}
+
+// Dimensions gets a dimensions argument
+func (a Args) Dimensions(name string) database.Dimensions {
+	d, _ := a[name].(database.Dimensions)
+	return d
+}
+
+// token is a word or quoted string within the input of a command
+type token struct {
+	// text is the text of the token with quotes removed
+	text string
+	// start is the index of the start of the token in the input
+	start int
+}
+
+// tokenize splits input into words
+// Text within double quotes is a single word, and a backslash
+// within quotes escapes the character that follows it
+func tokenize(input string) ([]token, error) {
+	tokens := []token{}
+	runes := []rune(input)
+	// Rune indexes are converted to byte indexes for start
+	offsets := make([]int, len(runes)+1)
+	n := 0
+	for i, r := range runes {
+		offsets[i] = n
+		n += len(string(r))
+	}
+	offsets[len(runes)] = n
+	i := 0
+	for i < len(runes) {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+		start := i
+		var b strings.Builder
+		if runes[i] == '"' {
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					b.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if runes[i] == '"' {
+					closed = true
+					i++
+					break
+				}
+				b.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, userErrorf("unterminated quote")
+			}
+		} else {
+			for i < len(runes) && !unicode.IsSpace(runes[i]) {
+				b.WriteRune(runes[i])
+				i++
+			}
+		}
+		tokens = append(tokens, token{text: b.String(), start: offsets[start]})
+	}
+	return tokens, nil
+}
+
+// parseArgs parses the arguments of a command from tokens
+// input is the text the tokens were made from
+func parseArgs(args []Arg, tokens []token, input string) (Args, error) {
+	result := Args{}
+	for i, arg := range args {
+		if i >= len(tokens) {
+			if !arg.Optional {
+				return nil, userErrorf("missing argument <%s>", arg.Name)
+			}
+			continue
+		}
+		if arg.Type == ArgText {
+			text := strings.TrimSpace(input[tokens[i].start:])
+			// A lone quoted string doesn't keep its quotes
+			if i == len(tokens)-1 {
+				text = tokens[i].text
+			}
+			v, err := ParseArg(arg, text)
+			if err != nil {
+				return nil, userErrorf("invalid argument <%s>: %s", arg.Name, err)
+			}
+			result[arg.Name] = v
+			return result, nil
+		}
+		v, err := ParseArg(arg, tokens[i].text)
+		if err != nil {
+			return nil, userErrorf("invalid argument <%s>: %s", arg.Name, err)
+		}
+		result[arg.Name] = v
+	}
+	if len(tokens) > len(args) {
+		return nil, userErrorf("too many arguments")
+	}
+	return result, nil
+}
+
+// mentionRegex matches user, role and channel mentions
+var mentionRegex = regexp.MustCompile(`^<(?:@!?|@&|#)(\d+)>$`)
+
+// ParseArg parses the value of an argument
+func ParseArg(arg Arg, s string) (interface{}, error) {
+	if len(arg.Choices) > 0 {
+		found := false
+		for _, c := range arg.Choices {
+			if strings.EqualFold(c, s) {
+				s, found = c, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%q isn't one of %s", s, strings.Join(arg.Choices, ", "))
+		}
+	}
+	switch arg.Type {
+	case ArgString, ArgText:
+		return s, nil
+	case ArgID, ArgUser, ArgRole:
+		return ParseID(s)
+	case ArgInt:
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("%q isn't a whole number", s)
+		}
+		return i, nil
+	case ArgDuration:
+		return database.ParseTicks(s)
+	case ArgDimensions:
+		return database.ParseDimensions(s)
+	}
+	return nil, fmt.Errorf("unknown argument type %d", arg.Type)
+}
+
+// ParseID parses an id or a mention of a user, role or channel
+func ParseID(s string) (string, error) {
+	if m := mentionRegex.FindStringSubmatch(s); m != nil {
+		s = m[1]
+	}
+	if _, err := strconv.ParseUint(s, 10, 63); err != nil {
+		return "", fmt.Errorf("%q isn't an id or mention", s)
+	}
+	return s, nil
+}
@@ -0,0 +1,193 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Kappeh/RecordBot/database"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+		err   bool
+	}{
+		{input: "", want: []string{}},
+		{input: "  build   info 1 ", want: []string{"build", "info", "1"}},
+		{input: `strike add 1 "a quoted reason"`, want: []string{"strike", "add", "1", "a quoted reason"}},
+		{input: `say "she said \"hi\""`, want: []string{"say", `she said "hi"`}},
+		{input: `say "a\\b"`, want: []string{"say", `a\b`}},
+		{input: `say ""`, want: []string{"say", ""}},
+		{input: `say "unterminated`, err: true},
+	}
+	for _, tt := range tests {
+		tokens, err := tokenize(tt.input)
+		if tt.err {
+			if err == nil {
+				t.Errorf("tokenize(%q) = %v, expected an error", tt.input, tokens)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("tokenize(%q) failed: %v", tt.input, err)
+			continue
+		}
+		got := []string{}
+		for _, tok := range tokens {
+			got = append(got, tok.text)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenize(%q) = %q, expected %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	timing := []Arg{
+		{Name: "build", Type: ArgID},
+		{Name: "close", Type: ArgDuration},
+		{Name: "open", Type: ArgDuration, Optional: true},
+	}
+	reason := []Arg{
+		{Name: "user", Type: ArgUser},
+		{Name: "reason", Type: ArgText},
+	}
+	tests := []struct {
+		name  string
+		args  []Arg
+		input string
+		want  Args
+		err   string
+	}{
+		{
+			name:  "durations",
+			args:  timing,
+			input: "12 27gt 1.35s",
+			want:  Args{"build": "12", "close": database.Ticks(27), "open": database.Ticks(27)},
+		},
+		{
+			name:  "redstone ticks and optional argument left out",
+			args:  timing,
+			input: "12 13.5rt",
+			want:  Args{"build": "12", "close": database.Ticks(27)},
+		},
+		{
+			name:  "fractional gameticks",
+			args:  timing,
+			input: "12 0.01s",
+			err:   "invalid argument <close>: \"0.01s\" isn't a whole number of gameticks",
+		},
+		{
+			name:  "dimensions",
+			args:  []Arg{{Name: "build", Type: ArgID}, {Name: "dimensions", Type: ArgDimensions}},
+			input: "12 3x4x5",
+			want:  Args{"build": "12", "dimensions": database.Dimensions{Width: 3, Height: 4, Depth: 5}},
+		},
+		{
+			name:  "invalid dimensions",
+			args:  []Arg{{Name: "build", Type: ArgID}, {Name: "dimensions", Type: ArgDimensions}},
+			input: "12 3x4",
+			err:   "invalid argument <dimensions>: \"3x4\" isn't of the form WxHxD such as 3x4x5",
+		},
+		{
+			name:  "mention and the rest of the input",
+			args:  reason,
+			input: "<@!123>   griefing   the  server",
+			want:  Args{"user": "123", "reason": "griefing   the  server"},
+		},
+		{
+			name:  "quoted text keeps its quotes with more words",
+			args:  reason,
+			input: `123 "a" b`,
+			want:  Args{"user": "123", "reason": `"a" b`},
+		},
+		{
+			name:  "lone quoted text",
+			args:  reason,
+			input: `123 "a reason"`,
+			want:  Args{"user": "123", "reason": "a reason"},
+		},
+		{
+			name:  "choices ignore case",
+			args:  []Arg{{Name: "timing", Type: ArgString, Choices: timingNames}},
+			input: "Visible",
+			want:  Args{"timing": "visible"},
+		},
+		{
+			name:  "not a choice",
+			args:  []Arg{{Name: "timing", Type: ArgString, Choices: []string{"normal", "visible"}}},
+			input: "sideways",
+			err:   "invalid argument <timing>: \"sideways\" isn't one of normal, visible",
+		},
+		{
+			name:  "not a number",
+			args:  []Arg{{Name: "strike", Type: ArgInt}},
+			input: "two",
+			err:   "invalid argument <strike>: \"two\" isn't a whole number",
+		},
+		{
+			name:  "missing argument",
+			args:  reason,
+			input: "123",
+			err:   "missing argument <reason>",
+		},
+		{
+			name:  "too many arguments",
+			args:  []Arg{{Name: "build", Type: ArgID}},
+			input: "1 2",
+			err:   "too many arguments",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := tokenize(tt.input)
+			if err != nil {
+				t.Fatalf("failed to tokenize: %v", err)
+			}
+			got, err := parseArgs(tt.args, tokens, tt.input)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				if _, ok := err.(*UserError); !ok {
+					t.Fatalf("expected a user error, got %T", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseID(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+		err  bool
+	}{
+		{text: "123", want: "123"},
+		{text: "<@123>", want: "123"},
+		{text: "<@!123>", want: "123"},
+		{text: "<@&123>", want: "123"},
+		{text: "<#123>", want: "123"},
+		{text: "-123", err: true},
+		{text: "<@abc>", err: true},
+		{text: "abc", err: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseID(tt.text)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParseID(%q) = %q, expected an error", tt.text, got)
+			}
+		} else if err != nil || got != tt.want {
+			t.Errorf("ParseID(%q) = %q, %v, expected %q", tt.text, got, err, tt.want)
+		}
+	}
+}
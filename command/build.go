@@ -0,0 +1,582 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kappeh/RecordBot/compat"
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/Kappeh/RecordBot/embed"
+	"github.com/pkg/errors"
+)
+
+// listLimit is the most rows shown by list commands
+const listLimit = 20
+
+// Build creates the build command and its subcommands
+func Build() *Command {
+	buildArg := Arg{Name: "build", Description: "The id of the build", Type: ArgID, Complete: CompleteBuild}
+	return &Command{
+		Name:        "build",
+		Aliases:     []string{"b"},
+		Description: "Shows and manages builds",
+		Global:      true,
+		Subcommands: []*Command{
+			{
+				Name:        "info",
+				Aliases:     []string{"show"},
+				Description: "Shows a build",
+				Args:        []Arg{buildArg},
+				Run:         runBuildInfo,
+			},
+			{
+				Name:        "list",
+				Aliases:     []string{"search"},
+				Description: "Lists verified builds, optionally only those with a name containing some text",
+				Args:        []Arg{{Name: "name", Description: "Text the name of the build contains", Type: ArgText, Optional: true}},
+				Run:         runBuildList,
+			},
+			{
+				Name:        "browse",
+				Description: "Lists the verified builds of an edition and build class",
+				Args: []Arg{
+					{Name: "edition", Description: "The name of the edition", Type: ArgString, Complete: CompleteEdition},
+					{Name: "class", Description: "The name of the build class", Type: ArgString, Complete: CompleteBuildClass},
+				},
+				Run: runBuildBrowse,
+			},
+			{
+				Name:        "compatibility",
+				Aliases:     []string{"matrix"},
+				Description: "Summarises the versions the verified builds of an edition and build class work in",
+				Args: []Arg{
+					{Name: "edition", Description: "The name of the edition", Type: ArgString, Complete: CompleteEdition},
+					{Name: "class", Description: "The name of the build class", Type: ArgString, Complete: CompleteBuildClass},
+					{Name: "name", Description: "Text the names of the builds contain", Type: ArgText, Optional: true},
+				},
+				Run: runBuildCompatibility,
+			},
+			{
+				Name:        "inferred",
+				Aliases:     []string{"likely"},
+				Description: "Shows the likely statuses of a build in the versions it hasn't been tested in",
+				Args:        []Arg{buildArg},
+				Run:         runBuildInferred,
+			},
+			{
+				Name:        "pending",
+				Description: "Lists builds and build updates waiting to be verified",
+				Permission:  PermissionModerator,
+				Capability:  database.CapabilityVerify,
+				Run:         runBuildPending,
+			},
+			{
+				Name:        "retest",
+				Description: "Lists builds waiting to be tested in new versions, record holders first",
+				Args: []Arg{
+					{Name: "edition", Description: "The name of the edition", Type: ArgString, Complete: CompleteEdition, Optional: true},
+				},
+				Permission: PermissionModerator,
+				Capability: database.CapabilityVerify,
+				Run:        runBuildRetest,
+			},
+			{
+				Name:        "verify",
+				Description: "Verifies a build or applies a build update",
+				Args:        []Arg{buildArg},
+				Permission:  PermissionModerator,
+				Capability:  database.CapabilityVerify,
+				Run:         runBuildVerify,
+			},
+			{
+				Name:        "report",
+				Description: "Reports a build as fake",
+				Args:        []Arg{buildArg},
+				Run:         runBuildReport,
+			},
+			{
+				Name:        "unreport",
+				Description: "Removes the report from a build",
+				Args:        []Arg{buildArg},
+				Permission:  PermissionModerator,
+				Capability:  database.CapabilityReportReview,
+				Run:         runBuildUnreport,
+			},
+			{
+				Name:        "dimensions",
+				Aliases:     []string{"size"},
+				Description: "Sets the dimensions of a build",
+				Args: []Arg{
+					buildArg,
+					{Name: "dimensions", Description: "The width, height and depth such as 3x4x5", Type: ArgDimensions},
+				},
+				Permission: PermissionModerator,
+				Capability: database.CapabilityVerify,
+				Run:        runBuildDimensions,
+			},
+			{
+				Name:        "timing",
+				Description: "Sets a pair of closing and opening durations of a build",
+				Args: []Arg{
+					buildArg,
+					{Name: "timing", Description: "Which pair of durations to set", Type: ArgString, Choices: timingNames},
+					{Name: "close", Description: "The closing or extension duration", Type: ArgDuration},
+					{Name: "open", Description: "The opening or retraction duration", Type: ArgDuration},
+				},
+				Permission: PermissionModerator,
+				Capability: database.CapabilityVerify,
+				Run:        runBuildTiming,
+			},
+			{
+				Name:        "version",
+				Aliases:     []string{"compat"},
+				Description: "Sets the status of a build in a version",
+				Args: []Arg{
+					buildArg,
+					{Name: "version", Description: "The version such as 1.14.2, 1.16-pre3 or 20w14a", Type: ArgString},
+					{Name: "status", Description: "The name of the status", Type: ArgString},
+					{Name: "notes", Description: "Notes about the status", Type: ArgText, Optional: true},
+				},
+				Permission: PermissionModerator,
+				Capability: database.CapabilityVerify,
+				Run:        runBuildVersion,
+			},
+			{
+				Name:        "versions",
+				Description: "Sets the status of a build in every version from one version to another",
+				Args: []Arg{
+					buildArg,
+					{Name: "from", Description: "The first version such as 1.12", Type: ArgString},
+					{Name: "to", Description: "The last version such as 1.16.5, or latest", Type: ArgString},
+					{Name: "status", Description: "The name of the status", Type: ArgString},
+					{Name: "notes", Description: "Notes about the status", Type: ArgText, Optional: true},
+				},
+				Permission: PermissionModerator,
+				Capability: database.CapabilityVerify,
+				Run:        runBuildVersions,
+			},
+			{
+				Name:        "delete",
+				Description: "Deletes a build",
+				Args:        []Arg{buildArg},
+				Permission:  PermissionAdministrator,
+				Capability:  database.CapabilityAdmin,
+				Run:         runBuildDelete,
+			},
+		},
+	}
+}
+
+// timingNames are the names of the duration pairs of a build
+// in the order they're shown
+var timingNames = []string{"normal", "visible", "delay", "reset", "extension", "extension-delay"}
+
+// timingFields gets the pair of duration fields with a name
+func timingFields(b *database.Build, name string) (*database.Ticks, *database.Ticks, bool) {
+	switch name {
+	case "normal":
+		return &b.NormalCloseDuration, &b.NormalOpenDuration, true
+	case "visible":
+		return &b.VisibleCloseDuration, &b.VisibleOpenDuration, true
+	case "delay":
+		return &b.DelayCloseDuration, &b.DelayOpenDuration, true
+	case "reset":
+		return &b.ResetCloseDuration, &b.ResetOpenDuration, true
+	case "extension":
+		return &b.ExtensionDuration, &b.RetractionDuration, true
+	case "extension-delay":
+		return &b.ExtensionDelayDuration, &b.RetractionDelayDuration, true
+	}
+	return nil, nil, false
+}
+
+// getBuild gets a build that a user asked for
+func getBuild(db *database.Database, buildID string) (database.Build, error) {
+	b, ok, err := db.Build(buildID)
+	if err != nil {
+		return database.Build{}, errors.Wrap(err, "failed to get build")
+	} else if !ok {
+		return database.Build{}, userErrorf("there isn't a build with id %s", buildID)
+	}
+	return b, nil
+}
+
+// buildEditError describes an error from editing a build
+// Invalid fields and links to versions or records of another edition
+// or build class are reported to the user so that they can be fixed
+func buildEditError(err error) error {
+	switch e := errors.Cause(err).(type) {
+	case *database.ValidationError:
+		return userErrorf("the build can't be saved: %s", e.Error())
+	case *database.ConsistencyError:
+		return userErrorf("the build can't be saved: %s", e.Error())
+	}
+	return errors.Wrap(err, "failed to edit build")
+}
+
+// runBuildInfo shows a build
+func runBuildInfo(ctx *Context, args Args) error {
+	b, err := getBuild(ctx.DB, args.ID("build"))
+	if err != nil {
+		return err
+	}
+	d, err := embed.LoadBuild(ctx.DB, b)
+	if err != nil {
+		return errors.Wrap(err, "failed to load build")
+	}
+	return ctx.ReplyEmbed(embed.Build(d))
+}
+
+// runBuildList lists verified builds
+func runBuildList(ctx *Context, args Args) error {
+	builds, err := ctx.DB.Builds()
+	if err != nil {
+		return errors.Wrap(err, "failed to get builds")
+	}
+	query := strings.ToLower(args.String("name"))
+	lines := []string{}
+	for _, b := range builds {
+		if !b.Verified || b.UpdateRequest || !strings.Contains(strings.ToLower(b.Name), query) {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("`%s` %s", b.ID, b.Name))
+	}
+	return ctx.ReplyEmbed(listEmbed("Builds", lines))
+}
+
+// runBuildBrowse lists the verified builds of an edition and build class
+func runBuildBrowse(ctx *Context, args Args) error {
+	e, err := findEdition(ctx.DB, args.String("edition"))
+	if err != nil {
+		return err
+	}
+	c, err := findBuildClass(ctx.DB, args.String("class"))
+	if err != nil {
+		return err
+	}
+	builds, err := ctx.DB.Builds()
+	if err != nil {
+		return errors.Wrap(err, "failed to get builds")
+	}
+	lines := []string{}
+	for _, b := range builds {
+		if !b.Verified || b.UpdateRequest || b.EditionID != e.ID || b.BuildClassID != c.ID {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("`%s` %s", b.ID, b.Name))
+	}
+	return ctx.ReplyEmbed(listEmbed(e.Name+" "+c.Name+" Builds", lines))
+}
+
+// runBuildCompatibility summarises the statuses of the verified
+// builds of an edition and build class in each version
+func runBuildCompatibility(ctx *Context, args Args) error {
+	e, err := findEdition(ctx.DB, args.String("edition"))
+	if err != nil {
+		return err
+	}
+	c, err := findBuildClass(ctx.DB, args.String("class"))
+	if err != nil {
+		return err
+	}
+	m, err := compat.Build(ctx.DB, compat.Filter{EditionID: e.ID, BuildClassID: c.ID, Name: args.String("name")})
+	if err != nil {
+		return errors.Wrap(err, "failed to build compatibility matrix")
+	}
+	lines := []string{}
+	for i, b := range m.Builds {
+		lines = append(lines, fmt.Sprintf("`%s` %s - %s", b.ID, b.Name, m.Summary(i)))
+	}
+	return ctx.ReplyEmbed(listEmbed(e.Name+" "+c.Name+" Compatibility", lines))
+}
+
+// runBuildInferred shows the statuses inferred for a build in the
+// versions it hasn't been tested in and any conflicting statuses
+func runBuildInferred(ctx *Context, args Args) error {
+	b, err := getBuild(ctx.DB, args.ID("build"))
+	if err != nil {
+		return err
+	}
+	m, err := compat.Build(ctx.DB, compat.Filter{EditionID: b.EditionID, BuildIDs: []string{b.ID}, IncludeUnverified: true})
+	if err != nil {
+		return errors.Wrap(err, "failed to build compatibility matrix")
+	}
+	if len(m.Builds) == 0 {
+		return userErrorf("build %s is an update request", b.ID)
+	}
+	inf := m.Infer()
+	lines := []string{}
+	for j, c := range inf.Cells[0] {
+		if c.Inferred {
+			lines = append(lines, fmt.Sprintf("%s: %s (%s confidence)", m.Versions[j].Number().String(), c.Name(), c.Confidence))
+		}
+	}
+	e := listEmbed("Inferred Compatibility of "+b.Name, lines)
+	if len(inf.Conflicts) > 0 {
+		conflicts := []string{}
+		for _, c := range inf.Conflicts {
+			conflicts = append(conflicts, m.DescribeConflict(c))
+		}
+		e.Fields = append(e.Fields, embed.Field{Name: "Conflicts", Value: strings.Join(conflicts, "\n")})
+	}
+	return ctx.ReplyEmbed(e)
+}
+
+// runBuildPending lists unverified builds
+func runBuildPending(ctx *Context, args Args) error {
+	builds, err := ctx.DB.Builds()
+	if err != nil {
+		return errors.Wrap(err, "failed to get builds")
+	}
+	lines := []string{}
+	for _, b := range builds {
+		if b.Verified {
+			continue
+		}
+		line := fmt.Sprintf("`%s` %s", b.ID, b.Name)
+		if b.UpdateRequest {
+			line += fmt.Sprintf(" (update to build %s)", b.UpdateRequestBuildID)
+		}
+		lines = append(lines, line)
+	}
+	return ctx.ReplyEmbed(listEmbed("Pending Builds", lines))
+}
+
+// runBuildRetest lists the builds with the untested status in a version
+// Setting the status of a build in the version removes it from the list
+func runBuildRetest(ctx *Context, args Args) error {
+	editionID := ""
+	if args.Has("edition") {
+		e, err := findEdition(ctx.DB, args.String("edition"))
+		if err != nil {
+			return err
+		}
+		editionID = e.ID
+	}
+	queue, err := ctx.DB.RetestQueue(editionID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get re-test queue")
+	}
+	lines := []string{}
+	for _, item := range queue {
+		line := fmt.Sprintf("`%s` %s in %s", item.Build.ID, item.Build.Name, item.Version.Number())
+		if n := len(item.Records); n == 1 {
+			line += " (holds 1 record)"
+		} else if n > 1 {
+			line += fmt.Sprintf(" (holds %d records)", n)
+		}
+		lines = append(lines, line)
+	}
+	return ctx.ReplyEmbed(listEmbed("Builds to Re-test", lines))
+}
+
+// runBuildVerify verifies a build
+// Verifying an update request replaces the original build's information
+// with the update and removes the update request
+func runBuildVerify(ctx *Context, args Args) error {
+	b, err := getBuild(ctx.DB, args.ID("build"))
+	if err != nil {
+		return err
+	}
+	if b.Verified {
+		return userErrorf("build %s is already verified", b.ID)
+	}
+	now := database.Timestamp(time.Now())
+	if !b.UpdateRequest {
+		b.Verified = true
+		b.VerifierID = ctx.AuthorID
+		b.VerifiedTimestamp = now
+		if _, _, err = ctx.DB.BuildEdit(b.ID, b); err != nil {
+			return buildEditError(err)
+		}
+		return ctx.Reply("Build %s has been verified.", b.ID)
+	}
+	err = ctx.DB.Transaction(func(tx *database.Database) error {
+		original, err := getBuild(tx, b.UpdateRequestBuildID)
+		if err != nil {
+			return err
+		}
+		updated := b
+		updated.ID = original.ID
+		updated.Verified = true
+		updated.VerifierID = ctx.AuthorID
+		updated.VerifiedTimestamp = now
+		updated.Reported = original.Reported
+		updated.ReporterID = original.ReporterID
+		updated.ReportedTimestamp = original.ReportedTimestamp
+		updated.UpdateRequest = false
+		updated.UpdateRequestBuildID = "0"
+		updated.SubmitterID = original.SubmitterID
+		if _, _, err = tx.BuildEdit(original.ID, updated); err != nil {
+			return buildEditError(err)
+		}
+		if _, _, err = tx.BuildDelete(b.ID); err != nil {
+			return errors.Wrap(err, "failed to delete update request")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.Reply("Build %s has been updated.", b.UpdateRequestBuildID)
+}
+
+// runBuildReport reports a build as fake
+func runBuildReport(ctx *Context, args Args) error {
+	b, err := getBuild(ctx.DB, args.ID("build"))
+	if err != nil {
+		return err
+	}
+	if b.Reported {
+		return userErrorf("build %s has already been reported", b.ID)
+	}
+	b.Reported = true
+	b.ReporterID = ctx.AuthorID
+	b.ReportedTimestamp = database.Timestamp(time.Now())
+	if _, _, err = ctx.DB.BuildEdit(b.ID, b); err != nil {
+		return buildEditError(err)
+	}
+	return ctx.Reply("Build %s has been reported.", b.ID)
+}
+
+// runBuildUnreport removes the report from a build
+func runBuildUnreport(ctx *Context, args Args) error {
+	b, err := getBuild(ctx.DB, args.ID("build"))
+	if err != nil {
+		return err
+	}
+	if !b.Reported {
+		return userErrorf("build %s hasn't been reported", b.ID)
+	}
+	b.Reported = false
+	b.ReporterID = "0"
+	b.ReportedTimestamp = database.Timestamp{}
+	if _, _, err = ctx.DB.BuildEdit(b.ID, b); err != nil {
+		return buildEditError(err)
+	}
+	return ctx.Reply("The report on build %s has been removed.", b.ID)
+}
+
+// runBuildDimensions sets the dimensions of a build
+func runBuildDimensions(ctx *Context, args Args) error {
+	b, err := getBuild(ctx.DB, args.ID("build"))
+	if err != nil {
+		return err
+	}
+	d := args.Dimensions("dimensions")
+	b.SetDimensions(d)
+	if _, _, err = ctx.DB.BuildEdit(b.ID, b); err != nil {
+		return buildEditError(err)
+	}
+	return ctx.Reply("The dimensions of build %s are now %s.", b.ID, d)
+}
+
+// runBuildTiming sets a pair of durations of a build
+func runBuildTiming(ctx *Context, args Args) error {
+	b, err := getBuild(ctx.DB, args.ID("build"))
+	if err != nil {
+		return err
+	}
+	// The timing is one of timingNames
+	closeField, openField, _ := timingFields(&b, args.String("timing"))
+	*closeField = args.Duration("close")
+	*openField = args.Duration("open")
+	if _, _, err = ctx.DB.BuildEdit(b.ID, b); err != nil {
+		return buildEditError(err)
+	}
+	return ctx.Reply("The %s timing of build %s is now %s / %s.",
+		args.String("timing"), b.ID, *closeField, *openField)
+}
+
+// runBuildVersion sets the status of a build in a version
+func runBuildVersion(ctx *Context, args Args) error {
+	b, err := getBuild(ctx.DB, args.ID("build"))
+	if err != nil {
+		return err
+	}
+	e, ok, err := ctx.DB.Edition(b.EditionID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get edition")
+	} else if !ok {
+		return errors.Errorf("edition %s of build %s doesn't exist", b.EditionID, b.ID)
+	}
+	v, err := findVersion(ctx.DB, e, args.String("version"))
+	if err != nil {
+		return err
+	}
+	s, err := findStatus(ctx.DB, args.String("status"))
+	if err != nil {
+		return err
+	}
+	notes := args.String("notes")
+	if _, ok, err = ctx.DB.BuildVersionEdit(b.ID, v.ID, s.ID, notes); err != nil {
+		return errors.Wrap(err, "failed to edit build version")
+	} else if !ok {
+		if _, _, err = ctx.DB.BuildVersionCreate(b.ID, v.ID, s.ID, notes); err != nil {
+			return errors.Wrap(err, "failed to create build version")
+		}
+	}
+	return ctx.Reply("Build %s is now %s in %s %s.", b.ID, s.Name, e.Name, v.Number())
+}
+
+// runBuildVersions sets the status of a build in a range of versions
+func runBuildVersions(ctx *Context, args Args) error {
+	b, err := getBuild(ctx.DB, args.ID("build"))
+	if err != nil {
+		return err
+	}
+	st, err := findStatus(ctx.DB, args.String("status"))
+	if err != nil {
+		return err
+	}
+	to := args.String("to")
+	if strings.EqualFold(to, "latest") {
+		to = ""
+	}
+	changes, _, err := ctx.DB.SetBuildStatusRange(b.ID, args.String("from"), to, st.ID, args.String("notes"))
+	if ve, ok := errors.Cause(err).(*database.VersionError); ok {
+		return userErrorf("%s", ve.Error())
+	} else if err != nil {
+		return errors.Wrap(err, "failed to set build statuses")
+	}
+	if len(changes) == 0 {
+		return userErrorf("there aren't any versions from %s to %s", args.String("from"), args.String("to"))
+	}
+	created := 0
+	for _, c := range changes {
+		if c.Created {
+			created++
+		}
+	}
+	first, last := changes[0].Version.Number(), changes[len(changes)-1].Version.Number()
+	return ctx.Reply("Build %s is now %s in %d versions from %s to %s (%d added, %d changed).",
+		b.ID, st.Name, len(changes), first, last, created, len(changes)-created)
+}
+
+// runBuildDelete deletes a build
+func runBuildDelete(ctx *Context, args Args) error {
+	b, ok, err := ctx.DB.BuildDelete(args.ID("build"))
+	if err != nil {
+		return errors.Wrap(err, "failed to delete build")
+	} else if !ok {
+		return userErrorf("there isn't a build with id %s", args.ID("build"))
+	}
+	return ctx.Reply("Build %s (%s) has been deleted.", b.ID, b.Name)
+}
+
+// listEmbed creates an embed listing rows
+// Only the first listLimit rows are shown
+func listEmbed(title string, lines []string) embed.Embed {
+	e := embed.Embed{Title: title}
+	if len(lines) == 0 {
+		e.Description = "There aren't any."
+		return e
+	}
+	if len(lines) > listLimit {
+		e.Footer = &embed.Footer{Text: fmt.Sprintf("Showing %d of %d", listLimit, len(lines))}
+		lines = lines[:listLimit]
+	}
+	e.Description = strings.Join(lines, "\n")
+	return e
+}
@@ -0,0 +1,84 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Kappeh/RecordBot/database"
+)
+
+// createBuild creates an unverified build of the test edition and build class
+func createBuild(t *testing.T, name string) database.Build {
+	t.Helper()
+	b, err := testDB.BuildCreate(database.Build{
+		EditionID:            testEdition.ID,
+		BuildClassID:         testBuildClass.ID,
+		Name:                 name,
+		Creators:             "player",
+		VerifierID:           "0",
+		ReporterID:           "0",
+		UpdateRequestBuildID: "0",
+		SubmitterID:          "0",
+	})
+	if err != nil {
+		t.Fatalf("failed to create build: %v", err)
+	}
+	return b
+}
+
+func TestBuildCommands(t *testing.T) {
+	s := newFakeSession()
+	b := createBuild(t, "Piston Tower")
+
+	if e := replyEmbed(t, s, otherGuild, member, "!b show "+b.ID); e.Title != b.Name {
+		t.Errorf("expected an embed of %s, got %+v", b.Name, e)
+	}
+
+	// Each step is ran in order on the same build
+	// %s is replaced with the build's id
+	steps := []struct {
+		userID  string
+		content string
+		want    string
+	}{
+		{moderator, "!build verify %s", "Build %s has been verified."},
+		{moderator, "!build verify %s", "build %s is already verified"},
+		{member, "!build report %s", "Build %s has been reported."},
+		{member, "!build report %s", "build %s has already been reported"},
+		{moderator, "!build unreport %s", "The report on build %s has been removed."},
+		{moderator, "!build size %s 3x4x5", "The dimensions of build %s are now 3x4x5."},
+		{moderator, "!build timing %s normal 27gt 1.35s", "The normal timing of build %s is now 1.35s (27gt) / 1.35s (27gt)."},
+		{moderator, "!build timing %s visible 30gt 1s", "the build can't be saved: the visible closing time can't be longer than the normal closing time"},
+	}
+	for _, step := range steps {
+		content := strings.Replace(step.content, "%s", b.ID, -1)
+		want := strings.Replace(step.want, "%s", b.ID, -1)
+		if got := reply(t, s, homeGuild, step.userID, content); got != want {
+			t.Errorf("%s: expected %q, got %q", content, want, got)
+		}
+	}
+
+	got, ok, err := testDB.Build(b.ID)
+	if err != nil || !ok {
+		t.Fatalf("failed to get build: %v", err)
+	}
+	if !got.Verified || got.VerifierID != moderator || got.Reported {
+		t.Errorf("expected the build to be verified by %s and not reported, got %+v", moderator, got)
+	}
+	if d := got.Dimensions(); d != (database.Dimensions{Width: 3, Height: 4, Depth: 5}) {
+		t.Errorf("expected dimensions 3x4x5, got %v", d)
+	}
+	if got.NormalCloseDuration != 27 || got.NormalOpenDuration != 27 || got.VisibleCloseDuration != 0 {
+		t.Errorf("expected only the normal timing to be set, got %+v", got)
+	}
+
+	want := fmt.Sprintf("Build %s (%s) has been deleted.", b.ID, b.Name)
+	if got := reply(t, s, homeGuild, admin, "!build delete "+b.ID); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	want = fmt.Sprintf("there isn't a build with id %s", b.ID)
+	if got := reply(t, s, homeGuild, member, "!build info "+b.ID); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,173 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/Kappeh/RecordBot/embed"
+	"github.com/pkg/errors"
+)
+
+// Permission is the level of trust a user needs to use a command
+// Each level includes the levels below it
+type Permission int
+
+const (
+	// PermissionEveryone allows anyone to use a command
+	PermissionEveryone Permission = iota
+	// PermissionModerator allows guild moderators to use a command
+	PermissionModerator
+	// PermissionAdministrator allows guild administrators to use a command
+	PermissionAdministrator
+	// PermissionOwner allows only the owners of the bot to use a command
+	PermissionOwner
+)
+
+// String gets the name of the permission
+func (p Permission) String() string {
+	switch p {
+	case PermissionEveryone:
+		return "everyone"
+	case PermissionModerator:
+		return "moderator"
+	case PermissionAdministrator:
+		return "administrator"
+	case PermissionOwner:
+		return "owner"
+	}
+	return "unknown"
+}
+
+// Command is a command that users can use by sending a message
+// A command either runs or groups together subcommands, or both
+type Command struct {
+	// Name is the name used to call the command
+	Name string
+	// Aliases are other names that can be used to call the command
+	Aliases []string
+	// Description is a short description of what the command does
+	Description string
+
+	// Args are the arguments the command takes
+	// Optional arguments may only be followed by other optional arguments
+	// and text arguments must be last
+	Args []Arg
+	// Permission is the level of trust needed to use the command
+	// Subcommands need at least the permission of their parent
+	Permission Permission
+	// Capability lets members with a role given the capability use the
+	// command without its permission level. Subcommands without a
+	// capability use their parent's. It's zero if there isn't one
+	Capability database.Capability
+	// GuildOnly indicates whether the command can only be used in a guild
+	GuildOnly bool
+	// Global indicates whether the command acts on data shared by every
+	// guild, such as builds, records and strikes. The permission level
+	// and capability of a global command only count within the home
+	// guild so that members of other guilds can't use it. Subcommands
+	// of a global command are global
+	Global bool
+
+	// Subcommands are the commands grouped under this command
+	Subcommands []*Command
+	// Run runs the command
+	// It may be nil if the command only groups subcommands
+	Run func(ctx *Context, args Args) error
+}
+
+// Context is the situation a command is being ran in
+type Context struct {
+	// Session is used to respond to the command
+	Session Session
+	// DB is the database the command acts on
+	DB *database.Database
+	// Router is the router which dispatched the command
+	Router *Router
+	// Prefix is the prefix that the command was called with
+	Prefix string
+
+	// GuildID is the id of the guild the command was used in
+	// It's empty if the command was used in direct messages
+	GuildID string
+	// ChannelID is the id of the channel the command was used in
+	ChannelID string
+	// AuthorID is the id of the user that used the command
+	AuthorID string
+	// Permission is the permission level of the author
+	Permission Permission
+	// Roles are the ids of the author's roles within the guild
+	// It's empty if the command was used in direct messages
+	Roles []string
+
+	// Path is the command and its parents, outermost first
+	Path []*Command
+}
+
+// Command gets the command being ran
+func (c *Context) Command() *Command {
+	return c.Path[len(c.Path)-1]
+}
+
+// Can determines whether the author's roles give them a capability
+func (c *Context) Can(capability database.Capability) (bool, error) {
+	if c.GuildID == "" {
+		return false, nil
+	}
+	ok, err := c.DB.Can(c.GuildID, c.Roles, capability)
+	return ok, errors.Wrap(err, "failed to check capability")
+}
+
+// allowed determines whether the author can use the last command in a path
+// The author needs the command's permission level or its capability
+// and, for global commands, to be in the home guild or an owner
+func (c *Context) allowed(path []*Command) (bool, error) {
+	required := requiredPermission(path)
+	if required == PermissionEveryone || c.Permission == PermissionOwner {
+		return true, nil
+	}
+	// Permissions within other guilds don't count for global commands
+	if isGlobal(path) && !c.inHomeGuild() {
+		return false, nil
+	}
+	if c.Permission >= required {
+		return true, nil
+	}
+	capability := requiredCapability(path)
+	if capability == 0 {
+		return false, nil
+	}
+	return c.Can(capability)
+}
+
+// inHomeGuild determines whether the command was used in the home guild
+func (c *Context) inHomeGuild() bool {
+	return c.Router != nil && c.Router.HomeGuildID != "" && c.GuildID == c.Router.HomeGuildID
+}
+
+// Reply sends a message to the channel the command was used in
+func (c *Context) Reply(format string, args ...interface{}) error {
+	return c.Session.Send(c.ChannelID, fmt.Sprintf(format, args...))
+}
+
+// ReplyEmbed sends an embed to the channel the command was used in
+func (c *Context) ReplyEmbed(e embed.Embed) error {
+	return c.Session.SendEmbed(c.ChannelID, e.Limit())
+}
+
+// UserError is an error caused by the user of a command
+// Its message is shown to the user rather than being treated
+// as a failure of the bot
+type UserError struct {
+	// Message is the message shown to the user
+	Message string
+}
+
+// Error gets the message of the error
+func (e *UserError) Error() string {
+	return e.Message
+}
+
+// userErrorf creates a user error with a formatted message
+func userErrorf(format string, args ...interface{}) error {
+	return &UserError{Message: fmt.Sprintf(format, args...)}
+}
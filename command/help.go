@@ -0,0 +1,102 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/Kappeh/RecordBot/embed"
+)
+
+// Help creates the help command
+// It lists the commands the user has permission to use
+// or describes a specific command
+func Help() *Command {
+	return &Command{
+		Name:        "help",
+		Aliases:     []string{"commands"},
+		Description: "Lists commands or describes a command",
+		Args: []Arg{
+			{Name: "command", Description: "The command to describe", Type: ArgText, Optional: true},
+		},
+		Run: runHelp,
+	}
+}
+
+// runHelp runs the help command
+func runHelp(ctx *Context, args Args) error {
+	r := ctx.Router
+	if !args.Has("command") {
+		e := embed.Embed{Title: "Commands"}
+		lines := []string{}
+		for _, c := range r.Commands() {
+			path := []*Command{c}
+			if ok, err := ctx.allowed(path); err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
+			lines = append(lines, "`"+usage(ctx.Prefix, path)+"` - "+c.Description)
+		}
+		e.Description = strings.Join(lines, "\n")
+		e.Footer = &embed.Footer{Text: "Use " + ctx.Prefix + "help <command> for more information"}
+		return ctx.ReplyEmbed(e)
+	}
+	words := strings.Fields(strings.TrimPrefix(args.String("command"), ctx.Prefix))
+	path, n := r.Find(words)
+	if len(path) == 0 || n != len(words) {
+		return userErrorf("there isn't a command called %q", args.String("command"))
+	}
+	if ok, err := ctx.allowed(path); err != nil {
+		return err
+	} else if !ok {
+		return userErrorf("there isn't a command called %q", args.String("command"))
+	}
+	c := path[len(path)-1]
+	e := embed.Embed{
+		Title:       usage(ctx.Prefix, path),
+		Description: c.Description,
+	}
+	if len(c.Aliases) > 0 {
+		e.Fields = append(e.Fields, embed.Field{Name: "Aliases", Value: strings.Join(c.Aliases, ", ")})
+	}
+	if len(c.Args) > 0 {
+		lines := []string{}
+		for _, a := range c.Args {
+			line := "`" + a.Name + "` (" + a.Type.String()
+			if a.Optional {
+				line += ", optional"
+			}
+			line += ")"
+			if a.Description != "" {
+				line += " - " + a.Description
+			}
+			lines = append(lines, line)
+		}
+		e.Fields = append(e.Fields, embed.Field{Name: "Arguments", Value: strings.Join(lines, "\n")})
+	}
+	if len(c.Subcommands) > 0 {
+		lines := []string{}
+		for _, sub := range c.Subcommands {
+			subPath := append(append([]*Command{}, path...), sub)
+			if ok, err := ctx.allowed(subPath); err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
+			lines = append(lines, "`"+usage(ctx.Prefix, subPath)+"` - "+sub.Description)
+		}
+		if len(lines) > 0 {
+			e.Fields = append(e.Fields, embed.Field{Name: "Subcommands", Value: strings.Join(lines, "\n")})
+		}
+	}
+	if p := requiredPermission(path); p != PermissionEveryone {
+		text := "Requires " + p.String() + " permission"
+		if capability := requiredCapability(path); capability != 0 {
+			text += " or the " + capability.String() + " capability"
+		}
+		if isGlobal(path) {
+			text += " in the home server"
+		}
+		e.Footer = &embed.Footer{Text: text}
+	}
+	return ctx.ReplyEmbed(e)
+}
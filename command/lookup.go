@@ -0,0 +1,77 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// findEdition finds an edition by id or name
+func findEdition(db *database.Database, s string) (database.Edition, error) {
+	editions, err := db.Editions()
+	if err != nil {
+		return database.Edition{}, errors.Wrap(err, "failed to get editions")
+	}
+	for _, e := range editions {
+		if e.ID == s || strings.EqualFold(e.Name, s) {
+			return e, nil
+		}
+	}
+	return database.Edition{}, userErrorf("there isn't an edition called %q", s)
+}
+
+// findBuildClass finds a build class by id or name
+func findBuildClass(db *database.Database, s string) (database.BuildClass, error) {
+	classes, err := db.BuildClasses()
+	if err != nil {
+		return database.BuildClass{}, errors.Wrap(err, "failed to get build classes")
+	}
+	for _, c := range classes {
+		if c.ID == s || strings.EqualFold(c.Name, s) {
+			return c, nil
+		}
+	}
+	return database.BuildClass{}, userErrorf("there isn't a build class called %q", s)
+}
+
+// findRecordType finds a record type by id or name
+func findRecordType(db *database.Database, s string) (database.RecordType, error) {
+	types, err := db.RecordTypes()
+	if err != nil {
+		return database.RecordType{}, errors.Wrap(err, "failed to get record types")
+	}
+	for _, t := range types {
+		if t.ID == s || strings.EqualFold(t.Name, s) {
+			return t, nil
+		}
+	}
+	return database.RecordType{}, userErrorf("there isn't a record type called %q", s)
+}
+
+// findVersion finds a version of an edition by its version number
+func findVersion(db *database.Database, edition database.Edition, s string) (database.Version, error) {
+	v, ok, err := db.ParseVersion(edition.ID, s)
+	if ve, isVersionErr := errors.Cause(err).(*database.VersionError); isVersionErr {
+		return database.Version{}, userErrorf("%s", ve.Error())
+	} else if err != nil {
+		return database.Version{}, errors.Wrap(err, "failed to get version")
+	} else if !ok {
+		return database.Version{}, userErrorf("%s doesn't have a version %s", edition.Name, s)
+	}
+	return v, nil
+}
+
+// findStatus finds a status by id or name
+func findStatus(db *database.Database, s string) (database.Status, error) {
+	statuses, err := db.Statuses()
+	if err != nil {
+		return database.Status{}, errors.Wrap(err, "failed to get statuses")
+	}
+	for _, st := range statuses {
+		if st.ID == s || strings.EqualFold(st.Name, s) {
+			return st, nil
+		}
+	}
+	return database.Status{}, userErrorf("there isn't a status called %q", s)
+}
@@ -0,0 +1,231 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/Kappeh/RecordBot/embed"
+	"github.com/pkg/errors"
+)
+
+// Record creates the record command and its subcommands
+func Record() *Command {
+	recordArg := Arg{Name: "record", Description: "The id of the record", Type: ArgID, Complete: CompleteRecord}
+	return &Command{
+		Name:        "record",
+		Aliases:     []string{"r"},
+		Description: "Shows and manages records",
+		Global:      true,
+		Subcommands: []*Command{
+			{
+				Name:        "info",
+				Aliases:     []string{"show"},
+				Description: "Shows a record and its current holders",
+				Args:        []Arg{recordArg},
+				Run:         runRecordInfo,
+			},
+			{
+				Name:        "list",
+				Aliases:     []string{"search"},
+				Description: "Lists verified records, optionally only those with a name containing some text",
+				Args:        []Arg{{Name: "name", Description: "Text the name of the record contains", Type: ArgText, Optional: true}},
+				Run:         runRecordList,
+			},
+			{
+				Name:        "browse",
+				Description: "Lists the verified records of a record type",
+				Args: []Arg{
+					{Name: "type", Description: "The name of the record type", Type: ArgString, Complete: CompleteRecordType},
+					{Name: "edition", Description: "Only list records of this edition", Type: ArgString, Optional: true, Complete: CompleteEdition},
+					{Name: "class", Description: "Only list records of this build class", Type: ArgString, Optional: true, Complete: CompleteBuildClass},
+				},
+				Run: runRecordBrowse,
+			},
+			{
+				Name:        "pending",
+				Description: "Lists records and record updates waiting to be verified",
+				Permission:  PermissionModerator,
+				Capability:  database.CapabilityVerify,
+				Run:         runRecordPending,
+			},
+			{
+				Name:        "verify",
+				Description: "Verifies a record or applies a record update",
+				Args:        []Arg{recordArg},
+				Permission:  PermissionModerator,
+				Capability:  database.CapabilityVerify,
+				Run:         runRecordVerify,
+			},
+			{
+				Name:        "delete",
+				Description: "Deletes a record",
+				Args:        []Arg{recordArg},
+				Permission:  PermissionAdministrator,
+				Capability:  database.CapabilityAdmin,
+				Run:         runRecordDelete,
+			},
+		},
+	}
+}
+
+// getRecord gets a record that a user asked for
+func getRecord(db *database.Database, recordID string) (database.Record, error) {
+	r, ok, err := db.Record(recordID)
+	if err != nil {
+		return database.Record{}, errors.Wrap(err, "failed to get record")
+	} else if !ok {
+		return database.Record{}, userErrorf("there isn't a record with id %s", recordID)
+	}
+	return r, nil
+}
+
+// runRecordInfo shows a record
+func runRecordInfo(ctx *Context, args Args) error {
+	r, err := getRecord(ctx.DB, args.ID("record"))
+	if err != nil {
+		return err
+	}
+	d, err := embed.LoadRecord(ctx.DB, r)
+	if err != nil {
+		return errors.Wrap(err, "failed to load record")
+	}
+	return ctx.ReplyEmbed(embed.Record(d))
+}
+
+// runRecordList lists verified records
+func runRecordList(ctx *Context, args Args) error {
+	records, err := ctx.DB.Records()
+	if err != nil {
+		return errors.Wrap(err, "failed to get records")
+	}
+	query := strings.ToLower(args.String("name"))
+	lines := []string{}
+	for _, r := range records {
+		if !r.Verified || r.UpdateRequest || !strings.Contains(strings.ToLower(r.Name), query) {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("`%s` %s", r.ID, r.Name))
+	}
+	return ctx.ReplyEmbed(listEmbed("Records", lines))
+}
+
+// runRecordBrowse lists the verified records of a record type
+// optionally of only one edition and build class
+func runRecordBrowse(ctx *Context, args Args) error {
+	t, err := findRecordType(ctx.DB, args.String("type"))
+	if err != nil {
+		return err
+	}
+	var e database.Edition
+	if args.Has("edition") {
+		if e, err = findEdition(ctx.DB, args.String("edition")); err != nil {
+			return err
+		}
+	}
+	var c database.BuildClass
+	if args.Has("class") {
+		if c, err = findBuildClass(ctx.DB, args.String("class")); err != nil {
+			return err
+		}
+	}
+	records, err := ctx.DB.Records()
+	if err != nil {
+		return errors.Wrap(err, "failed to get records")
+	}
+	lines := []string{}
+	for _, r := range records {
+		if !r.Verified || r.UpdateRequest || r.RecordTypeID != t.ID ||
+			(e.ID != "" && r.EditionID != e.ID) || (c.ID != "" && r.BuildClassID != c.ID) {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("`%s` %s", r.ID, r.Name))
+	}
+	return ctx.ReplyEmbed(listEmbed(t.Name+" Records", lines))
+}
+
+// runRecordPending lists unverified records
+func runRecordPending(ctx *Context, args Args) error {
+	records, err := ctx.DB.Records()
+	if err != nil {
+		return errors.Wrap(err, "failed to get records")
+	}
+	lines := []string{}
+	for _, r := range records {
+		if r.Verified {
+			continue
+		}
+		line := fmt.Sprintf("`%s` %s", r.ID, r.Name)
+		if r.UpdateRequest {
+			line += fmt.Sprintf(" (update to record %s)", r.UpdateRequestRecordID)
+		}
+		lines = append(lines, line)
+	}
+	return ctx.ReplyEmbed(listEmbed("Pending Records", lines))
+}
+
+// runRecordVerify verifies a record
+// Verifying an update request replaces the original record's information
+// with the update and removes the update request
+func runRecordVerify(ctx *Context, args Args) error {
+	r, err := getRecord(ctx.DB, args.ID("record"))
+	if err != nil {
+		return err
+	}
+	if r.Verified {
+		return userErrorf("record %s is already verified", r.ID)
+	}
+	now := database.Timestamp(time.Now())
+	if !r.UpdateRequest {
+		r.Verified = true
+		r.VerifierID = ctx.AuthorID
+		r.VerifiedTimestamp = now
+		if _, _, err = ctx.DB.RecordEdit(r.ID, r); err != nil {
+			if ce, ok := errors.Cause(err).(*database.ConsistencyError); ok {
+				return userErrorf("%s", ce.Error())
+			}
+			return errors.Wrap(err, "failed to edit record")
+		}
+		return ctx.Reply("Record %s has been verified.", r.ID)
+	}
+	err = ctx.DB.Transaction(func(tx *database.Database) error {
+		original, err := getRecord(tx, r.UpdateRequestRecordID)
+		if err != nil {
+			return err
+		}
+		updated := r
+		updated.ID = original.ID
+		updated.Verified = true
+		updated.VerifierID = ctx.AuthorID
+		updated.VerifiedTimestamp = now
+		updated.UpdateRequest = false
+		updated.UpdateRequestRecordID = "0"
+		updated.SubmitterID = original.SubmitterID
+		if _, _, err = tx.RecordEdit(original.ID, updated); err != nil {
+			if ce, ok := errors.Cause(err).(*database.ConsistencyError); ok {
+				return userErrorf("%s", ce.Error())
+			}
+			return errors.Wrap(err, "failed to edit record")
+		}
+		if _, _, err = tx.RecordDelete(r.ID); err != nil {
+			return errors.Wrap(err, "failed to delete update request")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.Reply("Record %s has been updated.", r.UpdateRequestRecordID)
+}
+
+// runRecordDelete deletes a record
+func runRecordDelete(ctx *Context, args Args) error {
+	r, ok, err := ctx.DB.RecordDelete(args.ID("record"))
+	if err != nil {
+		return errors.Wrap(err, "failed to delete record")
+	} else if !ok {
+		return userErrorf("there isn't a record with id %s", args.ID("record"))
+	}
+	return ctx.Reply("Record %s (%s) has been deleted.", r.ID, r.Name)
+}
@@ -0,0 +1,57 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Kappeh/RecordBot/database"
+)
+
+func TestRecordCommands(t *testing.T) {
+	s := newFakeSession()
+	r, err := testDB.RecordCreate(database.Record{
+		VerifierID:            "0",
+		UpdateRequestRecordID: "0",
+		EditionID:             testEdition.ID,
+		BuildClassID:          testBuildClass.ID,
+		RecordTypeID:          testRecordType.ID,
+		Name:                  "Fastest Piston",
+		SubmitterID:           "0",
+	})
+	if err != nil {
+		t.Fatalf("failed to create record: %v", err)
+	}
+
+	if e := replyEmbed(t, s, homeGuild, member, "!r info "+r.ID); e.Title != r.Name {
+		t.Errorf("expected an embed of %s, got %+v", r.Name, e)
+	}
+	line := fmt.Sprintf("`%s` %s", r.ID, r.Name)
+	if e := replyEmbed(t, s, homeGuild, moderator, "!record pending"); !strings.Contains(e.Description, line) {
+		t.Errorf("expected the record to be pending, got %q", e.Description)
+	}
+	if e := replyEmbed(t, s, homeGuild, member, "!record list"); strings.Contains(e.Description, line) {
+		t.Errorf("expected unverified records not to be listed, got %q", e.Description)
+	}
+
+	want := fmt.Sprintf("Record %s has been verified.", r.ID)
+	if got := reply(t, s, homeGuild, moderator, "!record verify "+r.ID); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	want = fmt.Sprintf("record %s is already verified", r.ID)
+	if got := reply(t, s, homeGuild, moderator, "!record verify "+r.ID); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if e := replyEmbed(t, s, homeGuild, member, "!record list fastest"); !strings.Contains(e.Description, line) {
+		t.Errorf("expected the verified record to be listed, got %q", e.Description)
+	}
+
+	want = fmt.Sprintf("Record %s (%s) has been deleted.", r.ID, r.Name)
+	if got := reply(t, s, homeGuild, admin, "!record delete "+r.ID); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	want = fmt.Sprintf("there isn't a record with id %s", r.ID)
+	if got := reply(t, s, homeGuild, admin, "!record delete "+r.ID); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,96 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// capabilityNames gets the names of the capabilities
+// that can be given to roles
+func capabilityNames() []string {
+	names := []string{}
+	for _, c := range database.Capabilities {
+		names = append(names, c.String())
+	}
+	return names
+}
+
+// Role creates the role command and its subcommands
+func Role() *Command {
+	roleArg := Arg{Name: "role", Description: "The role or its id", Type: ArgRole}
+	capabilityArg := Arg{Name: "capability", Description: "The capability", Type: ArgString, Choices: capabilityNames()}
+	return &Command{
+		Name:        "role",
+		Aliases:     []string{"roles"},
+		Description: "Manages the capabilities given to roles",
+		Permission:  PermissionAdministrator,
+		Capability:  database.CapabilityAdmin,
+		GuildOnly:   true,
+		Subcommands: []*Command{
+			{
+				Name:        "grant",
+				Aliases:     []string{"give"},
+				Description: "Gives a capability to a role",
+				Args:        []Arg{roleArg, capabilityArg},
+				Run:         runRoleGrant,
+			},
+			{
+				Name:        "revoke",
+				Aliases:     []string{"remove"},
+				Description: "Takes a capability away from a role",
+				Args:        []Arg{roleArg, capabilityArg},
+				Run:         runRoleRevoke,
+			},
+			{
+				Name:        "list",
+				Description: "Lists the capabilities given to roles",
+				Run:         runRoleList,
+			},
+		},
+	}
+}
+
+// runRoleGrant gives a capability to a role
+func runRoleGrant(ctx *Context, args Args) error {
+	c, err := database.ParseCapability(args.String("capability"))
+	if err != nil {
+		return userErrorf("%s", err)
+	}
+	roleID := args.ID("role")
+	if _, ok, err := ctx.DB.GuildRoleCreate(ctx.GuildID, roleID, c); err != nil {
+		return errors.Wrap(err, "failed to create guild role")
+	} else if !ok {
+		return userErrorf("<@&%s> already has the %s capability", roleID, c)
+	}
+	return ctx.Reply("<@&%s> has been given the %s capability.", roleID, c)
+}
+
+// runRoleRevoke takes a capability away from a role
+func runRoleRevoke(ctx *Context, args Args) error {
+	c, err := database.ParseCapability(args.String("capability"))
+	if err != nil {
+		return userErrorf("%s", err)
+	}
+	roleID := args.ID("role")
+	if _, ok, err := ctx.DB.GuildRoleDelete(ctx.GuildID, roleID, c); err != nil {
+		return errors.Wrap(err, "failed to delete guild role")
+	} else if !ok {
+		return userErrorf("<@&%s> doesn't have the %s capability", roleID, c)
+	}
+	return ctx.Reply("The %s capability has been taken away from <@&%s>.", c, roleID)
+}
+
+// runRoleList lists the capabilities given to the guild's roles
+func runRoleList(ctx *Context, args Args) error {
+	grs, err := ctx.DB.GuildRoles(ctx.GuildID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get guild roles")
+	}
+	lines := []string{}
+	for _, gr := range grs {
+		lines = append(lines, fmt.Sprintf("<@&%s> %s", gr.RoleID, gr.Capability))
+	}
+	return ctx.ReplyEmbed(listEmbed("Role capabilities", lines))
+}
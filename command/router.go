@@ -0,0 +1,383 @@
+package command
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/Kappeh/RecordBot/wizard"
+	"github.com/pkg/errors"
+)
+
+// Router dispatches messages to the commands they call
+type Router struct {
+	// Prefix is the text messages must start with to call a command
+	Prefix string
+	// HomeGuildID is the id of the guild whose moderators and
+	// administrators may use global commands
+	// Only the bot's owners may use them if it's empty
+	HomeGuildID string
+
+	// commands are the registered top level commands
+	commands []*Command
+}
+
+// Message is a message that may call a command
+type Message struct {
+	// GuildID is the id of the guild the message was sent in
+	// It's empty for direct messages
+	GuildID string
+	// ChannelID is the id of the channel the message was sent in
+	ChannelID string
+	// AuthorID is the id of the user that sent the message
+	AuthorID string
+	// Content is the text of the message
+	Content string
+}
+
+// NewRouter creates a router with no commands
+func NewRouter(prefix string) *Router {
+	return &Router{Prefix: prefix}
+}
+
+// NewDefaultRouter creates a router with all of the bot's commands
+func NewDefaultRouter(prefix string) (*Router, error) {
+	r := NewRouter(prefix)
+	if err := r.Register(Help(), Build(), Record(), Strike(), Ticket(), Role(), Settings()); err != nil {
+		return nil, errors.Wrap(err, "failed to register commands")
+	}
+	return r, nil
+}
+
+// Register adds top level commands to the router
+// An error is returned if a name or alias is already used
+// or if a command's arguments are invalid
+func (r *Router) Register(cmds ...*Command) error {
+	for _, c := range cmds {
+		if err := checkCommand(c, nil); err != nil {
+			return err
+		}
+		if err := checkNames(append(append([]*Command{}, r.commands...), c)); err != nil {
+			return err
+		}
+		r.commands = append(r.commands, c)
+	}
+	return nil
+}
+
+// Commands gets the registered top level commands sorted by name
+func (r *Router) Commands() []*Command {
+	cmds := append([]*Command{}, r.commands...)
+	sort.Slice(cmds, func(i, j int) bool {
+		return cmds[i].Name < cmds[j].Name
+	})
+	return cmds
+}
+
+// Find finds the command called by a list of words
+// The command and its parents are returned along with
+// the number of words used to find them
+func (r *Router) Find(words []string) ([]*Command, int) {
+	path := []*Command{}
+	cmds := r.commands
+	for _, w := range words {
+		c := lookup(cmds, w)
+		if c == nil {
+			break
+		}
+		path = append(path, c)
+		cmds = c.Subcommands
+	}
+	return path, len(path)
+}
+
+// GuildPrefix gets the prefix of message commands within a guild
+// The router's prefix is used in direct messages and in guilds
+// which haven't set their own prefix
+func (r *Router) GuildPrefix(db *database.Database, guildID string) (string, error) {
+	if guildID == "" {
+		return r.Prefix, nil
+	}
+	v, err := db.GuildSettingGet(guildID, database.SettingPrefix)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get prefix")
+	} else if v.String() == "" {
+		return r.Prefix, nil
+	}
+	return v.String(), nil
+}
+
+// Dispatch runs the command called by a message
+// false is returned if the message doesn't call a command
+// Mistakes made by the user are replied to rather than returned
+func (r *Router) Dispatch(s Session, db *database.Database, m Message) (bool, error) {
+	prefix, err := r.GuildPrefix(db, m.GuildID)
+	if err != nil {
+		return false, err
+	}
+	if !strings.HasPrefix(m.Content, prefix) {
+		return false, nil
+	}
+	input := m.Content[len(prefix):]
+	ctx := &Context{
+		Session:   s,
+		DB:        db,
+		Router:    r,
+		Prefix:    prefix,
+		GuildID:   m.GuildID,
+		ChannelID: m.ChannelID,
+		AuthorID:  m.AuthorID,
+	}
+	tokens, err := tokenize(input)
+	if err != nil {
+		return true, r.reply(ctx, err)
+	}
+	words := make([]string, len(tokens))
+	for i, t := range tokens {
+		words[i] = t.text
+	}
+	path, n := r.Find(words)
+	if len(path) == 0 {
+		// Messages starting with the prefix that don't
+		// call a command are ignored
+		return false, nil
+	}
+	ctx.Path = path
+	if err = r.authorize(ctx); err != nil {
+		return true, r.reply(ctx, err)
+	}
+	args, err := parseArgs(ctx.Command().Args, tokens[n:], input)
+	if err != nil {
+		return true, r.reply(ctx, userErrorf("%s\nUsage: `%s`", err, usage(ctx.Prefix, path)))
+	}
+	return true, r.reply(ctx, ctx.Command().Run(ctx, args))
+}
+
+// Execute runs a command with arguments that have already been parsed
+// path is the command and its parents, outermost first
+// Permissions are checked before the command is ran and mistakes
+// made by the user are replied to rather than returned
+func (r *Router) Execute(s Session, db *database.Database, m Message, path []*Command, args Args) error {
+	ctx := &Context{
+		Session:   s,
+		DB:        db,
+		Router:    r,
+		Prefix:    r.Prefix,
+		GuildID:   m.GuildID,
+		ChannelID: m.ChannelID,
+		AuthorID:  m.AuthorID,
+		Path:      path,
+	}
+	if err := r.authorize(ctx); err != nil {
+		return r.reply(ctx, err)
+	}
+	return r.reply(ctx, ctx.Command().Run(ctx, args))
+}
+
+// authorize gets the permission of the author and checks that
+// the command in the context can be ran
+// Groups of subcommands can't be ran so their subcommands are listed
+func (r *Router) authorize(ctx *Context) error {
+	var err error
+	if ctx.Permission, err = ctx.Session.Permission(ctx.GuildID, ctx.AuthorID); err != nil {
+		return errors.Wrap(err, "failed to get permission")
+	}
+	if ctx.GuildID != "" {
+		if ctx.Roles, err = ctx.Session.Roles(ctx.GuildID, ctx.AuthorID); err != nil {
+			return errors.Wrap(err, "failed to get roles")
+		}
+		// Members with one of the guild's moderator roles are moderators
+		if ctx.Permission < PermissionModerator {
+			moderatorRoles, err := ctx.DB.GuildSettingGet(ctx.GuildID, database.SettingModeratorRoles)
+			if err != nil {
+				return errors.Wrap(err, "failed to get moderator roles")
+			}
+			has := map[string]bool{}
+			for _, id := range ctx.Roles {
+				has[id] = true
+			}
+			for _, id := range moderatorRoles.IDs() {
+				if has[id] {
+					ctx.Permission = PermissionModerator
+				}
+			}
+		}
+	}
+	c := ctx.Command()
+	if ok, err := ctx.allowed(ctx.Path); err != nil {
+		return err
+	} else if !ok {
+		return userErrorf("you don't have permission to use this command")
+	}
+	if c.GuildOnly && ctx.GuildID == "" {
+		return userErrorf("this command can only be used in a server")
+	}
+	if c.Run == nil {
+		list, err := r.subcommandList(ctx, ctx.Path)
+		if err != nil {
+			return err
+		}
+		return userErrorf("%s", list)
+	}
+	return nil
+}
+
+// Usage gets the usage of a command such as "!strike add <user> <reason...>"
+// path is the command and its parents, outermost first
+func (r *Router) Usage(path []*Command) string {
+	return usage(r.Prefix, path)
+}
+
+// usage gets the usage of a command called with a prefix
+func usage(prefix string, path []*Command) string {
+	parts := []string{}
+	for _, c := range path {
+		parts = append(parts, c.Name)
+	}
+	c := path[len(path)-1]
+	if c.Run == nil && len(c.Subcommands) > 0 {
+		parts = append(parts, "<subcommand>")
+	}
+	for _, a := range c.Args {
+		name := a.Name
+		if a.Type == ArgText {
+			name += "..."
+		}
+		if a.Optional {
+			parts = append(parts, "["+name+"]")
+		} else {
+			parts = append(parts, "<"+name+">")
+		}
+	}
+	return prefix + strings.Join(parts, " ")
+}
+
+// reply replies to a user error and returns any other error
+func (r *Router) reply(ctx *Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	switch e := errors.Cause(err).(type) {
+	case *UserError:
+		return ctx.Reply("%s", e.Message)
+	case *wizard.InputError:
+		return ctx.Reply("%s", e.Message)
+	}
+	if replyErr := ctx.Reply("Something went wrong while running that command."); replyErr != nil {
+		return errors.Wrapf(err, "failed to reply to failed command (%v)", replyErr)
+	}
+	return err
+}
+
+// subcommandList lists the subcommands of a command
+// which the user has permission to use
+func (r *Router) subcommandList(ctx *Context, path []*Command) (string, error) {
+	lines := []string{"Usage: `" + usage(ctx.Prefix, path) + "`"}
+	for _, sub := range path[len(path)-1].Subcommands {
+		subPath := append(append([]*Command{}, path...), sub)
+		if ok, err := ctx.allowed(subPath); err != nil {
+			return "", err
+		} else if !ok {
+			continue
+		}
+		lines = append(lines, "`"+usage(ctx.Prefix, subPath)+"` - "+sub.Description)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// requiredPermission gets the permission needed to use
+// the last command in a path
+func requiredPermission(path []*Command) Permission {
+	p := PermissionEveryone
+	for _, c := range path {
+		if c.Permission > p {
+			p = c.Permission
+		}
+	}
+	return p
+}
+
+// isGlobal determines whether the last command in a path is global
+func isGlobal(path []*Command) bool {
+	for _, c := range path {
+		if c.Global {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredCapability gets the capability which allows the last command
+// in a path to be used, which is the innermost capability in the path
+func requiredCapability(path []*Command) database.Capability {
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i].Capability != 0 {
+			return path[i].Capability
+		}
+	}
+	return 0
+}
+
+// lookup finds a command by name or alias
+func lookup(cmds []*Command, name string) *Command {
+	name = strings.ToLower(name)
+	for _, c := range cmds {
+		if c.Name == name {
+			return c
+		}
+		for _, a := range c.Aliases {
+			if a == name {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// checkNames checks that no two commands share a name or alias
+func checkNames(cmds []*Command) error {
+	used := map[string]bool{}
+	for _, c := range cmds {
+		for _, name := range append([]string{c.Name}, c.Aliases...) {
+			if used[name] {
+				return errors.Errorf("command name %q is used more than once", name)
+			}
+			used[name] = true
+		}
+	}
+	return nil
+}
+
+// checkCommand checks that a command and its subcommands are valid
+func checkCommand(c *Command, parents []*Command) error {
+	path := append(append([]*Command{}, parents...), c)
+	if c.Name == "" || strings.ToLower(c.Name) != c.Name || strings.ContainsAny(c.Name, " \t\n") {
+		return errors.Errorf("invalid command name %q", c.Name)
+	}
+	if c.Run == nil && len(c.Subcommands) == 0 {
+		return errors.Errorf("command %q has nothing to run", c.Name)
+	}
+	if c.Capability != 0 && !c.Capability.Valid() {
+		return errors.Errorf("command %q has an unknown capability", c.Name)
+	}
+	optional := false
+	for i, a := range c.Args {
+		if a.Optional {
+			optional = true
+		} else if optional {
+			return errors.Errorf("command %q has a required argument after an optional one", c.Name)
+		}
+		if a.Type == ArgText && i != len(c.Args)-1 {
+			return errors.Errorf("command %q has a text argument that isn't last", c.Name)
+		}
+	}
+	if err := checkNames(c.Subcommands); err != nil {
+		return errors.Wrapf(err, "invalid subcommands of %q", c.Name)
+	}
+	for _, sub := range c.Subcommands {
+		if err := checkCommand(sub, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
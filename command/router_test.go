@@ -0,0 +1,367 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/Kappeh/RecordBot/embed"
+)
+
+// Ids of the guilds, channels and users that commands are used by
+const (
+	homeGuild  = "100"
+	otherGuild = "200"
+	channel    = "300"
+
+	owner     = "1"
+	admin     = "2"
+	moderator = "3"
+	member    = "4"
+	// striker is a member with a role given the strike capability
+	striker = "5"
+
+	strikeRole = "400"
+)
+
+// testDB is the database shared by the tests
+// The database package only allows one database to be opened
+var testDB *database.Database
+
+// The edition, build class and record type which
+// the builds and records of the tests are in
+var (
+	testEdition    database.Edition
+	testBuildClass database.BuildClass
+	testRecordType database.RecordType
+)
+
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "command")
+	if err != nil {
+		panic(err)
+	}
+	testDB, err = database.Open(filepath.Join(dir, "database.db"))
+	if err != nil {
+		os.RemoveAll(dir)
+		panic(err)
+	}
+	if err = seed(testDB); err != nil {
+		testDB.Close()
+		os.RemoveAll(dir)
+		panic(err)
+	}
+	code := m.Run()
+	testDB.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// seed sets up the guilds, edition, build class
+// and record type the tests use
+func seed(db *database.Database) (err error) {
+	for _, guildID := range []string{homeGuild, otherGuild} {
+		if _, _, err = db.GuildSettingCreate(guildID); err != nil {
+			return err
+		}
+	}
+	if _, _, err = db.GuildRoleCreate(homeGuild, strikeRole, database.CapabilityStrike); err != nil {
+		return err
+	}
+	if testEdition, err = db.EditionCreate("Java", "Java Edition"); err != nil {
+		return err
+	}
+	if testBuildClass, err = db.BuildClassCreate("Piston", "Piston builds", "", ""); err != nil {
+		return err
+	}
+	testRecordType, err = db.RecordTypeCreate("Fastest", "The fastest builds")
+	return err
+}
+
+// sent is a message or embed sent through a fake session
+type sent struct {
+	channelID string
+	content   string
+	embed     *embed.Embed
+}
+
+// fakeSession is a session where users have fixed permissions and roles
+// Everything sent through it is recorded
+type fakeSession struct {
+	permissions map[string]Permission
+	roles       map[string][]string
+
+	sent     []sent
+	channels int
+	deleted  []string
+}
+
+// newFakeSession creates a session with the permissions of the test users
+func newFakeSession() *fakeSession {
+	return &fakeSession{
+		permissions: map[string]Permission{
+			owner:     PermissionOwner,
+			admin:     PermissionAdministrator,
+			moderator: PermissionModerator,
+		},
+		roles: map[string][]string{
+			striker: {strikeRole},
+		},
+	}
+}
+
+func (s *fakeSession) Send(channelID, content string) error {
+	s.sent = append(s.sent, sent{channelID: channelID, content: content})
+	return nil
+}
+
+func (s *fakeSession) SendEmbed(channelID string, e embed.Embed) error {
+	s.sent = append(s.sent, sent{channelID: channelID, embed: &e})
+	return nil
+}
+
+func (s *fakeSession) Permission(guildID, userID string) (Permission, error) {
+	return s.permissions[userID], nil
+}
+
+func (s *fakeSession) Roles(guildID, userID string) ([]string, error) {
+	if guildID != homeGuild {
+		return nil, nil
+	}
+	return s.roles[userID], nil
+}
+
+func (s *fakeSession) CreateTicketChannel(guildID, categoryID, name, creatorID string) (string, error) {
+	s.channels++
+	return strconv.Itoa(1000 + s.channels), nil
+}
+
+func (s *fakeSession) DeleteChannel(channelID string) error {
+	s.deleted = append(s.deleted, channelID)
+	return nil
+}
+
+// newTestRouter creates a router with all of the bot's commands
+func newTestRouter(t *testing.T) *Router {
+	t.Helper()
+	r, err := NewDefaultRouter("!")
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+	r.HomeGuildID = homeGuild
+	return r
+}
+
+// dispatch sends a message calling a command and gets what was sent
+// in reply. The message has to call a command
+func dispatch(t *testing.T, s *fakeSession, guildID, authorID, content string) []sent {
+	t.Helper()
+	return dispatchIn(t, s, Message{GuildID: guildID, ChannelID: channel, AuthorID: authorID, Content: content})
+}
+
+// dispatchIn sends a message calling a command in any channel
+// and gets what was sent in reply
+func dispatchIn(t *testing.T, s *fakeSession, m Message) []sent {
+	t.Helper()
+	content := m.Content
+	n := len(s.sent)
+	ok, err := newTestRouter(t).Dispatch(s, testDB, m)
+	if err != nil {
+		t.Fatalf("%s failed: %v", content, err)
+	} else if !ok {
+		t.Fatalf("%s didn't call a command", content)
+	}
+	return s.sent[n:]
+}
+
+// reply sends a message calling a command and gets
+// the single message sent in reply
+func reply(t *testing.T, s *fakeSession, guildID, authorID, content string) string {
+	t.Helper()
+	replies := dispatch(t, s, guildID, authorID, content)
+	if len(replies) != 1 || replies[0].embed != nil || replies[0].channelID != channel {
+		t.Fatalf("%s: expected a single message in reply, got %+v", content, replies)
+	}
+	return replies[0].content
+}
+
+// replyEmbed sends a message calling a command and gets
+// the single embed sent in reply
+func replyEmbed(t *testing.T, s *fakeSession, guildID, authorID, content string) embed.Embed {
+	t.Helper()
+	replies := dispatch(t, s, guildID, authorID, content)
+	if len(replies) != 1 || replies[0].embed == nil || replies[0].channelID != channel {
+		t.Fatalf("%s: expected a single embed in reply, got %+v", content, replies)
+	}
+	return *replies[0].embed
+}
+
+func TestFind(t *testing.T) {
+	r := newTestRouter(t)
+	tests := []struct {
+		words []string
+		want  []string
+		n     int
+	}{
+		{[]string{"build", "info"}, []string{"build", "info"}, 2},
+		{[]string{"b", "show", "1"}, []string{"build", "info"}, 2},
+		{[]string{"STRIKES", "Give"}, []string{"strike", "add"}, 2},
+		{[]string{"t", "open", "build"}, []string{"ticket", "open"}, 2},
+		{[]string{"commands"}, []string{"help"}, 1},
+		{[]string{"build", "nope"}, []string{"build"}, 1},
+		{[]string{"nope"}, []string{}, 0},
+	}
+	for _, tt := range tests {
+		path, n := r.Find(tt.words)
+		got := []string{}
+		for _, c := range path {
+			got = append(got, c.Name)
+		}
+		if n != tt.n || strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("Find(%q) = %q, %d, expected %q, %d", tt.words, got, n, tt.want, tt.n)
+		}
+	}
+}
+
+func TestUsage(t *testing.T) {
+	r := newTestRouter(t)
+	tests := []struct {
+		words []string
+		want  string
+	}{
+		{[]string{"build"}, "!build <subcommand>"},
+		{[]string{"build", "timing"}, "!build timing <build> <timing> <close> <open>"},
+		{[]string{"b", "size"}, "!build dimensions <build> <dimensions>"},
+		{[]string{"strike", "add"}, "!strike add <user> <reason...>"},
+		{[]string{"strike", "list"}, "!strike list [user]"},
+		{[]string{"ticket", "open"}, "!ticket open [type]"},
+	}
+	for _, tt := range tests {
+		path, _ := r.Find(tt.words)
+		if got := r.Usage(path); got != tt.want {
+			t.Errorf("Usage(%q) = %q, expected %q", tt.words, got, tt.want)
+		}
+	}
+}
+
+func TestDispatchIgnoresOtherMessages(t *testing.T) {
+	r := newTestRouter(t)
+	s := newFakeSession()
+	for _, content := range []string{"hello", "!nope", "!"} {
+		m := Message{GuildID: homeGuild, ChannelID: channel, AuthorID: member, Content: content}
+		if ok, err := r.Dispatch(s, testDB, m); err != nil || ok {
+			t.Errorf("Dispatch(%q) = %v, %v, expected it to be ignored", content, ok, err)
+		}
+	}
+	if len(s.sent) != 0 {
+		t.Errorf("expected nothing to be sent, got %+v", s.sent)
+	}
+}
+
+func TestDispatchInvalidArguments(t *testing.T) {
+	s := newFakeSession()
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"!build info", "missing argument <build>\nUsage: `!build info <build>`"},
+		{"!build info abc", "invalid argument <build>: \"abc\" isn't an id or mention\nUsage: `!build info <build>`"},
+		{`!strike add 123 "unterminated`, "unterminated quote"},
+		{"!build", "Usage: `!build <subcommand>`"},
+	}
+	for _, tt := range tests {
+		if got := reply(t, s, homeGuild, owner, tt.content); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("%s: expected a reply starting with %q, got %q", tt.content, tt.want, got)
+		}
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	const denied = "you don't have permission to use this command"
+	tests := []struct {
+		name    string
+		guildID string
+		userID  string
+		content string
+		// allowed is whether the command is ran rather than rejected
+		allowed bool
+	}{
+		{"member", homeGuild, member, "!strike list", false},
+		{"moderator", homeGuild, moderator, "!strike list", true},
+		{"capability", homeGuild, striker, "!strike list", true},
+		{"owner", otherGuild, owner, "!strike list", true},
+		{"moderator of another guild", otherGuild, moderator, "!strike list", false},
+		{"moderator in direct messages", "", moderator, "!strike list", false},
+		{"everyone", otherGuild, member, "!build list", true},
+		{"moderator below administrator", homeGuild, moderator, "!build delete 999", false},
+		{"administrator", homeGuild, admin, "!build delete 999", true},
+		{"capability of another command", homeGuild, striker, "!build verify 999", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replies := dispatch(t, newFakeSession(), tt.guildID, tt.userID, tt.content)
+			rejected := len(replies) == 1 && replies[0].content == denied
+			if rejected == tt.allowed {
+				t.Fatalf("expected allowed %v, got %+v", tt.allowed, replies)
+			}
+		})
+	}
+}
+
+func TestGuildOnly(t *testing.T) {
+	got := reply(t, newFakeSession(), "", member, "!ticket open")
+	if want := "this command can only be used in a server"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestHelp(t *testing.T) {
+	s := newFakeSession()
+
+	e := replyEmbed(t, s, homeGuild, member, "!commands")
+	if !strings.Contains(e.Description, "`!help [command...]` - Lists commands or describes a command") {
+		t.Errorf("expected help to be listed, got %q", e.Description)
+	}
+	if strings.Contains(e.Description, "!strike") {
+		t.Errorf("expected commands the member can't use to be left out, got %q", e.Description)
+	}
+	if e = replyEmbed(t, s, homeGuild, moderator, "!help"); !strings.Contains(e.Description, "`!strike <subcommand>`") {
+		t.Errorf("expected moderators to see strike, got %q", e.Description)
+	}
+
+	e = replyEmbed(t, s, homeGuild, moderator, "!help !strikes give")
+	if e.Title != "!strike add <user> <reason...>" || e.Description != "Gives a strike to a user" {
+		t.Errorf("unexpected help for strike add %+v", e)
+	}
+	fields := map[string]string{}
+	for _, f := range e.Fields {
+		fields[f.Name] = f.Value
+	}
+	if fields["Aliases"] != "give" {
+		t.Errorf("expected aliases to be listed, got %q", fields["Aliases"])
+	}
+	if want := "`user` (user) - The user or their id\n`reason` (text) - Why the strike was given"; fields["Arguments"] != want {
+		t.Errorf("expected arguments %q, got %q", want, fields["Arguments"])
+	}
+	if want := "Requires moderator permission or the strike capability in the home server"; e.Footer == nil || e.Footer.Text != want {
+		t.Errorf("expected footer %q, got %+v", want, e.Footer)
+	}
+
+	// Subcommands the user can't use aren't listed
+	e = replyEmbed(t, s, homeGuild, member, "!help build")
+	for _, f := range e.Fields {
+		if f.Name == "Subcommands" && (!strings.Contains(f.Value, "!build info <build>") || strings.Contains(f.Value, "!build delete")) {
+			t.Errorf("unexpected subcommands %q", f.Value)
+		}
+	}
+
+	for _, content := range []string{"!help strike", "!help nope", "!help build nope"} {
+		if got := reply(t, s, homeGuild, member, content); !strings.HasPrefix(got, "there isn't a command called") {
+			t.Errorf("%s: expected the command not to be found, got %q", content, got)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package command
+
+import (
+	"github.com/Kappeh/RecordBot/embed"
+)
+
+// Session is the connection to discord that commands are ran through
+// It's an interface so that commands can be ran without discord
+type Session interface {
+	// Send sends a message to a channel
+	Send(channelID, content string) error
+	// SendEmbed sends an embed to a channel
+	SendEmbed(channelID string, e embed.Embed) error
+	// Permission gets the permission level of a user
+	// guildID is empty for direct messages
+	Permission(guildID, userID string) (Permission, error)
+	// Roles gets the ids of a member's roles within a guild
+	Roles(guildID, userID string) ([]string, error)
+	// CreateTicketChannel creates a channel within a category which can
+	// only be seen by the creator and the guild's moderators
+	// The id of the new channel is returned
+	CreateTicketChannel(guildID, categoryID, name, creatorID string) (string, error)
+	// DeleteChannel deletes a channel
+	DeleteChannel(channelID string) error
+}
@@ -0,0 +1,149 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/Kappeh/RecordBot/embed"
+	"github.com/pkg/errors"
+)
+
+// settingKeys gets the keys of the settings guilds can change
+func settingKeys() []string {
+	keys := []string{}
+	for _, sd := range database.SettingDefinitions {
+		keys = append(keys, sd.Key)
+	}
+	return keys
+}
+
+// Settings creates the settings command and its subcommands
+func Settings() *Command {
+	keyArg := Arg{Name: "setting", Description: "The setting", Type: ArgString, Choices: settingKeys()}
+	return &Command{
+		Name:        "settings",
+		Aliases:     []string{"setting", "config"},
+		Description: "Shows and changes the server's settings",
+		Permission:  PermissionAdministrator,
+		Capability:  database.CapabilityAdmin,
+		GuildOnly:   true,
+		Subcommands: []*Command{
+			{
+				Name:        "list",
+				Description: "Shows the value of every setting",
+				Run:         runSettingsList,
+			},
+			{
+				Name:        "set",
+				Description: "Changes a setting",
+				Args: []Arg{
+					keyArg,
+					{Name: "value", Description: "The new value", Type: ArgText},
+				},
+				Run: runSettingsSet,
+			},
+			{
+				Name:        "reset",
+				Description: "Changes a setting back to its default",
+				Args:        []Arg{keyArg},
+				Run:         runSettingsReset,
+			},
+			{
+				Name:        "history",
+				Description: "Shows the changes made to settings",
+				Args:        []Arg{{Name: "setting", Description: "The setting, all if left out", Type: ArgString, Optional: true, Choices: settingKeys()}},
+				Run:         runSettingsHistory,
+			},
+		},
+	}
+}
+
+// runSettingsList shows the value of every setting of the guild
+func runSettingsList(ctx *Context, args Args) error {
+	values, err := ctx.DB.GuildSettingValues(ctx.GuildID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get guild settings")
+	}
+	e := embed.Embed{Title: "Settings"}
+	for _, v := range values {
+		value := displaySetting(v.Definition, v.Value)
+		if v.IsDefault {
+			value += " (default)"
+		}
+		e.Fields = append(e.Fields, embed.Field{
+			Name:  v.Definition.Key,
+			Value: value + "\n" + v.Definition.Description,
+		})
+	}
+	return ctx.ReplyEmbed(e)
+}
+
+// runSettingsSet changes a setting of the guild
+func runSettingsSet(ctx *Context, args Args) error {
+	v, err := ctx.DB.GuildSettingSet(ctx.GuildID, args.String("setting"), args.String("value"), ctx.AuthorID)
+	if se, ok := errors.Cause(err).(*database.SettingError); ok {
+		return userErrorf("%s", se.Message)
+	} else if err != nil {
+		return errors.Wrap(err, "failed to set guild setting")
+	}
+	return ctx.Reply("%s has been set to %s.", v.Definition.Key, displaySetting(v.Definition, v.Value))
+}
+
+// runSettingsReset changes a setting of the guild back to its default
+func runSettingsReset(ctx *Context, args Args) error {
+	v, ok, err := ctx.DB.GuildSettingReset(ctx.GuildID, args.String("setting"), ctx.AuthorID)
+	if err != nil {
+		return errors.Wrap(err, "failed to reset guild setting")
+	} else if !ok {
+		return userErrorf("%s already has its default value", v.Definition.Key)
+	}
+	return ctx.Reply("%s has been reset to %s.", v.Definition.Key, displaySetting(v.Definition, v.Value))
+}
+
+// runSettingsHistory lists the changes made to the guild's settings
+func runSettingsHistory(ctx *Context, args Args) error {
+	key := ""
+	if args.Has("setting") {
+		key = args.String("setting")
+	}
+	changes, err := ctx.DB.GuildSettingChanges(ctx.GuildID, key)
+	if err != nil {
+		return errors.Wrap(err, "failed to get guild setting changes")
+	}
+	lines := []string{}
+	for _, c := range changes {
+		sd, _ := database.FindSetting(c.Key)
+		line := fmt.Sprintf("%s %s: %s → %s",
+			time.Time(c.Timestamp).Format("2006-01-02 15:04"), c.Key,
+			displaySetting(sd, c.OldValue), displaySetting(sd, c.NewValue),
+		)
+		if c.Reset {
+			line += " (reset)"
+		}
+		if c.ChangedByID != "" {
+			line += " by <@" + c.ChangedByID + ">"
+		}
+		lines = append(lines, line)
+	}
+	return ctx.ReplyEmbed(listEmbed("Setting History", lines))
+}
+
+// displaySetting formats the value of a setting to be shown in discord
+func displaySetting(sd database.SettingDefinition, value string) string {
+	if value == "" {
+		return "none"
+	}
+	switch sd.Type {
+	case database.SettingChannel:
+		return "<#" + value + ">"
+	case database.SettingRoles:
+		mentions := []string{}
+		for _, id := range strings.Split(value, ",") {
+			mentions = append(mentions, "<@&"+id+">")
+		}
+		return strings.Join(mentions, ", ")
+	}
+	return "`" + value + "`"
+}
@@ -0,0 +1,122 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// Strike creates the strike command and its subcommands
+func Strike() *Command {
+	userArg := Arg{Name: "user", Description: "The user or their id", Type: ArgUser}
+	strikeArg := Arg{Name: "strike", Description: "The id of the strike", Type: ArgInt}
+	reasonArg := Arg{Name: "reason", Description: "Why the strike was given", Type: ArgText}
+	return &Command{
+		Name:        "strike",
+		Aliases:     []string{"strikes"},
+		Description: "Manages the strikes given to users",
+		Permission:  PermissionModerator,
+		Capability:  database.CapabilityStrike,
+		Global:      true,
+		Subcommands: []*Command{
+			{
+				Name:        "add",
+				Aliases:     []string{"give"},
+				Description: "Gives a strike to a user",
+				Args:        []Arg{userArg, reasonArg},
+				Run:         runStrikeAdd,
+			},
+			{
+				Name:        "list",
+				Description: "Lists the strikes of a user, or how many strikes each user has",
+				Args:        []Arg{{Name: "user", Description: "The user or their id", Type: ArgUser, Optional: true}},
+				Run:         runStrikeList,
+			},
+			{
+				Name:        "edit",
+				Description: "Changes the reason for a strike",
+				Args:        []Arg{userArg, strikeArg, reasonArg},
+				Run:         runStrikeEdit,
+			},
+			{
+				Name:        "remove",
+				Aliases:     []string{"delete"},
+				Description: "Removes a strike from a user",
+				Args:        []Arg{userArg, strikeArg},
+				Run:         runStrikeRemove,
+			},
+		},
+	}
+}
+
+// runStrikeAdd gives a strike to a user
+func runStrikeAdd(ctx *Context, args Args) error {
+	userID := args.ID("user")
+	us, err := ctx.DB.UserStrikeCreate(userID, args.String("reason"), ctx.AuthorID)
+	if err != nil {
+		return errors.Wrap(err, "failed to create strike")
+	}
+	usc, err := ctx.DB.UserStrikeCount(userID)
+	if err != nil {
+		return errors.Wrap(err, "failed to count strikes")
+	}
+	return ctx.Reply("Strike %s has been given to <@%s>. They now have %d strike(s).", us.StrikeID, userID, usc.Count)
+}
+
+// runStrikeList lists the strikes of a user or
+// the number of strikes of every user
+func runStrikeList(ctx *Context, args Args) error {
+	lines := []string{}
+	if !args.Has("user") {
+		uscs, err := ctx.DB.UserStrikeCounts()
+		if err != nil {
+			return errors.Wrap(err, "failed to count strikes")
+		}
+		for _, usc := range uscs {
+			lines = append(lines, fmt.Sprintf("<@%s> %d", usc.UserID, usc.Count))
+		}
+		return ctx.ReplyEmbed(listEmbed("Strikes", lines))
+	}
+	userID := args.ID("user")
+	uss, err := ctx.DB.UserStrikes(userID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get strikes")
+	}
+	for _, us := range uss {
+		lines = append(lines, fmt.Sprintf("`%s` %s - %s by <@%s>",
+			us.StrikeID, us.Reason, time.Time(us.Timestamp).Format("2006-01-02"), us.AuthorID))
+	}
+	return ctx.ReplyEmbed(listEmbed("Strikes of "+userID, lines))
+}
+
+// runStrikeEdit changes the reason for a strike
+func runStrikeEdit(ctx *Context, args Args) error {
+	strikeID := strconv.Itoa(args.Int("strike"))
+	_, ok, err := ctx.DB.UserStrikeEdit(args.ID("user"), strikeID, args.String("reason"))
+	if err != nil {
+		return errors.Wrap(err, "failed to edit strike")
+	} else if !ok {
+		return noStrike(args.ID("user"), strikeID)
+	}
+	return ctx.Reply("Strike %s of <@%s> has been edited.", strikeID, args.ID("user"))
+}
+
+// runStrikeRemove removes a strike from a user
+func runStrikeRemove(ctx *Context, args Args) error {
+	strikeID := strconv.Itoa(args.Int("strike"))
+	_, ok, err := ctx.DB.UserStrikeDelete(args.ID("user"), strikeID)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete strike")
+	} else if !ok {
+		return noStrike(args.ID("user"), strikeID)
+	}
+	return ctx.Reply("Strike %s has been removed from <@%s>.", strikeID, args.ID("user"))
+}
+
+// noStrike is the error given when a user doesn't have a strike
+func noStrike(userID, strikeID string) error {
+	return userErrorf("<@%s> doesn't have a strike with id %s", userID, strikeID)
+}
@@ -0,0 +1,45 @@
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStrikeCommands(t *testing.T) {
+	const user = "900"
+	s := newFakeSession()
+
+	// Each step is ran in order on the same user
+	// Strikes are numbered from 0 for each user
+	steps := []struct {
+		userID  string
+		content string
+		want    string
+	}{
+		{moderator, "!strike add 900 griefing", "Strike 0 has been given to <@900>. They now have 1 strike(s)."},
+		{striker, "!strikes give <@!900> spamming in chat", "Strike 1 has been given to <@900>. They now have 2 strike(s)."},
+		{moderator, "!strike edit 900 0 griefing the spawn", "Strike 0 of <@900> has been edited."},
+		{moderator, "!strike edit 900 2 nothing", "<@900> doesn't have a strike with id 2"},
+		{moderator, "!strike remove 900 1", "Strike 1 has been removed from <@900>."},
+		{moderator, "!strike delete 900 1", "<@900> doesn't have a strike with id 1"},
+	}
+	for _, step := range steps {
+		if got := reply(t, s, homeGuild, step.userID, step.content); got != step.want {
+			t.Errorf("%s: expected %q, got %q", step.content, step.want, got)
+		}
+	}
+
+	e := replyEmbed(t, s, homeGuild, moderator, "!strike list "+user)
+	if e.Title != "Strikes of "+user {
+		t.Errorf("expected the strikes of %s, got %q", user, e.Title)
+	}
+	lines := strings.Split(e.Description, "\n")
+	if len(lines) != 1 || !strings.HasPrefix(lines[0], "`0` griefing the spawn - ") || !strings.HasSuffix(lines[0], " by <@"+moderator+">") {
+		t.Errorf("expected the edited strike to be listed, got %q", e.Description)
+	}
+
+	e = replyEmbed(t, s, homeGuild, moderator, "!strike list")
+	if e.Title != "Strikes" || !strings.Contains(e.Description, "<@"+user+"> 1") {
+		t.Errorf("expected the number of strikes to be listed, got %+v", e)
+	}
+}
@@ -0,0 +1,319 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/Kappeh/RecordBot/wizard"
+	"github.com/pkg/errors"
+)
+
+// ticketTypes are the names users give for each type of ticket
+var ticketTypes = []struct {
+	name       string
+	ticketType database.TicketType
+}{
+	{"general", database.TicketGeneral},
+	{"build", database.TicketSubmitBuild},
+	{"record", database.TicketSubmitRecord},
+	{"build-update", database.TicketSubmitBuildUpdate},
+	{"record-update", database.TicketSubmitRecordUpdate},
+}
+
+// ticketTypeNames gets the names of the ticket types
+func ticketTypeNames() []string {
+	names := []string{}
+	for _, t := range ticketTypes {
+		names = append(names, t.name)
+	}
+	return names
+}
+
+// Ticket creates the ticket command and its subcommands
+func Ticket() *Command {
+	return &Command{
+		Name:        "ticket",
+		Aliases:     []string{"t"},
+		Description: "Opens and manages tickets",
+		GuildOnly:   true,
+		Subcommands: []*Command{
+			{
+				Name:        "open",
+				Aliases:     []string{"new"},
+				Description: "Opens a ticket, which guides you through submitting if it's a submission",
+				Args: []Arg{
+					{Name: "type", Description: "The type of ticket, general if left out", Type: ArgString, Optional: true, Choices: ticketTypeNames()},
+				},
+				GuildOnly: true,
+				Run:       runTicketOpen,
+			},
+			{
+				Name:        "close",
+				Description: "Closes the ticket this is used in",
+				GuildOnly:   true,
+				Run:         runTicketClose,
+			},
+			{
+				Name:        "list",
+				Description: "Lists the open tickets",
+				Permission:  PermissionModerator,
+				Capability:  database.CapabilityTicketStaff,
+				GuildOnly:   true,
+				Run:         runTicketList,
+			},
+			{
+				Name:        "answer",
+				Description: "Answers the current step of a submission",
+				Args: []Arg{
+					{Name: "answer", Description: "The answer to the current step", Type: ArgText},
+				},
+				GuildOnly: true,
+				Run:       runTicketAnswer,
+			},
+			{
+				Name:        "skip",
+				Description: "Skips the current optional step of a submission",
+				GuildOnly:   true,
+				Run:         runTicketSkip,
+			},
+			{
+				Name:        "back",
+				Description: "Returns to the previous step of a submission",
+				GuildOnly:   true,
+				Run:         runTicketBack,
+			},
+			{
+				Name:        "submit",
+				Aliases:     []string{"finish"},
+				Description: "Submits a completed submission",
+				GuildOnly:   true,
+				Run:         runTicketSubmit,
+			},
+		},
+	}
+}
+
+// runTicketOpen opens a ticket
+func runTicketOpen(ctx *Context, args Args) error {
+	name := "general"
+	if args.Has("type") {
+		name = args.String("type")
+	}
+	// The type is one of ticketTypeNames
+	ticketType := database.TicketGeneral
+	for _, t := range ticketTypes {
+		if t.name == name {
+			ticketType = t.ticketType
+		}
+	}
+	category, err := ctx.DB.GuildSettingGet(ctx.GuildID, database.SettingTicketCategory)
+	if err != nil {
+		return errors.Wrap(err, "failed to get ticket category")
+	} else if category.ID() == "" {
+		return userErrorf("tickets haven't been set up in this server")
+	}
+	channelID, err := ctx.Session.CreateTicketChannel(ctx.GuildID, category.ID(), "ticket-"+name, ctx.AuthorID)
+	if err != nil {
+		return errors.Wrap(err, "failed to create ticket channel")
+	}
+	if _, _, err = ctx.DB.GuildTicketChannelCreate(ctx.GuildID, channelID, ticketType, ctx.AuthorID); err != nil {
+		// Don't leave behind a channel that isn't a ticket
+		if deleteErr := ctx.Session.DeleteChannel(channelID); deleteErr != nil {
+			return errors.Wrapf(err, "failed to create guild ticket channel (and failed to delete channel: %v)", deleteErr)
+		}
+		return errors.Wrap(err, "failed to create guild ticket channel")
+	}
+	if err = ctx.Reply("Your ticket has been opened in <#%s>.", channelID); err != nil {
+		return err
+	}
+	s, ok, err := wizard.Start(ctx.DB, ctx.GuildID, channelID)
+	if err != nil {
+		return errors.Wrap(err, "failed to start wizard")
+	} else if !ok {
+		return ctx.Session.Send(channelID, "Welcome <@"+ctx.AuthorID+">, a moderator will be with you shortly.")
+	}
+	return ctx.Session.Send(channelID, prompt(ctx.Prefix, s))
+}
+
+// getTicket gets the ticket the command was used in
+func getTicket(ctx *Context) (database.GuildTicketChannel, error) {
+	t, ok, err := ctx.DB.GuildTicketChannel(ctx.GuildID, ctx.ChannelID)
+	if err != nil {
+		return database.GuildTicketChannel{}, errors.Wrap(err, "failed to get guild ticket channel")
+	} else if !ok {
+		return database.GuildTicketChannel{}, userErrorf("this command can only be used in a ticket")
+	}
+	return t, nil
+}
+
+// runTicketClose closes the ticket the command was used in
+// Only the creator of the ticket, moderators and ticket staff can close it
+func runTicketClose(ctx *Context, args Args) error {
+	t, err := getTicket(ctx)
+	if err != nil {
+		return err
+	}
+	if t.CreatorID != ctx.AuthorID && ctx.Permission < PermissionModerator {
+		if ok, err := ctx.Can(database.CapabilityTicketStaff); err != nil {
+			return err
+		} else if !ok {
+			return userErrorf("only the creator of the ticket and moderators can close it")
+		}
+	}
+	err = ctx.DB.Transaction(func(tx *database.Database) error {
+		if _, _, err := tx.TicketWizardDelete(t.GuildID, t.ChannelID); err != nil {
+			return errors.Wrap(err, "failed to delete wizard")
+		}
+		if _, _, err := tx.GuildTicketChannelDelete(t.GuildID, t.ChannelID); err != nil {
+			return errors.Wrap(err, "failed to delete guild ticket channel")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(ctx.Session.DeleteChannel(t.ChannelID), "failed to delete ticket channel")
+}
+
+// runTicketList lists the open tickets of the guild
+func runTicketList(ctx *Context, args Args) error {
+	ts, err := ctx.DB.GuildTicketChannels(ctx.GuildID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get guild ticket channels")
+	}
+	lines := []string{}
+	for _, t := range ts {
+		name := "unknown"
+		for _, tt := range ticketTypes {
+			if tt.ticketType == t.TicketType {
+				name = tt.name
+			}
+		}
+		lines = append(lines, fmt.Sprintf("`%s` <#%s> %s by <@%s>", t.TicketID, t.ChannelID, name, t.CreatorID))
+	}
+	return ctx.ReplyEmbed(listEmbed("Tickets", lines))
+}
+
+// getWizard gets the wizard running in the ticket the command was used in
+func getWizard(ctx *Context) (*wizard.Session, error) {
+	t, err := getTicket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if t.CreatorID != ctx.AuthorID {
+		return nil, userErrorf("only the creator of the ticket can fill in the submission")
+	}
+	s, ok, err := wizard.Resume(ctx.DB, ctx.GuildID, ctx.ChannelID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resume wizard")
+	} else if !ok {
+		return nil, userErrorf("this ticket isn't a submission")
+	}
+	return s, nil
+}
+
+// runTicketAnswer answers the current step of the ticket's wizard
+// This does the same as sending the answer as a message, which
+// isn't possible when commands are only received as interactions
+func runTicketAnswer(ctx *Context, args Args) error {
+	s, err := getWizard(ctx)
+	if err != nil {
+		return err
+	}
+	if err = s.Submit(args.String("answer")); err != nil {
+		return err
+	}
+	return ctx.Reply("%s", prompt(ctx.Prefix, s))
+}
+
+// runTicketSkip skips the current step of the ticket's wizard
+func runTicketSkip(ctx *Context, args Args) error {
+	s, err := getWizard(ctx)
+	if err != nil {
+		return err
+	}
+	if err = s.Skip(); err != nil {
+		return err
+	}
+	return ctx.Reply("%s", prompt(ctx.Prefix, s))
+}
+
+// runTicketBack returns to the previous step of the ticket's wizard
+func runTicketBack(ctx *Context, args Args) error {
+	s, err := getWizard(ctx)
+	if err != nil {
+		return err
+	}
+	if err = s.Back(); err != nil {
+		return err
+	}
+	return ctx.Reply("%s", prompt(ctx.Prefix, s))
+}
+
+// runTicketSubmit submits the ticket's completed wizard
+func runTicketSubmit(ctx *Context, args Args) error {
+	s, err := getWizard(ctx)
+	if err != nil {
+		return err
+	}
+	d, err := s.Finish()
+	if err != nil {
+		return err
+	}
+	switch {
+	case d.Build.ID != "":
+		return ctx.Reply("Build %s has been submitted and is waiting to be verified.", d.Build.ID)
+	case d.Record.ID != "":
+		return ctx.Reply("Record %s has been submitted and is waiting to be verified.", d.Record.ID)
+	}
+	return ctx.Reply("Your submission has been submitted and is waiting to be verified.")
+}
+
+// WizardInput gives a message sent in a ticket channel to the ticket's
+// wizard as the answer to the current step
+// false is returned if the message isn't an answer to a wizard, such
+// as when it calls a command or wasn't sent by the ticket's creator
+func (r *Router) WizardInput(s Session, db *database.Database, m Message) (bool, error) {
+	if m.GuildID == "" {
+		return false, nil
+	}
+	prefix, err := r.GuildPrefix(db, m.GuildID)
+	if err != nil {
+		return false, err
+	} else if strings.HasPrefix(m.Content, prefix) {
+		return false, nil
+	}
+	t, ok, err := db.GuildTicketChannel(m.GuildID, m.ChannelID)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get guild ticket channel")
+	} else if !ok || t.CreatorID != m.AuthorID {
+		return false, nil
+	}
+	ws, ok, err := wizard.Resume(db, m.GuildID, m.ChannelID)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to resume wizard")
+	} else if !ok || ws.Complete() {
+		return false, nil
+	}
+	if err = ws.Submit(m.Content); err != nil {
+		if ie, ok := errors.Cause(err).(*wizard.InputError); ok {
+			return true, s.Send(m.ChannelID, ie.Message)
+		}
+		return true, errors.Wrap(err, "failed to submit wizard input")
+	}
+	return true, s.Send(m.ChannelID, prompt(prefix, ws))
+}
+
+// prompt gets the message asking for the current step of a wizard
+func prompt(prefix string, s *wizard.Session) string {
+	step, ok := s.Current()
+	if !ok {
+		return fmt.Sprintf("That's everything! Use `%sticket submit` to submit or `%sticket back` to change something.", prefix, prefix)
+	}
+	p := step.Prompt
+	if step.Optional {
+		p += fmt.Sprintf("\n(Optional, use `%sticket skip` to skip)", prefix)
+	}
+	return p
+}
@@ -0,0 +1,99 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Kappeh/RecordBot/database"
+)
+
+// openTicket opens a ticket and gets its channel and the
+// message sent to it
+func openTicket(t *testing.T, s *fakeSession, authorID, content string) (string, string) {
+	t.Helper()
+	replies := dispatch(t, s, homeGuild, authorID, content)
+	if len(replies) != 2 || replies[0].channelID != channel || replies[1].channelID == channel {
+		t.Fatalf("%s: expected a reply and a message in the ticket, got %+v", content, replies)
+	}
+	channelID := replies[1].channelID
+	if want := "Your ticket has been opened in <#" + channelID + ">."; replies[0].content != want {
+		t.Fatalf("%s: expected %q, got %q", content, want, replies[0].content)
+	}
+	return channelID, replies[1].content
+}
+
+// replyIn sends a message calling a command in a ticket
+// and gets the single message sent in reply
+func replyIn(t *testing.T, s *fakeSession, channelID, authorID, content string) string {
+	t.Helper()
+	replies := dispatchIn(t, s, Message{GuildID: homeGuild, ChannelID: channelID, AuthorID: authorID, Content: content})
+	if len(replies) != 1 || replies[0].embed != nil || replies[0].channelID != channelID {
+		t.Fatalf("%s: expected a single message in reply, got %+v", content, replies)
+	}
+	return replies[0].content
+}
+
+func TestTicketCommands(t *testing.T) {
+	s := newFakeSession()
+	if got, want := reply(t, s, otherGuild, member, "!ticket open"), "tickets haven't been set up in this server"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if _, err := testDB.GuildSettingSet(homeGuild, database.SettingTicketCategory, "500", owner); err != nil {
+		t.Fatalf("failed to set ticket category: %v", err)
+	}
+
+	general, welcome := openTicket(t, s, member, "!t open")
+	if want := "Welcome <@" + member + ">, a moderator will be with you shortly."; welcome != want {
+		t.Errorf("expected %q, got %q", want, welcome)
+	}
+	build, prompt := openTicket(t, s, member, "!ticket open build")
+	if want := "Which edition of Minecraft is the build in?"; prompt != want {
+		t.Errorf("expected %q, got %q", want, prompt)
+	}
+
+	e := replyEmbed(t, s, homeGuild, moderator, "!ticket list")
+	for _, want := range []string{"<#" + general + "> general by <@4>", "<#" + build + "> build by <@4>"} {
+		if !strings.Contains(e.Description, want) {
+			t.Errorf("expected tickets to contain %q, got %q", want, e.Description)
+		}
+	}
+
+	// The wizard is filled in with commands and kept between them
+	steps := []struct {
+		authorID string
+		content  string
+		want     string
+	}{
+		{member, "!ticket answer java", "What class of build is it? (e.g. Piston Door)"},
+		{member, "!ticket answer nope", "there is no build class called \"nope\""},
+		{member, "!ticket answer piston", "What is the name of the build?"},
+		{member, "!ticket back", "What class of build is it? (e.g. Piston Door)"},
+		{member, "!ticket answer piston", "What is the name of the build?"},
+		{member, "!ticket answer Piston Tower", "Give a short description of the build.\n(Optional, use `!ticket skip` to skip)"},
+		{member, "!ticket skip", "Who created the build? Give a comma separated list of in game names."},
+		{moderator, "!ticket answer someone", "only the creator of the ticket can fill in the submission"},
+	}
+	for _, step := range steps {
+		if got := replyIn(t, s, build, step.authorID, step.content); got != step.want {
+			t.Errorf("%s: expected %q, got %q", step.content, step.want, got)
+		}
+	}
+	if got, want := replyIn(t, s, general, member, "!ticket skip"), "this ticket isn't a submission"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	if got, want := replyIn(t, s, general, striker, "!ticket close"), "only the creator of the ticket and moderators can close it"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	dispatchIn(t, s, Message{GuildID: homeGuild, ChannelID: general, AuthorID: member, Content: "!ticket close"})
+	dispatchIn(t, s, Message{GuildID: homeGuild, ChannelID: build, AuthorID: moderator, Content: "!ticket close"})
+	if len(s.deleted) != 2 || s.deleted[0] != general || s.deleted[1] != build {
+		t.Errorf("expected the ticket channels to be deleted, got %v", s.deleted)
+	}
+	if e = replyEmbed(t, s, homeGuild, moderator, "!ticket list"); e.Description != "There aren't any." {
+		t.Errorf("expected the tickets to be closed, got %q", e.Description)
+	}
+	if got, want := reply(t, s, homeGuild, member, "!ticket close"), "this command can only be used in a ticket"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
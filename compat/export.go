@@ -0,0 +1,145 @@
+package compat
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteCSV writes the matrix as csv with a row for each build
+// and a column for each version
+func (m Matrix) WriteCSV(w io.Writer) error {
+	return m.writeCSV(w, m.cellName)
+}
+
+// WriteInferredCSV writes the matrix as csv like WriteCSV with the
+// inferred statuses in the versions the builds haven't been tested in
+func (m Matrix) WriteInferredCSV(w io.Writer, inf Inference) error {
+	return m.writeCSV(w, inf.cellName(m))
+}
+
+// writeCSV writes the matrix as csv naming cells with a function
+func (m Matrix) writeCSV(w io.Writer, name func(i, j int) string) error {
+	cw := csv.NewWriter(w)
+	header := []string{"Build ID", "Build"}
+	for _, v := range m.Versions {
+		header = append(header, v.Number().String())
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for i, b := range m.Builds {
+		record := []string{b.ID, b.Name}
+		for j := range m.Cells[i] {
+			record = append(record, name(i, j))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteSummaryCSV writes the summary of each build as csv
+func (m Matrix) WriteSummaryCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"Build ID", "Build", "Compatibility"}); err != nil {
+		return err
+	}
+	for i, b := range m.Builds {
+		if err := cw.Write([]string{b.ID, b.Name, m.Summary(i)}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteMarkdown writes the matrix as a markdown table with a row
+// for each build and a column for each version
+func (m Matrix) WriteMarkdown(w io.Writer) error {
+	return m.writeMarkdown(w, m.cellName)
+}
+
+// WriteInferredMarkdown writes the matrix as a markdown table like
+// WriteMarkdown with the inferred statuses in the versions the
+// builds haven't been tested in
+func (m Matrix) WriteInferredMarkdown(w io.Writer, inf Inference) error {
+	return m.writeMarkdown(w, inf.cellName(m))
+}
+
+// writeMarkdown writes the matrix as a markdown table
+// naming cells with a function
+func (m Matrix) writeMarkdown(w io.Writer, name func(i, j int) string) error {
+	header := []string{"Build"}
+	for _, v := range m.Versions {
+		header = append(header, v.Number().String())
+	}
+	rows := [][]string{}
+	for i, b := range m.Builds {
+		row := []string{b.Name + " (" + b.ID + ")"}
+		for j := range m.Cells[i] {
+			row = append(row, name(i, j))
+		}
+		rows = append(rows, row)
+	}
+	return writeMarkdownTable(w, header, rows)
+}
+
+// WriteSummaryMarkdown writes the summary of each build
+// as a markdown table
+func (m Matrix) WriteSummaryMarkdown(w io.Writer) error {
+	rows := [][]string{}
+	for i, b := range m.Builds {
+		rows = append(rows, []string{b.Name + " (" + b.ID + ")", m.Summary(i)})
+	}
+	return writeMarkdownTable(w, []string{"Build", "Compatibility"}, rows)
+}
+
+// cellName gets the name of the status of a build in a version
+func (m Matrix) cellName(i, j int) string {
+	return m.Cells[i][j].Name()
+}
+
+// cellName gets a function that names the status of a build in a
+// version, or its inferred status and confidence if it's untested
+// e.g. "Likely Working (high)"
+func (inf Inference) cellName(m Matrix) func(i, j int) string {
+	return func(i, j int) string {
+		if c := inf.Cells[i][j]; c.Inferred {
+			return c.Name() + " (" + c.Confidence.String() + ")"
+		}
+		return m.Cells[i][j].Name()
+	}
+}
+
+// writeMarkdownTable writes a markdown table
+func writeMarkdownTable(w io.Writer, header []string, rows [][]string) error {
+	line := func(cells []string) error {
+		escaped := make([]string, len(cells))
+		for i, c := range cells {
+			// Pipes would end the cell and new lines the row
+			escaped[i] = strings.NewReplacer("|", `\|`, "\n", " ").Replace(c)
+		}
+		_, err := fmt.Fprintf(w, "| %s |\n", strings.Join(escaped, " | "))
+		return err
+	}
+	if err := line(header); err != nil {
+		return err
+	}
+	divider := make([]string, len(header))
+	for i := range divider {
+		divider[i] = "---"
+	}
+	if err := line(divider); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if err := line(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package compat
+
+import (
+	"strings"
+
+	"github.com/Kappeh/RecordBot/database"
+)
+
+// Confidence is how likely an inferred status is to be right
+type Confidence int
+
+const (
+	// ConfidenceLow is a status carried on from the versions
+	// on one side only, or for a build with conflicting statuses
+	ConfidenceLow Confidence = iota
+	// ConfidenceMedium is a status between versions of different
+	// release lines that have the same status
+	ConfidenceMedium
+	// ConfidenceHigh is a status between versions of the same
+	// release line that have the same status
+	ConfidenceHigh
+)
+
+// String gets the name of the confidence
+func (c Confidence) String() string {
+	switch c {
+	case ConfidenceLow:
+		return "low"
+	case ConfidenceMedium:
+		return "medium"
+	case ConfidenceHigh:
+		return "high"
+	}
+	return "unknown"
+}
+
+// Inferred is the status a build likely has in a version it
+// hasn't been tested in
+// It's never stored as a build version, which only holds
+// statuses that have been entered by users
+type Inferred struct {
+	// Inferred indicates whether a status could be inferred
+	Inferred bool
+	// Status is the likely status of the build
+	Status database.Status
+	// Confidence is how likely the status is to be right
+	Confidence Confidence
+	// Before is the index of the closest earlier version the
+	// build has been tested in or -1 if there isn't one
+	Before int
+	// After is the index of the closest later version the
+	// build has been tested in or -1 if there isn't one
+	After int
+}
+
+// Name gets the name of the inferred status such as
+// "Likely Working" or Untested
+func (i Inferred) Name() string {
+	if !i.Inferred {
+		return Untested
+	}
+	return "Likely " + i.Status.Name
+}
+
+// Conflict is a status of a build that changes and then
+// changes back, such as working, broken then working again
+type Conflict struct {
+	// Build is the index of the build within the matrix
+	Build int
+	// Runs are the tested statuses from the first time the status
+	// is seen up to when it's seen again, ignoring untested versions
+	Runs []Run
+}
+
+// Inference is what can be inferred about the versions
+// the builds of a matrix haven't been tested in
+type Inference struct {
+	// Cells are the inferred statuses, with Cells[i][j] being the
+	// inferred status of Builds[i] in Versions[j]
+	// Cells of tested versions are never inferred
+	Cells [][]Inferred
+	// Conflicts are the conflicting statuses of the builds
+	Conflicts []Conflict
+}
+
+// Infer infers the statuses of the builds in the versions
+// they haven't been tested in
+// An untested version between two tested versions with the same
+// status likely has that status too, e.g. a build that works in
+// 1.14 and 1.14.2 likely works in 1.14.1
+// An untested version with a tested version on one side only
+// likely has its status if both are of the same release line
+func (m Matrix) Infer() Inference {
+	inf := Inference{Cells: make([][]Inferred, len(m.Builds)), Conflicts: []Conflict{}}
+	for i := range m.Builds {
+		conflicts := m.conflicts(i)
+		inf.Conflicts = append(inf.Conflicts, conflicts...)
+		row := m.Cells[i]
+		inf.Cells[i] = make([]Inferred, len(row))
+		// Find the closest tested versions on either side of each version
+		before := -1
+		for j := range row {
+			inf.Cells[i][j].Before = before
+			if row[j].Tested {
+				before = j
+			}
+		}
+		after := -1
+		for j := len(row) - 1; j >= 0; j-- {
+			inf.Cells[i][j].After = after
+			if row[j].Tested {
+				after = j
+			}
+		}
+		for j, c := range row {
+			if c.Tested {
+				continue
+			}
+			in := &inf.Cells[i][j]
+			switch {
+			case in.Before >= 0 && in.After >= 0:
+				b, a := row[in.Before], row[in.After]
+				if b.Status.ID != a.Status.ID {
+					continue
+				}
+				in.Inferred, in.Status, in.Confidence = true, b.Status, ConfidenceMedium
+				if m.sameLine(in.Before, in.After) {
+					in.Confidence = ConfidenceHigh
+				}
+			case in.Before >= 0 && m.sameLine(in.Before, j):
+				in.Inferred, in.Status, in.Confidence = true, row[in.Before].Status, ConfidenceLow
+			case in.After >= 0 && m.sameLine(j, in.After):
+				in.Inferred, in.Status, in.Confidence = true, row[in.After].Status, ConfidenceLow
+			}
+			if len(conflicts) > 0 {
+				in.Confidence = ConfidenceLow
+			}
+		}
+	}
+	return inf
+}
+
+// conflicts finds the conflicting statuses of a build
+// i is the index of the build within the matrix
+func (m Matrix) conflicts(i int) []Conflict {
+	runs := m.TestedRuns(i)
+	conflicts := []Conflict{}
+	for k := range runs {
+		for l := k + 2; l < len(runs); l++ {
+			if runs[l].Name == runs[k].Name {
+				conflicts = append(conflicts, Conflict{Build: i, Runs: runs[k : l+1]})
+				break
+			}
+		}
+	}
+	return conflicts
+}
+
+// sameLine indicates whether the versions at two indices and every
+// version between them are of the same release line such as 1.14
+func (m Matrix) sameLine(first, last int) bool {
+	a := m.Versions[first].Number()
+	for _, v := range m.Versions[first : last+1] {
+		n := v.Number()
+		if n.Snapshot != "" || n.Major != a.Major || n.Minor != a.Minor {
+			return false
+		}
+	}
+	return true
+}
+
+// DescribeConflict describes a conflict such as
+// "Working 1.14–1.15, Broken 1.16, Working 1.17"
+func (m Matrix) DescribeConflict(c Conflict) string {
+	parts := []string{}
+	for _, r := range c.Runs {
+		parts = append(parts, m.describe(r, false))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,151 @@
+package compat
+
+import (
+	"strings"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// Untested is the name shown for versions a build hasn't been tested in
+const Untested = database.UntestedStatusName
+
+// Filter chooses the builds and versions of a matrix
+type Filter struct {
+	// EditionID is the id of the edition of the builds and versions
+	EditionID string
+	// BuildClassID is the id of the build class of the builds
+	// Builds of every class are included if it's empty
+	BuildClassID string
+	// BuildIDs are the ids of the builds to include
+	// Every build is included if it's empty
+	BuildIDs []string
+	// Name is text the names of the builds contain
+	Name string
+	// IncludeUnverified includes builds that haven't been verified
+	IncludeUnverified bool
+	// From is the first version included, such as 1.13
+	// The versions start from the oldest if it's empty
+	From string
+	// To is the last version included, such as 1.16.5
+	// The versions go up to the newest if it's empty
+	To string
+}
+
+// Cell is the status of a build in a version
+type Cell struct {
+	// Tested indicates whether the build has a status in the version
+	Tested bool
+	// Status is the status of the build if it's been tested
+	Status database.Status
+	// Notes are the notes about the status
+	Notes string
+}
+
+// Name gets the name of the cell's status or Untested
+func (c Cell) Name() string {
+	if !c.Tested {
+		return Untested
+	}
+	return c.Status.Name
+}
+
+// Matrix is the status of each of a set of builds in each version
+type Matrix struct {
+	// Edition is the edition of the builds and versions
+	Edition database.Edition
+	// Builds are the builds in the order they were created
+	Builds []database.Build
+	// Versions are the versions from oldest to newest
+	Versions []database.Version
+	// Cells are the statuses, with Cells[i][j] being the
+	// status of Builds[i] in Versions[j]
+	Cells [][]Cell
+	// Open indicates whether the versions go up to the newest
+	// version, so that statuses of the last version carry on
+	Open bool
+}
+
+// Build makes the compatibility matrix of the builds and
+// versions chosen by a filter
+// A *database.VersionError is returned if From or To
+// can't be parsed
+func Build(db *database.Database, f Filter) (Matrix, error) {
+	e, ok, err := db.Edition(f.EditionID)
+	if err != nil {
+		return Matrix{}, errors.Wrap(err, "failed to get edition")
+	} else if !ok {
+		return Matrix{}, errors.Errorf("edition %s doesn't exist", f.EditionID)
+	}
+	m := Matrix{Edition: e, Builds: []database.Build{}, Cells: [][]Cell{}, Open: f.To == ""}
+	if m.Versions, err = db.VersionsBetween(e.ID, f.From, f.To); err != nil {
+		return Matrix{}, err
+	}
+	column := map[string]int{}
+	for j, v := range m.Versions {
+		column[v.ID] = j
+	}
+	statuses, err := db.Statuses()
+	if err != nil {
+		return Matrix{}, errors.Wrap(err, "failed to get statuses")
+	}
+	statusByID := map[string]database.Status{}
+	for _, s := range statuses {
+		statusByID[s.ID] = s
+	}
+	builds, err := db.Builds()
+	if err != nil {
+		return Matrix{}, errors.Wrap(err, "failed to get builds")
+	}
+	for _, b := range builds {
+		if !f.includes(b) {
+			continue
+		}
+		bvs, err := b.BuildVersions()
+		if err != nil {
+			return Matrix{}, errors.Wrap(err, "failed to get build versions")
+		}
+		row := make([]Cell, len(m.Versions))
+		for _, bv := range bvs {
+			j, ok := column[bv.VersionID]
+			if !ok {
+				continue
+			}
+			// Placeholders for versions the build hasn't been
+			// re-tested in are the same as having no status
+			s := statusByID[bv.StatusID]
+			if strings.EqualFold(s.Name, database.UntestedStatusName) {
+				continue
+			}
+			row[j] = Cell{Tested: true, Status: s, Notes: bv.Notes}
+		}
+		m.Builds = append(m.Builds, b)
+		m.Cells = append(m.Cells, row)
+	}
+	return m, nil
+}
+
+// includes indicates whether a build is chosen by the filter
+func (f Filter) includes(b database.Build) bool {
+	if b.UpdateRequest || b.EditionID != f.EditionID {
+		return false
+	}
+	if !b.Verified && !f.IncludeUnverified {
+		return false
+	}
+	if f.BuildClassID != "" && b.BuildClassID != f.BuildClassID {
+		return false
+	}
+	if f.Name != "" && !strings.Contains(strings.ToLower(b.Name), strings.ToLower(f.Name)) {
+		return false
+	}
+	if len(f.BuildIDs) == 0 {
+		return true
+	}
+	for _, id := range f.BuildIDs {
+		if id == b.ID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package compat
+
+import (
+	"strings"
+)
+
+// Run is a range of consecutive versions in which
+// a build has the same status
+type Run struct {
+	// Name is the name of the status or Untested
+	Name string
+	// First is the index of the first version of the run
+	First int
+	// Last is the index of the last version of the run
+	Last int
+}
+
+// Runs gets the runs of the statuses of a build
+// i is the index of the build within the matrix
+func (m Matrix) Runs(i int) []Run {
+	runs := []Run{}
+	for j, c := range m.Cells[i] {
+		name := c.Name()
+		if n := len(runs); n > 0 && runs[n-1].Name == name {
+			runs[n-1].Last = j
+			continue
+		}
+		runs = append(runs, Run{Name: name, First: j, Last: j})
+	}
+	return runs
+}
+
+// TestedRuns gets the runs of the statuses a build has been tested
+// with, joining runs that are only split by untested versions, such as
+// releases with snapshots between them that haven't been tested
+// i is the index of the build within the matrix
+func (m Matrix) TestedRuns(i int) []Run {
+	runs := []Run{}
+	for j, c := range m.Cells[i] {
+		if !c.Tested {
+			continue
+		}
+		name := c.Name()
+		if n := len(runs); n > 0 && runs[n-1].Name == name {
+			runs[n-1].Last = j
+			continue
+		}
+		runs = append(runs, Run{Name: name, First: j, Last: j})
+	}
+	return runs
+}
+
+// Summary describes the statuses of a build in a few words
+// such as "Working 1.13–1.16.5, Broken 1.17+"
+// Versions the build hasn't been tested in are left out
+// i is the index of the build within the matrix
+func (m Matrix) Summary(i int) string {
+	parts := []string{}
+	for _, r := range m.TestedRuns(i) {
+		parts = append(parts, m.describe(r, m.Open))
+	}
+	if len(parts) == 0 {
+		return Untested
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describe describes a run such as "Working 1.13–1.16.5"
+// A run up to the last version ends with a plus if open is true
+func (m Matrix) describe(r Run, open bool) string {
+	first := m.Versions[r.First].Number().String()
+	switch {
+	case open && r.Last == len(m.Versions)-1:
+		return r.Name + " " + first + "+"
+	case r.First == r.Last:
+		return r.Name + " " + first
+	}
+	return r.Name + " " + first + "–" + m.Versions[r.Last].Number().String()
+}
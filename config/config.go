@@ -0,0 +1,214 @@
+package config
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// EnvPrefix is the prefix of environment variables which override
+// config values, such as RECORDBOT_TOKEN for token
+const EnvPrefix = "RECORDBOT_"
+
+// LogLevels are the valid log levels from most to least verbose
+var LogLevels = []string{"debug", "info", "warn", "error"}
+
+// Config is the configuration of the bot
+type Config struct {
+	// Token is the bot's token
+	Token string `json:"token"`
+	// ApplicationID is the id of the bot's application
+	ApplicationID string `json:"application_id"`
+	// PublicKey is the hex encoded public key of the bot's application
+	// used to verify interactions
+	PublicKey string `json:"public_key"`
+	// DatabasePath is the path to the database file
+	DatabasePath string `json:"database_path"`
+	// LogLevel is the least severe level of messages that are logged
+	LogLevel string `json:"log_level"`
+	// OwnerIDs are the ids of the users that own the bot
+	OwnerIDs []string `json:"owner_ids"`
+	// HomeGuildID is the id of the guild whose moderators and
+	// administrators manage builds, records and strikes
+	// Only the owners can manage them if it's empty
+	HomeGuildID string `json:"home_guild_id"`
+	// ListenAddress is the address the interactions endpoint listens on
+	ListenAddress string `json:"listen_address"`
+}
+
+// Default gets the config used for values that aren't given
+func Default() Config {
+	return Config{
+		DatabasePath:  database.DefaultPath,
+		LogLevel:      "info",
+		OwnerIDs:      []string{},
+		ListenAddress: ":8080",
+	}
+}
+
+// Load loads a config file on top of the default config
+// The format is chosen by the file's extension, which is one of
+// .toml, .yaml, .yml or .json
+func Load(path string) (Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, errors.Wrap(err, "failed to read config file")
+	}
+	c := Default()
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.DisallowUnknownFields()
+		if err = d.Decode(&c); err != nil {
+			return Config{}, errors.Wrap(err, "failed to parse json config")
+		}
+		return c, nil
+	case ".toml":
+		values, err := parseTOML(string(data))
+		if err != nil {
+			return Config{}, errors.Wrap(err, "failed to parse toml config")
+		}
+		return c, c.setAll(values)
+	case ".yaml", ".yml":
+		values, err := parseYAML(string(data))
+		if err != nil {
+			return Config{}, errors.Wrap(err, "failed to parse yaml config")
+		}
+		return c, c.setAll(values)
+	}
+	return Config{}, errors.Errorf("unknown config format %q, use .toml, .yaml or .json", filepath.Ext(path))
+}
+
+// ApplyEnv overrides config values with environment variables
+// lookup gets an environment variable, such as os.LookupEnv
+// Owner ids are given as a comma separated list
+func (c *Config) ApplyEnv(lookup func(key string) (string, bool)) error {
+	for _, key := range keys {
+		v, ok := lookup(EnvPrefix + strings.ToUpper(key))
+		if !ok {
+			continue
+		}
+		if err := c.set(key, value{text: v, list: splitList(v)}); err != nil {
+			return errors.Wrapf(err, "invalid environment variable %s%s", EnvPrefix, strings.ToUpper(key))
+		}
+	}
+	return nil
+}
+
+// Validate checks that the config can be used to run the bot
+// Every problem is reported rather than only the first
+func (c Config) Validate() error {
+	problems := []string{}
+	if c.Token == "" {
+		problems = append(problems, "token is required")
+	}
+	if !isID(c.ApplicationID) {
+		problems = append(problems, "application_id must be an id")
+	}
+	if key, err := hex.DecodeString(c.PublicKey); err != nil || len(key) != 32 {
+		problems = append(problems, "public_key must be 64 hex characters")
+	}
+	if c.DatabasePath == "" {
+		problems = append(problems, "database_path is required")
+	}
+	valid := false
+	for _, l := range LogLevels {
+		valid = valid || c.LogLevel == l
+	}
+	if !valid {
+		problems = append(problems, "log_level must be one of "+strings.Join(LogLevels, ", "))
+	}
+	for _, id := range c.OwnerIDs {
+		if !isID(id) {
+			problems = append(problems, "owner id "+strconv.Quote(id)+" isn't an id")
+		}
+	}
+	if c.HomeGuildID != "" && !isID(c.HomeGuildID) {
+		problems = append(problems, "home_guild_id must be an id")
+	}
+	if _, _, err := net.SplitHostPort(c.ListenAddress); err != nil {
+		problems = append(problems, "listen_address must be of the form host:port")
+	}
+	if len(problems) > 0 {
+		return errors.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// keys are the names of the config values
+var keys = []string{"token", "application_id", "public_key", "database_path", "log_level", "owner_ids", "home_guild_id", "listen_address"}
+
+// value is a value parsed from a config file
+type value struct {
+	// text is the value if it's a string
+	text string
+	// list is the value if it's a list
+	list []string
+	// isList indicates whether the value was given as a list
+	isList bool
+}
+
+// setAll sets config values by key
+func (c *Config) setAll(values map[string]value) error {
+	for key, v := range values {
+		if err := c.set(key, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// set sets a config value by key
+func (c *Config) set(key string, v value) error {
+	if key == "owner_ids" {
+		c.OwnerIDs = v.list
+		return nil
+	}
+	if v.isList {
+		return errors.Errorf("%s must be a string, not a list", key)
+	}
+	switch key {
+	case "token":
+		c.Token = v.text
+	case "application_id":
+		c.ApplicationID = v.text
+	case "public_key":
+		c.PublicKey = v.text
+	case "database_path":
+		c.DatabasePath = v.text
+	case "log_level":
+		c.LogLevel = strings.ToLower(v.text)
+	case "home_guild_id":
+		c.HomeGuildID = v.text
+	case "listen_address":
+		c.ListenAddress = v.text
+	default:
+		return errors.Errorf("unknown config key %q", key)
+	}
+	return nil
+}
+
+// isID indicates whether s is a discord id
+func isID(s string) bool {
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil
+}
+
+// splitList splits a comma separated list leaving out empty items
+func splitList(s string) []string {
+	list := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// parseTOML parses the subset of toml used by config files
+// Only top level keys with string, number or inline list values
+// are supported
+//
+//	token = "abc"
+//	owner_ids = ["123", 456]
+func parseTOML(data string) (map[string]value, error) {
+	values := map[string]value{}
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(stripComment(line))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			return nil, errors.Errorf("line %d: tables aren't supported", i+1)
+		}
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			return nil, errors.Errorf("line %d: expected key = value", i+1)
+		}
+		key := strings.TrimSpace(line[:eq])
+		v, err := parseValue(strings.TrimSpace(line[eq+1:]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", i+1)
+		}
+		if _, ok := values[key]; ok {
+			return nil, errors.Errorf("line %d: %s is given more than once", i+1, key)
+		}
+		values[key] = v
+	}
+	return values, nil
+}
+
+// parseYAML parses the subset of yaml used by config files
+// Only top level keys with scalar values, flow lists or block lists
+// of scalars are supported. A key without a value or list items,
+// such as "token:", has an empty value
+//
+//	token: abc
+//	owner_ids:
+//	  - 123
+//	  - 456
+func parseYAML(data string) (map[string]value, error) {
+	values := map[string]value{}
+	// listKey is the key of the block list being parsed
+	listKey := ""
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(stripComment(line), " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			if listKey == "" || line == trimmed {
+				return nil, errors.Errorf("line %d: unexpected list item", i+1)
+			}
+			item, err := parseScalar(strings.TrimSpace(strings.TrimPrefix(trimmed, "-")))
+			if err != nil {
+				return nil, errors.Wrapf(err, "line %d", i+1)
+			}
+			v := values[listKey]
+			v.list = append(v.list, item)
+			v.isList = true
+			values[listKey] = v
+			continue
+		}
+		if line != trimmed {
+			return nil, errors.Errorf("line %d: nested values aren't supported", i+1)
+		}
+		colon := strings.Index(line, ":")
+		if colon < 0 {
+			return nil, errors.Errorf("line %d: expected key: value", i+1)
+		}
+		key := strings.TrimSpace(line[:colon])
+		if _, ok := values[key]; ok {
+			return nil, errors.Errorf("line %d: %s is given more than once", i+1, key)
+		}
+		rest := strings.TrimSpace(line[colon+1:])
+		listKey = ""
+		if rest == "" {
+			// Either a block list follows or the value is empty
+			// It's made a list when its first item is found
+			listKey = key
+			values[key] = value{list: []string{}}
+			continue
+		}
+		v, err := parseValue(rest)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", i+1)
+		}
+		values[key] = v
+	}
+	return values, nil
+}
+
+// parseValue parses a scalar or an inline list such as ["a", 1]
+func parseValue(s string) (value, error) {
+	if !strings.HasPrefix(s, "[") {
+		text, err := parseScalar(s)
+		return value{text: text, list: splitList(text)}, err
+	}
+	if !strings.HasSuffix(s, "]") {
+		return value{}, errors.New("unterminated list")
+	}
+	v := value{list: []string{}, isList: true}
+	inner := strings.TrimSpace(s[1 : len(s)-1])
+	for inner != "" {
+		var end int
+		if inner[0] == '"' || inner[0] == '\'' {
+			// Find the closing quote so commas within strings are kept
+			end = closingQuote(inner) + 1
+			if end == 0 {
+				return value{}, errors.New("unterminated string")
+			}
+		} else if end = strings.Index(inner, ","); end < 0 {
+			end = len(inner)
+		}
+		item, err := parseScalar(strings.TrimSpace(inner[:end]))
+		if err != nil {
+			return value{}, err
+		}
+		v.list = append(v.list, item)
+		inner = strings.TrimSpace(inner[end:])
+		if strings.HasPrefix(inner, ",") {
+			inner = strings.TrimSpace(inner[1:])
+		} else if inner != "" {
+			return value{}, errors.New("expected , between list items")
+		}
+	}
+	return v, nil
+}
+
+// parseScalar parses a quoted string or a bare word or number
+func parseScalar(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	switch s[0] {
+	case '"':
+		if closingQuote(s) != len(s)-1 {
+			return "", errors.New("invalid quoted string")
+		}
+		unquoted, err := strconv.Unquote(s)
+		if err != nil {
+			return "", errors.Wrap(err, "invalid quoted string")
+		}
+		return unquoted, nil
+	case '\'':
+		if closingQuote(s) != len(s)-1 {
+			return "", errors.New("invalid quoted string")
+		}
+		return s[1 : len(s)-1], nil
+	}
+	return s, nil
+}
+
+// closingQuote finds the index of the quote that closes
+// the string s starts with or -1 if it isn't closed
+func closingQuote(s string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		if quote == '"' && s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+// stripComment removes a # comment from the end of a line
+// # within quoted strings isn't treated as a comment
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch {
+		case quote == 0 && (line[i] == '"' || line[i] == '\''):
+			quote = line[i]
+		case quote == '"' && line[i] == '\\':
+			i++
+		case quote != 0 && line[i] == quote:
+			quote = 0
+		case quote == 0 && line[i] == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,254 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// scalar makes the value parsed from a scalar
+func scalar(text string) value {
+	return value{text: text, list: splitList(text)}
+}
+
+// list makes the value parsed from a list
+func list(items ...string) value {
+	return value{list: append([]string{}, items...), isList: true}
+}
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want map[string]value
+		err  string
+	}{
+		{
+			name: "empty",
+			data: "",
+			want: map[string]value{},
+		},
+		{
+			name: "strings and numbers",
+			data: "token = \"abc\"\napplication_id = 123\nlog_level = 'debug'\n",
+			want: map[string]value{
+				"token":          scalar("abc"),
+				"application_id": scalar("123"),
+				"log_level":      scalar("debug"),
+			},
+		},
+		{
+			name: "comments",
+			data: "# a comment\ntoken = \"a#b\" # another comment\n\n",
+			want: map[string]value{"token": scalar("a#b")},
+		},
+		{
+			name: "escapes",
+			data: `token = "a\"b"`,
+			want: map[string]value{"token": scalar(`a"b`)},
+		},
+		{
+			name: "inline list",
+			data: `owner_ids = ["123", 456, 'a,b']`,
+			want: map[string]value{"owner_ids": list("123", "456", "a,b")},
+		},
+		{
+			name: "empty inline list",
+			data: "owner_ids = []",
+			want: map[string]value{"owner_ids": list()},
+		},
+		{
+			name: "empty value",
+			data: "token =",
+			want: map[string]value{"token": scalar("")},
+		},
+		{
+			name: "table",
+			data: "[bot]\ntoken = \"abc\"",
+			err:  "line 1: tables aren't supported",
+		},
+		{
+			name: "missing equals",
+			data: "token \"abc\"",
+			err:  "line 1: expected key = value",
+		},
+		{
+			name: "duplicate key",
+			data: "token = \"a\"\ntoken = \"b\"",
+			err:  "line 2: token is given more than once",
+		},
+		{
+			name: "unterminated list",
+			data: `owner_ids = ["123"`,
+			err:  "line 1: unterminated list",
+		},
+		{
+			name: "unterminated string in list",
+			data: `owner_ids = ["123]`,
+			err:  "line 1: unterminated string",
+		},
+		{
+			name: "missing comma",
+			data: `owner_ids = ["1" "2"]`,
+			err:  "line 1: expected , between list items",
+		},
+		{
+			name: "invalid string",
+			data: `token = "abc" def`,
+			err:  "line 1: invalid quoted string",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTOML(tt.data)
+			checkParse(t, got, err, tt.want, tt.err)
+		})
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want map[string]value
+		err  string
+	}{
+		{
+			name: "empty",
+			data: "---\n",
+			want: map[string]value{},
+		},
+		{
+			name: "scalars",
+			data: "token: abc\napplication_id: 123\nlog_level: \"debug\"\ndatabase_path: 'a b.db'\n",
+			want: map[string]value{
+				"token":          scalar("abc"),
+				"application_id": scalar("123"),
+				"log_level":      scalar("debug"),
+				"database_path":  scalar("a b.db"),
+			},
+		},
+		{
+			name: "comments",
+			data: "# a comment\ntoken: \"a#b\" # another comment\n",
+			want: map[string]value{"token": scalar("a#b")},
+		},
+		{
+			name: "block list",
+			data: "owner_ids:\n  - 123\n  - \"456\"\ntoken: abc\n",
+			want: map[string]value{
+				"owner_ids": list("123", "456"),
+				"token":     scalar("abc"),
+			},
+		},
+		{
+			name: "flow list",
+			data: "owner_ids: [123, '456']",
+			want: map[string]value{"owner_ids": list("123", "456")},
+		},
+		{
+			name: "empty value followed by a key",
+			data: "token:\nlog_level: info\n",
+			want: map[string]value{
+				"token":     scalar(""),
+				"log_level": scalar("info"),
+			},
+		},
+		{
+			name: "empty value at the end",
+			data: "log_level: info\ntoken:",
+			want: map[string]value{
+				"token":     scalar(""),
+				"log_level": scalar("info"),
+			},
+		},
+		{
+			name: "windows line endings",
+			data: "token: abc\r\nowner_ids:\r\n  - 1\r\n",
+			want: map[string]value{
+				"token":     scalar("abc"),
+				"owner_ids": list("1"),
+			},
+		},
+		{
+			name: "list item without a key",
+			data: "- 123",
+			err:  "line 1: unexpected list item",
+		},
+		{
+			name: "list item after a scalar",
+			data: "token: abc\n  - 123",
+			err:  "line 2: unexpected list item",
+		},
+		{
+			name: "unindented list item",
+			data: "owner_ids:\n- 123",
+			err:  "line 2: unexpected list item",
+		},
+		{
+			name: "nested value",
+			data: "bot:\n  token: abc",
+			err:  "line 2: nested values aren't supported",
+		},
+		{
+			name: "missing colon",
+			data: "token abc",
+			err:  "line 1: expected key: value",
+		},
+		{
+			name: "duplicate key",
+			data: "token: a\ntoken: b",
+			err:  "line 2: token is given more than once",
+		},
+		{
+			name: "invalid string",
+			data: `token: "abc`,
+			err:  "line 1: invalid quoted string",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseYAML(tt.data)
+			checkParse(t, got, err, tt.want, tt.err)
+		})
+	}
+}
+
+// checkParse checks the result of parsing against the values or
+// the start of the error message that are expected
+func checkParse(t *testing.T, got map[string]value, err error, want map[string]value, wantErr string) {
+	t.Helper()
+	if wantErr != "" {
+		if err == nil {
+			t.Fatalf("expected error %q, got %#v", wantErr, got)
+		}
+		if !strings.HasPrefix(err.Error(), wantErr) {
+			t.Fatalf("expected error %q, got %q", wantErr, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %#v, got %#v", want, got)
+	}
+}
+
+func TestSetEmptyYAMLValues(t *testing.T) {
+	values, err := parseYAML("token:\nowner_ids:\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c := Default()
+	c.Token = "abc"
+	if err = c.setAll(values); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Token != "" {
+		t.Errorf("expected empty token, got %q", c.Token)
+	}
+	if len(c.OwnerIDs) != 0 {
+		t.Errorf("expected no owner ids, got %v", c.OwnerIDs)
+	}
+}
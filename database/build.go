@@ -253,10 +253,10 @@ func (b Build) BuildRecord(recordID string) (BuildRecord, bool, error) {
 		return BuildRecord{}, false, errors.Wrap(err, "failed to extract data")
 	}
 	// Parse timestamps
-	if verifiedTimestamp, err = time.Parse(timeLayout, verifiedTimestampString); err != nil {
+	if verifiedTimestamp, err = parseTimestamp(verifiedTimestampString); err != nil {
 		return BuildRecord{}, false, errors.Wrap(err, "failed to parse verified timestamp")
 	}
-	if reportedTimestamp, err = time.Parse(timeLayout, reportedTimestampString); err != nil {
+	if reportedTimestamp, err = parseTimestamp(reportedTimestampString); err != nil {
 		return BuildRecord{}, false, errors.Wrap(err, "failed to parse reported timestamp")
 	}
 	if editedTimestamp, err = time.Parse(timeLayout, editedTimestampString); err != nil {
@@ -339,10 +339,10 @@ func (b Build) BuildRecords() ([]BuildRecord, error) {
 			return nil, errors.Wrap(err, "failed to extract data")
 		}
 		// Convert timestamps
-		if verifiedTimestamp, err = time.Parse(timeLayout, verifiedTimestampString); err != nil {
+		if verifiedTimestamp, err = parseTimestamp(verifiedTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse verified timestmap")
 		}
-		if reportedTimestamp, err = time.Parse(timeLayout, reportedTimestampString); err != nil {
+		if reportedTimestamp, err = parseTimestamp(reportedTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse reported timestmap")
 		}
 		if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
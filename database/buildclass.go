@@ -57,18 +57,18 @@ func (b BuildClass) Builds() ([]Build, error) {
 		width                   int
 		height                  int
 		depth                   int
-		normalCloseDuration     int
-		normalOpenDuration      int
-		visibleCloseDuration    int
-		visibleOpenDuration     int
-		delayCloseDuration      int
-		delayOpenDuration       int
-		resetCloseDuration      int
-		resetOpenDuration       int
-		extensionDuration       int
-		retractionDuration      int
-		extensionDelayDuration  int
-		retractionDelayDuration int
+		normalCloseDuration     Ticks
+		normalOpenDuration      Ticks
+		visibleCloseDuration    Ticks
+		visibleOpenDuration     Ticks
+		delayCloseDuration      Ticks
+		delayOpenDuration       Ticks
+		resetCloseDuration      Ticks
+		resetOpenDuration       Ticks
+		extensionDuration       Ticks
+		retractionDuration      Ticks
+		extensionDelayDuration  Ticks
+		retractionDelayDuration Ticks
 		imageURL                string
 		youtubeURL              string
 		worldDownloadURL        string
@@ -102,13 +102,13 @@ func (b BuildClass) Builds() ([]Build, error) {
 			return nil, errors.Wrap(err, "failed to extract data")
 		}
 		// Parse timestamps
-		if verifiedTimestamp, err = time.Parse(timeLayout, verifiedTimestampString); err != nil {
+		if verifiedTimestamp, err = parseTimestamp(verifiedTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse verified timestamp")
 		}
-		if reportedTimestamp, err = time.Parse(timeLayout, reportedTimestampString); err != nil {
+		if reportedTimestamp, err = parseTimestamp(reportedTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse reported timestamp")
 		}
-		if creationTimestamp, err = time.Parse(timeLayout, creationTimestampString); err != nil {
+		if creationTimestamp, err = parseTimestamp(creationTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse creation timestamp")
 		}
 		if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
@@ -218,7 +218,7 @@ func (b BuildClass) Records() ([]Record, error) {
 			return nil, errors.Wrap(err, "failed to extract data")
 		}
 		// Parse timestamps
-		if verifiedTimestamp, err = time.Parse(timeLayout, verifiedTimestampString); err != nil {
+		if verifiedTimestamp, err = parseTimestamp(verifiedTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse verified timestamp")
 		}
 		if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
@@ -111,10 +111,10 @@ func (b BuildRecord) FirstJointBuildRecord() (BuildRecord, bool, error) {
 		return BuildRecord{}, false, errors.Wrap(err, "failed to extract data")
 	}
 	// Parse timestamps
-	if verifiedTimestamp, err = time.Parse(timeLayout, verifiedTimestampString); err != nil {
+	if verifiedTimestamp, err = parseTimestamp(verifiedTimestampString); err != nil {
 		return BuildRecord{}, false, errors.Wrap(err, "failed to parse verified timestamp")
 	}
-	if reportedTimestamp, err = time.Parse(timeLayout, reportedTimestampString); err != nil {
+	if reportedTimestamp, err = parseTimestamp(reportedTimestampString); err != nil {
 		return BuildRecord{}, false, errors.Wrap(err, "failed to parse reported timestamp")
 	}
 	if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
@@ -223,10 +223,10 @@ func (b BuildRecord) JointBuildRecords() ([]BuildRecord, error) {
 			return nil, errors.Wrap(err, "failed to extract data")
 		}
 		// Parse timestamps
-		if verifiedTimestamp, err = time.Parse(timeLayout, verifiedTimestampString); err != nil {
+		if verifiedTimestamp, err = parseTimestamp(verifiedTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse verified timestamp")
 		}
-		if reportedTimestamp, err = time.Parse(timeLayout, reportedTimestampString); err != nil {
+		if reportedTimestamp, err = parseTimestamp(reportedTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse reported timestamp")
 		}
 		if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
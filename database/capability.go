@@ -0,0 +1,75 @@
+package database
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Capabilities are all of the capabilities that can be given to roles
+var Capabilities = []Capability{
+	CapabilityVerify,
+	CapabilityReportReview,
+	CapabilityStrike,
+	CapabilityTicketStaff,
+	CapabilityAdmin,
+}
+
+// String gets the name of the capability
+func (c Capability) String() string {
+	switch c {
+	case CapabilityVerify:
+		return "verify"
+	case CapabilityReportReview:
+		return "report-review"
+	case CapabilityStrike:
+		return "strike"
+	case CapabilityTicketStaff:
+		return "ticket-staff"
+	case CapabilityAdmin:
+		return "admin"
+	}
+	return "unknown"
+}
+
+// Valid indicates whether the capability is one of Capabilities
+func (c Capability) Valid() bool {
+	return c >= CapabilityVerify && c <= CapabilityAdmin
+}
+
+// ParseCapability gets a capability from its name
+func ParseCapability(name string) (Capability, error) {
+	for _, c := range Capabilities {
+		if strings.EqualFold(name, c.String()) {
+			return c, nil
+		}
+	}
+	return 0, errors.Errorf("unknown capability %q", name)
+}
+
+// Can determines whether a member of a guild with the given roles has
+// a capability. Roles with CapabilityAdmin have every capability
+// Callers should check this before making changes on behalf of a member,
+// such as calling BuildEdit to verify a build or UserStrikeCreate
+func (d *Database) Can(guildID string, userRoleIDs []string, capability Capability) (bool, error) {
+	if !capability.Valid() {
+		return false, errors.Errorf("unknown capability %d", capability)
+	}
+	if len(userRoleIDs) == 0 {
+		return false, nil
+	}
+	grs, err := d.GuildRoles(guildID)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get guild roles")
+	}
+	has := map[string]bool{}
+	for _, id := range userRoleIDs {
+		has[id] = true
+	}
+	for _, gr := range grs {
+		if has[gr.RoleID] && (gr.Capability == capability || gr.Capability == CapabilityAdmin) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// BuildStatusChange is a change made to the status of a build
+// in a version by SetBuildStatusRange
+type BuildStatusChange struct {
+	// Version is the version the status was set in
+	Version Version
+	// BuildVersion is the status of the build in the version
+	BuildVersion BuildVersion
+	// Created indicates whether the build didn't have
+	// a status in the version before
+	Created bool
+	// Previous is the status the build had in the version before
+	// if it had one
+	Previous BuildVersion
+}
+
+// SetBuildStatusRange sets the status of a build in every version of its
+// edition from one version to another, such as "1.12" to "1.16.5"
+// The bounds are given as for VersionsBetween, so an empty bound isn't
+// limited. Every status is set in a single transaction and the changes
+// are returned from oldest to newest version
+// The build isn't found if false is returned
+func (d *Database) SetBuildStatusRange(buildID, fromVersion, toVersion, statusID, notes string) ([]BuildStatusChange, bool, error) {
+	changes := []BuildStatusChange{}
+	found := false
+	err := d.Transaction(func(tx *Database) error {
+		b, ok, err := tx.Build(buildID)
+		if err != nil {
+			return errors.Wrap(err, "failed to get build")
+		} else if !ok {
+			return nil
+		}
+		found = true
+		if _, ok, err := tx.Status(statusID); err != nil {
+			return errors.Wrap(err, "failed to get status")
+		} else if !ok {
+			return errors.Errorf("status %s doesn't exist", statusID)
+		}
+		versions, err := tx.VersionsBetween(b.EditionID, fromVersion, toVersion)
+		if err != nil {
+			return err
+		}
+		for _, v := range versions {
+			c := BuildStatusChange{Version: v}
+			previous, ok, err := tx.BuildVersion(buildID, v.ID)
+			if err != nil {
+				return errors.Wrap(err, "failed to get build version")
+			}
+			if ok {
+				c.Previous = previous
+				c.BuildVersion, _, err = tx.BuildVersionEdit(buildID, v.ID, statusID, notes)
+				if err != nil {
+					return errors.Wrap(err, "failed to edit build version")
+				}
+			} else {
+				c.Created = true
+				c.BuildVersion, _, err = tx.BuildVersionCreate(buildID, v.ID, statusID, notes)
+				if err != nil {
+					return errors.Wrap(err, "failed to create build version")
+				}
+			}
+			changes = append(changes, c)
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		// The build's messages show its status in each version
+		return tx.outboxEntryAdd(OutboxBuild, buildID, Timestamp(time.Now()))
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return changes, found, nil
+}
@@ -1,8 +1,10 @@
 package database
 
+// DefaultPath is the path to the database file used
+// if a path isn't given
+const DefaultPath = "./database.db"
+
 const (
-	// databasePath is the path to the database file
-	databasePath = "./database.db"
 	// timeLayout is the layout used by the database
 	// to store time
 	timeLayout = "20060102150405"
@@ -0,0 +1,298 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// ConsistencyError is returned when a row would link rows
+// of different editions or build classes, such as a java
+// build and a bedrock version
+type ConsistencyError struct {
+	// Field is what the rows disagree on, edition or build class
+	Field string
+	// Row describes the first row such as "build 3"
+	Row string
+	// RowValue is the id of the first row's edition or build class
+	RowValue string
+	// Other describes the second row such as "version 7"
+	Other string
+	// OtherValue is the id of the second row's edition or build class
+	OtherValue string
+}
+
+// Error describes the disagreement
+func (e *ConsistencyError) Error() string {
+	return fmt.Sprintf("%s is of %s %s but %s is of %s %s",
+		e.Row, e.Field, e.RowValue, e.Other, e.Field, e.OtherValue)
+}
+
+// buildVersionConsistency checks that a build and version are of the same edition
+func buildVersionConsistency(b Build, v Version) *ConsistencyError {
+	if b.EditionID != v.EditionID {
+		return &ConsistencyError{"edition", "build " + b.ID, b.EditionID, "version " + v.ID, v.EditionID}
+	}
+	return nil
+}
+
+// buildRecordConsistency checks that a build and record are
+// of the same edition and build class
+func buildRecordConsistency(b Build, r Record) *ConsistencyError {
+	if b.EditionID != r.EditionID {
+		return &ConsistencyError{"edition", "build " + b.ID, b.EditionID, "record " + r.ID, r.EditionID}
+	}
+	if b.BuildClassID != r.BuildClassID {
+		return &ConsistencyError{"build class", "build " + b.ID, b.BuildClassID, "record " + r.ID, r.BuildClassID}
+	}
+	return nil
+}
+
+// checkBuildVersion checks that a build and version can be linked
+// Rows that don't exist aren't checked
+func (d *Database) checkBuildVersion(buildID, versionID string) error {
+	b, ok, err := d.Build(buildID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get build")
+	} else if !ok {
+		return nil
+	}
+	v, ok, err := d.Version(versionID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get version")
+	} else if !ok {
+		return nil
+	}
+	if ce := buildVersionConsistency(b, v); ce != nil {
+		return ce
+	}
+	return nil
+}
+
+// checkBuildRecord checks that a build and record can be linked
+// Rows that don't exist aren't checked
+func (d *Database) checkBuildRecord(buildID, recordID string) error {
+	b, ok, err := d.Build(buildID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get build")
+	} else if !ok {
+		return nil
+	}
+	r, ok, err := d.Record(recordID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get record")
+	} else if !ok {
+		return nil
+	}
+	if ce := buildRecordConsistency(b, r); ce != nil {
+		return ce
+	}
+	return nil
+}
+
+// checkRecordBuilds checks that a record's edition and build class
+// agree with those of the builds which hold or held the record
+func (d *Database) checkRecordBuilds(r Record) error {
+	// Convert record id to int
+	recordIDint, err := strconv.Atoi(r.ID)
+	if err != nil {
+		return errors.Wrap(err, "failed to convert record id to integer")
+	}
+	links, err := d.buildRecordLinks("WHERE RecordID = ?", recordIDint)
+	if err != nil {
+		return err
+	}
+	for _, l := range links {
+		b, ok, err := d.Build(l.BuildID)
+		if err != nil {
+			return errors.Wrap(err, "failed to get build")
+		} else if !ok {
+			continue
+		}
+		if ce := buildRecordConsistency(b, r); ce != nil {
+			return ce
+		}
+	}
+	return nil
+}
+
+// checkBuildLinks checks that a build's edition and build class agree
+// with those of the versions and records it's linked to
+func (d *Database) checkBuildLinks(b Build) error {
+	// Convert build id to int
+	buildIDint, err := strconv.Atoi(b.ID)
+	if err != nil {
+		return errors.Wrap(err, "failed to convert build id to integer")
+	}
+	// Query the database
+	rows, err := d.db.Query(`
+		SELECT VersionID
+		FROM BuildVersions
+		WHERE BuildID = ?
+	`, buildIDint)
+	if err != nil {
+		return errors.Wrap(err, "database query failed")
+	}
+	defer rows.Close()
+	// Extract data
+	versionIDs := []string{}
+	var versionIDint int
+	for rows.Next() {
+		if err = rows.Scan(&versionIDint); err != nil {
+			return errors.Wrap(err, "failed to extract data")
+		}
+		versionIDs = append(versionIDs, strconv.Itoa(versionIDint))
+	}
+	if err = rows.Err(); err != nil {
+		return errors.Wrap(err, "failed to read rows")
+	}
+	rows.Close()
+	for _, id := range versionIDs {
+		v, ok, err := d.Version(id)
+		if err != nil {
+			return errors.Wrap(err, "failed to get version")
+		} else if !ok {
+			continue
+		}
+		if ce := buildVersionConsistency(b, v); ce != nil {
+			return ce
+		}
+	}
+	links, err := d.buildRecordLinks("WHERE BuildID = ?", buildIDint)
+	if err != nil {
+		return err
+	}
+	for _, l := range links {
+		r, ok, err := d.Record(l.RecordID)
+		if err != nil {
+			return errors.Wrap(err, "failed to get record")
+		} else if !ok {
+			continue
+		}
+		if ce := buildRecordConsistency(b, r); ce != nil {
+			return ce
+		}
+	}
+	return nil
+}
+
+// Violation is an existing row which links rows of
+// different editions or build classes
+type Violation struct {
+	// Table is the table of the row, BuildVersions or BuildRecords
+	Table string
+	// BuildID is the id of the build the row links
+	BuildID string
+	// VersionID is the id of the version for build versions
+	VersionID string
+	// BuildRecordID is the id of the row for build records
+	BuildRecordID string
+	// RecordID is the id of the record for build records
+	RecordID string
+	// Err describes what the rows disagree on
+	Err *ConsistencyError
+}
+
+// Violations scans the build versions and build records for rows which
+// link rows of different editions or build classes
+// They can only have been made before they were checked for
+func (d *Database) Violations() ([]Violation, error) {
+	builds, err := d.Builds()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get builds")
+	}
+	buildByID := map[string]Build{}
+	for _, b := range builds {
+		buildByID[b.ID] = b
+	}
+	versions, err := d.Versions()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get versions")
+	}
+	versionByID := map[string]Version{}
+	for _, v := range versions {
+		versionByID[v.ID] = v
+	}
+	records, err := d.Records()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get records")
+	}
+	recordByID := map[string]Record{}
+	for _, r := range records {
+		recordByID[r.ID] = r
+	}
+
+	violations := []Violation{}
+	rows, err := d.db.Query(`
+		SELECT BuildID, VersionID
+		FROM BuildVersions
+		ORDER BY BuildID, VersionID
+	`)
+	if err != nil {
+		return nil, errors.Wrap(err, "database query failed")
+	}
+	defer rows.Close()
+	var buildIDint, versionIDint int
+	for rows.Next() {
+		if err = rows.Scan(&buildIDint, &versionIDint); err != nil {
+			return nil, errors.Wrap(err, "failed to extract data")
+		}
+		b, bok := buildByID[strconv.Itoa(buildIDint)]
+		v, vok := versionByID[strconv.Itoa(versionIDint)]
+		if !bok || !vok {
+			continue
+		}
+		if ce := buildVersionConsistency(b, v); ce != nil {
+			violations = append(violations, Violation{Table: "BuildVersions", BuildID: b.ID, VersionID: v.ID, Err: ce})
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read rows")
+	}
+
+	links, err := d.buildRecordLinks("ORDER BY ID")
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range links {
+		b, bok := buildByID[l.BuildID]
+		r, rok := recordByID[l.RecordID]
+		if !bok || !rok {
+			continue
+		}
+		if ce := buildRecordConsistency(b, r); ce != nil {
+			violations = append(violations, Violation{Table: "BuildRecords", BuildID: b.ID, BuildRecordID: l.ID, RecordID: r.ID, Err: ce})
+		}
+	}
+	return violations, nil
+}
+
+// buildRecordLinks gets the ids of build records and the builds and
+// records they link, selected by the end of a query
+func (d *Database) buildRecordLinks(clause string, args ...interface{}) ([]BuildRecord, error) {
+	rows, err := d.db.Query(`
+		SELECT ID, BuildID, RecordID
+		FROM BuildRecords
+		`+clause, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "database query failed")
+	}
+	defer rows.Close()
+	results := []BuildRecord{}
+	var idInt, buildIDint, recordIDint int
+	for rows.Next() {
+		if err = rows.Scan(&idInt, &buildIDint, &recordIDint); err != nil {
+			return nil, errors.Wrap(err, "failed to extract data")
+		}
+		results = append(results, BuildRecord{
+			ID:       strconv.Itoa(idInt),
+			BuildID:  strconv.Itoa(buildIDint),
+			RecordID: strconv.Itoa(recordIDint),
+		})
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read rows")
+	}
+	return results, nil
+}
@@ -0,0 +1,288 @@
+package database
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// testDB is the database shared by the tests
+// Only one database can be opened
+var testDB *Database
+
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "database")
+	if err != nil {
+		panic(err)
+	}
+	testDB, err = Open(filepath.Join(dir, "database.db"))
+	if err != nil {
+		os.RemoveAll(dir)
+		panic(err)
+	}
+	code := m.Run()
+	testDB.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// fixture is an edition and build class along with
+// another of each which builds and records can disagree on
+type fixture struct {
+	java, bedrock  Edition
+	piston, flying BuildClass
+	recordType     RecordType
+}
+
+// newFixture creates the editions, build classes and record type
+// of a fixture, named after the test so they don't clash
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	var (
+		f   fixture
+		err error
+	)
+	if f.java, err = testDB.EditionCreate(t.Name()+" Java", ""); err != nil {
+		t.Fatalf("failed to create edition: %v", err)
+	}
+	if f.bedrock, err = testDB.EditionCreate(t.Name()+" Bedrock", ""); err != nil {
+		t.Fatalf("failed to create edition: %v", err)
+	}
+	if f.piston, err = testDB.BuildClassCreate(t.Name()+" Piston", "", "", ""); err != nil {
+		t.Fatalf("failed to create build class: %v", err)
+	}
+	if f.flying, err = testDB.BuildClassCreate(t.Name()+" Flying", "", "", ""); err != nil {
+		t.Fatalf("failed to create build class: %v", err)
+	}
+	if f.recordType, err = testDB.RecordTypeCreate(t.Name()+" Fastest", ""); err != nil {
+		t.Fatalf("failed to create record type: %v", err)
+	}
+	return f
+}
+
+// build creates a build of an edition and build class
+func (f fixture) build(t *testing.T, e Edition, c BuildClass) Build {
+	t.Helper()
+	b, err := testDB.BuildCreate(Build{
+		VerifierID:           "0",
+		EditionID:            e.ID,
+		BuildClassID:         c.ID,
+		Name:                 "Piston Door",
+		ReporterID:           "0",
+		UpdateRequestBuildID: "0",
+		SubmitterID:          "0",
+	})
+	if err != nil {
+		t.Fatalf("failed to create build: %v", err)
+	}
+	return b
+}
+
+// record creates a record of an edition and build class
+func (f fixture) record(t *testing.T, e Edition, c BuildClass) Record {
+	t.Helper()
+	r, err := testDB.RecordCreate(Record{
+		VerifierID:            "0",
+		UpdateRequestRecordID: "0",
+		EditionID:             e.ID,
+		BuildClassID:          c.ID,
+		RecordTypeID:          f.recordType.ID,
+		Name:                  "Fastest Piston Door",
+		SubmitterID:           "0",
+	})
+	if err != nil {
+		t.Fatalf("failed to create record: %v", err)
+	}
+	return r
+}
+
+// version creates a version of an edition
+func (f fixture) version(t *testing.T, e Edition) Version {
+	t.Helper()
+	v, err := testDB.VersionCreate(Version{EditionID: e.ID, MajorVersion: 1, MinorVersion: 16, Name: "1.16"})
+	if err != nil {
+		t.Fatalf("failed to create version: %v", err)
+	}
+	return v
+}
+
+// hold creates a build record linking a build and record
+func hold(b Build, r Record) (BuildRecord, error) {
+	return testDB.BuildRecordCreate(BuildRecord{
+		BuildID:            b.ID,
+		RecordID:           r.ID,
+		VerifierID:         "0",
+		ReporterID:         "0",
+		JointBuildRecordID: "0",
+		SubmitterID:        "0",
+	})
+}
+
+// checkConsistencyError checks that an error is a *ConsistencyError
+// about a field, or that there's no error if the field is empty
+func checkConsistencyError(t *testing.T, err error, field string) {
+	t.Helper()
+	if field == "" {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+	ce, ok := errors.Cause(err).(*ConsistencyError)
+	if !ok {
+		t.Fatalf("expected a consistency error about the %s, got %v", field, err)
+	}
+	if ce.Field != field {
+		t.Fatalf("expected a consistency error about the %s, got %v", field, ce)
+	}
+}
+
+func TestBuildVersionCreateConsistency(t *testing.T) {
+	f := newFixture(t)
+	b := f.build(t, f.java, f.piston)
+
+	_, _, err := testDB.BuildVersionCreate(b.ID, f.version(t, f.bedrock).ID, "0", "")
+	checkConsistencyError(t, err, "edition")
+	_, ok, err := testDB.BuildVersionCreate(b.ID, f.version(t, f.java).ID, "0", "")
+	checkConsistencyError(t, err, "")
+	if !ok {
+		t.Fatalf("expected the build version to be created")
+	}
+}
+
+func TestBuildRecordCreateConsistency(t *testing.T) {
+	f := newFixture(t)
+	b := f.build(t, f.java, f.piston)
+	tests := []struct {
+		name    string
+		edition Edition
+		class   BuildClass
+		field   string
+	}{
+		{"different edition", f.bedrock, f.piston, "edition"},
+		{"different build class", f.java, f.flying, "build class"},
+		{"same edition and build class", f.java, f.piston, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := hold(b, f.record(t, tt.edition, tt.class))
+			checkConsistencyError(t, err, tt.field)
+		})
+	}
+}
+
+func TestRecordEditConsistency(t *testing.T) {
+	f := newFixture(t)
+	b := f.build(t, f.java, f.piston)
+	tests := []struct {
+		name    string
+		edition Edition
+		class   BuildClass
+		field   string
+	}{
+		{"change edition", f.bedrock, f.piston, "edition"},
+		{"change build class", f.java, f.flying, "build class"},
+		{"change name", f.java, f.piston, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := f.record(t, f.java, f.piston)
+			if _, err := hold(b, r); err != nil {
+				t.Fatalf("failed to create build record: %v", err)
+			}
+			r.EditionID, r.BuildClassID, r.Name = tt.edition.ID, tt.class.ID, "Fastest Piston Tower"
+			_, _, err := testDB.RecordEdit(r.ID, r)
+			checkConsistencyError(t, err, tt.field)
+			got, _, err := testDB.Record(r.ID)
+			if err != nil {
+				t.Fatalf("failed to get record: %v", err)
+			}
+			if edited := got.Name == r.Name; edited != (tt.field == "") {
+				t.Fatalf("expected the record to be edited only if consistent, got %+v", got)
+			}
+		})
+	}
+}
+
+func TestBuildEditConsistency(t *testing.T) {
+	f := newFixture(t)
+	tests := []struct {
+		name    string
+		edition Edition
+		class   BuildClass
+		field   string
+	}{
+		{"change edition", f.bedrock, f.piston, "edition"},
+		{"change build class", f.java, f.flying, "build class"},
+		{"change name", f.java, f.piston, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := f.build(t, f.java, f.piston)
+			if _, err := hold(b, f.record(t, f.java, f.piston)); err != nil {
+				t.Fatalf("failed to create build record: %v", err)
+			}
+			b.EditionID, b.BuildClassID, b.Name = tt.edition.ID, tt.class.ID, "Piston Tower"
+			_, _, err := testDB.BuildEdit(b.ID, b)
+			checkConsistencyError(t, err, tt.field)
+		})
+	}
+}
+
+func TestViolations(t *testing.T) {
+	f := newFixture(t)
+	b := f.build(t, f.java, f.piston)
+	r := f.record(t, f.java, f.piston)
+	br, err := hold(b, r)
+	if err != nil {
+		t.Fatalf("failed to create build record: %v", err)
+	}
+	v := f.version(t, f.java)
+	if _, _, err = testDB.BuildVersionCreate(b.ID, v.ID, "0", ""); err != nil {
+		t.Fatalf("failed to create build version: %v", err)
+	}
+	before, err := testDB.Violations()
+	if err != nil {
+		t.Fatalf("failed to get violations: %v", err)
+	}
+
+	// The build is moved to another edition as if before it was checked for
+	if _, err = testDB.conn.Exec(`UPDATE Builds SET EditionID = ? WHERE ID = ?`, f.bedrock.ID, b.ID); err != nil {
+		t.Fatalf("failed to update build: %v", err)
+	}
+	after, err := testDB.Violations()
+	if err != nil {
+		t.Fatalf("failed to get violations: %v", err)
+	}
+	if len(after) != len(before)+2 {
+		t.Fatalf("expected 2 new violations, got %d", len(after)-len(before))
+	}
+	found := map[string]bool{}
+	for _, violation := range after {
+		if violation.BuildID != b.ID {
+			continue
+		}
+		switch {
+		case violation.Table == "BuildVersions" && violation.VersionID == v.ID:
+		case violation.Table == "BuildRecords" && violation.BuildRecordID == br.ID && violation.RecordID == r.ID:
+		default:
+			t.Errorf("unexpected violation %+v", violation)
+		}
+		if violation.Err == nil || violation.Err.Field != "edition" {
+			t.Errorf("expected a violation of the edition, got %+v", violation.Err)
+		}
+		found[violation.Table] = true
+	}
+	if !found["BuildVersions"] || !found["BuildRecords"] {
+		t.Errorf("expected violations of both tables, got %v", found)
+	}
+
+	// The record can still be edited without changing its edition
+	r.Name = "Fastest Piston Tower"
+	if _, ok, err := testDB.RecordEdit(r.ID, r); err != nil || !ok {
+		t.Fatalf("expected the record to be edited, got %v, %v", ok, err)
+	}
+}
@@ -1,23 +1,33 @@
 package database
 
 import (
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 )
 
 // Instance gets the packages database instance
+// If Open hasn't been called, the database at DefaultPath is opened
 func Instance() (*Database, error) {
-	if Error != nil {
-		return nil, errors.Wrap(Error, "database connection failed during init")
+	instanceMutex.Lock()
+	defer instanceMutex.Unlock()
+	if !opened {
+		db, err := open(DefaultPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to open database")
+		}
+		databaseInstance = *db
+		opened = true
 	}
 	return &databaseInstance, nil
 }
 
 // Close closes the database connection
 func (d *Database) Close() error {
-	return d.db.Close()
+	return d.conn.Close()
 }
 
 // UserStrikeCount gets the number of strikes that have been given
@@ -322,6 +332,7 @@ func (d *Database) UserStrikeEdit(userID, strikeID, reason string) (UserStrike,
 }
 
 // GuildSetting gets the setting information for a guild
+// The guild's settings themselves are got with GuildSettingGet
 func (d *Database) GuildSetting(guildID string) (GuildSetting, bool, error) {
 	// Convert guildID to int
 	guildIDint, err := strconv.Atoi(guildID)
@@ -330,7 +341,7 @@ func (d *Database) GuildSetting(guildID string) (GuildSetting, bool, error) {
 	}
 	// Query the database
 	rows, err := d.db.Query(`
-		SELECT BuildChannelID, TicketChannelCategoryID, Timestamp, EditedTimestamp
+		SELECT Timestamp, EditedTimestamp
 		FROM GuildSettings
 		WHERE GuildID = ?
 	`, guildIDint)
@@ -344,17 +355,12 @@ func (d *Database) GuildSetting(guildID string) (GuildSetting, bool, error) {
 	}
 	// Extract data
 	var (
-		buildChannelIDint          int
-		ticketChannelCategoryIDint int
-		timestampString            string
-		editedTimestampString      string
-		timestamp                  time.Time
-		editedTimestamp            time.Time
+		timestampString       string
+		editedTimestampString string
+		timestamp             time.Time
+		editedTimestamp       time.Time
 	)
-	if err = rows.Scan(
-		&buildChannelIDint, &ticketChannelCategoryIDint,
-		&timestampString, &editedTimestampString,
-	); err != nil {
+	if err = rows.Scan(&timestampString, &editedTimestampString); err != nil {
 		return GuildSetting{}, false, errors.Wrap(err, "database query failed")
 	}
 	// Parse timestamps
@@ -365,11 +371,9 @@ func (d *Database) GuildSetting(guildID string) (GuildSetting, bool, error) {
 		return GuildSetting{}, false, errors.Wrap(err, "failed to parse edited timestamp")
 	}
 	return GuildSetting{
-		GuildID:                 guildID,
-		BuildChannelID:          strconv.Itoa(buildChannelIDint),
-		TicketChannelCategoryID: strconv.Itoa(ticketChannelCategoryIDint),
-		Timestamp:               Timestamp(timestamp),
-		EditedTimestamp:         Timestamp(editedTimestamp),
+		GuildID:         guildID,
+		Timestamp:       Timestamp(timestamp),
+		EditedTimestamp: Timestamp(editedTimestamp),
 	}, true, nil
 }
 
@@ -377,7 +381,7 @@ func (d *Database) GuildSetting(guildID string) (GuildSetting, bool, error) {
 func (d *Database) GuildSettings() ([]GuildSetting, error) {
 	// Query the database
 	rows, err := d.db.Query(`
-		SELECT *
+		SELECT GuildID, Timestamp, EditedTimestamp
 		FROM GuildSettings
 	`)
 	if err != nil {
@@ -387,21 +391,16 @@ func (d *Database) GuildSettings() ([]GuildSetting, error) {
 	// Create space to store results
 	results := []GuildSetting{}
 	var (
-		guildIDint                 int
-		buildChannelIDint          int
-		ticketChannelCategoryIDint int
-		timestampString            string
-		editedTimestampString      string
-		timestamp                  time.Time
-		editedTimestamp            time.Time
+		guildIDint            int
+		timestampString       string
+		editedTimestampString string
+		timestamp             time.Time
+		editedTimestamp       time.Time
 	)
 	// For each row
 	for rows.Next() {
 		// Extract data
-		if err = rows.Scan(
-			&guildIDint, &buildChannelIDint, &ticketChannelCategoryIDint,
-			&timestampString, &editedTimestampString,
-		); err != nil {
+		if err = rows.Scan(&guildIDint, &timestampString, &editedTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to extract data")
 		}
 		// Parse timestamps
@@ -413,31 +412,22 @@ func (d *Database) GuildSettings() ([]GuildSetting, error) {
 		}
 		// Add to results
 		results = append(results, GuildSetting{
-			GuildID:                 strconv.Itoa(guildIDint),
-			BuildChannelID:          strconv.Itoa(buildChannelIDint),
-			TicketChannelCategoryID: strconv.Itoa(ticketChannelCategoryIDint),
-			Timestamp:               Timestamp(timestamp),
-			EditedTimestamp:         Timestamp(editedTimestamp),
+			GuildID:         strconv.Itoa(guildIDint),
+			Timestamp:       Timestamp(timestamp),
+			EditedTimestamp: Timestamp(editedTimestamp),
 		})
 	}
 	return results, nil
 }
 
 // GuildSettingCreate creates setting information for a guild
-func (d *Database) GuildSettingCreate(guildID, buildChannelID, ticketCategoryID string) (GuildSetting, bool, error) {
-	// Convert guildID, buildChannelID and ticketCategoryID to ints
+// Every setting of the guild has its default value
+func (d *Database) GuildSettingCreate(guildID string) (GuildSetting, bool, error) {
+	// Convert guildID to int
 	guildIDint, err := strconv.Atoi(guildID)
 	if err != nil {
 		return GuildSetting{}, false, errors.Wrap(err, "failed to convert guild id to integer")
 	}
-	buildChannelIDint, err := strconv.Atoi(buildChannelID)
-	if err != nil {
-		return GuildSetting{}, false, errors.Wrap(err, "failed to convert build channel id to integer")
-	}
-	ticketCategoryIDint, err := strconv.Atoi(ticketCategoryID)
-	if err != nil {
-		return GuildSetting{}, false, errors.Wrap(err, "failed to convert ticket category id to integer")
-	}
 	// Check if guild setting already exists
 	if _, ok, err := d.GuildSetting(guildID); err != nil {
 		return GuildSetting{}, false, errors.Wrap(err, "failed to determine if guild setting exists")
@@ -447,16 +437,14 @@ func (d *Database) GuildSettingCreate(guildID, buildChannelID, ticketCategoryID
 	}
 	// Create guild setting
 	gs := GuildSetting{
-		GuildID:                 guildID,
-		BuildChannelID:          buildChannelID,
-		TicketChannelCategoryID: ticketCategoryID,
-		Timestamp:               Timestamp(time.Now()),
-		EditedTimestamp:         Timestamp(time.Now()),
+		GuildID:         guildID,
+		Timestamp:       Timestamp(time.Now()),
+		EditedTimestamp: Timestamp(time.Now()),
 	}
 	// Prepare query
 	s, err := d.db.Prepare(`
-		INSERT INTO GuildSettings 
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO GuildSettings
+		VALUES (?, ?, ?)
 	`)
 	if err != nil {
 		return GuildSetting{}, false, errors.Wrap(err, "failed to prepare query")
@@ -464,16 +452,18 @@ func (d *Database) GuildSettingCreate(guildID, buildChannelID, ticketCategoryID
 	defer s.Close()
 	// Execute query
 	if _, err = s.Exec(
-		guildIDint, buildChannelIDint, ticketCategoryIDint,
+		guildIDint,
 		time.Time(gs.Timestamp).Format(timeLayout),
 		time.Time(gs.EditedTimestamp).Format(timeLayout),
 	); err != nil {
 		return GuildSetting{}, false, errors.Wrap(err, "database query failed")
 	}
-	return gs, false, nil
+	return gs, true, nil
 }
 
 // GuildSettingDelete deletes the setting information for a guild
+// along with the values of its settings. The history of its
+// settings is kept
 func (d *Database) GuildSettingDelete(guildID string) (GuildSetting, bool, error) {
 	// Convert guildID to int
 	guildIDint, err := strconv.Atoi(guildID)
@@ -489,69 +479,232 @@ func (d *Database) GuildSettingDelete(guildID string) (GuildSetting, bool, error
 		// Row doesn't exist
 		return GuildSetting{}, false, nil
 	}
+	err = d.Transaction(func(tx *Database) error {
+		for _, query := range []string{
+			`DELETE FROM GuildSettingValues WHERE GuildID = ?`,
+			`DELETE FROM GuildSettings WHERE GuildID = ?`,
+		} {
+			// Prepare query
+			s, err := tx.db.Prepare(query)
+			if err != nil {
+				return errors.Wrap(err, "failed to prepare query")
+			}
+			// Execute query
+			_, err = s.Exec(guildIDint)
+			s.Close()
+			if err != nil {
+				return errors.Wrap(err, "database query failed")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return GuildSetting{}, false, err
+	}
+	return gs, true, nil
+}
+
+// guildSettingTouch updates the time the settings of a guild were last edited
+func (d *Database) guildSettingTouch(guildID string) error {
+	// Convert guildID to int
+	guildIDint, err := strconv.Atoi(guildID)
+	if err != nil {
+		return errors.Wrap(err, "failed to convert guild id to integer")
+	}
 	// Prepare query
 	s, err := d.db.Prepare(`
-		DELETE FROM GuildSettings
+		UPDATE GuildSettings
+		SET EditedTimestamp = ?
 		WHERE GuildID = ?
 	`)
 	if err != nil {
-		return GuildSetting{}, false, errors.Wrap(err, "failed to prepare query")
+		return errors.Wrap(err, "failed to prepare query")
 	}
 	defer s.Close()
 	// Execute query
-	if _, err = s.Exec(guildIDint); err != nil {
-		return GuildSetting{}, false, errors.Wrap(err, "database query failed")
+	if _, err = s.Exec(time.Now().Format(timeLayout), guildIDint); err != nil {
+		return errors.Wrap(err, "database query failed")
 	}
-	return gs, true, nil
+	return nil
 }
 
-// GuildSettingEdit edits the setting information for a guild
-func (d *Database) GuildSettingEdit(guildID, buildChannelID, ticketChannelCategoryID string) (GuildSetting, bool, error) {
-	// Convert guildID, buildChannelID and ticketCategory to ints
+// guildSettingValue gets the stored value of a setting of a guild
+// false is returned if the setting has its default value
+func (d *Database) guildSettingValue(guildID, key string) (string, bool, error) {
+	// Convert guildID to int
 	guildIDint, err := strconv.Atoi(guildID)
 	if err != nil {
-		return GuildSetting{}, false, errors.Wrap(err, "failed to convert guild id to integer")
+		return "", false, errors.Wrap(err, "failed to convert guild id to integer")
+	}
+	// Query the database
+	rows, err := d.db.Query(`
+		SELECT Value
+		FROM GuildSettingValues
+		WHERE GuildID = ? AND Key = ?
+	`, guildIDint, key)
+	if err != nil {
+		return "", false, errors.Wrap(err, "database query failed")
+	}
+	defer rows.Close()
+	// Check if the value exists
+	if !rows.Next() {
+		return "", false, nil
+	}
+	// Extract data
+	var value string
+	if err = rows.Scan(&value); err != nil {
+		return "", false, errors.Wrap(err, "database query failed")
 	}
-	buildChannelIDint, err := strconv.Atoi(buildChannelID)
+	return value, true, nil
+}
+
+// guildSettingValueSet stores the value of a setting of a guild
+func (d *Database) guildSettingValueSet(guildID, key, value string) error {
+	// Convert guildID to int
+	guildIDint, err := strconv.Atoi(guildID)
 	if err != nil {
-		return GuildSetting{}, false, errors.Wrap(err, "failed to convert build channel id to integer")
+		return errors.Wrap(err, "failed to convert guild id to integer")
 	}
-	ticketChannelCategoryIDint, err := strconv.Atoi(ticketChannelCategoryID)
+	now := time.Now().Format(timeLayout)
+	// Prepare query
+	s, err := d.db.Prepare(`
+		INSERT INTO GuildSettingValues
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (GuildID, Key)
+		DO UPDATE SET Value = excluded.Value, EditedTimestamp = excluded.EditedTimestamp
+	`)
 	if err != nil {
-		return GuildSetting{}, false, errors.Wrap(err, "failed to convert ticket category id to integer")
+		return errors.Wrap(err, "failed to prepare query")
 	}
-	// Get the guild setting to return after deletion and
-	// to check if it exists
-	gs, ok, err := d.GuildSetting(guildID)
+	defer s.Close()
+	// Execute query
+	if _, err = s.Exec(guildIDint, key, value, now, now); err != nil {
+		return errors.Wrap(err, "database query failed")
+	}
+	return nil
+}
+
+// guildSettingValueDelete removes the stored value of a setting of
+// a guild so that it has its default value
+func (d *Database) guildSettingValueDelete(guildID, key string) error {
+	// Convert guildID to int
+	guildIDint, err := strconv.Atoi(guildID)
 	if err != nil {
-		return GuildSetting{}, false, errors.Wrap(err, "failed to determine if guild setting exists")
-	} else if !ok {
-		// Row doesn't exist
-		return GuildSetting{}, false, nil
+		return errors.Wrap(err, "failed to convert guild id to integer")
 	}
-	// Update information
-	gs.BuildChannelID = buildChannelID
-	gs.TicketChannelCategoryID = ticketChannelCategoryID
-	gs.EditedTimestamp = Timestamp(time.Now())
 	// Prepare query
 	s, err := d.db.Prepare(`
-		UPDATE GuildSettings
-		SET BuildChannelID = ?, TicketChannelCategoryID = ?, EditedTimestamp = ?
-		WHERE GuildID = ?
+		DELETE FROM GuildSettingValues
+		WHERE GuildID = ? AND Key = ?
 	`)
 	if err != nil {
-		return GuildSetting{}, false, errors.Wrap(err, "failed to prepare query")
+		return errors.Wrap(err, "failed to prepare query")
+	}
+	defer s.Close()
+	// Execute query
+	if _, err = s.Exec(guildIDint, key); err != nil {
+		return errors.Wrap(err, "database query failed")
+	}
+	return nil
+}
+
+// GuildSettingChanges gets the history of changes to a setting of a guild
+// with the most recent change first. All settings are included if key is empty
+func (d *Database) GuildSettingChanges(guildID, key string) ([]GuildSettingChange, error) {
+	// Convert guildID to int
+	guildIDint, err := strconv.Atoi(guildID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert guild id to integer")
+	}
+	// Query the database
+	rows, err := d.db.Query(`
+		SELECT ID, Key, OldValue, NewValue, Reset, ChangedByID, Timestamp
+		FROM GuildSettingChanges
+		WHERE GuildID = ? AND (? = '' OR Key = ?)
+		ORDER BY ID DESC
+	`, guildIDint, key, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "database query failed")
+	}
+	defer rows.Close()
+	// Create space to store results
+	results := []GuildSettingChange{}
+	var (
+		idInt           int
+		changeKey       string
+		oldValue        string
+		newValue        string
+		reset           bool
+		changedByIDint  int
+		timestampString string
+		timestamp       time.Time
+	)
+	// For each row
+	for rows.Next() {
+		// Extract data
+		if err = rows.Scan(
+			&idInt, &changeKey, &oldValue, &newValue,
+			&reset, &changedByIDint, &timestampString,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to extract data")
+		}
+		// Parse timestamp
+		if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
+			return nil, errors.Wrap(err, "failed to parse timestamp")
+		}
+		// Changes that weren't made by a user have no id
+		changedByID := ""
+		if changedByIDint != 0 {
+			changedByID = strconv.Itoa(changedByIDint)
+		}
+		// Add to results
+		results = append(results, GuildSettingChange{
+			ID:          strconv.Itoa(idInt),
+			GuildID:     guildID,
+			Key:         changeKey,
+			OldValue:    oldValue,
+			NewValue:    newValue,
+			Reset:       reset,
+			ChangedByID: changedByID,
+			Timestamp:   Timestamp(timestamp),
+		})
+	}
+	return results, nil
+}
+
+// guildSettingChangeCreate records a change to a setting of a guild
+// changedByID may be empty if the change wasn't made by a user
+func (d *Database) guildSettingChangeCreate(gsc GuildSettingChange) error {
+	// Convert ids to ints
+	guildIDint, err := strconv.Atoi(gsc.GuildID)
+	if err != nil {
+		return errors.Wrap(err, "failed to convert guild id to integer")
+	}
+	changedByIDint := 0
+	if gsc.ChangedByID != "" {
+		if changedByIDint, err = strconv.Atoi(gsc.ChangedByID); err != nil {
+			return errors.Wrap(err, "failed to convert changed by id to integer")
+		}
+	}
+	// Prepare query
+	s, err := d.db.Prepare(`
+		INSERT INTO GuildSettingChanges (GuildID, Key, OldValue,
+			NewValue, Reset, ChangedByID, Timestamp
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare query")
 	}
 	defer s.Close()
 	// Execute query
 	if _, err = s.Exec(
-		buildChannelIDint, ticketChannelCategoryIDint,
-		time.Time(gs.EditedTimestamp).Format(timeLayout),
-		guildIDint,
+		guildIDint, gsc.Key, gsc.OldValue, gsc.NewValue, gsc.Reset,
+		changedByIDint, time.Time(gsc.Timestamp).Format(timeLayout),
 	); err != nil {
-		return GuildSetting{}, false, errors.Wrap(err, "database query failed")
+		return errors.Wrap(err, "database query failed")
 	}
-	return gs, true, nil
+	return nil
 }
 
 // Edition gets the edition information with the specified id
@@ -777,7 +930,7 @@ func (d *Database) BuildClass(buildClassID string) (BuildClass, bool, error) {
 	}
 	// Query the database
 	rows, err := d.db.Query(`
-		SELECT Name, Description, EmbedColour, Timestamp, EditedTimestamp
+		SELECT Name, Description, EmbedColour, Rule, Timestamp, EditedTimestamp
 		FROM BuildClasses
 		WHERE ID = ?
 	`, buildClassIDint)
@@ -794,6 +947,7 @@ func (d *Database) BuildClass(buildClassID string) (BuildClass, bool, error) {
 		name                  string
 		description           string
 		embedColour           string
+		rule                  string
 		timestampString       string
 		editedTimestampString string
 		timestamp             time.Time
@@ -801,7 +955,7 @@ func (d *Database) BuildClass(buildClassID string) (BuildClass, bool, error) {
 	)
 	// Extract data
 	if err = rows.Scan(
-		&name, &description, &embedColour,
+		&name, &description, &embedColour, &rule,
 		&timestampString, &editedTimestampString,
 	); err != nil {
 		return BuildClass{}, false, errors.Wrap(err, "failed to extract data")
@@ -818,6 +972,7 @@ func (d *Database) BuildClass(buildClassID string) (BuildClass, bool, error) {
 		Name:            name,
 		Description:     description,
 		EmbedColour:     embedColour,
+		Rule:            rule,
 		Timestamp:       Timestamp(timestamp),
 		EditedTimestamp: Timestamp(editedTimestamp),
 	}, true, nil
@@ -827,7 +982,7 @@ func (d *Database) BuildClass(buildClassID string) (BuildClass, bool, error) {
 func (d *Database) BuildClasses() ([]BuildClass, error) {
 	// Query the database
 	rows, err := d.db.Query(`
-		SELECT *
+		SELECT ID, Name, Description, EmbedColour, Rule, Timestamp, EditedTimestamp
 		FROM BuildClasses
 	`)
 	if err != nil {
@@ -841,6 +996,7 @@ func (d *Database) BuildClasses() ([]BuildClass, error) {
 		name                  string
 		description           string
 		embedColour           string
+		rule                  string
 		timestampString       string
 		editedTimestampString string
 		timestamp             time.Time
@@ -850,7 +1006,7 @@ func (d *Database) BuildClasses() ([]BuildClass, error) {
 	for rows.Next() {
 		// Extract data
 		if err = rows.Scan(
-			&idInt, &name, &description, &embedColour,
+			&idInt, &name, &description, &embedColour, &rule,
 			&timestampString, &editedTimestampString,
 		); err != nil {
 			return nil, errors.Wrap(err, "failed to extract data")
@@ -868,6 +1024,7 @@ func (d *Database) BuildClasses() ([]BuildClass, error) {
 			Name:            name,
 			Description:     description,
 			EmbedColour:     embedColour,
+			Rule:            rule,
 			Timestamp:       Timestamp(timestamp),
 			EditedTimestamp: Timestamp(editedTimestamp),
 		})
@@ -876,19 +1033,24 @@ func (d *Database) BuildClasses() ([]BuildClass, error) {
 }
 
 // BuildClassCreate creates a new build class
-func (d *Database) BuildClassCreate(name, description, embedColour string) (BuildClass, error) {
+// The rule is the key of the rule its builds follow, or empty for none
+func (d *Database) BuildClassCreate(name, description, embedColour, rule string) (BuildClass, error) {
+	if err := checkBuildClassRule(rule); err != nil {
+		return BuildClass{}, err
+	}
 	// Create build class
 	bc := BuildClass{
 		Name:            name,
 		Description:     description,
 		EmbedColour:     embedColour,
+		Rule:            rule,
 		Timestamp:       Timestamp(time.Now()),
 		EditedTimestamp: Timestamp(time.Now()),
 	}
 	// Prepare query
 	s, err := d.db.Prepare(`
-		INSERT INTO BuildClasses (Name, Description, EmbedColour, Timestamp, EditedTimestamp)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO BuildClasses (Name, Description, EmbedColour, Rule, Timestamp, EditedTimestamp)
+		VALUES (?, ?, ?, ?, ?, ?)
 	`)
 	if err != nil {
 		return BuildClass{}, errors.Wrap(err, "failed to prepare query")
@@ -896,7 +1058,7 @@ func (d *Database) BuildClassCreate(name, description, embedColour string) (Buil
 	defer s.Close()
 	// Execute query
 	res, err := s.Exec(
-		name, description, embedColour,
+		name, description, embedColour, rule,
 		time.Time(bc.Timestamp).Format(timeLayout),
 		time.Time(bc.EditedTimestamp).Format(timeLayout),
 	)
@@ -948,7 +1110,11 @@ func (d *Database) BuildClassDelete(buildClassID string) (BuildClass, bool, erro
 }
 
 // BuildClassEdit edits an existing build class
-func (d *Database) BuildClassEdit(buildClassID, name, description, embedColour string) (BuildClass, bool, error) {
+// The rule is the key of the rule its builds follow, or empty for none
+func (d *Database) BuildClassEdit(buildClassID, name, description, embedColour, rule string) (BuildClass, bool, error) {
+	if err := checkBuildClassRule(rule); err != nil {
+		return BuildClass{}, false, err
+	}
 	// Convert build class id to int
 	buildClassIDint, err := strconv.Atoi(buildClassID)
 	if err != nil {
@@ -966,11 +1132,12 @@ func (d *Database) BuildClassEdit(buildClassID, name, description, embedColour s
 	bc.Name = name
 	bc.Description = description
 	bc.EmbedColour = embedColour
+	bc.Rule = rule
 	bc.EditedTimestamp = Timestamp(time.Now())
 	// Prepare query
 	s, err := d.db.Prepare(`
 		UPDATE BuildClasses
-		SET Name = ?, Description = ?, EmbedColour = ?, EditedTimestamp = ?
+		SET Name = ?, Description = ?, EmbedColour = ?, Rule = ?, EditedTimestamp = ?
 		WHERE ID = ?
 	`)
 	if err != nil {
@@ -979,7 +1146,7 @@ func (d *Database) BuildClassEdit(buildClassID, name, description, embedColour s
 	defer s.Close()
 	// Execute query
 	if _, err = s.Exec(
-		name, description, embedColour,
+		name, description, embedColour, rule,
 		time.Time(bc.EditedTimestamp).Format(timeLayout),
 		buildClassIDint,
 	); err != nil {
@@ -1490,18 +1657,18 @@ func (d *Database) Build(buildID string) (Build, bool, error) {
 		width                   int
 		height                  int
 		depth                   int
-		normalCloseDuration     int
-		normalOpenDuration      int
-		visibleCloseDuration    int
-		visibleOpenDuration     int
-		delayCloseDuration      int
-		delayOpenDuration       int
-		resetCloseDuration      int
-		resetOpenDuration       int
-		extensionDuration       int
-		retractionDuration      int
-		extensionDelayDuration  int
-		retractionDelayDuration int
+		normalCloseDuration     Ticks
+		normalOpenDuration      Ticks
+		visibleCloseDuration    Ticks
+		visibleOpenDuration     Ticks
+		delayCloseDuration      Ticks
+		delayOpenDuration       Ticks
+		resetCloseDuration      Ticks
+		resetOpenDuration       Ticks
+		extensionDuration       Ticks
+		retractionDuration      Ticks
+		extensionDelayDuration  Ticks
+		retractionDelayDuration Ticks
 		imageURL                string
 		youtubeURL              string
 		worldDownloadURL        string
@@ -1531,13 +1698,13 @@ func (d *Database) Build(buildID string) (Build, bool, error) {
 		return Build{}, false, errors.Wrap(err, "failed to extract data")
 	}
 	// Parse timestamps
-	if verifiedTimestamp, err = time.Parse(timeLayout, verifiedTimestampString); err != nil {
+	if verifiedTimestamp, err = parseTimestamp(verifiedTimestampString); err != nil {
 		return Build{}, false, errors.Wrap(err, "failed to parse verified timestamp")
 	}
-	if reportedTimestamp, err = time.Parse(timeLayout, reportedTimestampString); err != nil {
+	if reportedTimestamp, err = parseTimestamp(reportedTimestampString); err != nil {
 		return Build{}, false, errors.Wrap(err, "failed to parse reported timestamp")
 	}
-	if creationTimestamp, err = time.Parse(timeLayout, creationTimestampString); err != nil {
+	if creationTimestamp, err = parseTimestamp(creationTimestampString); err != nil {
 		return Build{}, false, errors.Wrap(err, "failed to parse creation timestamp")
 	}
 	if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
@@ -1622,18 +1789,18 @@ func (d *Database) Builds() ([]Build, error) {
 		width                   int
 		height                  int
 		depth                   int
-		normalCloseDuration     int
-		normalOpenDuration      int
-		visibleCloseDuration    int
-		visibleOpenDuration     int
-		delayCloseDuration      int
-		delayOpenDuration       int
-		resetCloseDuration      int
-		resetOpenDuration       int
-		extensionDuration       int
-		retractionDuration      int
-		extensionDelayDuration  int
-		retractionDelayDuration int
+		normalCloseDuration     Ticks
+		normalOpenDuration      Ticks
+		visibleCloseDuration    Ticks
+		visibleOpenDuration     Ticks
+		delayCloseDuration      Ticks
+		delayOpenDuration       Ticks
+		resetCloseDuration      Ticks
+		resetOpenDuration       Ticks
+		extensionDuration       Ticks
+		retractionDuration      Ticks
+		extensionDelayDuration  Ticks
+		retractionDelayDuration Ticks
 		imageURL                string
 		youtubeURL              string
 		worldDownloadURL        string
@@ -1665,13 +1832,13 @@ func (d *Database) Builds() ([]Build, error) {
 			return nil, errors.Wrap(err, "failed to extract data")
 		}
 		// Parse timestamps
-		if verifiedTimestamp, err = time.Parse(timeLayout, verifiedTimestampString); err != nil {
+		if verifiedTimestamp, err = parseTimestamp(verifiedTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse verified timestamp")
 		}
-		if reportedTimestamp, err = time.Parse(timeLayout, reportedTimestampString); err != nil {
+		if reportedTimestamp, err = parseTimestamp(reportedTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse reported timestamp")
 		}
-		if creationTimestamp, err = time.Parse(timeLayout, creationTimestampString); err != nil {
+		if creationTimestamp, err = parseTimestamp(creationTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse creation timestamp")
 		}
 		if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
@@ -1727,7 +1894,28 @@ func (d *Database) Builds() ([]Build, error) {
 }
 
 // BuildCreate creates a new build
+// An outbox entry for the build is created in the same transaction
+// A *ValidationError is returned if the build has invalid fields
 func (d *Database) BuildCreate(b Build) (Build, error) {
+	var result Build
+	err := d.Transaction(func(tx *Database) error {
+		if err := tx.validateBuild(b); err != nil {
+			return err
+		}
+		var err error
+		if result, err = tx.buildCreate(b); err != nil {
+			return err
+		}
+		return tx.outboxEntryAdd(OutboxBuild, result.ID, result.EditedTimestamp)
+	})
+	if err != nil {
+		return Build{}, err
+	}
+	return result, nil
+}
+
+// buildCreate creates a new build without creating an outbox entry
+func (d *Database) buildCreate(b Build) (Build, error) {
 	// Convert ids to ints
 	verifierIDint, err := strconv.Atoi(b.VerifierID)
 	if err != nil {
@@ -1808,7 +1996,32 @@ func (d *Database) BuildCreate(b Build) (Build, error) {
 }
 
 // BuildDelete removes build information from the database
+// An outbox entry for the build is created in the same transaction
+// The records the build held are kept on the entry so that
+// their messages can be updated once it's gone
 func (d *Database) BuildDelete(buildID string) (Build, bool, error) {
+	var (
+		result Build
+		ok     bool
+	)
+	err := d.Transaction(func(tx *Database) error {
+		recordIDs, err := tx.buildRecordIDs(buildID)
+		if err != nil {
+			return err
+		}
+		if result, ok, err = tx.buildDelete(buildID); err != nil || !ok {
+			return err
+		}
+		return tx.outboxEntryAdd(OutboxBuild, result.ID, Timestamp(time.Now()), recordIDs...)
+	})
+	if err != nil {
+		return Build{}, false, err
+	}
+	return result, ok, nil
+}
+
+// buildDelete removes a build without creating an outbox entry
+func (d *Database) buildDelete(buildID string) (Build, bool, error) {
 	// Convert buildID to int
 	buildIDint, err := strconv.Atoi(buildID)
 	if err != nil {
@@ -1840,7 +2053,45 @@ func (d *Database) BuildDelete(buildID string) (Build, bool, error) {
 }
 
 // BuildEdit edits the information for a build in the database
+// An outbox entry for the build is created in the same transaction
+// A *ValidationError is returned if the edit makes fields invalid
+// Problems the build already had don't stop it being edited
+// A *ConsistencyError is returned if the build's edition or build class
+// is changed to one the versions or records it's linked to aren't of
 func (d *Database) BuildEdit(buildID string, build Build) (Build, bool, error) {
+	var (
+		result Build
+		ok     bool
+	)
+	err := d.Transaction(func(tx *Database) error {
+		old, found, err := tx.Build(buildID)
+		if err != nil {
+			return errors.Wrap(err, "failed to get build")
+		} else if !found {
+			return nil
+		}
+		if err = tx.validateBuildEdit(old, build); err != nil {
+			return err
+		}
+		if build.EditionID != old.EditionID || build.BuildClassID != old.BuildClassID {
+			build.ID = old.ID
+			if err = tx.checkBuildLinks(build); err != nil {
+				return err
+			}
+		}
+		if result, ok, err = tx.buildEdit(buildID, build); err != nil || !ok {
+			return err
+		}
+		return tx.outboxEntryAdd(OutboxBuild, result.ID, result.EditedTimestamp)
+	})
+	if err != nil {
+		return Build{}, false, err
+	}
+	return result, ok, nil
+}
+
+// buildEdit edits a build without creating an outbox entry
+func (d *Database) buildEdit(buildID string, build Build) (Build, bool, error) {
 	// Convert ids to int
 	buildIDint, err := strconv.Atoi(buildID)
 	if err != nil {
@@ -1963,8 +2214,9 @@ func (d *Database) Version(versionID string) (Version, bool, error) {
 	}
 	// Query the database
 	rows, err := d.db.Query(`
-		SELECT EditionID, MajorVersion, MinorVersion, Patch, Name,
-			Description, VersionTimestamp, Timestamp, EditedTimestamp
+		SELECT EditionID, MajorVersion, MinorVersion, Patch, Revision,
+			Type, PreRelease, Snapshot, Name, Description, VersionTimestamp,
+			Timestamp, EditedTimestamp
 		FROM Versions
 		WHERE ID = ?
 	`, versionIDint)
@@ -1982,6 +2234,10 @@ func (d *Database) Version(versionID string) (Version, bool, error) {
 		majorVersion           int
 		minorVersion           int
 		patch                  int
+		revision               int
+		versionType            int
+		preRelease             int
+		snapshot               string
 		name                   string
 		description            string
 		versionTimestampString string
@@ -1992,14 +2248,14 @@ func (d *Database) Version(versionID string) (Version, bool, error) {
 		editedTimestamp        time.Time
 	)
 	if err = rows.Scan(
-		&editionIDint, &majorVersion, &minorVersion, &patch,
-		&name, &description, &versionTimestampString,
-		&timestampString, &editedTimestampString,
+		&editionIDint, &majorVersion, &minorVersion, &patch, &revision,
+		&versionType, &preRelease, &snapshot, &name, &description,
+		&versionTimestampString, &timestampString, &editedTimestampString,
 	); err != nil {
 		return Version{}, false, errors.Wrap(err, "failed to extract data")
 	}
 	// Parse timestamps
-	if versionTimestamp, err = time.Parse(timeLayout, versionTimestampString); err != nil {
+	if versionTimestamp, err = parseTimestamp(versionTimestampString); err != nil {
 		return Version{}, false, errors.Wrap(err, "failed to parse version timestamp")
 	}
 	if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
@@ -2014,6 +2270,10 @@ func (d *Database) Version(versionID string) (Version, bool, error) {
 		MajorVersion:     majorVersion,
 		MinorVersion:     minorVersion,
 		Patch:            patch,
+		Revision:         revision,
+		Type:             VersionType(versionType),
+		PreRelease:       preRelease,
+		Snapshot:         snapshot,
 		Name:             name,
 		Description:      description,
 		VersionTimestamp: Timestamp(versionTimestamp),
@@ -2026,7 +2286,9 @@ func (d *Database) Version(versionID string) (Version, bool, error) {
 func (d *Database) Versions() ([]Version, error) {
 	// Query the database
 	rows, err := d.db.Query(`
-		SELECT *
+		SELECT ID, EditionID, MajorVersion, MinorVersion, Patch, Revision,
+			Type, PreRelease, Snapshot, Name, Description, VersionTimestamp,
+			Timestamp, EditedTimestamp
 		FROM Versions
 	`)
 	if err != nil {
@@ -2041,6 +2303,10 @@ func (d *Database) Versions() ([]Version, error) {
 		majorVersion           int
 		minorVersion           int
 		patch                  int
+		revision               int
+		versionType            int
+		preRelease             int
+		snapshot               string
 		name                   string
 		description            string
 		versionTimestampString string
@@ -2055,13 +2321,14 @@ func (d *Database) Versions() ([]Version, error) {
 		// Extract data
 		if err = rows.Scan(
 			&idInt, &editionIDint, &majorVersion, &minorVersion, &patch,
-			&name, &description, &versionTimestampString,
-			&timestampString, &editedTimestampString,
+			&revision, &versionType, &preRelease, &snapshot, &name,
+			&description, &versionTimestampString, &timestampString,
+			&editedTimestampString,
 		); err != nil {
 			return nil, errors.Wrap(err, "failed to extract data")
 		}
 		// Parse timestamps
-		if versionTimestamp, err = time.Parse(timeLayout, versionTimestampString); err != nil {
+		if versionTimestamp, err = parseTimestamp(versionTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse version timestamp")
 		}
 		if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
@@ -2077,6 +2344,10 @@ func (d *Database) Versions() ([]Version, error) {
 			MajorVersion:     majorVersion,
 			MinorVersion:     minorVersion,
 			Patch:            patch,
+			Revision:         revision,
+			Type:             VersionType(versionType),
+			PreRelease:       preRelease,
+			Snapshot:         snapshot,
 			Name:             name,
 			Description:      description,
 			VersionTimestamp: Timestamp(versionTimestamp),
@@ -2088,7 +2359,24 @@ func (d *Database) Versions() ([]Version, error) {
 }
 
 // VersionCreate creates a new version in the database
+// The version hooks are run within the same transaction
 func (d *Database) VersionCreate(version Version) (Version, error) {
+	var result Version
+	err := d.Transaction(func(tx *Database) error {
+		var err error
+		if result, err = tx.versionCreate(version); err != nil {
+			return err
+		}
+		return tx.runVersionHooks(result)
+	})
+	if err != nil {
+		return Version{}, err
+	}
+	return result, nil
+}
+
+// versionCreate creates a new version without running the version hooks
+func (d *Database) versionCreate(version Version) (Version, error) {
 	// Convert ids to ints
 	editionIDint, err := strconv.Atoi(version.EditionID)
 	if err != nil {
@@ -2100,9 +2388,10 @@ func (d *Database) VersionCreate(version Version) (Version, error) {
 	// Prepare query
 	s, err := d.db.Prepare(`
 		INSERT INTO Versions (EditionID, MajorVersion, MinorVersion, Patch,
-			Name, Description, VersionTimestamp, Timestamp, EditedTimestamp
+			Revision, Type, PreRelease, Snapshot, Name, Description,
+			VersionTimestamp, Timestamp, EditedTimestamp
 		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`)
 	if err != nil {
 		return Version{}, errors.Wrap(err, "failed to prepare query")
@@ -2111,6 +2400,7 @@ func (d *Database) VersionCreate(version Version) (Version, error) {
 	// Execute query
 	res, err := s.Exec(
 		editionIDint, version.MajorVersion, version.MinorVersion, version.Patch,
+		version.Revision, int(version.Type), version.PreRelease, version.Snapshot,
 		version.Name, version.Description,
 		time.Time(version.VersionTimestamp).Format(timeLayout),
 		time.Time(version.Timestamp).Format(timeLayout),
@@ -2187,6 +2477,10 @@ func (d *Database) VersionEdit(versionID string, version Version) (Version, bool
 	v.MajorVersion = version.MajorVersion
 	v.MinorVersion = version.MinorVersion
 	v.Patch = version.Patch
+	v.Revision = version.Revision
+	v.Type = version.Type
+	v.PreRelease = version.PreRelease
+	v.Snapshot = version.Snapshot
 	v.Name = version.Name
 	v.Description = version.Description
 	v.VersionTimestamp = version.VersionTimestamp
@@ -2194,7 +2488,8 @@ func (d *Database) VersionEdit(versionID string, version Version) (Version, bool
 	// Prepare query
 	s, err := d.db.Prepare(`
 		UPDATE Versions
-		SET EditionID = ?, MajorVersion = ?, MinorVersion = ?, Patch = ?, Name = ?,
+		SET EditionID = ?, MajorVersion = ?, MinorVersion = ?, Patch = ?,
+			Revision = ?, Type = ?, PreRelease = ?, Snapshot = ?, Name = ?,
 			Description = ?, VersionTimestamp = ?, EditedTimestamp = ?
 		WHERE ID = ?
 	`)
@@ -2205,6 +2500,7 @@ func (d *Database) VersionEdit(versionID string, version Version) (Version, bool
 	// Execute query
 	if _, err = s.Exec(
 		editionIDint, v.MajorVersion, v.MinorVersion, v.Patch,
+		v.Revision, int(v.Type), v.PreRelease, v.Snapshot,
 		v.Name, v.Description,
 		time.Time(v.VersionTimestamp).Format(timeLayout),
 		time.Time(v.EditedTimestamp).Format(timeLayout),
@@ -2224,7 +2520,7 @@ func (d *Database) Record(recordID string) (Record, bool, error) {
 	}
 	// Query the database
 	rows, err := d.db.Query(`
-		SELECT Verified, VerifierID, VerifiedTimestap, UpdateRequest, UpdateRequestRecordID,
+		SELECT Verified, VerifierID, VerifiedTimestamp, UpdateRequest, UpdateRequestRecordID,
 			EditionID, BuildClassID, RecordTypeID, Name, Description, SubmitterID,
 			Timestamp, EditedTimestamp
 		FROM Records
@@ -2265,7 +2561,7 @@ func (d *Database) Record(recordID string) (Record, bool, error) {
 		return Record{}, false, errors.Wrap(err, "failed to extract data")
 	}
 	// Parse timestamps
-	if verifiedTimestamp, err = time.Parse(timeLayout, verifiedTimestampString); err != nil {
+	if verifiedTimestamp, err = parseTimestamp(verifiedTimestampString); err != nil {
 		return Record{}, false, errors.Wrap(err, "failed to parse verified timestamp")
 	}
 	if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
@@ -2336,7 +2632,7 @@ func (d *Database) Records() ([]Record, error) {
 			return nil, errors.Wrap(err, "failed to extract data")
 		}
 		// Parse timestamps
-		if verifiedTimestamp, err = time.Parse(timeLayout, verifiedTimestampString); err != nil {
+		if verifiedTimestamp, err = parseTimestamp(verifiedTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse verified timestamp")
 		}
 		if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
@@ -2367,7 +2663,24 @@ func (d *Database) Records() ([]Record, error) {
 }
 
 // RecordCreate creates a new record
+// An outbox entry for the record is created in the same transaction
 func (d *Database) RecordCreate(record Record) (Record, error) {
+	var result Record
+	err := d.Transaction(func(tx *Database) error {
+		var err error
+		if result, err = tx.recordCreate(record); err != nil {
+			return err
+		}
+		return tx.outboxEntryAdd(OutboxRecord, result.ID, result.EditedTimestamp)
+	})
+	if err != nil {
+		return Record{}, err
+	}
+	return result, nil
+}
+
+// recordCreate creates a new record without creating an outbox entry
+func (d *Database) recordCreate(record Record) (Record, error) {
 	// Convert ids to ints
 	verifierIDint, err := strconv.Atoi(record.VerifierID)
 	if err != nil {
@@ -2434,7 +2747,27 @@ func (d *Database) RecordCreate(record Record) (Record, error) {
 }
 
 // RecordDelete removes a specified record from the database
+// An outbox entry for the record is created in the same transaction
 func (d *Database) RecordDelete(recordID string) (Record, bool, error) {
+	var (
+		result Record
+		ok     bool
+	)
+	err := d.Transaction(func(tx *Database) error {
+		var err error
+		if result, ok, err = tx.recordDelete(recordID); err != nil || !ok {
+			return err
+		}
+		return tx.outboxEntryAdd(OutboxRecord, result.ID, Timestamp(time.Now()))
+	})
+	if err != nil {
+		return Record{}, false, err
+	}
+	return result, ok, nil
+}
+
+// recordDelete removes a record without creating an outbox entry
+func (d *Database) recordDelete(recordID string) (Record, bool, error) {
 	// Convert recordID to int
 	recordIDint, err := strconv.Atoi(recordID)
 	if err != nil {
@@ -2466,7 +2799,40 @@ func (d *Database) RecordDelete(recordID string) (Record, bool, error) {
 }
 
 // RecordEdit edits the information for a record in the database
+// An outbox entry for the record is created in the same transaction
+// A *ConsistencyError is returned if the record's edition or build class
+// is changed to one the builds which hold or held it aren't of
 func (d *Database) RecordEdit(recordID string, record Record) (Record, bool, error) {
+	var (
+		result Record
+		ok     bool
+	)
+	err := d.Transaction(func(tx *Database) error {
+		old, found, err := tx.Record(recordID)
+		if err != nil {
+			return errors.Wrap(err, "failed to get record")
+		} else if !found {
+			return nil
+		}
+		if record.EditionID != old.EditionID || record.BuildClassID != old.BuildClassID {
+			record.ID = old.ID
+			if err = tx.checkRecordBuilds(record); err != nil {
+				return err
+			}
+		}
+		if result, ok, err = tx.recordEdit(recordID, record); err != nil || !ok {
+			return err
+		}
+		return tx.outboxEntryAdd(OutboxRecord, result.ID, result.EditedTimestamp)
+	})
+	if err != nil {
+		return Record{}, false, err
+	}
+	return result, ok, nil
+}
+
+// recordEdit edits a record without creating an outbox entry
+func (d *Database) recordEdit(recordID string, record Record) (Record, bool, error) {
 	// Convert ids to ints
 	recordIDint, err := strconv.Atoi(recordID)
 	if err != nil {
@@ -2600,11 +2966,17 @@ func (d *Database) GuildBuildMessage(guildID, buildID string) (GuildBuildMessage
 // GuildBuildMessages get the guild build message information for a
 // specified guild
 func (d *Database) GuildBuildMessages(guildID string) ([]GuildBuildMessage, error) {
+	// Convert guildID to int
+	guildIDint, err := strconv.Atoi(guildID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert guild id to integer")
+	}
 	// Query the database
 	rows, err := d.db.Query(`
-		SELECT *
+		SELECT BuildID, ChannelID, MessageID, Timestamp, EditedTimestamp
 		FROM GuildBuildMessages
-	`)
+		WHERE GuildID = ?
+	`, guildIDint)
 	if err != nil {
 		return nil, errors.Wrap(err, "database query failed")
 	}
@@ -2612,7 +2984,6 @@ func (d *Database) GuildBuildMessages(guildID string) ([]GuildBuildMessage, erro
 	// Create space to store results
 	results := []GuildBuildMessage{}
 	var (
-		guildIDint            int
 		buildIDint            int
 		channelIDint          int
 		messageIDint          int
@@ -2625,7 +2996,7 @@ func (d *Database) GuildBuildMessages(guildID string) ([]GuildBuildMessage, erro
 	for rows.Next() {
 		// Extract data
 		if err = rows.Scan(
-			&guildIDint, &buildIDint, &channelIDint, &messageIDint,
+			&buildIDint, &channelIDint, &messageIDint,
 			&timestampString, &editedTimestampString,
 		); err != nil {
 			return nil, errors.Wrap(err, "failed to extract data")
@@ -2639,7 +3010,7 @@ func (d *Database) GuildBuildMessages(guildID string) ([]GuildBuildMessage, erro
 		}
 		// Add to results
 		results = append(results, GuildBuildMessage{
-			GuildID:         strconv.Itoa(guildIDint),
+			GuildID:         guildID,
 			BuildID:         strconv.Itoa(buildIDint),
 			ChannelID:       strconv.Itoa(channelIDint),
 			MessageID:       strconv.Itoa(messageIDint),
@@ -2782,6 +3153,7 @@ func (d *Database) GuildBuildMessageEdit(guildID, buildID, channelID, messageID
 	if err != nil {
 		return GuildBuildMessage{}, false, errors.Wrap(err, "failed to prepare query")
 	}
+	defer s.Close()
 	// Execute query
 	if _, err = s.Exec(
 		channelIDint, messageIDint,
@@ -2850,6 +3222,7 @@ func (d *Database) BuildVersion(buildID, versionID string) (BuildVersion, bool,
 
 // BuildVersionCreate creates information in the database for a specified
 // build and version
+// A *ConsistencyError is returned if they're of different editions
 func (d *Database) BuildVersionCreate(buildID, versionID, statusID, notes string) (BuildVersion, bool, error) {
 	// Convert buildID, versionID and statusID to ints
 	buildIDint, err := strconv.Atoi(buildID)
@@ -2864,6 +3237,10 @@ func (d *Database) BuildVersionCreate(buildID, versionID, statusID, notes string
 	if err != nil {
 		return BuildVersion{}, false, errors.Wrap(err, "failed to convert status id to integer")
 	}
+	// Check the build and version are of the same edition
+	if err = d.checkBuildVersion(buildID, versionID); err != nil {
+		return BuildVersion{}, false, err
+	}
 	// Check if the build version already exists
 	if _, ok, err := d.BuildVersion(buildID, versionID); err != nil {
 		return BuildVersion{}, false, errors.Wrap(err, "failed to determine if build version exists")
@@ -2940,6 +3317,7 @@ func (d *Database) BuildVersionDelete(buildID, versionID string) (BuildVersion,
 
 // BuildVersionEdit edits build version information from the database
 // for a specified build and version
+// A *ConsistencyError is returned if they're of different editions
 func (d *Database) BuildVersionEdit(buildID, versionID, statusID, notes string) (BuildVersion, bool, error) {
 	// Convert buildID, versionID and statusID to ints
 	buildIDint, err := strconv.Atoi(buildID)
@@ -2962,6 +3340,10 @@ func (d *Database) BuildVersionEdit(buildID, versionID, statusID, notes string)
 		// Row doesn't exist
 		return BuildVersion{}, false, nil
 	}
+	// Check the build and version are of the same edition
+	if err = d.checkBuildVersion(buildID, versionID); err != nil {
+		return BuildVersion{}, false, err
+	}
 	// Update values
 	bv.StatusID = statusID
 	bv.Notes = notes
@@ -3243,10 +3625,10 @@ func (d *Database) BuildRecord(buildRecordID string) (BuildRecord, bool, error)
 		return BuildRecord{}, false, errors.Wrap(err, "failed to extract data")
 	}
 	// Parse timestamps
-	if verifiedTimestamp, err = time.Parse(timeLayout, verifiedTimestampString); err != nil {
+	if verifiedTimestamp, err = parseTimestamp(verifiedTimestampString); err != nil {
 		return BuildRecord{}, false, errors.Wrap(err, "failed to parse verified timestamp")
 	}
-	if reportedTimestamp, err = time.Parse(timeLayout, reportedTimestampString); err != nil {
+	if reportedTimestamp, err = parseTimestamp(reportedTimestampString); err != nil {
 		return BuildRecord{}, false, errors.Wrap(err, "failed to parse reported timestamp")
 	}
 	if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
@@ -3274,7 +3656,30 @@ func (d *Database) BuildRecord(buildRecordID string) (BuildRecord, bool, error)
 }
 
 // BuildRecordCreate creates new build record information
+// An outbox entry for the build record is created in the same transaction
+// A *ConsistencyError is returned if the build and record are of
+// different editions or build classes
 func (d *Database) BuildRecordCreate(br BuildRecord) (BuildRecord, error) {
+	var result BuildRecord
+	err := d.Transaction(func(tx *Database) error {
+		// Check the build and record are of the same edition and build class
+		if err := tx.checkBuildRecord(br.BuildID, br.RecordID); err != nil {
+			return err
+		}
+		var err error
+		if result, err = tx.buildRecordCreate(br); err != nil {
+			return err
+		}
+		return tx.outboxEntryAdd(OutboxBuildRecord, result.ID, result.EditedTimestamp)
+	})
+	if err != nil {
+		return BuildRecord{}, err
+	}
+	return result, nil
+}
+
+// buildRecordCreate creates a new build record without creating an outbox entry
+func (d *Database) buildRecordCreate(br BuildRecord) (BuildRecord, error) {
 	// Convert ids to ints
 	buildIDint, err := strconv.Atoi(br.BuildID)
 	if err != nil {
@@ -3340,7 +3745,29 @@ func (d *Database) BuildRecordCreate(br BuildRecord) (BuildRecord, error) {
 }
 
 // BuildRecordDelete removes build record information from the database
+// An outbox entry for the build record is created in the same transaction
+// The record is kept on the entry so that its messages
+// can be updated once the build record is gone
 func (d *Database) BuildRecordDelete(buildRecordID string) (BuildRecord, bool, error) {
+	var (
+		result BuildRecord
+		ok     bool
+	)
+	err := d.Transaction(func(tx *Database) error {
+		var err error
+		if result, ok, err = tx.buildRecordDelete(buildRecordID); err != nil || !ok {
+			return err
+		}
+		return tx.outboxEntryAdd(OutboxBuildRecord, result.ID, Timestamp(time.Now()), result.RecordID)
+	})
+	if err != nil {
+		return BuildRecord{}, false, err
+	}
+	return result, ok, nil
+}
+
+// buildRecordDelete removes a build record without creating an outbox entry
+func (d *Database) buildRecordDelete(buildRecordID string) (BuildRecord, bool, error) {
 	// Convert build record id to int
 	buildRecordIDint, err := strconv.Atoi(buildRecordID)
 	if err != nil {
@@ -3372,7 +3799,33 @@ func (d *Database) BuildRecordDelete(buildRecordID string) (BuildRecord, bool, e
 }
 
 // BuildRecordEdit edits build record information within the database
+// An outbox entry for the build record is created in the same transaction
+// A *ConsistencyError is returned if the build and record are of
+// different editions or build classes
 func (d *Database) BuildRecordEdit(buildRecordID string, br BuildRecord) (BuildRecord, bool, error) {
+	var (
+		result BuildRecord
+		ok     bool
+	)
+	err := d.Transaction(func(tx *Database) error {
+		// Check the build and record are of the same edition and build class
+		if err := tx.checkBuildRecord(br.BuildID, br.RecordID); err != nil {
+			return err
+		}
+		var err error
+		if result, ok, err = tx.buildRecordEdit(buildRecordID, br); err != nil || !ok {
+			return err
+		}
+		return tx.outboxEntryAdd(OutboxBuildRecord, result.ID, result.EditedTimestamp)
+	})
+	if err != nil {
+		return BuildRecord{}, false, err
+	}
+	return result, ok, nil
+}
+
+// buildRecordEdit edits a build record without creating an outbox entry
+func (d *Database) buildRecordEdit(buildRecordID string, br BuildRecord) (BuildRecord, bool, error) {
 	// Convert ids to ints
 	buildRecordIDint, err := strconv.Atoi(buildRecordID)
 	if err != nil {
@@ -3416,7 +3869,7 @@ func (d *Database) BuildRecordEdit(buildRecordID string, br BuildRecord) (BuildR
 	s, err := d.db.Prepare(`
 		UPDATE BuildRecords
 		SET BuildID = ?, RecordID = ?, Verified = ?, VerifierID = ?, VerifiedTimestamp = ?,
-			Reported = ?, ReporterID = ? ReportedTimestamp = ?, JointBuildRecord = ?,
+			Reported = ?, ReporterID = ?, ReportedTimestamp = ?, JointBuildRecord = ?,
 			JointBuildRecordID = ?, SubmitterID = ?, EditedTimestamp = ?
 		WHERE ID = ?
 	`)
@@ -3678,7 +4131,7 @@ func (d *Database) GuildRecordMessageEdit(guildID, recordID, channelID, messageI
 	s, err := d.db.Prepare(`
 		UPDATE GuildRecordMessages
 		SET ChannelID = ?, MessageID = ?, EditedTimestamp = ?
-		WHERE GuildID = ?, RecordID = ?
+		WHERE GuildID = ? AND RecordID = ?
 	`)
 	if err != nil {
 		return GuildRecordMessage{}, false, errors.Wrap(err, "failed to prepare query")
@@ -3889,12 +4342,731 @@ func (d *Database) GuildTicketChannelDelete(guildID, channelID string) (GuildTic
 	return gtc, true, nil
 }
 
-// Private functions
-
-// nextStrikeID gets the next strike id for a specified user
-func (d *Database) nextStrikeID(userID string) (int, error) {
-	// Convert userID to int
-	userIDint, err := strconv.Atoi(userID)
+// GuildRole gets a capability given to a role within a guild
+// false is returned if the role hasn't been given the capability
+func (d *Database) GuildRole(guildID, roleID string, capability Capability) (GuildRole, bool, error) {
+	// Convert guildID and roleID to ints
+	guildIDint, err := strconv.Atoi(guildID)
+	if err != nil {
+		return GuildRole{}, false, errors.Wrap(err, "failed to convert guild id to integer")
+	}
+	roleIDint, err := strconv.Atoi(roleID)
+	if err != nil {
+		return GuildRole{}, false, errors.Wrap(err, "failed to convert role id to integer")
+	}
+	// Query database
+	rows, err := d.db.Query(`
+		SELECT Timestamp
+		FROM GuildRoles
+		WHERE GuildID = ? AND RoleID = ? AND Capability = ?
+	`, guildIDint, roleIDint, capability)
+	if err != nil {
+		return GuildRole{}, false, errors.Wrap(err, "database query failed")
+	}
+	defer rows.Close()
+	// Check if guild role exists
+	if !rows.Next() {
+		return GuildRole{}, false, nil
+	}
+	// Extract data
+	var (
+		timestampString string
+		timestamp       time.Time
+	)
+	if err = rows.Scan(&timestampString); err != nil {
+		return GuildRole{}, false, errors.Wrap(err, "database query failed")
+	}
+	// Parse timestamp
+	if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
+		return GuildRole{}, false, errors.Wrap(err, "failed to parse timestamp")
+	}
+	return GuildRole{
+		GuildID:    guildID,
+		RoleID:     roleID,
+		Capability: capability,
+		Timestamp:  Timestamp(timestamp),
+	}, true, nil
+}
+
+// GuildRoles gets all capabilities given to roles within a guild
+func (d *Database) GuildRoles(guildID string) ([]GuildRole, error) {
+	// Convert guildID to int
+	guildIDint, err := strconv.Atoi(guildID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert guild id to integer")
+	}
+	// Query the database
+	rows, err := d.db.Query(`
+		SELECT RoleID, Capability, Timestamp
+		FROM GuildRoles
+		WHERE GuildID = ?
+		ORDER BY RoleID, Capability
+	`, guildIDint)
+	if err != nil {
+		return nil, errors.Wrap(err, "database query failed")
+	}
+	defer rows.Close()
+	// Create space to store results
+	results := []GuildRole{}
+	var (
+		roleIDint       int
+		capability      int
+		timestampString string
+		timestamp       time.Time
+	)
+	// For each row
+	for rows.Next() {
+		// Extract data
+		if err = rows.Scan(&roleIDint, &capability, &timestampString); err != nil {
+			return nil, errors.Wrap(err, "failed to extract data")
+		}
+		// Parse timestamp
+		if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
+			return nil, errors.Wrap(err, "failed to parse timestamp")
+		}
+		// Add to results
+		results = append(results, GuildRole{
+			GuildID:    guildID,
+			RoleID:     strconv.Itoa(roleIDint),
+			Capability: Capability(capability),
+			Timestamp:  Timestamp(timestamp),
+		})
+	}
+	return results, nil
+}
+
+// GuildRoleCreate gives a capability to a role within a guild
+// false is returned if the role already has the capability
+func (d *Database) GuildRoleCreate(guildID, roleID string, capability Capability) (GuildRole, bool, error) {
+	// Convert ids to ints
+	guildIDint, err := strconv.Atoi(guildID)
+	if err != nil {
+		return GuildRole{}, false, errors.Wrap(err, "failed to convert guild id to integer")
+	}
+	roleIDint, err := strconv.Atoi(roleID)
+	if err != nil {
+		return GuildRole{}, false, errors.Wrap(err, "failed to convert role id to integer")
+	}
+	if !capability.Valid() {
+		return GuildRole{}, false, errors.Errorf("unknown capability %d", capability)
+	}
+	// Check if guild role already exists
+	if _, ok, err := d.GuildRole(guildID, roleID, capability); err != nil {
+		return GuildRole{}, false, errors.Wrap(err, "failed to determine if guild role exists")
+	} else if ok {
+		// Row already exists
+		return GuildRole{}, false, nil
+	}
+	// Create guild role
+	gr := GuildRole{
+		GuildID:    guildID,
+		RoleID:     roleID,
+		Capability: capability,
+		Timestamp:  Timestamp(time.Now()),
+	}
+	// Prepare query
+	s, err := d.db.Prepare(`
+		INSERT INTO GuildRoles
+		VALUES (?, ?, ?, ?)
+	`)
+	if err != nil {
+		return GuildRole{}, false, errors.Wrap(err, "failed to prepare query")
+	}
+	defer s.Close()
+	// Execute query
+	if _, err = s.Exec(
+		guildIDint, roleIDint, capability,
+		time.Time(gr.Timestamp).Format(timeLayout),
+	); err != nil {
+		return GuildRole{}, false, errors.Wrap(err, "database query failed")
+	}
+	return gr, true, nil
+}
+
+// GuildRoleDelete takes a capability away from a role within a guild
+// false is returned if the role didn't have the capability
+func (d *Database) GuildRoleDelete(guildID, roleID string, capability Capability) (GuildRole, bool, error) {
+	// Convert ids to ints
+	guildIDint, err := strconv.Atoi(guildID)
+	if err != nil {
+		return GuildRole{}, false, errors.Wrap(err, "failed to convert guild id to integer")
+	}
+	roleIDint, err := strconv.Atoi(roleID)
+	if err != nil {
+		return GuildRole{}, false, errors.Wrap(err, "failed to convert role id to integer")
+	}
+	// Get the guild role to return after deletion
+	// and to check if it exists
+	gr, ok, err := d.GuildRole(guildID, roleID, capability)
+	if err != nil {
+		return GuildRole{}, false, errors.Wrap(err, "failed to determine if guild role exists")
+	} else if !ok {
+		// Row doesn't exist
+		return GuildRole{}, false, nil
+	}
+	// Prepare query
+	s, err := d.db.Prepare(`
+		DELETE FROM GuildRoles
+		WHERE GuildID = ? AND RoleID = ? AND Capability = ?
+	`)
+	if err != nil {
+		return GuildRole{}, false, errors.Wrap(err, "failed to prepare query")
+	}
+	defer s.Close()
+	// Execute query
+	if _, err = s.Exec(guildIDint, roleIDint, capability); err != nil {
+		return GuildRole{}, false, errors.Wrap(err, "database query failed")
+	}
+	return gr, true, nil
+}
+
+// TicketWizard gets the saved wizard progress for a specified ticket within a guild
+func (d *Database) TicketWizard(guildID, channelID string) (TicketWizard, bool, error) {
+	// Convert guildID and channelID to ints
+	guildIDint, err := strconv.Atoi(guildID)
+	if err != nil {
+		return TicketWizard{}, false, errors.Wrap(err, "failed to convert guild id to integer")
+	}
+	channelIDint, err := strconv.Atoi(channelID)
+	if err != nil {
+		return TicketWizard{}, false, errors.Wrap(err, "failed to convert channel id to integer")
+	}
+	// Query the database
+	rows, err := d.db.Query(`
+		SELECT TicketType, Step, History, Draft, Timestamp, EditedTimestamp
+		FROM TicketWizards
+		WHERE GuildID = ? AND ChannelID = ?
+	`, guildIDint, channelIDint)
+	if err != nil {
+		return TicketWizard{}, false, errors.Wrap(err, "database query failed")
+	}
+	defer rows.Close()
+	// Check if ticket wizard exists
+	if !rows.Next() {
+		return TicketWizard{}, false, nil
+	}
+	// Extract data
+	var (
+		ticketType            int
+		step                  int
+		history               string
+		draft                 string
+		timestampString       string
+		editedTimestampString string
+		timestamp             time.Time
+		editedTimestamp       time.Time
+	)
+	if err = rows.Scan(
+		&ticketType, &step, &history, &draft,
+		&timestampString, &editedTimestampString,
+	); err != nil {
+		return TicketWizard{}, false, errors.Wrap(err, "failed to extract data")
+	}
+	// Parse timestamps
+	if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
+		return TicketWizard{}, false, errors.Wrap(err, "failed to parse timestamp")
+	}
+	if editedTimestamp, err = time.Parse(timeLayout, editedTimestampString); err != nil {
+		return TicketWizard{}, false, errors.Wrap(err, "failed to parse edited timestamp")
+	}
+	return TicketWizard{
+		GuildID:         guildID,
+		ChannelID:       channelID,
+		TicketType:      TicketType(ticketType),
+		Step:            step,
+		History:         history,
+		Draft:           draft,
+		Timestamp:       Timestamp(timestamp),
+		EditedTimestamp: Timestamp(editedTimestamp),
+	}, true, nil
+}
+
+// TicketWizardCreate saves the progress of a newly started wizard
+// for a ticket within a guild
+func (d *Database) TicketWizardCreate(guildID, channelID string, ticketType TicketType, history, draft string) (TicketWizard, bool, error) {
+	// Convert guildID and channelID to ints
+	guildIDint, err := strconv.Atoi(guildID)
+	if err != nil {
+		return TicketWizard{}, false, errors.Wrap(err, "failed to convert guild id to integer")
+	}
+	channelIDint, err := strconv.Atoi(channelID)
+	if err != nil {
+		return TicketWizard{}, false, errors.Wrap(err, "failed to convert channel id to integer")
+	}
+	// Check if ticket wizard already exists
+	if _, ok, err := d.TicketWizard(guildID, channelID); err != nil {
+		return TicketWizard{}, false, errors.Wrap(err, "failed to determine if ticket wizard exists")
+	} else if ok {
+		// Row already exists
+		return TicketWizard{}, false, nil
+	}
+	// Create ticket wizard
+	tw := TicketWizard{
+		GuildID:         guildID,
+		ChannelID:       channelID,
+		TicketType:      ticketType,
+		Step:            0,
+		History:         history,
+		Draft:           draft,
+		Timestamp:       Timestamp(time.Now()),
+		EditedTimestamp: Timestamp(time.Now()),
+	}
+	// Prepare query
+	s, err := d.db.Prepare(`
+		INSERT INTO TicketWizards
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return TicketWizard{}, false, errors.Wrap(err, "failed to prepare query")
+	}
+	defer s.Close()
+	// Execute query
+	if _, err = s.Exec(
+		guildIDint, channelIDint, int(ticketType), tw.Step, history, draft,
+		time.Time(tw.Timestamp).Format(timeLayout),
+		time.Time(tw.EditedTimestamp).Format(timeLayout),
+	); err != nil {
+		return TicketWizard{}, false, errors.Wrap(err, "database query failed")
+	}
+	return tw, true, nil
+}
+
+// TicketWizardDelete removes the saved wizard progress for a ticket within a guild
+func (d *Database) TicketWizardDelete(guildID, channelID string) (TicketWizard, bool, error) {
+	// Convert guildID and channelID to ints
+	guildIDint, err := strconv.Atoi(guildID)
+	if err != nil {
+		return TicketWizard{}, false, errors.Wrap(err, "failed to convert guild id to integer")
+	}
+	channelIDint, err := strconv.Atoi(channelID)
+	if err != nil {
+		return TicketWizard{}, false, errors.Wrap(err, "failed to convert channel id to integer")
+	}
+	// Get the ticket wizard to return after deletion
+	// and to check if it exists
+	tw, ok, err := d.TicketWizard(guildID, channelID)
+	if err != nil {
+		return TicketWizard{}, false, errors.Wrap(err, "failed to determine if ticket wizard exists")
+	} else if !ok {
+		// Row doesn't exist
+		return TicketWizard{}, false, nil
+	}
+	// Prepare query
+	s, err := d.db.Prepare(`
+		DELETE FROM TicketWizards
+		WHERE GuildID = ? AND ChannelID = ?
+	`)
+	if err != nil {
+		return TicketWizard{}, false, errors.Wrap(err, "failed to prepare query")
+	}
+	defer s.Close()
+	// Execute query
+	if _, err = s.Exec(guildIDint, channelIDint); err != nil {
+		return TicketWizard{}, false, errors.Wrap(err, "database query failed")
+	}
+	return tw, true, nil
+}
+
+// TicketWizardEdit saves the progress of a wizard for a ticket within a guild
+func (d *Database) TicketWizardEdit(guildID, channelID string, step int, history, draft string) (TicketWizard, bool, error) {
+	// Convert guildID and channelID to ints
+	guildIDint, err := strconv.Atoi(guildID)
+	if err != nil {
+		return TicketWizard{}, false, errors.Wrap(err, "failed to convert guild id to integer")
+	}
+	channelIDint, err := strconv.Atoi(channelID)
+	if err != nil {
+		return TicketWizard{}, false, errors.Wrap(err, "failed to convert channel id to integer")
+	}
+	// Get the ticket wizard that is to be updated
+	tw, ok, err := d.TicketWizard(guildID, channelID)
+	if err != nil {
+		return TicketWizard{}, false, errors.Wrap(err, "failed to determine if ticket wizard exists")
+	} else if !ok {
+		// Row doesn't exist
+		return TicketWizard{}, false, nil
+	}
+	// Update values
+	tw.Step = step
+	tw.History = history
+	tw.Draft = draft
+	tw.EditedTimestamp = Timestamp(time.Now())
+	// Prepare query
+	s, err := d.db.Prepare(`
+		UPDATE TicketWizards
+		SET Step = ?, History = ?, Draft = ?, EditedTimestamp = ?
+		WHERE GuildID = ? AND ChannelID = ?
+	`)
+	if err != nil {
+		return TicketWizard{}, false, errors.Wrap(err, "failed to prepare query")
+	}
+	defer s.Close()
+	// Execute query
+	if _, err = s.Exec(
+		step, history, draft,
+		time.Time(tw.EditedTimestamp).Format(timeLayout),
+		guildIDint, channelIDint,
+	); err != nil {
+		return TicketWizard{}, false, errors.Wrap(err, "database query failed")
+	}
+	return tw, true, nil
+}
+
+// GuildMessageHash gets the content hash of a specified message
+func (d *Database) GuildMessageHash(messageID string) (GuildMessageHash, bool, error) {
+	// Convert messageID to int
+	messageIDint, err := strconv.Atoi(messageID)
+	if err != nil {
+		return GuildMessageHash{}, false, errors.Wrap(err, "failed to convert message id to integer")
+	}
+	// Query the database
+	rows, err := d.db.Query(`
+		SELECT GuildID, Hash, Timestamp, EditedTimestamp
+		FROM GuildMessageHashes
+		WHERE MessageID = ?
+	`, messageIDint)
+	if err != nil {
+		return GuildMessageHash{}, false, errors.Wrap(err, "database query failed")
+	}
+	defer rows.Close()
+	// Check if guild message hash exists
+	if !rows.Next() {
+		return GuildMessageHash{}, false, nil
+	}
+	// Extract data
+	var (
+		guildIDint            int
+		hash                  string
+		timestampString       string
+		editedTimestampString string
+		timestamp             time.Time
+		editedTimestamp       time.Time
+	)
+	if err = rows.Scan(&guildIDint, &hash, &timestampString, &editedTimestampString); err != nil {
+		return GuildMessageHash{}, false, errors.Wrap(err, "failed to extract data")
+	}
+	// Parse timestamps
+	if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
+		return GuildMessageHash{}, false, errors.Wrap(err, "failed to parse timestamp")
+	}
+	if editedTimestamp, err = time.Parse(timeLayout, editedTimestampString); err != nil {
+		return GuildMessageHash{}, false, errors.Wrap(err, "failed to parse edited timestamp")
+	}
+	return GuildMessageHash{
+		MessageID:       messageID,
+		GuildID:         strconv.Itoa(guildIDint),
+		Hash:            hash,
+		Timestamp:       Timestamp(timestamp),
+		EditedTimestamp: Timestamp(editedTimestamp),
+	}, true, nil
+}
+
+// GuildMessageHashCreate creates a content hash for a specified message
+func (d *Database) GuildMessageHashCreate(messageID, guildID, hash string) (GuildMessageHash, bool, error) {
+	// Convert ids to ints
+	messageIDint, err := strconv.Atoi(messageID)
+	if err != nil {
+		return GuildMessageHash{}, false, errors.Wrap(err, "failed to convert message id to integer")
+	}
+	guildIDint, err := strconv.Atoi(guildID)
+	if err != nil {
+		return GuildMessageHash{}, false, errors.Wrap(err, "failed to convert guild id to integer")
+	}
+	// Check if guild message hash already exists
+	if _, ok, err := d.GuildMessageHash(messageID); err != nil {
+		return GuildMessageHash{}, false, errors.Wrap(err, "failed to determine if guild message hash exists")
+	} else if ok {
+		// Row already exists
+		return GuildMessageHash{}, false, nil
+	}
+	// Create guild message hash
+	gmh := GuildMessageHash{
+		MessageID:       messageID,
+		GuildID:         guildID,
+		Hash:            hash,
+		Timestamp:       Timestamp(time.Now()),
+		EditedTimestamp: Timestamp(time.Now()),
+	}
+	// Prepare query
+	s, err := d.db.Prepare(`
+		INSERT INTO GuildMessageHashes
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return GuildMessageHash{}, false, errors.Wrap(err, "failed to prepare query")
+	}
+	defer s.Close()
+	// Execute query
+	if _, err = s.Exec(
+		messageIDint, guildIDint, hash,
+		time.Time(gmh.Timestamp).Format(timeLayout),
+		time.Time(gmh.EditedTimestamp).Format(timeLayout),
+	); err != nil {
+		return GuildMessageHash{}, false, errors.Wrap(err, "database query failed")
+	}
+	return gmh, true, nil
+}
+
+// GuildMessageHashDelete removes the content hash of a specified message
+func (d *Database) GuildMessageHashDelete(messageID string) (GuildMessageHash, bool, error) {
+	// Convert messageID to int
+	messageIDint, err := strconv.Atoi(messageID)
+	if err != nil {
+		return GuildMessageHash{}, false, errors.Wrap(err, "failed to convert message id to integer")
+	}
+	// Get the guild message hash to return after deletion
+	// and to check if it exists
+	gmh, ok, err := d.GuildMessageHash(messageID)
+	if err != nil {
+		return GuildMessageHash{}, false, errors.Wrap(err, "failed to determine if guild message hash exists")
+	} else if !ok {
+		// Row doesn't exist
+		return GuildMessageHash{}, false, nil
+	}
+	// Prepare query
+	s, err := d.db.Prepare(`
+		DELETE FROM GuildMessageHashes
+		WHERE MessageID = ?
+	`)
+	if err != nil {
+		return GuildMessageHash{}, false, errors.Wrap(err, "failed to prepare query")
+	}
+	defer s.Close()
+	// Execute query
+	if _, err = s.Exec(messageIDint); err != nil {
+		return GuildMessageHash{}, false, errors.Wrap(err, "database query failed")
+	}
+	return gmh, true, nil
+}
+
+// GuildMessageHashEdit edits the content hash of a specified message
+func (d *Database) GuildMessageHashEdit(messageID, hash string) (GuildMessageHash, bool, error) {
+	// Convert messageID to int
+	messageIDint, err := strconv.Atoi(messageID)
+	if err != nil {
+		return GuildMessageHash{}, false, errors.Wrap(err, "failed to convert message id to integer")
+	}
+	// Get the guild message hash that is to be updated
+	gmh, ok, err := d.GuildMessageHash(messageID)
+	if err != nil {
+		return GuildMessageHash{}, false, errors.Wrap(err, "failed to determine if guild message hash exists")
+	} else if !ok {
+		// Row doesn't exist
+		return GuildMessageHash{}, false, nil
+	}
+	// Update values
+	gmh.Hash = hash
+	gmh.EditedTimestamp = Timestamp(time.Now())
+	// Prepare query
+	s, err := d.db.Prepare(`
+		UPDATE GuildMessageHashes
+		SET Hash = ?, EditedTimestamp = ?
+		WHERE MessageID = ?
+	`)
+	if err != nil {
+		return GuildMessageHash{}, false, errors.Wrap(err, "failed to prepare query")
+	}
+	defer s.Close()
+	// Execute query
+	if _, err = s.Exec(
+		hash, time.Time(gmh.EditedTimestamp).Format(timeLayout), messageIDint,
+	); err != nil {
+		return GuildMessageHash{}, false, errors.Wrap(err, "database query failed")
+	}
+	return gmh, true, nil
+}
+
+// OutboxEntry gets a specified outbox entry
+func (d *Database) OutboxEntry(outboxEntryID string) (OutboxEntry, bool, error) {
+	// Convert outboxEntryID to int
+	outboxEntryIDint, err := strconv.Atoi(outboxEntryID)
+	if err != nil {
+		return OutboxEntry{}, false, errors.Wrap(err, "failed to convert outbox entry id to integer")
+	}
+	// Query the database
+	oes, err := d.outboxEntries(`
+		SELECT ID, IdempotencyKey, Kind, EntityID, Attempts, NextAttemptTimestamp,
+			LastError, Dead, Timestamp, EditedTimestamp, RecordIDs
+		FROM Outbox
+		WHERE ID = ?
+	`, outboxEntryIDint)
+	if err != nil {
+		return OutboxEntry{}, false, err
+	}
+	// Check if outbox entry exists
+	if len(oes) == 0 {
+		return OutboxEntry{}, false, nil
+	}
+	return oes[0], true, nil
+}
+
+// OutboxEntries gets all of the outbox entries that are waiting to be
+// attempted or, if dead is true, all of the dead outbox entries
+func (d *Database) OutboxEntries(dead bool) ([]OutboxEntry, error) {
+	return d.outboxEntries(`
+		SELECT ID, IdempotencyKey, Kind, EntityID, Attempts, NextAttemptTimestamp,
+			LastError, Dead, Timestamp, EditedTimestamp, RecordIDs
+		FROM Outbox
+		WHERE Dead = ?
+		ORDER BY ID
+	`, d.btoi(dead))
+}
+
+// OutboxDueEntries gets up to limit outbox entries which aren't dead and
+// are due to be attempted at the specified time, oldest first
+func (d *Database) OutboxDueEntries(now time.Time, limit int) ([]OutboxEntry, error) {
+	return d.outboxEntries(`
+		SELECT ID, IdempotencyKey, Kind, EntityID, Attempts, NextAttemptTimestamp,
+			LastError, Dead, Timestamp, EditedTimestamp, RecordIDs
+		FROM Outbox
+		WHERE Dead = 0 AND NextAttemptTimestamp <= ?
+		ORDER BY ID
+		LIMIT ?
+	`, now.Format(timeLayout), limit)
+}
+
+// OutboxEntryCreate creates an outbox entry for a change to a row
+// recordIDs are the records whose messages the change affected
+// if the row was deleted
+// If an entry with the idempotency key already exists,
+// no entry is created
+func (d *Database) OutboxEntryCreate(kind OutboxKind, entityID, idempotencyKey string, recordIDs []string) (OutboxEntry, bool, error) {
+	// Convert entityID to int
+	entityIDint, err := strconv.Atoi(entityID)
+	if err != nil {
+		return OutboxEntry{}, false, errors.Wrap(err, "failed to convert entity id to integer")
+	}
+	// Create outbox entry
+	oe := OutboxEntry{
+		IdempotencyKey:       idempotencyKey,
+		Kind:                 kind,
+		EntityID:             entityID,
+		RecordIDs:            recordIDs,
+		NextAttemptTimestamp: Timestamp(time.Now()),
+		Timestamp:            Timestamp(time.Now()),
+		EditedTimestamp:      Timestamp(time.Now()),
+	}
+	// Prepare query
+	s, err := d.db.Prepare(`
+		INSERT OR IGNORE INTO Outbox (IdempotencyKey, Kind, EntityID, Attempts,
+			NextAttemptTimestamp, LastError, Dead, Timestamp, EditedTimestamp,
+			RecordIDs
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return OutboxEntry{}, false, errors.Wrap(err, "failed to prepare query")
+	}
+	defer s.Close()
+	// Execute query
+	res, err := s.Exec(
+		idempotencyKey, int(kind), entityIDint, oe.Attempts,
+		time.Time(oe.NextAttemptTimestamp).Format(timeLayout),
+		oe.LastError, d.btoi(oe.Dead),
+		time.Time(oe.Timestamp).Format(timeLayout),
+		time.Time(oe.EditedTimestamp).Format(timeLayout),
+		strings.Join(recordIDs, ","),
+	)
+	if err != nil {
+		return OutboxEntry{}, false, errors.Wrap(err, "database query failed")
+	}
+	// Check if the idempotency key already existed
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return OutboxEntry{}, false, errors.Wrap(err, "couldn't determine if outbox entry was created")
+	} else if affected == 0 {
+		return OutboxEntry{}, false, nil
+	}
+	// Update outbox entry id
+	idInt, err := res.LastInsertId()
+	if err != nil {
+		return OutboxEntry{}, false, errors.Wrap(err, "couldn't update outbox entry id")
+	}
+	oe.ID = strconv.FormatInt(idInt, 10)
+	return oe, true, nil
+}
+
+// OutboxEntryDelete removes a specified outbox entry
+func (d *Database) OutboxEntryDelete(outboxEntryID string) (OutboxEntry, bool, error) {
+	// Convert outboxEntryID to int
+	outboxEntryIDint, err := strconv.Atoi(outboxEntryID)
+	if err != nil {
+		return OutboxEntry{}, false, errors.Wrap(err, "failed to convert outbox entry id to integer")
+	}
+	// Get the outbox entry to return after deletion
+	// and to check if it exists
+	oe, ok, err := d.OutboxEntry(outboxEntryID)
+	if err != nil {
+		return OutboxEntry{}, false, errors.Wrap(err, "failed to determine if outbox entry exists")
+	} else if !ok {
+		// Row doesn't exist
+		return OutboxEntry{}, false, nil
+	}
+	// Prepare query
+	s, err := d.db.Prepare(`
+		DELETE FROM Outbox
+		WHERE ID = ?
+	`)
+	if err != nil {
+		return OutboxEntry{}, false, errors.Wrap(err, "failed to prepare query")
+	}
+	defer s.Close()
+	// Execute query
+	if _, err = s.Exec(outboxEntryIDint); err != nil {
+		return OutboxEntry{}, false, errors.Wrap(err, "database query failed")
+	}
+	return oe, true, nil
+}
+
+// OutboxEntryEdit edits the attempt information of a specified outbox entry
+func (d *Database) OutboxEntryEdit(outboxEntryID string, attempts int, nextAttemptTimestamp Timestamp, lastError string, dead bool) (OutboxEntry, bool, error) {
+	// Convert outboxEntryID to int
+	outboxEntryIDint, err := strconv.Atoi(outboxEntryID)
+	if err != nil {
+		return OutboxEntry{}, false, errors.Wrap(err, "failed to convert outbox entry id to integer")
+	}
+	// Get the outbox entry that is to be updated
+	oe, ok, err := d.OutboxEntry(outboxEntryID)
+	if err != nil {
+		return OutboxEntry{}, false, errors.Wrap(err, "failed to determine if outbox entry exists")
+	} else if !ok {
+		// Row doesn't exist
+		return OutboxEntry{}, false, nil
+	}
+	// Update values
+	oe.Attempts = attempts
+	oe.NextAttemptTimestamp = nextAttemptTimestamp
+	oe.LastError = lastError
+	oe.Dead = dead
+	oe.EditedTimestamp = Timestamp(time.Now())
+	// Prepare query
+	s, err := d.db.Prepare(`
+		UPDATE Outbox
+		SET Attempts = ?, NextAttemptTimestamp = ?, LastError = ?, Dead = ?,
+			EditedTimestamp = ?
+		WHERE ID = ?
+	`)
+	if err != nil {
+		return OutboxEntry{}, false, errors.Wrap(err, "failed to prepare query")
+	}
+	defer s.Close()
+	// Execute query
+	if _, err = s.Exec(
+		attempts, time.Time(nextAttemptTimestamp).Format(timeLayout),
+		lastError, d.btoi(dead),
+		time.Time(oe.EditedTimestamp).Format(timeLayout), outboxEntryIDint,
+	); err != nil {
+		return OutboxEntry{}, false, errors.Wrap(err, "database query failed")
+	}
+	return oe, true, nil
+}
+
+// Private functions
+
+// nextStrikeID gets the next strike id for a specified user
+func (d *Database) nextStrikeID(userID string) (int, error) {
+	// Convert userID to int
+	userIDint, err := strconv.Atoi(userID)
 	if err != nil {
 		return 0, errors.Wrap(err, "failed to convert user id to integer")
 	}
@@ -3959,6 +5131,107 @@ func (d *Database) nextTicketID(guildID string) (int, error) {
 	return ticketID, nil
 }
 
+// buildRecordIDs gets the ids of the records a build holds or held
+func (d *Database) buildRecordIDs(buildID string) ([]string, error) {
+	// Convert buildID to int
+	buildIDint, err := strconv.Atoi(buildID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert build id to integer")
+	}
+	links, err := d.buildRecordLinks("WHERE BuildID = ?", buildIDint)
+	if err != nil {
+		return nil, err
+	}
+	recordIDs := []string{}
+	for _, l := range links {
+		recordIDs = append(recordIDs, l.RecordID)
+	}
+	return recordIDs, nil
+}
+
+// outboxEntryAdd creates an outbox entry for a change to a row
+// The idempotency key is made from the kind, the id of the row and
+// the time of the change so that each change has one entry
+// recordIDs are the records whose messages a deletion affected
+func (d *Database) outboxEntryAdd(kind OutboxKind, entityID string, changed Timestamp, recordIDs ...string) error {
+	key := fmt.Sprintf("%s:%s:%d", kind, entityID, time.Time(changed).UnixNano())
+	if _, _, err := d.OutboxEntryCreate(kind, entityID, key, recordIDs); err != nil {
+		return errors.Wrap(err, "failed to create outbox entry")
+	}
+	return nil
+}
+
+// outboxEntries gets the outbox entries selected by a query
+// The query must select every column of the Outbox table
+// in the order they were declared
+func (d *Database) outboxEntries(query string, args ...interface{}) ([]OutboxEntry, error) {
+	// Query the database
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "database query failed")
+	}
+	defer rows.Close()
+	// Extract data
+	var (
+		idInt                      int
+		idempotencyKey             string
+		kind                       int
+		entityIDint                int
+		attempts                   int
+		nextAttemptTimestampString string
+		lastError                  string
+		deadInt                    int
+		timestampString            string
+		editedTimestampString      string
+		recordIDs                  string
+		nextAttemptTimestamp       time.Time
+		timestamp                  time.Time
+		editedTimestamp            time.Time
+		results                    = []OutboxEntry{}
+	)
+	for rows.Next() {
+		if err = rows.Scan(
+			&idInt, &idempotencyKey, &kind, &entityIDint, &attempts,
+			&nextAttemptTimestampString, &lastError, &deadInt,
+			&timestampString, &editedTimestampString, &recordIDs,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to extract data")
+		}
+		// Parse timestamps
+		if nextAttemptTimestamp, err = time.Parse(timeLayout, nextAttemptTimestampString); err != nil {
+			return nil, errors.Wrap(err, "failed to parse next attempt timestamp")
+		}
+		if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
+			return nil, errors.Wrap(err, "failed to parse timestamp")
+		}
+		if editedTimestamp, err = time.Parse(timeLayout, editedTimestampString); err != nil {
+			return nil, errors.Wrap(err, "failed to parse edited timestamp")
+		}
+		results = append(results, OutboxEntry{
+			ID:                   strconv.Itoa(idInt),
+			IdempotencyKey:       idempotencyKey,
+			Kind:                 OutboxKind(kind),
+			EntityID:             strconv.Itoa(entityIDint),
+			RecordIDs:            splitIDs(recordIDs),
+			Attempts:             attempts,
+			NextAttemptTimestamp: Timestamp(nextAttemptTimestamp),
+			LastError:            lastError,
+			Dead:                 deadInt != 0,
+			Timestamp:            Timestamp(timestamp),
+			EditedTimestamp:      Timestamp(editedTimestamp),
+		})
+	}
+	return results, nil
+}
+
+// splitIDs splits a comma separated list of ids
+func splitIDs(ids string) []string {
+	if ids == "" {
+		return []string{}
+	}
+	return strings.Split(ids, ",")
+}
+
 // btoi converts a bool to an int
 // true 	-> 1
 // false	-> 0
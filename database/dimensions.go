@@ -0,0 +1,151 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Dimensions is the size of a build in blocks
+type Dimensions struct {
+	// Width is the width of the build
+	Width int
+	// Height is the height of the build
+	Height int
+	// Depth is the depth of the build
+	Depth int
+}
+
+// Orientation is how a build may be turned when comparing sizes
+type Orientation int
+
+const (
+	// OrientationFixed compares dimensions exactly as they're given
+	OrientationFixed Orientation = iota
+	// OrientationHorizontal allows a build to be turned about the
+	// vertical axis, so the width and depth may be swapped
+	OrientationHorizontal
+	// OrientationAny allows a build to be turned in any direction
+	OrientationAny
+)
+
+// SmallestOrientation is how builds may be turned when comparing
+// them for Smallest record types
+// Builds can be rotated but not turned on their side
+// as they wouldn't behave in the same way
+const SmallestOrientation = OrientationHorizontal
+
+// ParseDimensions parses dimensions of the form WxHxD such as 3x4x5
+func ParseDimensions(s string) (Dimensions, error) {
+	parts := strings.Split(strings.Replace(strings.ToLower(s), "×", "x", -1), "x")
+	if len(parts) != 3 {
+		return Dimensions{}, errors.Errorf("%q isn't of the form WxHxD such as 3x4x5", s)
+	}
+	sizes := [3]int{}
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil || n <= 0 {
+			return Dimensions{}, errors.Errorf("%q isn't of the form WxHxD such as 3x4x5", s)
+		}
+		sizes[i] = n
+	}
+	return Dimensions{Width: sizes[0], Height: sizes[1], Depth: sizes[2]}, nil
+}
+
+// String formats the dimensions as WxHxD
+func (d Dimensions) String() string {
+	return fmt.Sprintf("%dx%dx%d", d.Width, d.Height, d.Depth)
+}
+
+// Known is whether all of the dimensions have been given
+func (d Dimensions) Known() bool {
+	return d.Width > 0 && d.Height > 0 && d.Depth > 0
+}
+
+// Volume is the number of blocks within the dimensions
+func (d Dimensions) Volume() int {
+	return d.Width * d.Height * d.Depth
+}
+
+// Footprint is the area of ground covered by the dimensions
+func (d Dimensions) Footprint() int {
+	return d.Width * d.Depth
+}
+
+// Canonical turns the dimensions in the ways an orientation allows
+// so that the same size always has the same dimensions
+// The width is made no more than the depth and, when the build can
+// be turned in any direction, the height is made the smallest
+// e.g. 5x3x4 is 4x3x5 when turned horizontally and 3x5x4 and 5x3x4
+// are both 4x3x5 when turned in any direction
+func (d Dimensions) Canonical(o Orientation) Dimensions {
+	if o == OrientationFixed {
+		return d
+	}
+	if o == OrientationAny {
+		if d.Height > d.Width {
+			d.Height, d.Width = d.Width, d.Height
+		}
+		if d.Height > d.Depth {
+			d.Height, d.Depth = d.Depth, d.Height
+		}
+	}
+	if d.Width > d.Depth {
+		d.Width, d.Depth = d.Depth, d.Width
+	}
+	return d
+}
+
+// Compare compares the sizes of two dimensions after turning them in
+// the ways an orientation allows
+// -1 is returned if d is smaller, 1 if d is bigger and 0 if they're
+// the same size. Builds with smaller volumes are smaller, then those
+// with smaller footprints, shorter heights and then narrower widths
+// Unknown dimensions are bigger than any known dimensions
+func (d Dimensions) Compare(o Dimensions, orientation Orientation) int {
+	if d.Known() != o.Known() {
+		if d.Known() {
+			return -1
+		}
+		return 1
+	}
+	d, o = d.Canonical(orientation), o.Canonical(orientation)
+	for _, pair := range [][2]int{
+		{d.Volume(), o.Volume()},
+		{d.Footprint(), o.Footprint()},
+		{d.Height, o.Height},
+		{d.Width, o.Width},
+	} {
+		if pair[0] < pair[1] {
+			return -1
+		}
+		if pair[0] > pair[1] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Dimensions gets the dimensions of the build
+func (b Build) Dimensions() Dimensions {
+	return Dimensions{Width: b.Width, Height: b.Height, Depth: b.Depth}
+}
+
+// SetDimensions sets the width, height and depth of the build
+func (b *Build) SetDimensions(d Dimensions) {
+	b.Width, b.Height, b.Depth = d.Width, d.Height, d.Depth
+}
+
+// Smallest is whether records of the type are held by the smallest
+// builds, such as Smallest or Smallest Observerless
+func (t RecordType) Smallest() bool {
+	return strings.HasPrefix(strings.ToLower(t.Name), "smallest")
+}
+
+// CompareSmallest compares the sizes of two builds for Smallest record
+// types in the same way as Dimensions.Compare with SmallestOrientation
+func CompareSmallest(a, b Build) int {
+	return a.Dimensions().Compare(b.Dimensions(), SmallestOrientation)
+}
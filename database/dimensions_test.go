@@ -0,0 +1,128 @@
+package database
+
+import "testing"
+
+func TestParseDimensions(t *testing.T) {
+	tests := []struct {
+		text string
+		want Dimensions
+		err  bool
+	}{
+		{text: "3x4x5", want: Dimensions{Width: 3, Height: 4, Depth: 5}},
+		{text: " 3 X 4 x 5 ", want: Dimensions{Width: 3, Height: 4, Depth: 5}},
+		{text: "3×4×5", want: Dimensions{Width: 3, Height: 4, Depth: 5}},
+		{text: "3x4", err: true},
+		{text: "3x4x5x6", err: true},
+		{text: "3x0x5", err: true},
+		{text: "3x-4x5", err: true},
+		{text: "3xax5", err: true},
+		{text: "", err: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDimensions(tt.text)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParseDimensions(%q) = %v, expected an error", tt.text, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDimensions(%q) failed: %v", tt.text, err)
+		} else if got != tt.want {
+			t.Errorf("ParseDimensions(%q) = %v, expected %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestDimensionsCanonical(t *testing.T) {
+	tests := []struct {
+		d           Dimensions
+		orientation Orientation
+		want        Dimensions
+	}{
+		{Dimensions{5, 3, 4}, OrientationFixed, Dimensions{5, 3, 4}},
+		{Dimensions{5, 3, 4}, OrientationHorizontal, Dimensions{4, 3, 5}},
+		{Dimensions{4, 3, 5}, OrientationHorizontal, Dimensions{4, 3, 5}},
+		{Dimensions{3, 5, 4}, OrientationHorizontal, Dimensions{3, 5, 4}},
+		{Dimensions{3, 5, 4}, OrientationAny, Dimensions{4, 3, 5}},
+		{Dimensions{5, 3, 4}, OrientationAny, Dimensions{4, 3, 5}},
+		{Dimensions{5, 4, 3}, OrientationAny, Dimensions{4, 3, 5}},
+	}
+	for _, tt := range tests {
+		if got := tt.d.Canonical(tt.orientation); got != tt.want {
+			t.Errorf("%v.Canonical(%d) = %v, expected %v", tt.d, tt.orientation, got, tt.want)
+		}
+	}
+}
+
+func TestDimensionsCompare(t *testing.T) {
+	tests := []struct {
+		a, b        Dimensions
+		orientation Orientation
+		want        int
+	}{
+		// Turned horizontally, 3x5x4 and 5x3x4 aren't the same size
+		// but 5x3x4 and 4x3x5 are
+		{Dimensions{5, 3, 4}, Dimensions{4, 3, 5}, OrientationHorizontal, 0},
+		{Dimensions{5, 3, 4}, Dimensions{4, 3, 5}, OrientationFixed, 1},
+		{Dimensions{3, 5, 4}, Dimensions{5, 3, 4}, OrientationAny, 0},
+		// Smaller volumes are smaller
+		{Dimensions{2, 2, 2}, Dimensions{1, 1, 9}, OrientationAny, -1},
+		// Then smaller footprints
+		{Dimensions{1, 4, 3}, Dimensions{2, 2, 3}, OrientationHorizontal, -1},
+		{Dimensions{2, 3, 2}, Dimensions{2, 2, 3}, OrientationFixed, -1},
+		// Then narrower widths
+		{Dimensions{1, 2, 6}, Dimensions{2, 2, 3}, OrientationFixed, -1},
+		// Unknown dimensions are bigger than known ones
+		{Dimensions{}, Dimensions{9, 9, 9}, OrientationHorizontal, 1},
+		{Dimensions{9, 9, 9}, Dimensions{0, 1, 1}, OrientationHorizontal, -1},
+		{Dimensions{}, Dimensions{}, OrientationHorizontal, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Compare(tt.b, tt.orientation); got != tt.want {
+			t.Errorf("%v.Compare(%v, %d) = %d, expected %d", tt.a, tt.b, tt.orientation, got, tt.want)
+		}
+		if got := tt.b.Compare(tt.a, tt.orientation); got != -tt.want {
+			t.Errorf("%v.Compare(%v, %d) = %d, expected %d", tt.b, tt.a, tt.orientation, got, -tt.want)
+		}
+	}
+}
+
+func TestCompareSmallest(t *testing.T) {
+	build := func(d Dimensions) Build {
+		b := Build{}
+		b.SetDimensions(d)
+		return b
+	}
+	// Builds can be rotated but not turned on their side
+	if got := CompareSmallest(build(Dimensions{5, 3, 4}), build(Dimensions{4, 3, 5})); got != 0 {
+		t.Errorf("expected rotated builds to be the same size, got %d", got)
+	}
+	// Taller builds of the same volume cover less ground
+	if got := CompareSmallest(build(Dimensions{3, 4, 5}), build(Dimensions{4, 3, 5})); got != -1 {
+		t.Errorf("expected a build with a smaller footprint to be smaller, got %d", got)
+	}
+	if got := CompareSmallest(build(Dimensions{5, 4, 3}), build(Dimensions{3, 5, 4})); got != 1 {
+		t.Errorf("expected a build turned on its side to be a different size, got %d", got)
+	}
+	if got := CompareSmallest(build(Dimensions{3, 3, 3}), build(Dimensions{})); got != -1 {
+		t.Errorf("expected a build without dimensions to be bigger, got %d", got)
+	}
+}
+
+func TestRecordTypeSmallest(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"Smallest", true},
+		{"smallest observerless", true},
+		{"Fastest", false},
+		{"Not Smallest", false},
+	}
+	for _, tt := range tests {
+		if got := (RecordType{Name: tt.name}).Smallest(); got != tt.want {
+			t.Errorf("RecordType{Name: %q}.Smallest() = %v, expected %v", tt.name, got, tt.want)
+		}
+	}
+}
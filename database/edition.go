@@ -20,8 +20,9 @@ func (e Edition) Versions() ([]Version, error) {
 	}
 	// Query the database
 	rows, err := db.db.Query(`
-		SELECT ID, MajorVersion, MinorVersion, Patch, Name, Description,
-			VersionTimestamp, Timestamp, EditedTimestamp
+		SELECT ID, MajorVersion, MinorVersion, Patch, Revision, Type,
+			PreRelease, Snapshot, Name, Description, VersionTimestamp,
+			Timestamp, EditedTimestamp
 		FROM Versions
 		WHERE EditionID = ?
 	`, idInt)
@@ -36,6 +37,10 @@ func (e Edition) Versions() ([]Version, error) {
 		majorVersion           int
 		minorVersion           int
 		patch                  int
+		revision               int
+		versionType            int
+		preRelease             int
+		snapshot               string
 		name                   string
 		description            string
 		versionTimestampString string
@@ -49,14 +54,15 @@ func (e Edition) Versions() ([]Version, error) {
 	for rows.Next() {
 		// Extract data
 		if err = rows.Scan(
-			&versionIDint, &majorVersion, &minorVersion, &patch, &name,
-			&description, &versionTimestampString, &timestampString,
+			&versionIDint, &majorVersion, &minorVersion, &patch, &revision,
+			&versionType, &preRelease, &snapshot, &name, &description,
+			&versionTimestampString, &timestampString,
 			&editedTimestampString,
 		); err != nil {
 			return nil, errors.Wrap(err, "failed to extract data")
 		}
 		// Parse timestamps
-		if versionTimestamp, err = time.Parse(timeLayout, versionTimestampString); err != nil {
+		if versionTimestamp, err = parseTimestamp(versionTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse version timestamp")
 		}
 		if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
@@ -67,11 +73,15 @@ func (e Edition) Versions() ([]Version, error) {
 		}
 		// Add to results
 		results = append(results, Version{
-			ID:               strconv.Itoa(idInt),
+			ID:               strconv.Itoa(versionIDint),
 			EditionID:        e.ID,
 			MajorVersion:     majorVersion,
 			MinorVersion:     minorVersion,
 			Patch:            patch,
+			Revision:         revision,
+			Type:             VersionType(versionType),
+			PreRelease:       preRelease,
+			Snapshot:         snapshot,
 			Name:             name,
 			Description:      description,
 			VersionTimestamp: Timestamp(versionTimestamp),
@@ -132,18 +142,18 @@ func (e Edition) Builds() ([]Build, error) {
 		width                   int
 		height                  int
 		depth                   int
-		normalCloseDuration     int
-		normalOpenDuration      int
-		visibleCloseDuration    int
-		visibleOpenDuration     int
-		delayCloseDuration      int
-		delayOpenDuration       int
-		resetCloseDuration      int
-		resetOpenDuration       int
-		extensionDuration       int
-		retractionDuration      int
-		extensionDelayDuration  int
-		retractionDelayDuration int
+		normalCloseDuration     Ticks
+		normalOpenDuration      Ticks
+		visibleCloseDuration    Ticks
+		visibleOpenDuration     Ticks
+		delayCloseDuration      Ticks
+		delayOpenDuration       Ticks
+		resetCloseDuration      Ticks
+		resetOpenDuration       Ticks
+		extensionDuration       Ticks
+		retractionDuration      Ticks
+		extensionDelayDuration  Ticks
+		retractionDelayDuration Ticks
 		imageURL                string
 		youtubeURL              string
 		worldDownloadURL        string
@@ -175,13 +185,13 @@ func (e Edition) Builds() ([]Build, error) {
 			return nil, errors.Wrap(err, "failed to extract data")
 		}
 		// Parse timestamps
-		if verifiedTimestamp, err = time.Parse(timeLayout, verifiedTimestampString); err != nil {
+		if verifiedTimestamp, err = parseTimestamp(verifiedTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse verified timestamp")
 		}
-		if reportedTimestamp, err = time.Parse(timeLayout, reportedTimestampString); err != nil {
+		if reportedTimestamp, err = parseTimestamp(reportedTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse reported timestamp")
 		}
-		if creationTimestamp, err = time.Parse(timeLayout, creationTimestampString); err != nil {
+		if creationTimestamp, err = parseTimestamp(creationTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse creation timestamp")
 		}
 		if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
@@ -291,7 +301,7 @@ func (e Edition) Records() ([]Record, error) {
 			return nil, errors.Wrap(err, "failed to extract data")
 		}
 		// Parse timestamps
-		if verifiedTimestamp, err = time.Parse(timeLayout, verifiedTimestampString); err != nil {
+		if verifiedTimestamp, err = parseTimestamp(verifiedTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse verified timestamp")
 		}
 		if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
@@ -320,3 +330,32 @@ func (e Edition) Records() ([]Record, error) {
 	}
 	return results, nil
 }
+
+// LatestVersion gets the newest version of the edition
+func (e Edition) LatestVersion() (Version, bool, error) {
+	versions, err := e.Versions()
+	if err != nil {
+		return Version{}, false, errors.Wrap(err, "failed to get versions")
+	}
+	if len(versions) == 0 {
+		return Version{}, false, nil
+	}
+	latest := versions[0]
+	for _, v := range versions[1:] {
+		if v.Compare(latest) > 0 {
+			latest = v
+		}
+	}
+	return latest, true, nil
+}
+
+// VersionsBetween gets the versions of the edition from one version
+// number to another, including both, from oldest to newest
+// See Database.VersionsBetween for how the bounds are given
+func (e Edition) VersionsBetween(from, to string) ([]Version, error) {
+	db, err := Instance()
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get database instance")
+	}
+	return db.VersionsBetween(e.ID, from, to)
+}
@@ -0,0 +1,403 @@
+package database
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// ChannelPurpose is what a channel planned when onboarding a guild is for
+type ChannelPurpose int
+
+const (
+	// ChannelBuilds is the channel that builds are posted in
+	ChannelBuilds ChannelPurpose = iota
+	// ChannelTicketCategory is the category that tickets are made in
+	ChannelTicketCategory
+	// ChannelLog is the channel that moderation actions are logged in
+	ChannelLog
+	// ChannelRecordType is the channel that records of a type are posted in
+	ChannelRecordType
+)
+
+// String gets the name of the channel purpose
+func (p ChannelPurpose) String() string {
+	switch p {
+	case ChannelBuilds:
+		return "builds"
+	case ChannelTicketCategory:
+		return "ticket category"
+	case ChannelLog:
+		return "log"
+	case ChannelRecordType:
+		return "record type"
+	}
+	return "unknown"
+}
+
+// PlannedChannel is a channel that should be created in a guild
+type PlannedChannel struct {
+	// Purpose is what the channel is for
+	Purpose ChannelPurpose
+	// Name is the suggested name of the channel
+	Name string
+	// Category indicates whether the channel is a category
+	Category bool
+	// SettingKey is the setting the channel's id is stored in
+	// It's empty for record type channels
+	SettingKey string
+	// RecordTypeID is the id of the record type of a record type channel
+	RecordTypeID string
+}
+
+// OnboardReport is what changed when a guild was onboarded
+type OnboardReport struct {
+	// GuildID is the id of the guild
+	GuildID string
+	// SettingsCreated indicates whether the guild's setting information
+	// was created. It's false if the guild had already been onboarded
+	SettingsCreated bool
+	// Channels are the channels that should be created and then
+	// recorded with OnboardChannel
+	Channels []PlannedChannel
+}
+
+// OffboardMode is what happens to the rows of a guild when it's offboarded
+type OffboardMode int
+
+const (
+	// OffboardArchive saves the rows as a GuildArchive before removing them
+	OffboardArchive OffboardMode = iota
+	// OffboardPurge removes the rows without saving them
+	OffboardPurge
+)
+
+// String gets the name of the offboard mode
+func (m OffboardMode) String() string {
+	switch m {
+	case OffboardArchive:
+		return "archive"
+	case OffboardPurge:
+		return "purge"
+	}
+	return "unknown"
+}
+
+// OffboardReport is what changed when a guild was offboarded
+type OffboardReport struct {
+	// GuildID is the id of the guild
+	GuildID string
+	// Mode is what happened to the rows
+	Mode OffboardMode
+	// Removed is the number of rows removed from each table
+	// Tables without any rows for the guild are left out
+	Removed map[string]int
+	// ArchiveID is the id of the archive the rows were saved in
+	// It's empty when the rows were purged
+	ArchiveID string
+}
+
+// Total gets the number of rows that were removed
+func (r OffboardReport) Total() int {
+	total := 0
+	for _, n := range r.Removed {
+		total += n
+	}
+	return total
+}
+
+// guildTables are the tables that have rows belonging to guilds
+// Tables are in the order their rows are removed, so rows that
+// refer to other rows come first
+var guildTables = []string{
+	"TicketWizards",
+	"GuildTicketChannels",
+	"GuildMessageHashes",
+	"GuildBuildMessages",
+	"GuildRecordMessages",
+	"GuildRecordTypeChannels",
+	"GuildRoles",
+	"GuildSettingChanges",
+	"GuildSettingValues",
+	"GuildSettings",
+}
+
+// channelSettings are the settings holding channels which are
+// planned when onboarding and what the channels are for
+var channelSettings = []struct {
+	key      string
+	purpose  ChannelPurpose
+	name     string
+	category bool
+}{
+	{SettingBuildChannel, ChannelBuilds, "builds", false},
+	{SettingTicketCategory, ChannelTicketCategory, "Tickets", true},
+	{SettingLogChannel, ChannelLog, "bot-log", false},
+}
+
+// OnboardGuild prepares the database for a guild the bot has joined
+// The guild's setting information is created, leaving every setting
+// with its default value, and the channels the guild is missing are
+// planned. Onboarding a guild again plans any channels still missing
+func (d *Database) OnboardGuild(guildID string) (OnboardReport, error) {
+	report := OnboardReport{GuildID: guildID, Channels: []PlannedChannel{}}
+	err := d.Transaction(func(tx *Database) error {
+		var err error
+		if _, report.SettingsCreated, err = tx.GuildSettingCreate(guildID); err != nil {
+			return errors.Wrap(err, "failed to create guild setting")
+		}
+		// Channels held by settings
+		for _, cs := range channelSettings {
+			v, err := tx.GuildSettingGet(guildID, cs.key)
+			if err != nil {
+				return err
+			}
+			if v.ID() != "" {
+				continue
+			}
+			report.Channels = append(report.Channels, PlannedChannel{
+				Purpose:    cs.purpose,
+				Name:       cs.name,
+				Category:   cs.category,
+				SettingKey: cs.key,
+			})
+		}
+		// A channel for each record type
+		rts, err := tx.RecordTypes()
+		if err != nil {
+			return errors.Wrap(err, "failed to get record types")
+		}
+		grtcs, err := tx.GuildRecordTypeChannels(guildID)
+		if err != nil {
+			return errors.Wrap(err, "failed to get guild record type channels")
+		}
+		has := map[string]bool{}
+		for _, grtc := range grtcs {
+			has[grtc.RecordTypeID] = true
+		}
+		for _, rt := range rts {
+			if has[rt.ID] {
+				continue
+			}
+			report.Channels = append(report.Channels, PlannedChannel{
+				Purpose:      ChannelRecordType,
+				Name:         channelName(rt.Name),
+				RecordTypeID: rt.ID,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return OnboardReport{}, err
+	}
+	return report, nil
+}
+
+// OnboardChannel records a channel that was created for a guild
+// from a channel planned by OnboardGuild
+func (d *Database) OnboardChannel(guildID string, pc PlannedChannel, channelID string) error {
+	if pc.Purpose == ChannelRecordType {
+		_, ok, err := d.GuildRecordTypeChannelCreate(guildID, pc.RecordTypeID, channelID)
+		if err != nil {
+			return errors.Wrap(err, "failed to create guild record type channel")
+		} else if !ok {
+			_, _, err = d.GuildRecordTypeChannelEdit(guildID, pc.RecordTypeID, channelID)
+			return errors.Wrap(err, "failed to edit guild record type channel")
+		}
+		return nil
+	}
+	_, err := d.GuildSettingSet(guildID, pc.SettingKey, channelID, "")
+	return err
+}
+
+// channelName makes a discord channel name from a name such
+// as "Smallest Observerless" which becomes "smallest-observerless"
+func channelName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
+
+// OffboardGuild removes every row belonging to a guild the bot has left
+// in a single transaction. When archiving, the rows are saved as a
+// GuildArchive first so they can be looked at later
+func (d *Database) OffboardGuild(guildID string, mode OffboardMode) (OffboardReport, error) {
+	guildIDint, err := strconv.Atoi(guildID)
+	if err != nil {
+		return OffboardReport{}, errors.Wrap(err, "failed to convert guild id to integer")
+	}
+	if mode != OffboardArchive && mode != OffboardPurge {
+		return OffboardReport{}, errors.Errorf("unknown offboard mode %d", mode)
+	}
+	report := OffboardReport{GuildID: guildID, Mode: mode, Removed: map[string]int{}}
+	err = d.Transaction(func(tx *Database) error {
+		if mode == OffboardArchive {
+			tables := map[string][]map[string]interface{}{}
+			for _, table := range guildTables {
+				rows, err := tx.guildRows(table, guildIDint)
+				if err != nil {
+					return errors.Wrapf(err, "failed to get rows of %s", table)
+				}
+				if len(rows) > 0 {
+					tables[table] = rows
+				}
+			}
+			data, err := json.Marshal(tables)
+			if err != nil {
+				return errors.Wrap(err, "failed to encode archive")
+			}
+			ga, err := tx.guildArchiveCreate(guildID, string(data))
+			if err != nil {
+				return errors.Wrap(err, "failed to create guild archive")
+			}
+			report.ArchiveID = ga.ID
+		}
+		for _, table := range guildTables {
+			// Prepare query
+			s, err := tx.db.Prepare(`DELETE FROM ` + table + ` WHERE GuildID = ?`)
+			if err != nil {
+				return errors.Wrap(err, "failed to prepare query")
+			}
+			// Execute query
+			res, err := s.Exec(guildIDint)
+			s.Close()
+			if err != nil {
+				return errors.Wrapf(err, "failed to remove rows of %s", table)
+			}
+			n, err := res.RowsAffected()
+			if err != nil {
+				return errors.Wrap(err, "failed to count removed rows")
+			}
+			if n > 0 {
+				report.Removed[table] = int(n)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return OffboardReport{}, err
+	}
+	return report, nil
+}
+
+// guildRows gets the rows of a table belonging to a guild
+// as maps from column names to values
+func (d *Database) guildRows(table string, guildIDint int) ([]map[string]interface{}, error) {
+	rows, err := d.db.Query(`SELECT * FROM `+table+` WHERE GuildID = ?`, guildIDint)
+	if err != nil {
+		return nil, errors.Wrap(err, "database query failed")
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get columns")
+	}
+	results := []map[string]interface{}{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err = rows.Scan(pointers...); err != nil {
+			return nil, errors.Wrap(err, "failed to extract data")
+		}
+		row := map[string]interface{}{}
+		for i, column := range columns {
+			// Text is scanned as bytes which would be encoded as base64
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			row[column] = values[i]
+		}
+		results = append(results, row)
+	}
+	return results, nil
+}
+
+// GuildArchives gets the archives of a guild's rows, oldest first
+func (d *Database) GuildArchives(guildID string) ([]GuildArchive, error) {
+	// Convert guildID to int
+	guildIDint, err := strconv.Atoi(guildID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert guild id to integer")
+	}
+	// Query the database
+	rows, err := d.db.Query(`
+		SELECT ID, Data, Timestamp
+		FROM GuildArchives
+		WHERE GuildID = ?
+		ORDER BY ID
+	`, guildIDint)
+	if err != nil {
+		return nil, errors.Wrap(err, "database query failed")
+	}
+	defer rows.Close()
+	// Create space to store results
+	results := []GuildArchive{}
+	var (
+		idInt           int
+		data            string
+		timestampString string
+		timestamp       time.Time
+	)
+	// For each row
+	for rows.Next() {
+		// Extract data
+		if err = rows.Scan(&idInt, &data, &timestampString); err != nil {
+			return nil, errors.Wrap(err, "failed to extract data")
+		}
+		// Parse timestamp
+		if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
+			return nil, errors.Wrap(err, "failed to parse timestamp")
+		}
+		// Add to results
+		results = append(results, GuildArchive{
+			ID:        strconv.Itoa(idInt),
+			GuildID:   guildID,
+			Data:      data,
+			Timestamp: Timestamp(timestamp),
+		})
+	}
+	return results, nil
+}
+
+// guildArchiveCreate saves an archive of a guild's rows
+func (d *Database) guildArchiveCreate(guildID, data string) (GuildArchive, error) {
+	// Convert guildID to int
+	guildIDint, err := strconv.Atoi(guildID)
+	if err != nil {
+		return GuildArchive{}, errors.Wrap(err, "failed to convert guild id to integer")
+	}
+	ga := GuildArchive{
+		GuildID:   guildID,
+		Data:      data,
+		Timestamp: Timestamp(time.Now()),
+	}
+	// Prepare query
+	s, err := d.db.Prepare(`
+		INSERT INTO GuildArchives (GuildID, Data, Timestamp)
+		VALUES (?, ?, ?)
+	`)
+	if err != nil {
+		return GuildArchive{}, errors.Wrap(err, "failed to prepare query")
+	}
+	defer s.Close()
+	// Execute query
+	res, err := s.Exec(guildIDint, data, time.Time(ga.Timestamp).Format(timeLayout))
+	if err != nil {
+		return GuildArchive{}, errors.Wrap(err, "database query failed")
+	}
+	// Update archive id
+	idInt, err := res.LastInsertId()
+	if err != nil {
+		return GuildArchive{}, errors.Wrap(err, "couldn't update guild archive id")
+	}
+	ga.ID = strconv.FormatInt(idInt, 10)
+	return ga, nil
+}
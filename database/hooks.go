@@ -0,0 +1,39 @@
+package database
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// VersionHook is run after a version is created
+// It's run within the transaction that creates the version,
+// which is rolled back if the hook returns an error
+type VersionHook func(tx *Database, v Version) error
+
+// hooks are the functions run when rows are created
+type hooks struct {
+	mu            sync.RWMutex
+	versionCreate []VersionHook
+}
+
+// OnVersionCreate adds a hook that's run whenever
+// VersionCreate creates a version
+func (d *Database) OnVersionCreate(hook VersionHook) {
+	d.hooks.mu.Lock()
+	defer d.hooks.mu.Unlock()
+	d.hooks.versionCreate = append(d.hooks.versionCreate, hook)
+}
+
+// runVersionHooks runs the version hooks in the order they were added
+func (d *Database) runVersionHooks(v Version) error {
+	d.hooks.mu.RLock()
+	hs := append([]VersionHook{}, d.hooks.versionCreate...)
+	d.hooks.mu.RUnlock()
+	for _, hook := range hs {
+		if err := hook(d, v); err != nil {
+			return errors.Wrap(err, "version hook failed")
+		}
+	}
+	return nil
+}
@@ -2,8 +2,7 @@ package database
 
 import (
 	"database/sql"
-	"os"
-	"path/filepath"
+	"sync"
 
 	// Sqlite3 database driver
 	_ "github.com/mattn/go-sqlite3"
@@ -11,12 +10,12 @@ import (
 )
 
 var (
-	// Error is the error that occured during init
-	// if an error occured during init
-	// Otherwise, it's nil
-	Error error
 	// databaseInstance is the instance of the database
 	databaseInstance Database
+	// opened indicates whether databaseInstance has been opened
+	opened bool
+	// instanceMutex guards databaseInstance and opened
+	instanceMutex sync.Mutex
 )
 
 // tables is a list of sql queries where each query
@@ -34,15 +33,37 @@ var tables = [...]string{
 		)
 	`,
 	`	CREATE TABLE IF NOT EXISTS GuildSettings (
-			GuildID 				INTEGER NOT NULL,
-			BuildChannelID 			INTEGER NOT NULL,
-			TicketChannelCategoryID	INTEGER NOT NULL,
-			Timestamp				TEXT	NOT NULL,
-			EditedTimestamp			TEXT	NOT NULL,
+			GuildID 		INTEGER NOT NULL,
+			Timestamp		TEXT	NOT NULL,
+			EditedTimestamp	TEXT	NOT NULL,
 
 			PRIMARY KEY (GuildID)
 		)
 	`,
+	`	CREATE TABLE IF NOT EXISTS GuildSettingValues (
+			GuildID 		INTEGER NOT NULL,
+			Key 			TEXT	NOT NULL,
+			Value 			TEXT	NOT NULL,
+			Timestamp		TEXT	NOT NULL,
+			EditedTimestamp	TEXT	NOT NULL,
+
+			PRIMARY KEY (GuildID, Key),
+			FOREIGN KEY (GuildID) REFERENCES GuildSettings(GuildID)
+		)
+	`,
+	`	CREATE TABLE IF NOT EXISTS GuildSettingChanges (
+			ID 				INTEGER NOT NULL,
+			GuildID 		INTEGER NOT NULL,
+			Key 			TEXT	NOT NULL,
+			OldValue 		TEXT	NOT NULL,
+			NewValue 		TEXT	NOT NULL,
+			Reset 			INTEGER NOT NULL,
+			ChangedByID 	INTEGER NOT NULL,
+			Timestamp		TEXT	NOT NULL,
+
+			PRIMARY KEY (ID)
+		)
+	`,
 	`	CREATE TABLE IF NOT EXISTS Editions (
 			ID				INTEGER NOT NULL,
 			Name 			TEXT	NOT NULL,
@@ -60,6 +81,7 @@ var tables = [...]string{
 			EmbedColour 	TEXT 	NOT NULL,
 			Timestamp 		TEXT	NOT NULL,
 			EditedTimestamp TEXT	NOT NULL,
+			Rule 			TEXT 	NOT NULL DEFAULT '',
 
 			PRIMARY KEY (ID)
 		)
@@ -143,6 +165,10 @@ var tables = [...]string{
 			VersionTimestamp 	INTEGER NOT NULL,
 			Timestamp 			TEXT	NOT NULL,
 			EditedTimestamp 	TEXT	NOT NULL,
+			Revision 			INTEGER NOT NULL DEFAULT 0,
+			Type 				INTEGER NOT NULL DEFAULT 0,
+			PreRelease 			INTEGER NOT NULL DEFAULT 0,
+			Snapshot 			TEXT	NOT NULL DEFAULT '',
 
 			PRIMARY KEY (ID),
 			FOREIGN KEY (EditionID) REFERENCES Editions(ID)
@@ -252,26 +278,64 @@ var tables = [...]string{
 			PRIMARY KEY (GuildID, ChannelID)
 		)
 	`,
-}
+	`	CREATE TABLE IF NOT EXISTS GuildRoles (
+			GuildID 	INTEGER NOT NULL,
+			RoleID 		INTEGER NOT NULL,
+			Capability 	INTEGER NOT NULL,
+			Timestamp 	TEXT	NOT NULL,
 
-// databaseFileExists tests to see if there is already
-// a file at the database path
-func databaseFileExists() (bool, error) {
-	// Get absolute path of databasePath
-	path, err := filepath.Abs(databasePath)
-	if err != nil {
-		return false, err
-	}
-	// Getting status of the file path
-	if _, err := os.Stat(path); err == nil {
-		// The file exists
-		return true, nil
-	} else if !os.IsNotExist(err) {
-		// An error occured
-		return false, err
-	}
-	// The file doesn't exist
-	return false, nil
+			PRIMARY KEY (GuildID, RoleID, Capability)
+		)
+	`,
+	`	CREATE TABLE IF NOT EXISTS GuildArchives (
+			ID 			INTEGER NOT NULL,
+			GuildID 	INTEGER NOT NULL,
+			Data 		TEXT	NOT NULL,
+			Timestamp	TEXT	NOT NULL,
+
+			PRIMARY KEY (ID)
+		)
+	`,
+	`	CREATE TABLE IF NOT EXISTS TicketWizards (
+			GuildID 		INTEGER NOT NULL,
+			ChannelID 		INTEGER NOT NULL,
+			TicketType 		INTEGER NOT NULL,
+			Step 			INTEGER NOT NULL,
+			History 		TEXT	NOT NULL,
+			Draft 			TEXT	NOT NULL,
+			Timestamp 		TEXT	NOT NULL,
+			EditedTimestamp TEXT	NOT NULL,
+
+			PRIMARY KEY (GuildID, ChannelID),
+			FOREIGN KEY (GuildID, ChannelID) REFERENCES GuildTicketChannels(GuildID, ChannelID)
+		)
+	`,
+	`	CREATE TABLE IF NOT EXISTS GuildMessageHashes (
+			MessageID 		INTEGER NOT NULL,
+			GuildID 		INTEGER NOT NULL,
+			Hash 			TEXT	NOT NULL,
+			Timestamp 		TEXT	NOT NULL,
+			EditedTimestamp TEXT	NOT NULL,
+
+			PRIMARY KEY (MessageID)
+		)
+	`,
+	`	CREATE TABLE IF NOT EXISTS Outbox (
+			ID 						INTEGER NOT NULL,
+			IdempotencyKey 			TEXT	NOT NULL UNIQUE,
+			Kind 					INTEGER NOT NULL,
+			EntityID 				INTEGER NOT NULL,
+			Attempts 				INTEGER NOT NULL,
+			NextAttemptTimestamp 	TEXT	NOT NULL,
+			LastError 				TEXT	NOT NULL,
+			Dead 					INTEGER NOT NULL,
+			Timestamp 				TEXT	NOT NULL,
+			EditedTimestamp 		TEXT	NOT NULL,
+			RecordIDs 				TEXT	NOT NULL DEFAULT '',
+
+			PRIMARY KEY (ID)
+		)
+	`,
 }
 
 // executeQuery performs an sql query on an sql database
@@ -303,30 +367,45 @@ func createTables(db *sql.DB) error {
 	return nil
 }
 
-// init creates the database connection
-func init() {
-	// Test if the database already exists
-	exists, err := databaseFileExists()
+// Open opens the database file at path and makes it
+// the package's database instance
+// The file is created if it doesn't exist
+func Open(path string) (*Database, error) {
+	instanceMutex.Lock()
+	defer instanceMutex.Unlock()
+	if opened {
+		return nil, errors.New("database is already open")
+	}
+	db, err := open(path)
 	if err != nil {
-		Error = errors.Wrap(err, "failed to determine status of database file")
+		return nil, err
 	}
+	databaseInstance = *db
+	opened = true
+	return &databaseInstance, nil
+}
 
+// open creates a database connection
+func open(path string) (*Database, error) {
 	// Create database connection
-	db, err := sql.Open("sqlite3", databasePath)
+	db, err := sql.Open("sqlite3", path)
 	if err != nil {
-		Error = errors.Wrap(err, "failed to open database connection")
-		return
+		return nil, errors.Wrap(err, "failed to open database connection")
 	}
 
-	// If database was just created, create tables
-	if !exists {
-		err := createTables(db)
-		if err != nil {
-			Error = errors.Wrap(err, "failed to create table")
-			return
-		}
+	// Create any tables that don't exist yet
+	// This is also done for existing databases so that
+	// tables added since the database was made are created
+	if err := createTables(db); err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "failed to create table")
+	}
+
+	// Update databases made by older versions
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "failed to migrate database")
 	}
 
-	// Create the database instance
-	databaseInstance = Database{db: db}
+	return &Database{db: db, conn: db, hooks: &hooks{}}, nil
 }
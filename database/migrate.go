@@ -0,0 +1,172 @@
+package database
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+// migration updates a database made by an older version of the bot
+// Migrations check whether they're needed so that every
+// migration can be ran each time a database is opened
+type migration struct {
+	// name describes what the migration does
+	name string
+	// run carries out the migration within a transaction
+	run func(tx *sql.Tx) error
+}
+
+// migrations are ran in order after the tables are created
+var migrations = []migration{
+	{
+		name: "move guild setting columns into guild setting values",
+		run:  migrateGuildSettingColumns,
+	},
+	{
+		name: "add version type and extra component columns",
+		run:  migrateVersionColumns,
+	},
+	{
+		name: "add record ids column to the outbox",
+		run:  migrateOutboxRecordIDs,
+	},
+	{
+		name: "add rule column to build classes",
+		run:  migrateBuildClassRule,
+	},
+}
+
+// migrate runs every migration, each within its own transaction
+func migrate(db *sql.DB) error {
+	for _, m := range migrations {
+		tx, err := db.Begin()
+		if err != nil {
+			return errors.Wrap(err, "failed to begin transaction")
+		}
+		if err = m.run(tx); err != nil {
+			tx.Rollback()
+			return errors.Wrapf(err, "failed to %s", m.name)
+		}
+		if err = tx.Commit(); err != nil {
+			return errors.Wrapf(err, "failed to commit %s", m.name)
+		}
+	}
+	return nil
+}
+
+// hasColumn determines whether a table has a column
+func hasColumn(tx *sql.Tx, table, column string) (bool, error) {
+	var count int
+	err := tx.QueryRow(`
+		SELECT COUNT(*)
+		FROM pragma_table_info(?)
+		WHERE name = ?
+	`, table, column).Scan(&count)
+	if err != nil {
+		return false, errors.Wrap(err, "database query failed")
+	}
+	return count > 0, nil
+}
+
+// migrateGuildSettingColumns moves the build channel and ticket category
+// columns of GuildSettings into GuildSettingValues and removes them
+func migrateGuildSettingColumns(tx *sql.Tx) error {
+	if ok, err := hasColumn(tx, "GuildSettings", "BuildChannelID"); err != nil || !ok {
+		return err
+	}
+	queries := []string{
+		// Unset channels were stored as 0
+		`	INSERT OR IGNORE INTO GuildSettingValues
+			SELECT GuildID, '` + SettingBuildChannel + `', CAST(BuildChannelID AS TEXT), Timestamp, EditedTimestamp
+			FROM GuildSettings
+			WHERE BuildChannelID != 0
+		`,
+		`	INSERT OR IGNORE INTO GuildSettingValues
+			SELECT GuildID, '` + SettingTicketCategory + `', CAST(TicketChannelCategoryID AS TEXT), Timestamp, EditedTimestamp
+			FROM GuildSettings
+			WHERE TicketChannelCategoryID != 0
+		`,
+		// SQLite can't drop columns from old versions of tables
+		// so the table is made again without them
+		`	CREATE TABLE GuildSettingsMigrated (
+				GuildID 		INTEGER NOT NULL,
+				Timestamp		TEXT	NOT NULL,
+				EditedTimestamp	TEXT	NOT NULL,
+
+				PRIMARY KEY (GuildID)
+			)
+		`,
+		`	INSERT INTO GuildSettingsMigrated
+			SELECT GuildID, Timestamp, EditedTimestamp
+			FROM GuildSettings
+		`,
+		`DROP TABLE GuildSettings`,
+		`ALTER TABLE GuildSettingsMigrated RENAME TO GuildSettings`,
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			return errors.Wrap(err, "database query failed")
+		}
+	}
+	return nil
+}
+
+// migrateVersionColumns adds the columns describing snapshots,
+// pre-releases and four component versions to Versions
+// Existing versions are releases without a fourth component
+func migrateVersionColumns(tx *sql.Tx) error {
+	columns := []struct {
+		name       string
+		definition string
+	}{
+		{"Revision", "INTEGER NOT NULL DEFAULT 0"},
+		{"Type", "INTEGER NOT NULL DEFAULT 0"},
+		{"PreRelease", "INTEGER NOT NULL DEFAULT 0"},
+		{"Snapshot", "TEXT NOT NULL DEFAULT ''"},
+	}
+	for _, c := range columns {
+		if ok, err := hasColumn(tx, "Versions", c.name); err != nil {
+			return err
+		} else if ok {
+			continue
+		}
+		if _, err := tx.Exec(`ALTER TABLE Versions ADD COLUMN ` + c.name + ` ` + c.definition); err != nil {
+			return errors.Wrapf(err, "failed to add column %s", c.name)
+		}
+	}
+	return nil
+}
+
+// migrateOutboxRecordIDs adds the column of the records whose messages
+// a deletion affected to Outbox
+// Existing entries didn't keep them
+func migrateOutboxRecordIDs(tx *sql.Tx) error {
+	if ok, err := hasColumn(tx, "Outbox", "RecordIDs"); err != nil || ok {
+		return err
+	}
+	if _, err := tx.Exec(`ALTER TABLE Outbox ADD COLUMN RecordIDs TEXT NOT NULL DEFAULT ''`); err != nil {
+		return errors.Wrap(err, "failed to add column RecordIDs")
+	}
+	return nil
+}
+
+// migrateBuildClassRule adds the column of the rule a build class's
+// builds follow. Classes are given the rule their name used to imply
+func migrateBuildClassRule(tx *sql.Tx) error {
+	if ok, err := hasColumn(tx, "BuildClasses", "Rule"); err != nil || ok {
+		return err
+	}
+	if _, err := tx.Exec(`ALTER TABLE BuildClasses ADD COLUMN Rule TEXT NOT NULL DEFAULT ''`); err != nil {
+		return errors.Wrap(err, "failed to add column Rule")
+	}
+	for _, rule := range []string{BuildClassRuleDoor, BuildClassRuleExtender} {
+		if _, err := tx.Exec(`
+			UPDATE BuildClasses
+			SET Rule = ?
+			WHERE Rule = '' AND LOWER(Name) LIKE ?
+		`, rule, "%"+rule+"%"); err != nil {
+			return errors.Wrapf(err, "failed to set rule %s", rule)
+		}
+	}
+	return nil
+}
@@ -108,10 +108,10 @@ func (r Record) BuildRecords(buildID string) ([]BuildRecord, error) {
 			return nil, errors.Wrap(err, "failed to extract data")
 		}
 		// Parse timestamps
-		if verifiedTimestamp, err = time.Parse(timeLayout, verifiedTimestampString); err != nil {
+		if verifiedTimestamp, err = parseTimestamp(verifiedTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse verified timestamp")
 		}
-		if reportedTimestamp, err = time.Parse(timeLayout, reportedTimestampString); err != nil {
+		if reportedTimestamp, err = parseTimestamp(reportedTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse reported timestamp")
 		}
 		if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
@@ -197,10 +197,10 @@ func (r Record) BuildRecordsAll() ([]BuildRecord, error) {
 			return nil, errors.Wrap(err, "failed to extract data")
 		}
 		// Parse timestamps
-		if verifiedTimestamp, err = time.Parse(timeLayout, verifiedTimestampString); err != nil {
+		if verifiedTimestamp, err = parseTimestamp(verifiedTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse verified timestamp")
 		}
-		if reportedTimestamp, err = time.Parse(timeLayout, reportedTimestampString); err != nil {
+		if reportedTimestamp, err = parseTimestamp(reportedTimestampString); err != nil {
 			return nil, errors.Wrap(err, "failed to parse reported timestamp")
 		}
 		if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
@@ -303,3 +303,59 @@ func (r Record) GuildRecordMessages() ([]GuildRecordMessage, error) {
 	}
 	return results, nil
 }
+
+// CurrentBuildRecords gets the build records of the builds which
+// currently hold the record. This is the most recently submitted
+// verified build record and the verified build records that tie with it
+// Build records that have been reported are ignored
+func (r Record) CurrentBuildRecords() ([]BuildRecord, error) {
+	brs, err := r.BuildRecordsAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get build records")
+	}
+	// Index the verified and unreported build records
+	valid := map[string]BuildRecord{}
+	for _, br := range brs {
+		if br.Verified && !br.Reported {
+			valid[br.ID] = br
+		}
+	}
+	// rootID follows joint build records back to the
+	// first build record of the tie
+	rootID := func(br BuildRecord) string {
+		seen := map[string]bool{}
+		for br.JointBuildRecord && !seen[br.ID] {
+			seen[br.ID] = true
+			parent, ok := valid[br.JointBuildRecordID]
+			if !ok {
+				return br.JointBuildRecordID
+			}
+			br = parent
+		}
+		return br.ID
+	}
+	// Find the most recent build record that isn't a joint build record
+	var (
+		latest BuildRecord
+		found  bool
+	)
+	for _, br := range brs {
+		if _, ok := valid[br.ID]; !ok || br.JointBuildRecord {
+			continue
+		}
+		if !found || time.Time(br.Timestamp).After(time.Time(latest.Timestamp)) {
+			latest, found = br, true
+		}
+	}
+	if !found {
+		return []BuildRecord{}, nil
+	}
+	// Collect the build records that tie with it
+	results := []BuildRecord{}
+	for _, br := range brs {
+		if _, ok := valid[br.ID]; ok && rootID(br) == latest.ID {
+			results = append(results, br)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,172 @@
+package database
+
+import (
+	"database/sql"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CheckSchema checks that the database file at path has every table
+// with the columns that the package expects once it's been opened
+// The file isn't changed. Instead its schema is copied into memory and
+// the tables that would be created and the migrations that would be
+// ran when the database is opened are applied to the copy, so only
+// problems that opening the database wouldn't fix are reported
+func CheckSchema(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return errors.Wrap(err, "failed to find database file")
+	}
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return errors.Wrap(err, "failed to open database connection")
+	}
+	defer db.Close()
+	// The expected schema is made by creating the tables in memory
+	expected, err := memoryDatabase()
+	if err != nil {
+		return err
+	}
+	defer expected.Close()
+	if err = createTables(expected); err != nil {
+		return errors.Wrap(err, "failed to create expected tables")
+	}
+	want, err := schema(expected)
+	if err != nil {
+		return errors.Wrap(err, "failed to read expected schema")
+	}
+	// The database's schema is upgraded in the same way as when it's opened
+	upgraded, err := memoryDatabase()
+	if err != nil {
+		return err
+	}
+	defer upgraded.Close()
+	if err = copySchema(db, upgraded); err != nil {
+		return errors.Wrap(err, "failed to copy schema")
+	}
+	if err = createTables(upgraded); err != nil {
+		return errors.Wrap(err, "failed to create tables")
+	}
+	if err = migrate(upgraded); err != nil {
+		return errors.Wrap(err, "database can't be migrated")
+	}
+	have, err := schema(upgraded)
+	if err != nil {
+		return errors.Wrap(err, "failed to read schema")
+	}
+	problems := []string{}
+	for table, columns := range want {
+		got, ok := have[table]
+		if !ok {
+			problems = append(problems, "missing table "+table)
+			continue
+		}
+		for _, c := range columns {
+			if !contains(got, c) {
+				problems = append(problems, "missing column "+table+"."+c)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.Errorf("database schema doesn't match: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// memoryDatabase opens an empty in memory database
+// Every connection to :memory: is a different database
+// so only one connection is used
+func memoryDatabase() (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open in memory database")
+	}
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// copySchema creates the tables and indexes of one database in another
+// Tables made by sqlite itself, such as sqlite_sequence, are left out
+func copySchema(from, to *sql.DB) error {
+	rows, err := from.Query(`
+		SELECT sql
+		FROM sqlite_master
+		WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%'
+		ORDER BY CASE type WHEN 'table' THEN 0 ELSE 1 END
+	`)
+	if err != nil {
+		return errors.Wrap(err, "database query failed")
+	}
+	statements := []string{}
+	for rows.Next() {
+		var statement string
+		if err = rows.Scan(&statement); err != nil {
+			rows.Close()
+			return errors.Wrap(err, "failed to extract data")
+		}
+		statements = append(statements, statement)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return errors.Wrap(err, "failed to extract data")
+	}
+	for _, statement := range statements {
+		if _, err = to.Exec(statement); err != nil {
+			return errors.Wrap(err, "failed to execute query")
+		}
+	}
+	return nil
+}
+
+// schema gets the columns of each table in a database
+func schema(db *sql.DB) (map[string][]string, error) {
+	rows, err := db.Query(`
+		SELECT name
+		FROM sqlite_master
+		WHERE type = 'table'
+	`)
+	if err != nil {
+		return nil, errors.Wrap(err, "database query failed")
+	}
+	tables := []string{}
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, errors.Wrap(err, "failed to extract data")
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+	result := map[string][]string{}
+	for _, table := range tables {
+		// Table names come from sqlite_master so can be quoted safely
+		columns, err := db.Query(`SELECT name FROM pragma_table_info('` + strings.Replace(table, "'", "''", -1) + `')`)
+		if err != nil {
+			return nil, errors.Wrap(err, "database query failed")
+		}
+		for columns.Next() {
+			var name string
+			if err = columns.Scan(&name); err != nil {
+				columns.Close()
+				return nil, errors.Wrap(err, "failed to extract data")
+			}
+			result[table] = append(result[table], name)
+		}
+		columns.Close()
+	}
+	return result, nil
+}
+
+// contains indicates whether a list of strings contains a string
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,394 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Keys of the settings that guilds can change
+const (
+	// SettingPrefix is the prefix of message commands
+	// It's empty to use the bot's default prefix
+	SettingPrefix = "prefix"
+	// SettingBuildChannel is the channel that builds are posted in
+	SettingBuildChannel = "build_channel"
+	// SettingTicketCategory is the category that tickets are made in
+	SettingTicketCategory = "ticket_category"
+	// SettingLogChannel is the channel that moderation actions are logged in
+	SettingLogChannel = "log_channel"
+	// SettingModeratorRoles are the roles whose members are moderators
+	SettingModeratorRoles = "moderator_roles"
+	// SettingLocale is the language the bot replies in
+	SettingLocale = "locale"
+	// SettingRequiredApprovals is the number of verifiers that need
+	// to approve a submission before it's verified
+	SettingRequiredApprovals = "required_approvals"
+	// SettingAutoPostBuilds indicates whether verified builds are
+	// posted in the build channel
+	SettingAutoPostBuilds = "auto_post_builds"
+	// SettingAutoPostRecords indicates whether verified records are
+	// posted in the channels of their record types
+	SettingAutoPostRecords = "auto_post_records"
+)
+
+// SettingType is the type of value a setting has
+type SettingType int
+
+const (
+	// SettingText is a line of text
+	SettingText SettingType = iota
+	// SettingChannel is the id of a channel, or empty for no channel
+	SettingChannel
+	// SettingRoles is a list of role ids
+	SettingRoles
+	// SettingInt is a whole number
+	SettingInt
+	// SettingBool is true or false
+	SettingBool
+	// SettingChoice is one of a list of choices
+	SettingChoice
+)
+
+// String gets the name of the setting type
+func (t SettingType) String() string {
+	switch t {
+	case SettingText:
+		return "text"
+	case SettingChannel:
+		return "channel"
+	case SettingRoles:
+		return "roles"
+	case SettingInt:
+		return "number"
+	case SettingBool:
+		return "true or false"
+	case SettingChoice:
+		return "choice"
+	}
+	return "unknown"
+}
+
+// SettingDefinition describes a setting that guilds can change
+type SettingDefinition struct {
+	// Key identifies the setting
+	Key string
+	// Description describes what the setting does
+	Description string
+	// Type is the type of value the setting has
+	Type SettingType
+	// Default is the value of the setting until it's changed
+	Default string
+
+	// Choices are the values of choice settings
+	Choices []string
+	// Min and Max are the smallest and largest values of number settings
+	Min, Max int
+	// MaxLength is the longest a text setting can be
+	MaxLength int
+}
+
+// SettingDefinitions are the settings that guilds can change
+var SettingDefinitions = []SettingDefinition{
+	{
+		Key:         SettingPrefix,
+		Description: "The prefix of message commands, empty for the default",
+		Type:        SettingText,
+		MaxLength:   5,
+	},
+	{
+		Key:         SettingBuildChannel,
+		Description: "The channel that verified builds are posted in",
+		Type:        SettingChannel,
+	},
+	{
+		Key:         SettingTicketCategory,
+		Description: "The category that tickets are made in",
+		Type:        SettingChannel,
+	},
+	{
+		Key:         SettingLogChannel,
+		Description: "The channel that moderation actions are logged in",
+		Type:        SettingChannel,
+	},
+	{
+		Key:         SettingModeratorRoles,
+		Description: "The roles whose members are moderators",
+		Type:        SettingRoles,
+	},
+	{
+		Key:         SettingLocale,
+		Description: "The language the bot replies in",
+		Type:        SettingChoice,
+		Default:     "en-GB",
+		Choices:     []string{"en-GB", "en-US"},
+	},
+	{
+		Key:         SettingRequiredApprovals,
+		Description: "The number of verifiers that need to approve a submission",
+		Type:        SettingInt,
+		Default:     "1",
+		Min:         1,
+		Max:         10,
+	},
+	{
+		Key:         SettingAutoPostBuilds,
+		Description: "Whether verified builds are posted in the build channel",
+		Type:        SettingBool,
+		Default:     "true",
+	},
+	{
+		Key:         SettingAutoPostRecords,
+		Description: "Whether verified records are posted in their record type's channel",
+		Type:        SettingBool,
+		Default:     "true",
+	},
+}
+
+// SettingError is an error caused by giving a setting
+// a value that can't be used
+type SettingError struct {
+	// Key is the key of the setting
+	Key string
+	// Message describes why the value can't be used
+	Message string
+}
+
+// Error describes the setting error
+func (e *SettingError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Key, e.Message)
+}
+
+// settingErrorf creates a setting error with a formatted message
+func settingErrorf(key, format string, args ...interface{}) error {
+	return &SettingError{Key: key, Message: fmt.Sprintf(format, args...)}
+}
+
+// FindSetting gets the definition of a setting from its key
+func FindSetting(key string) (SettingDefinition, bool) {
+	for _, sd := range SettingDefinitions {
+		if sd.Key == key {
+			return sd, true
+		}
+	}
+	return SettingDefinition{}, false
+}
+
+// idPattern matches an id or a mention of a channel or role
+var idPattern = regexp.MustCompile(`^(?:<#|<@&)?(\d+)>?$`)
+
+// Normalize validates a value for the setting and gets the form it's stored in
+// Channels and roles may be given as mentions and lists of roles are
+// separated by commas or spaces. A SettingError is returned if the
+// value is invalid
+func (sd SettingDefinition) Normalize(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch sd.Type {
+	case SettingText:
+		if strings.ContainsAny(value, "\n\t") {
+			return "", settingErrorf(sd.Key, "it must be on one line")
+		}
+		if sd.MaxLength > 0 && len(value) > sd.MaxLength {
+			return "", settingErrorf(sd.Key, "it can't be longer than %d characters", sd.MaxLength)
+		}
+		return value, nil
+	case SettingChannel:
+		if value == "" || value == "none" {
+			return "", nil
+		}
+		m := idPattern.FindStringSubmatch(value)
+		if m == nil {
+			return "", settingErrorf(sd.Key, "%q isn't a channel", value)
+		}
+		return m[1], nil
+	case SettingRoles:
+		ids := []string{}
+		for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+			m := idPattern.FindStringSubmatch(field)
+			if m == nil {
+				return "", settingErrorf(sd.Key, "%q isn't a role", field)
+			}
+			if !contains(ids, m[1]) {
+				ids = append(ids, m[1])
+			}
+		}
+		return strings.Join(ids, ","), nil
+	case SettingInt:
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return "", settingErrorf(sd.Key, "%q isn't a whole number", value)
+		}
+		if i < sd.Min || i > sd.Max {
+			return "", settingErrorf(sd.Key, "it must be between %d and %d", sd.Min, sd.Max)
+		}
+		return strconv.Itoa(i), nil
+	case SettingBool:
+		switch strings.ToLower(value) {
+		case "true", "yes", "on", "1":
+			return "true", nil
+		case "false", "no", "off", "0":
+			return "false", nil
+		}
+		return "", settingErrorf(sd.Key, "%q isn't true or false", value)
+	case SettingChoice:
+		for _, c := range sd.Choices {
+			if strings.EqualFold(c, value) {
+				return c, nil
+			}
+		}
+		return "", settingErrorf(sd.Key, "it must be one of %s", strings.Join(sd.Choices, ", "))
+	}
+	return "", errors.Errorf("unknown setting type %d", sd.Type)
+}
+
+// SettingValue is the value of a setting of a guild
+type SettingValue struct {
+	// Definition is the definition of the setting
+	Definition SettingDefinition
+	// Value is the value in the form it's stored in
+	Value string
+	// IsDefault indicates whether the setting has its default value
+	IsDefault bool
+}
+
+// String gets the value as text
+func (v SettingValue) String() string {
+	return v.Value
+}
+
+// ID gets the id of a channel setting
+// It's empty if no channel is set
+func (v SettingValue) ID() string {
+	return v.Value
+}
+
+// IDs gets the ids of a roles setting
+func (v SettingValue) IDs() []string {
+	if v.Value == "" {
+		return []string{}
+	}
+	return strings.Split(v.Value, ",")
+}
+
+// Int gets the value of a number setting
+func (v SettingValue) Int() int {
+	i, _ := strconv.Atoi(v.Value)
+	return i
+}
+
+// Bool gets the value of a true or false setting
+func (v SettingValue) Bool() bool {
+	return v.Value == "true"
+}
+
+// GuildSettingGet gets the value of a setting of a guild
+// The default value is returned if the guild hasn't changed it
+func (d *Database) GuildSettingGet(guildID, key string) (SettingValue, error) {
+	sd, ok := FindSetting(key)
+	if !ok {
+		return SettingValue{}, errors.Errorf("unknown setting %q", key)
+	}
+	value, ok, err := d.guildSettingValue(guildID, key)
+	if err != nil {
+		return SettingValue{}, errors.Wrap(err, "failed to get guild setting value")
+	} else if !ok {
+		return SettingValue{Definition: sd, Value: sd.Default, IsDefault: true}, nil
+	}
+	return SettingValue{Definition: sd, Value: value}, nil
+}
+
+// GuildSettingValues gets the value of every setting of a guild
+// in the order of SettingDefinitions
+func (d *Database) GuildSettingValues(guildID string) ([]SettingValue, error) {
+	values := []SettingValue{}
+	for _, sd := range SettingDefinitions {
+		v, err := d.GuildSettingGet(guildID, sd.Key)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// GuildSettingSet changes the value of a setting of a guild and records
+// the change. changedByID is the id of the user making the change and
+// may be empty. The guild's setting information is created if it
+// doesn't exist. A SettingError is returned if the value is invalid
+func (d *Database) GuildSettingSet(guildID, key, value, changedByID string) (SettingValue, error) {
+	sd, ok := FindSetting(key)
+	if !ok {
+		return SettingValue{}, errors.Errorf("unknown setting %q", key)
+	}
+	value, err := sd.Normalize(value)
+	if err != nil {
+		return SettingValue{}, err
+	}
+	err = d.Transaction(func(tx *Database) error {
+		old, err := tx.GuildSettingGet(guildID, key)
+		if err != nil {
+			return err
+		}
+		if _, _, err = tx.GuildSettingCreate(guildID); err != nil {
+			return errors.Wrap(err, "failed to create guild setting")
+		}
+		if err = tx.guildSettingValueSet(guildID, key, value); err != nil {
+			return errors.Wrap(err, "failed to set guild setting value")
+		}
+		if err = tx.guildSettingTouch(guildID); err != nil {
+			return errors.Wrap(err, "failed to edit guild setting")
+		}
+		return errors.Wrap(tx.guildSettingChangeCreate(GuildSettingChange{
+			GuildID:     guildID,
+			Key:         key,
+			OldValue:    old.Value,
+			NewValue:    value,
+			ChangedByID: changedByID,
+			Timestamp:   Timestamp(time.Now()),
+		}), "failed to record guild setting change")
+	})
+	if err != nil {
+		return SettingValue{}, err
+	}
+	return SettingValue{Definition: sd, Value: value}, nil
+}
+
+// GuildSettingReset changes a setting of a guild back to its default value
+// and records the change. false is returned if it already had its default
+func (d *Database) GuildSettingReset(guildID, key, changedByID string) (SettingValue, bool, error) {
+	sd, ok := FindSetting(key)
+	if !ok {
+		return SettingValue{}, false, errors.Errorf("unknown setting %q", key)
+	}
+	reset := false
+	err := d.Transaction(func(tx *Database) error {
+		old, err := tx.GuildSettingGet(guildID, key)
+		if err != nil || old.IsDefault {
+			return err
+		}
+		if err = tx.guildSettingValueDelete(guildID, key); err != nil {
+			return errors.Wrap(err, "failed to delete guild setting value")
+		}
+		if err = tx.guildSettingTouch(guildID); err != nil {
+			return errors.Wrap(err, "failed to edit guild setting")
+		}
+		reset = true
+		return errors.Wrap(tx.guildSettingChangeCreate(GuildSettingChange{
+			GuildID:     guildID,
+			Key:         key,
+			OldValue:    old.Value,
+			NewValue:    sd.Default,
+			Reset:       true,
+			ChangedByID: changedByID,
+			Timestamp:   Timestamp(time.Now()),
+		}), "failed to record guild setting change")
+	})
+	if err != nil {
+		return SettingValue{}, false, err
+	}
+	return SettingValue{Definition: sd, Value: sd.Default, IsDefault: true}, reset, nil
+}
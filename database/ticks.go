@@ -0,0 +1,155 @@
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Ticks is a duration in gameticks
+type Ticks int
+
+const (
+	// TicksPerSecond is the number of gameticks in a second
+	TicksPerSecond = 20
+	// TicksPerRedstoneTick is the number of gameticks in a redstone tick
+	TicksPerRedstoneTick = 2
+)
+
+// tickUnits are the suffixes a duration can be given in
+// along with the number of gameticks in one of that unit
+var tickUnits = []struct {
+	suffix string
+	ticks  float64
+}{
+	{"gt", 1},
+	{"rt", TicksPerRedstoneTick},
+	{"s", TicksPerSecond},
+}
+
+// ParseTicks parses a duration into gameticks
+// Durations are given in gameticks such as 27 or 27gt, in redstone
+// ticks such as 13.5rt or in seconds such as 1.35s, and must be
+// a whole number of gameticks
+func ParseTicks(s string) (Ticks, error) {
+	number, perUnit := strings.ToLower(strings.TrimSpace(s)), 1.0
+	for _, u := range tickUnits {
+		if strings.HasSuffix(number, u.suffix) {
+			number, perUnit = strings.TrimSpace(strings.TrimSuffix(number, u.suffix)), u.ticks
+			break
+		}
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, errors.Errorf("%q isn't a duration, durations look like 27gt, 13.5rt or 1.35s", s)
+	}
+	ticks := value * perUnit
+	if math.Abs(ticks-math.Round(ticks)) > 1e-6 {
+		return 0, errors.Errorf("%q isn't a whole number of gameticks", s)
+	}
+	if ticks > math.MaxInt32 {
+		return 0, errors.Errorf("%q is too long", s)
+	}
+	return Ticks(math.Round(ticks)), nil
+}
+
+// Seconds gets the duration in seconds
+func (t Ticks) Seconds() float64 {
+	return float64(t) / TicksPerSecond
+}
+
+// RedstoneTicks gets the duration in redstone ticks
+func (t Ticks) RedstoneTicks() float64 {
+	return float64(t) / TicksPerRedstoneTick
+}
+
+// FormatGameticks formats the duration in gameticks such as 27gt
+func (t Ticks) FormatGameticks() string {
+	return strconv.Itoa(int(t)) + "gt"
+}
+
+// FormatRedstoneTicks formats the duration in redstone ticks such as 13.5rt
+func (t Ticks) FormatRedstoneTicks() string {
+	return strconv.FormatFloat(t.RedstoneTicks(), 'f', -1, 64) + "rt"
+}
+
+// FormatSeconds formats the duration in seconds such as 1.35s
+func (t Ticks) FormatSeconds() string {
+	return fmt.Sprintf("%.2fs", t.Seconds())
+}
+
+// String formats the duration in seconds and gameticks such as 1.35s (27gt)
+func (t Ticks) String() string {
+	return t.FormatSeconds() + " (" + t.FormatGameticks() + ")"
+}
+
+// Add gets the sum of two durations
+func (t Ticks) Add(u Ticks) Ticks {
+	return t + u
+}
+
+// Sub gets the difference between two durations
+func (t Ticks) Sub(u Ticks) Ticks {
+	return t - u
+}
+
+// Mul gets the duration repeated n times
+func (t Ticks) Mul(n int) Ticks {
+	return t * Ticks(n)
+}
+
+// MarshalJSON encodes the duration as a number of gameticks
+func (t Ticks) MarshalJSON() ([]byte, error) {
+	return json.Marshal(int(t))
+}
+
+// UnmarshalJSON decodes a duration given as a number of gameticks
+// or as a string accepted by ParseTicks
+func (t *Ticks) UnmarshalJSON(data []byte) error {
+	var n int
+	if err := json.Unmarshal(data, &n); err == nil {
+		*t = Ticks(n)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.Errorf("%s isn't a duration", data)
+	}
+	ticks, err := ParseTicks(s)
+	if err != nil {
+		return err
+	}
+	*t = ticks
+	return nil
+}
+
+// Value stores the duration as a number of gameticks
+func (t Ticks) Value() (driver.Value, error) {
+	return int64(t), nil
+}
+
+// Scan reads a duration stored as a number of gameticks
+func (t *Ticks) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*t = 0
+	case int64:
+		*t = Ticks(v)
+	case []byte:
+		return t.Scan(string(v))
+	case string:
+		ticks, err := ParseTicks(v)
+		if err != nil {
+			return err
+		}
+		*t = ticks
+	default:
+		return errors.Errorf("can't scan %T into a duration", src)
+	}
+	return nil
+}
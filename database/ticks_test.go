@@ -0,0 +1,165 @@
+package database
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseTicks(t *testing.T) {
+	tests := []struct {
+		text string
+		want Ticks
+		err  bool
+	}{
+		{text: "27gt", want: 27},
+		{text: "27", want: 27},
+		{text: "13.5rt", want: 27},
+		{text: "1.35s", want: 27},
+		{text: " 2 S ", want: 40},
+		{text: "0", want: 0},
+		{text: "0.5rt", want: 1},
+		{text: "0.5gt", err: true},
+		{text: "0.25rt", err: true},
+		{text: "1.01s", err: true},
+		{text: "-1", err: true},
+		{text: "-13.5rt", err: true},
+		{text: "", err: true},
+		{text: "gt", err: true},
+		{text: "27ms", err: true},
+		{text: "NaN", err: true},
+		{text: "Inf", err: true},
+		{text: "1e10s", err: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTicks(tt.text)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParseTicks(%q) = %d, expected an error", tt.text, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseTicks(%q) = %d, %v, expected %d", tt.text, got, err, tt.want)
+		}
+	}
+}
+
+func TestTicksFormat(t *testing.T) {
+	tests := []struct {
+		ticks     Ticks
+		gameticks string
+		redstone  string
+		seconds   string
+	}{
+		{27, "27gt", "13.5rt", "1.35s"},
+		{40, "40gt", "20rt", "2.00s"},
+		{0, "0gt", "0rt", "0.00s"},
+	}
+	for _, tt := range tests {
+		if got := tt.ticks.FormatGameticks(); got != tt.gameticks {
+			t.Errorf("%d.FormatGameticks() = %q, expected %q", tt.ticks, got, tt.gameticks)
+		}
+		if got := tt.ticks.FormatRedstoneTicks(); got != tt.redstone {
+			t.Errorf("%d.FormatRedstoneTicks() = %q, expected %q", tt.ticks, got, tt.redstone)
+		}
+		if got := tt.ticks.FormatSeconds(); got != tt.seconds {
+			t.Errorf("%d.FormatSeconds() = %q, expected %q", tt.ticks, got, tt.seconds)
+		}
+		// Each format can be parsed back into the same duration
+		for _, s := range []string{tt.gameticks, tt.redstone, tt.seconds} {
+			if got, err := ParseTicks(s); err != nil || got != tt.ticks {
+				t.Errorf("ParseTicks(%q) = %d, %v, expected %d", s, got, err, tt.ticks)
+			}
+		}
+	}
+}
+
+func TestTicksJSON(t *testing.T) {
+	data, err := json.Marshal(struct{ Close Ticks }{27})
+	if err != nil || string(data) != `{"Close":27}` {
+		t.Fatalf("expected the duration to be encoded as gameticks, got %s, %v", data, err)
+	}
+	tests := []struct {
+		json string
+		want Ticks
+		err  bool
+	}{
+		{json: `27`, want: 27},
+		{json: `"27gt"`, want: 27},
+		{json: `"13.5rt"`, want: 27},
+		{json: `"1.35s"`, want: 27},
+		{json: `"0.5gt"`, err: true},
+		{json: `"-1"`, err: true},
+		{json: `1.5`, err: true},
+		{json: `true`, err: true},
+	}
+	for _, tt := range tests {
+		var got Ticks
+		err := json.Unmarshal([]byte(tt.json), &got)
+		if tt.err {
+			if err == nil {
+				t.Errorf("unmarshaling %s gave %d, expected an error", tt.json, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("unmarshaling %s gave %d, %v, expected %d", tt.json, got, err, tt.want)
+		}
+		// The decoded duration round-trips through its encoding
+		data, err := json.Marshal(got)
+		if err != nil {
+			t.Fatalf("failed to marshal %d: %v", got, err)
+		}
+		var again Ticks
+		if err = json.Unmarshal(data, &again); err != nil || again != got {
+			t.Errorf("round-tripping %d gave %d, %v", got, again, err)
+		}
+	}
+}
+
+func TestTicksSQL(t *testing.T) {
+	value, err := Ticks(27).Value()
+	if err != nil || value != int64(27) {
+		t.Fatalf("expected the duration to be stored as gameticks, got %v (%T), %v", value, value, err)
+	}
+	tests := []struct {
+		src  interface{}
+		want Ticks
+		err  bool
+	}{
+		{src: int64(27), want: 27},
+		{src: nil, want: 0},
+		{src: "13.5rt", want: 27},
+		{src: []byte("27"), want: 27},
+		{src: "0.5gt", err: true},
+		{src: 27.0, err: true},
+	}
+	for _, tt := range tests {
+		got := Ticks(1)
+		err := got.Scan(tt.src)
+		if tt.err {
+			if err == nil {
+				t.Errorf("scanning %#v gave %d, expected an error", tt.src, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("scanning %#v gave %d, %v, expected %d", tt.src, got, err, tt.want)
+		}
+	}
+
+	// Durations are saved and read back through the database
+	f := newFixture(t)
+	b := f.build(t, f.java, f.piston)
+	b.NormalCloseDuration, b.NormalOpenDuration, b.ExtensionDuration = 27, 40, 3
+	if _, _, err = testDB.BuildEdit(b.ID, b); err != nil {
+		t.Fatalf("failed to edit build: %v", err)
+	}
+	got, _, err := testDB.Build(b.ID)
+	if err != nil {
+		t.Fatalf("failed to get build: %v", err)
+	}
+	if got.NormalCloseDuration != 27 || got.NormalOpenDuration != 40 || got.ExtensionDuration != 3 {
+		t.Fatalf("expected the durations to be saved, got %+v", got)
+	}
+}
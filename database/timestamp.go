@@ -0,0 +1,33 @@
+package database
+
+import (
+	"strings"
+	"time"
+)
+
+// MarshalJSON encodes the timestamp in the same way as time.Time
+// so that rows containing timestamps can be json encoded
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return time.Time(t).MarshalJSON()
+}
+
+// UnmarshalJSON decodes a timestamp encoded by MarshalJSON
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	var tt time.Time
+	if err := tt.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	*t = Timestamp(tt)
+	return nil
+}
+
+// parseTimestamp parses a timestamp stored in the database
+// Timestamps stored in INTEGER columns lose their leading zeros
+// (e.g. the zero time) so they are padded back to the length
+// of timeLayout before being parsed
+func parseTimestamp(s string) (time.Time, error) {
+	if len(s) < len(timeLayout) {
+		s = strings.Repeat("0", len(timeLayout)-len(s)) + s
+	}
+	return time.Parse(timeLayout, s)
+}
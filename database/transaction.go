@@ -0,0 +1,31 @@
+package database
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Transaction runs fn within a database transaction
+// All queries made through the database passed to fn are made within
+// the transaction. The transaction is committed if fn returns nil and
+// is rolled back otherwise
+// Transactions started within fn are part of the outer transaction
+func (d *Database) Transaction(fn func(tx *Database) error) error {
+	// Nested transactions join the outer transaction
+	if d.tx != nil {
+		return fn(d)
+	}
+	tx, err := d.conn.Begin()
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err = fn(&Database{db: tx, conn: d.conn, tx: tx, hooks: d.hooks}); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return errors.Wrapf(err, "failed to roll back transaction (%v)", rollbackErr)
+		}
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	return nil
+}
@@ -6,7 +6,26 @@ import (
 )
 
 // Database is an instance of a database connection
-type Database struct{ db *sql.DB }
+type Database struct {
+	// db is what queries are made through
+	// This is conn, or tx within a transaction
+	db queryer
+	// conn is the database connection
+	conn *sql.DB
+	// tx is the transaction queries are made within
+	// This is nil outside of a transaction
+	tx *sql.Tx
+	// hooks are run when rows are created
+	// They're shared by the database and its transactions
+	hooks *hooks
+}
+
+// queryer makes queries on a database
+// It's implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	Prepare(query string) (*sql.Stmt, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
 
 // Timestamp is a time
 type Timestamp time.Time
@@ -32,6 +51,51 @@ const (
 	TicketSubmitRecordUpdate
 )
 
+// Capability is something that members of a guild can be allowed
+// to do by giving a role the capability
+type Capability int
+
+const (
+	// CapabilityVerify allows verifying builds and records
+	// and correcting their details
+	CapabilityVerify Capability = iota + 1
+	// CapabilityReportReview allows reviewing reported builds
+	CapabilityReportReview
+	// CapabilityStrike allows giving, editing and removing user strikes
+	CapabilityStrike
+	// CapabilityTicketStaff allows seeing and closing the tickets of others
+	CapabilityTicketStaff
+	// CapabilityAdmin allows everything, including deleting rows
+	// and giving capabilities to roles
+	CapabilityAdmin
+)
+
+// OutboxKind indicates what type of row an outbox entry is for
+type OutboxKind int
+
+const (
+	// OutboxBuild is an outbox entry for a change to a build
+	OutboxBuild OutboxKind = iota
+	// OutboxRecord is an outbox entry for a change to a record
+	OutboxRecord
+	// OutboxBuildRecord is an outbox entry for a change
+	// to a build record
+	OutboxBuildRecord
+)
+
+// String gets the name of the outbox kind
+func (k OutboxKind) String() string {
+	switch k {
+	case OutboxBuild:
+		return "build"
+	case OutboxRecord:
+		return "record"
+	case OutboxBuildRecord:
+		return "buildrecord"
+	}
+	return "unknown"
+}
+
 // Table Elements
 
 // UserStrike is a strike which has been given to a user
@@ -59,15 +123,9 @@ type UserStrike struct {
 // GuildSetting contains guild specific settings
 type GuildSetting struct {
 	// GuildID is the id of the guild the settings apply to
+	// The values of the settings are got with GuildSettingGet
 	GuildID string
 
-	// BuildChannelID is the id of the channel within the guild
-	// in which build messages are to be sent to and reside in
-	BuildChannelID string
-	// TicketChannelCategoryID is the id of the category in which
-	// ticket channels are to be made within
-	TicketChannelCategoryID string
-
 	// Timestamp is the time in which the guild setting was first
 	// created. This coinsides with when the application was
 	// first initializes (joined) for a specific guild
@@ -77,6 +135,44 @@ type GuildSetting struct {
 	EditedTimestamp Timestamp
 }
 
+// GuildArchive is a snapshot of the rows of a guild
+// taken when the bot left the guild
+type GuildArchive struct {
+	// ID is the id of the archive
+	ID string
+	// GuildID is the id of the guild the rows belonged to
+	GuildID string
+
+	// Data is the json encoded rows of each table
+	Data string
+
+	// Timestamp is the time the archive was made
+	Timestamp Timestamp
+}
+
+// GuildSettingChange is a change made to a setting of a guild
+type GuildSettingChange struct {
+	// ID is the id of the change
+	ID string
+	// GuildID is the id of the guild the setting applies to
+	GuildID string
+	// Key is the key of the setting
+	Key string
+
+	// OldValue is the value of the setting before the change
+	OldValue string
+	// NewValue is the value of the setting after the change
+	NewValue string
+	// Reset indicates whether the setting was reset to its default
+	Reset bool
+
+	// ChangedByID is the id of the user that made the change
+	// It's empty if the change wasn't made by a user
+	ChangedByID string
+	// Timestamp is the time the change was made
+	Timestamp Timestamp
+}
+
 // Edition is a Minecraft edition
 type Edition struct {
 	// ID is the id of the edition within the database
@@ -106,6 +202,9 @@ type BuildClass struct {
 	// EmbedColour is the colour to be used for discord
 	// embeds for messages related to the build class
 	EmbedColour string
+	// Rule is the key of the rule the builds of the class follow,
+	// such as BuildClassRuleDoor, or empty if there isn't one
+	Rule string
 
 	// Timestamp is the time which the build class was created
 	Timestamp Timestamp
@@ -208,41 +307,41 @@ type Build struct {
 
 	// NormalCloseDuration is the normal closing time (in gameticks)
 	// in accordance with 2.1.2.3 - b.i
-	NormalCloseDuration int
+	NormalCloseDuration Ticks
 	// NormalOpenDuration is the normal opening time (in gameticks)
 	// in accordance with 2.1.2.3 - b.ii
-	NormalOpenDuration int
+	NormalOpenDuration Ticks
 	// VisibleCloseDuration is the visible closing time (in gameticks)
 	// in accordance with 2.1.2.3 - a.i
-	VisibleCloseDuration int
+	VisibleCloseDuration Ticks
 	// VisibleOpenDuration is the visible opening time (in gameticks)
 	// in accordance with 2.1.2.3 - a.ii
-	VisibleOpenDuration int
+	VisibleOpenDuration Ticks
 	// DelayCloseDuration is the closing input delay (in gameticks)
 	// in accordance with 2.1.2.3 - c.i
-	DelayCloseDuration int
+	DelayCloseDuration Ticks
 	// DelayOpenDuration is the opening input delay (in gameticks)
 	// in accordance with 2.1.2.3 - c.ii
-	DelayOpenDuration int
+	DelayOpenDuration Ticks
 	// ResetCloseDuration is the closing reset time (in gameticks)
 	// in accordance with 2.1.2.3 - d.i
-	ResetCloseDuration int
+	ResetCloseDuration Ticks
 	// ResetOpenDuration is the opening reset time (in gameticks)
 	// in accordance with 2.1.2.3 - d.ii
-	ResetOpenDuration int
+	ResetOpenDuration Ticks
 
 	// ExtensionDuration is the normal extension time (in gameticks)
 	// in accordance with 2.3.2.2 - a.i
-	ExtensionDuration int
+	ExtensionDuration Ticks
 	// RetractionDuration is the normal retraction time (in gameticks)
 	// in accordance with 2.3.2.2 - a.ii
-	RetractionDuration int
+	RetractionDuration Ticks
 	// ExtensionDelayDuration is the extension input delay (in gameticks)
 	// in accordance with 2.3.2.2 - b.i
-	ExtensionDelayDuration int
+	ExtensionDelayDuration Ticks
 	// RetractionDelayDuration is the retraction input delay (in gameticks)
 	// in accordance with 2.3.2.2 - b.ii
-	RetractionDelayDuration int
+	RetractionDelayDuration Ticks
 
 	// ImageURL is the URL to an image of the build
 	ImageURL string
@@ -281,6 +380,18 @@ type Version struct {
 	MinorVersion int
 	// Patch if the patch version of the version
 	Patch int
+	// Revision is the fourth component of the version number
+	// e.g. 56 in bedrock's 1.16.100.56, or 0 if there isn't one
+	Revision int
+	// Type is the type of release the version is
+	// Snapshots, pre-releases and release candidates have the
+	// version number of the release they lead up to
+	Type VersionType
+	// PreRelease is the number of a pre-release or release candidate
+	// e.g. 3 in 1.16-pre3
+	PreRelease int
+	// Snapshot is the name of a snapshot e.g. 20w14a
+	Snapshot string
 	// Name is the name of the version
 	Name string
 	// Description is a description of the version
@@ -491,6 +602,99 @@ type GuildTicketChannel struct {
 	Timestamp Timestamp
 }
 
+// GuildRole is a capability given to a role within a discord guild
+type GuildRole struct {
+	// GuildID is the id of the discord guild containing the role
+	GuildID string
+	// RoleID is the id of the role
+	RoleID string
+	// Capability is the capability given to members with the role
+	Capability Capability
+
+	// Timestamp is the time the capability was given
+	Timestamp Timestamp
+}
+
+// TicketWizard is the saved progress of a submission wizard
+// running within a ticket channel
+type TicketWizard struct {
+	// GuildID is the id of the discord guild that contains the ticket
+	GuildID string
+	// ChannelID is the id of the ticket channel the wizard is running in
+	ChannelID string
+
+	// TicketType is the type of the ticket which determines
+	// which wizard is being ran
+	TicketType TicketType
+
+	// Step is the index of the step the wizard is currently on
+	Step int
+	// History is a json encoded list of the steps that have been
+	// visited so that the wizard can go back through them
+	History string
+	// Draft is the json encoded draft that the wizard is building
+	Draft string
+
+	// Timestamp is the time the wizard was started
+	Timestamp Timestamp
+	// EditedTimestamp is the time the wizard was last progressed
+	EditedTimestamp Timestamp
+}
+
+// GuildMessageHash is a hash of the content of a message sent to a guild
+// It's used to determine whether the message needs to be edited
+type GuildMessageHash struct {
+	// MessageID is the id of the message
+	MessageID string
+	// GuildID is the id of the guild the message is within
+	GuildID string
+
+	// Hash is the hash of the content of the message
+	Hash string
+
+	// Timestamp is the time the guild message hash was created
+	Timestamp Timestamp
+	// EditedTimestamp is the time the guild message hash was last edited
+	EditedTimestamp Timestamp
+}
+
+// OutboxEntry is a change to the database whose discord side effects,
+// such as updating messages, haven't been carried out yet
+// Entries are created in the same transaction as the change so that
+// side effects aren't lost if the bot stops before carrying them out
+type OutboxEntry struct {
+	// ID is the id of the outbox entry in the database
+	ID string
+	// IdempotencyKey uniquely identifies the change
+	// Creating an entry with a key that already exists does nothing
+	IdempotencyKey string
+
+	// Kind is the type of row that was changed
+	Kind OutboxKind
+	// EntityID is the id of the row that was changed
+	EntityID string
+	// RecordIDs are the ids of the records whose messages a deletion
+	// affected, as they can't be found from a row that no longer exists
+	RecordIDs []string
+
+	// Attempts is the number of times carrying out the side effects
+	// has failed
+	Attempts int
+	// NextAttemptTimestamp is the earliest time the side effects
+	// should next be attempted
+	NextAttemptTimestamp Timestamp
+	// LastError is the error from the last failed attempt
+	LastError string
+	// Dead indicates whether the entry has failed too many times
+	// and won't be attempted again unless it's replayed
+	Dead bool
+
+	// Timestamp is the time the outbox entry was created
+	Timestamp Timestamp
+	// EditedTimestamp is the time the outbox entry was last edited
+	EditedTimestamp Timestamp
+}
+
 // Other Elements
 
 // UserStrikeCount indicates how many strikes a user has
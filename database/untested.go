@@ -0,0 +1,219 @@
+package database
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// UntestedStatusName is the name of the status builds are given in
+// versions they haven't been re-tested in since the version came out
+const UntestedStatusName = "Untested"
+
+// UntestedStatus gets the status called UntestedStatusName
+// The status is created if it doesn't exist
+func (d *Database) UntestedStatus() (Status, error) {
+	statuses, err := d.Statuses()
+	if err != nil {
+		return Status{}, errors.Wrap(err, "failed to get statuses")
+	}
+	for _, s := range statuses {
+		if strings.EqualFold(s.Name, UntestedStatusName) {
+			return s, nil
+		}
+	}
+	s, err := d.StatusCreate(UntestedStatusName, "The build hasn't been tested in this version yet")
+	if err != nil {
+		return Status{}, errors.Wrap(err, "failed to create untested status")
+	}
+	return s, nil
+}
+
+// AddUntestedBuildVersions gives every verified build of a version's
+// edition the untested status in the version
+// Builds which already have a status in the version are left alone.
+// The build versions that were created are returned
+func (d *Database) AddUntestedBuildVersions(v Version) ([]BuildVersion, error) {
+	created := []BuildVersion{}
+	err := d.Transaction(func(tx *Database) error {
+		untested, err := tx.UntestedStatus()
+		if err != nil {
+			return err
+		}
+		builds, err := tx.Builds()
+		if err != nil {
+			return errors.Wrap(err, "failed to get builds")
+		}
+		for _, b := range builds {
+			if !b.Verified || b.UpdateRequest || b.EditionID != v.EditionID {
+				continue
+			}
+			bv, ok, err := tx.BuildVersionCreate(b.ID, v.ID, untested.ID, "")
+			if err != nil {
+				return errors.Wrap(err, "failed to create build version")
+			} else if !ok {
+				// The build already has a status in the version
+				continue
+			}
+			created = append(created, bv)
+			// The build's messages show its status in each version
+			if err = tx.outboxEntryAdd(OutboxBuild, b.ID, Timestamp(time.Now())); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// UntestedHook is a version hook which gives every verified build
+// the untested status in new versions of its edition
+// It's added with OnVersionCreate
+func UntestedHook(tx *Database, v Version) error {
+	_, err := tx.AddUntestedBuildVersions(v)
+	return err
+}
+
+// RetestItem is a build that needs testing in a version
+type RetestItem struct {
+	// Build is the build to test
+	Build Build
+	// Version is the version to test the build in
+	Version Version
+	// BuildVersion is the build's untested status in the version
+	BuildVersion BuildVersion
+	// Records are the records the build currently holds
+	Records []Record
+}
+
+// RetestQueue gets the builds which have the untested status in
+// versions of an edition, or of every edition if editionID is empty
+// Builds that currently hold the most records come first,
+// followed by the newest versions and then the oldest builds
+func (d *Database) RetestQueue(editionID string) ([]RetestItem, error) {
+	queue := []RetestItem{}
+	statuses, err := d.Statuses()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get statuses")
+	}
+	var untested Status
+	for _, s := range statuses {
+		if strings.EqualFold(s.Name, UntestedStatusName) {
+			untested = s
+		}
+	}
+	if untested.ID == "" {
+		// Nothing can be untested without the status
+		return queue, nil
+	}
+	// Find which records each build currently holds
+	records, err := d.Records()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get records")
+	}
+	held := map[string][]Record{}
+	for _, r := range records {
+		if !r.Verified || r.UpdateRequest {
+			continue
+		}
+		brs, err := r.CurrentBuildRecords()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get current build records")
+		}
+		for _, br := range brs {
+			held[br.BuildID] = append(held[br.BuildID], r)
+		}
+	}
+	bvs, err := d.buildVersionsWithStatus(untested.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, bv := range bvs {
+		b, ok, err := d.Build(bv.BuildID)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get build")
+		} else if !ok || !b.Verified || b.UpdateRequest {
+			continue
+		}
+		if editionID != "" && b.EditionID != editionID {
+			continue
+		}
+		v, ok, err := d.Version(bv.VersionID)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get version")
+		} else if !ok {
+			continue
+		}
+		queue = append(queue, RetestItem{Build: b, Version: v, BuildVersion: bv, Records: held[b.ID]})
+	}
+	sort.SliceStable(queue, func(i, j int) bool {
+		a, b := queue[i], queue[j]
+		if len(a.Records) != len(b.Records) {
+			return len(a.Records) > len(b.Records)
+		}
+		if c := a.Version.Compare(b.Version); c != 0 {
+			return c > 0
+		}
+		ai, _ := strconv.Atoi(a.Build.ID)
+		bi, _ := strconv.Atoi(b.Build.ID)
+		return ai < bi
+	})
+	return queue, nil
+}
+
+// buildVersionsWithStatus gets the build versions which have a status
+func (d *Database) buildVersionsWithStatus(statusID string) ([]BuildVersion, error) {
+	// Convert status id to int
+	statusIDint, err := strconv.Atoi(statusID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert status id to integer")
+	}
+	// Query the database
+	rows, err := d.db.Query(`
+		SELECT BuildID, VersionID, Notes, Timestamp, EditedTimestamp
+		FROM BuildVersions
+		WHERE StatusID = ?
+	`, statusIDint)
+	if err != nil {
+		return nil, errors.Wrap(err, "database query failed")
+	}
+	defer rows.Close()
+	// Extract data
+	var (
+		buildIDint            int
+		versionIDint          int
+		notes                 string
+		timestampString       string
+		editedTimestampString string
+		timestamp             time.Time
+		editedTimestamp       time.Time
+	)
+	results := []BuildVersion{}
+	for rows.Next() {
+		if err = rows.Scan(&buildIDint, &versionIDint, &notes, &timestampString, &editedTimestampString); err != nil {
+			return nil, errors.Wrap(err, "failed to extract data")
+		}
+		// Convert strings to times
+		if timestamp, err = time.Parse(timeLayout, timestampString); err != nil {
+			return nil, errors.Wrap(err, "failed to parse timestamp")
+		}
+		if editedTimestamp, err = time.Parse(timeLayout, editedTimestampString); err != nil {
+			return nil, errors.Wrap(err, "failed to parse edited timestamp")
+		}
+		results = append(results, BuildVersion{
+			BuildID:         strconv.Itoa(buildIDint),
+			VersionID:       strconv.Itoa(versionIDint),
+			StatusID:        statusID,
+			Notes:           notes,
+			Timestamp:       Timestamp(timestamp),
+			EditedTimestamp: Timestamp(editedTimestamp),
+		})
+	}
+	return results, nil
+}
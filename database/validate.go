@@ -0,0 +1,219 @@
+package database
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// FieldError is a problem with a field of a row
+type FieldError struct {
+	// Field is the name of the field such as Width
+	Field string
+	// Message describes the problem
+	Message string
+}
+
+// Error describes the problem
+func (e FieldError) Error() string {
+	return e.Message
+}
+
+// ValidationError is returned when a row has invalid fields
+type ValidationError struct {
+	// Fields are the problems with the row's fields
+	Fields []FieldError
+}
+
+// Error lists the problems with the fields
+func (e *ValidationError) Error() string {
+	messages := []string{}
+	for _, f := range e.Fields {
+		messages = append(messages, f.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// add adds a problem with a field
+func (e *ValidationError) add(field, format string, args ...interface{}) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// err gets the validation error if there are any problems
+func (e *ValidationError) err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Keys of the rules build classes can have
+// The rule is stored on the class rather than worked out from its name,
+// so renaming a class doesn't change what its builds need
+const (
+	// BuildClassRuleDoor is the rule of doors
+	BuildClassRuleDoor = "door"
+	// BuildClassRuleExtender is the rule of piston extenders
+	BuildClassRuleExtender = "extender"
+)
+
+// buildClassRules add the problems with a build of a class with each rule
+// Only the times every build of a class has are required. A zero
+// duration means that it isn't known, but the other times of 2.1.2.3
+// and 2.3.2.2 (visible times, input delays and reset times) can really
+// be zero, such as a door that starts moving on the tick it's powered,
+// and so they can't be required without rejecting those builds
+var buildClassRules = map[string]func(b Build, ve *ValidationError){
+	// Doors are timed in accordance with 2.1.2.3
+	BuildClassRuleDoor: func(b Build, ve *ValidationError) {
+		if b.NormalCloseDuration == 0 || b.NormalOpenDuration == 0 {
+			ve.add("NormalCloseDuration", "doors need their normal closing and opening times (2.1.2.3 - b)")
+		}
+	},
+	// Piston extenders are timed in accordance with 2.3.2.2
+	BuildClassRuleExtender: func(b Build, ve *ValidationError) {
+		if b.ExtensionDuration == 0 || b.RetractionDuration == 0 {
+			ve.add("ExtensionDuration", "piston extenders need their extension and retraction times (2.3.2.2 - a)")
+		}
+	},
+}
+
+// checkBuildClassRule checks that a build class rule exists
+// The empty rule is allowed for classes without a rule of their own
+func checkBuildClassRule(rule string) error {
+	if _, ok := buildClassRules[rule]; rule != "" && !ok {
+		return errors.Errorf("there is no build class rule called %q", rule)
+	}
+	return nil
+}
+
+// Validate checks the fields of the build, including the
+// rules of its build class
+// A *ValidationError is returned listing every invalid field
+func (b Build) Validate() error {
+	db, err := Instance()
+	if err != nil {
+		return errors.Wrap(err, "couldn't get database instance")
+	}
+	return db.validateBuild(b)
+}
+
+// validateBuild checks the fields of a build in the same way as Validate
+func (d *Database) validateBuild(b Build) error {
+	c, _, err := d.BuildClass(b.BuildClassID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get build class")
+	}
+	return b.validate(c)
+}
+
+// validateBuildEdit checks the fields of a build being edited
+// Only problems that the edit introduces are reported, so that builds
+// made before a rule existed can still be verified, reported or have
+// their other fields changed without fixing every problem first
+func (d *Database) validateBuildEdit(old, b Build) error {
+	err := d.validateBuild(b)
+	ve, ok := err.(*ValidationError)
+	if !ok {
+		return err
+	}
+	existing := map[FieldError]bool{}
+	if oldErr, ok := d.validateBuild(old).(*ValidationError); ok {
+		for _, f := range oldErr.Fields {
+			existing[f] = true
+		}
+	}
+	introduced := &ValidationError{}
+	for _, f := range ve.Fields {
+		if !existing[f] {
+			introduced.Fields = append(introduced.Fields, f)
+		}
+	}
+	return introduced.err()
+}
+
+// validate checks the fields of the build given its build class
+// Classes that don't exist have no rules of their own
+func (b Build) validate(c BuildClass) error {
+	ve := &ValidationError{}
+	if strings.TrimSpace(b.Name) == "" {
+		ve.add("Name", "the name can't be empty")
+	}
+	if time.Time(b.CreationTimestamp).After(time.Now()) {
+		ve.add("CreationTimestamp", "the creation time can't be in the future")
+	}
+
+	// Dimensions are either all unknown or all known
+	negative := false
+	for _, d := range []struct {
+		field string
+		value int
+	}{{"Width", b.Width}, {"Height", b.Height}, {"Depth", b.Depth}} {
+		if d.value < 0 {
+			ve.add(d.field, "the %s can't be negative", strings.ToLower(d.field))
+			negative = true
+		}
+	}
+	if d := b.Dimensions(); !negative && d != (Dimensions{}) && !d.Known() {
+		ve.add("Width", "the width, height and depth must all be given")
+	}
+
+	durations := []struct {
+		field string
+		name  string
+		value Ticks
+	}{
+		{"NormalCloseDuration", "normal closing time", b.NormalCloseDuration},
+		{"NormalOpenDuration", "normal opening time", b.NormalOpenDuration},
+		{"VisibleCloseDuration", "visible closing time", b.VisibleCloseDuration},
+		{"VisibleOpenDuration", "visible opening time", b.VisibleOpenDuration},
+		{"DelayCloseDuration", "closing input delay", b.DelayCloseDuration},
+		{"DelayOpenDuration", "opening input delay", b.DelayOpenDuration},
+		{"ResetCloseDuration", "closing reset time", b.ResetCloseDuration},
+		{"ResetOpenDuration", "opening reset time", b.ResetOpenDuration},
+		{"ExtensionDuration", "extension time", b.ExtensionDuration},
+		{"RetractionDuration", "retraction time", b.RetractionDuration},
+		{"ExtensionDelayDuration", "extension input delay", b.ExtensionDelayDuration},
+		{"RetractionDelayDuration", "retraction input delay", b.RetractionDelayDuration},
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			ve.add(d.field, "the %s can't be negative", d.name)
+		}
+	}
+	// The visible time is part of the normal time (2.1.2.3 - a and b)
+	if b.NormalCloseDuration > 0 && b.VisibleCloseDuration > b.NormalCloseDuration {
+		ve.add("VisibleCloseDuration", "the visible closing time can't be longer than the normal closing time")
+	}
+	if b.NormalOpenDuration > 0 && b.VisibleOpenDuration > b.NormalOpenDuration {
+		ve.add("VisibleOpenDuration", "the visible opening time can't be longer than the normal opening time")
+	}
+
+	for _, u := range []struct {
+		field string
+		name  string
+		value string
+	}{
+		{"ImageURL", "image", b.ImageURL},
+		{"YoutubeURL", "video", b.YoutubeURL},
+		{"WorldDownloadURL", "world download", b.WorldDownloadURL},
+	} {
+		if u.value == "" {
+			continue
+		}
+		if parsed, err := url.Parse(u.value); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			ve.add(u.field, "the %s link %q isn't a valid http or https link", u.name, u.value)
+		}
+	}
+	if b.ServerIPAddress == "" && (b.ServerCoordinates != "" || b.ServerCommand != "") {
+		ve.add("ServerIPAddress", "the server ip is needed for the server coordinates and command")
+	}
+
+	if check, ok := buildClassRules[c.Rule]; ok {
+		check(b, ve)
+	}
+	return ve.err()
+}
@@ -0,0 +1,310 @@
+package database
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// validationFields gets the fields of a *ValidationError in order,
+// or an empty list if there's no error
+func validationFields(t *testing.T, err error) []string {
+	t.Helper()
+	fields := []string{}
+	if err == nil {
+		return fields
+	}
+	ve, ok := errors.Cause(err).(*ValidationError)
+	if !ok {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	for _, f := range ve.Fields {
+		fields = append(fields, f.Field)
+	}
+	return fields
+}
+
+func TestBuildValidate(t *testing.T) {
+	door := BuildClass{Name: "Piston Door", Rule: BuildClassRuleDoor}
+	extender := BuildClass{Name: "Piston Extender", Rule: BuildClassRuleExtender}
+	tests := []struct {
+		name   string
+		class  BuildClass
+		edit   func(b *Build)
+		fields []string
+	}{
+		{
+			name: "valid",
+			edit: func(b *Build) {},
+		},
+		{
+			name:   "empty name",
+			edit:   func(b *Build) { b.Name = " " },
+			fields: []string{"Name"},
+		},
+		{
+			name:   "future creation time",
+			edit:   func(b *Build) { b.CreationTimestamp = Timestamp(time.Now().Add(time.Hour)) },
+			fields: []string{"CreationTimestamp"},
+		},
+		{
+			name: "past creation time",
+			edit: func(b *Build) { b.CreationTimestamp = Timestamp(time.Now().Add(-time.Hour)) },
+		},
+		{
+			name:   "negative dimensions",
+			edit:   func(b *Build) { b.Width, b.Depth = -1, -3 },
+			fields: []string{"Width", "Depth"},
+		},
+		{
+			name:   "some dimensions",
+			edit:   func(b *Build) { b.Width, b.Height = 3, 4 },
+			fields: []string{"Width"},
+		},
+		{
+			name:   "negative durations",
+			edit:   func(b *Build) { b.DelayOpenDuration, b.RetractionDuration = -1, -2 },
+			fields: []string{"DelayOpenDuration", "RetractionDuration"},
+		},
+		{
+			name: "visible time longer than the normal time",
+			edit: func(b *Build) {
+				b.NormalCloseDuration, b.VisibleCloseDuration = 20, 21
+				b.NormalOpenDuration, b.VisibleOpenDuration = 20, 21
+			},
+			fields: []string{"VisibleCloseDuration", "VisibleOpenDuration"},
+		},
+		{
+			name: "visible time as long as the normal time",
+			edit: func(b *Build) { b.NormalCloseDuration, b.VisibleCloseDuration = 20, 20 },
+		},
+		{
+			// The visible time can't be checked without the normal time
+			name: "visible time without the normal time",
+			edit: func(b *Build) { b.VisibleCloseDuration = 20 },
+		},
+		{
+			name: "bad links",
+			edit: func(b *Build) {
+				b.ImageURL = "ftp://example.com/door.png"
+				b.YoutubeURL = "youtube.com/watch?v=door"
+				b.WorldDownloadURL = "https://"
+			},
+			fields: []string{"ImageURL", "YoutubeURL", "WorldDownloadURL"},
+		},
+		{
+			name: "good links",
+			edit: func(b *Build) {
+				b.ImageURL = "https://example.com/door.png"
+				b.YoutubeURL = "http://youtube.com/watch?v=door"
+			},
+		},
+		{
+			name:   "server coordinates without an ip",
+			edit:   func(b *Build) { b.ServerCoordinates = "0 64 0" },
+			fields: []string{"ServerIPAddress"},
+		},
+		{
+			name:   "door without times",
+			class:  door,
+			edit:   func(b *Build) { b.NormalCloseDuration = 20 },
+			fields: []string{"NormalCloseDuration"},
+		},
+		{
+			name:  "door with times",
+			class: door,
+			edit:  func(b *Build) { b.NormalCloseDuration, b.NormalOpenDuration = 20, 20 },
+		},
+		{
+			name:   "extender without times",
+			class:  extender,
+			edit:   func(b *Build) {},
+			fields: []string{"ExtensionDuration"},
+		},
+		{
+			name:  "extender with times",
+			class: extender,
+			edit:  func(b *Build) { b.ExtensionDuration, b.RetractionDuration = 4, 2 },
+		},
+		{
+			// Only the rule decides what a class's builds need, not its name
+			name:  "door without the rule",
+			class: BuildClass{Name: "Piston Door"},
+			edit:  func(b *Build) {},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := Build{Name: "Piston Door"}
+			tt.edit(&b)
+			if tt.fields == nil {
+				tt.fields = []string{}
+			}
+			if fields := validationFields(t, b.validate(tt.class)); !reflect.DeepEqual(fields, tt.fields) {
+				t.Fatalf("expected problems with %v, got %v", tt.fields, fields)
+			}
+		})
+	}
+}
+
+func TestValidateBuildEdit(t *testing.T) {
+	c, err := testDB.BuildClassCreate(t.Name()+" Door", "", "", BuildClassRuleDoor)
+	if err != nil {
+		t.Fatalf("failed to create build class: %v", err)
+	}
+	// old is a door made before doors needed their times
+	old := Build{BuildClassID: c.ID, Name: "Piston Door", Width: -1}
+	tests := []struct {
+		name   string
+		edit   func(b *Build)
+		fields []string
+	}{
+		{
+			name: "existing problems",
+			edit: func(b *Build) { b.Name = "Piston Tower" },
+		},
+		{
+			name:   "new problems",
+			edit:   func(b *Build) { b.Name, b.ImageURL = "", "door.png" },
+			fields: []string{"Name", "ImageURL"},
+		},
+		{
+			// The width is still negative, which isn't a new problem
+			name: "same problems",
+			edit: func(b *Build) { b.Width = -2 },
+		},
+		{
+			name: "fixed problems",
+			edit: func(b *Build) { b.Width, b.NormalCloseDuration, b.NormalOpenDuration = 0, 20, 20 },
+		},
+		{
+			name: "some fixed problems",
+			edit: func(b *Build) { b.Width = 0 },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := old
+			tt.edit(&b)
+			if tt.fields == nil {
+				tt.fields = []string{}
+			}
+			if fields := validationFields(t, testDB.validateBuildEdit(old, b)); !reflect.DeepEqual(fields, tt.fields) {
+				t.Fatalf("expected problems with %v, got %v", tt.fields, fields)
+			}
+		})
+	}
+}
+
+func TestBuildClassRule(t *testing.T) {
+	c, err := testDB.BuildClassCreate(t.Name()+" Door", "", "", BuildClassRuleDoor)
+	if err != nil {
+		t.Fatalf("failed to create build class: %v", err)
+	}
+	b := Build{BuildClassID: c.ID, Name: "Piston Door"}
+	if fields := validationFields(t, testDB.validateBuild(b)); len(fields) != 1 || fields[0] != "NormalCloseDuration" {
+		t.Fatalf("expected a problem with the door's times, got %v", fields)
+	}
+
+	// Renaming the class keeps its rule
+	if c, _, err = testDB.BuildClassEdit(c.ID, "Flying Machine", "", "", c.Rule); err != nil {
+		t.Fatalf("failed to edit build class: %v", err)
+	}
+	if got, _, err := testDB.BuildClass(c.ID); err != nil || got.Rule != BuildClassRuleDoor {
+		t.Fatalf("expected the door rule, got %+v, %v", got, err)
+	}
+	if fields := validationFields(t, testDB.validateBuild(b)); len(fields) != 1 {
+		t.Fatalf("expected a problem with the door's times, got %v", fields)
+	}
+	// Removing the rule removes the problem
+	if _, _, err = testDB.BuildClassEdit(c.ID, "Flying Machine", "", "", ""); err != nil {
+		t.Fatalf("failed to edit build class: %v", err)
+	}
+	if fields := validationFields(t, testDB.validateBuild(b)); len(fields) != 0 {
+		t.Fatalf("expected no problems, got %v", fields)
+	}
+
+	if _, err = testDB.BuildClassCreate(t.Name()+" Logic", "", "", "logic"); err == nil {
+		t.Errorf("expected a build class with an unknown rule to be rejected")
+	}
+	if _, _, err = testDB.BuildClassEdit(c.ID, "Flying Machine", "", "", "logic"); err == nil {
+		t.Errorf("expected a build class to not be given an unknown rule")
+	}
+}
+
+func TestMigrateBuildClassRule(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "database.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	// The build classes table before it had rules
+	if _, err = db.Exec(`
+		CREATE TABLE BuildClasses (
+			ID 				INTEGER NOT NULL,
+			Name 			TEXT 	NOT NULL,
+			Description 	TEXT 	NOT NULL,
+			EmbedColour 	TEXT 	NOT NULL,
+			Timestamp 		TEXT	NOT NULL,
+			EditedTimestamp TEXT	NOT NULL,
+
+			PRIMARY KEY (ID)
+		)
+	`); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	names := []string{"Piston Door", "Piston Extenders", "Logic"}
+	for _, name := range names {
+		if _, err = db.Exec(`INSERT INTO BuildClasses (Name, Description, EmbedColour, Timestamp, EditedTimestamp) VALUES (?, '', '', '', '')`, name); err != nil {
+			t.Fatalf("failed to insert build class: %v", err)
+		}
+	}
+	run := func() {
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatalf("failed to begin transaction: %v", err)
+		}
+		if err = migrateBuildClassRule(tx); err != nil {
+			tx.Rollback()
+			t.Fatalf("failed to migrate: %v", err)
+		}
+		if err = tx.Commit(); err != nil {
+			t.Fatalf("failed to commit: %v", err)
+		}
+	}
+	run()
+	// Running it again doesn't give rules to classes named since
+	if _, err = db.Exec(`UPDATE BuildClasses SET Name = 'Doors' WHERE Name = 'Logic'`); err != nil {
+		t.Fatalf("failed to rename build class: %v", err)
+	}
+	run()
+
+	rows, err := db.Query(`SELECT Name, Rule FROM BuildClasses ORDER BY ID`)
+	if err != nil {
+		t.Fatalf("failed to get build classes: %v", err)
+	}
+	defer rows.Close()
+	got := map[string]string{}
+	var name, rule string
+	for rows.Next() {
+		if err = rows.Scan(&name, &rule); err != nil {
+			t.Fatalf("failed to read build class: %v", err)
+		}
+		got[name] = rule
+	}
+	want := map[string]string{"Piston Door": BuildClassRuleDoor, "Piston Extenders": BuildClassRuleExtender, "Doors": ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected rules %v, got %v", want, got)
+	}
+}
@@ -1,7 +1,11 @@
 package database
 
 import (
+	"fmt"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -93,3 +97,386 @@ func (v Version) BuildVersions() ([]BuildVersion, error) {
 	}
 	return results, nil
 }
+
+// VersionType is the type of release a version is
+type VersionType int
+
+const (
+	// VersionRelease is a full release such as 1.16 or 1.16.100.56
+	VersionRelease VersionType = iota
+	// VersionSnapshot is a java snapshot such as 20w14a
+	VersionSnapshot
+	// VersionPreRelease is a java pre-release such as 1.16-pre3
+	VersionPreRelease
+	// VersionReleaseCandidate is a java release candidate such as 1.16-rc1
+	VersionReleaseCandidate
+	// VersionPreview is a bedrock beta or preview such as 1.20.0.20
+	// Previews are numbered like releases
+	VersionPreview
+)
+
+// VersionTypes are the types of version from earliest to latest
+// within the development of a release
+var VersionTypes = []VersionType{VersionPreview, VersionSnapshot, VersionPreRelease, VersionReleaseCandidate, VersionRelease}
+
+// String gets the name of the version type
+func (t VersionType) String() string {
+	switch t {
+	case VersionRelease:
+		return "release"
+	case VersionSnapshot:
+		return "snapshot"
+	case VersionPreRelease:
+		return "pre-release"
+	case VersionReleaseCandidate:
+		return "release-candidate"
+	case VersionPreview:
+		return "preview"
+	}
+	return "unknown"
+}
+
+// Valid indicates whether the version type is one of VersionTypes
+func (t VersionType) Valid() bool {
+	return t.rank() >= 0
+}
+
+// rank gets the position of the version type within VersionTypes
+func (t VersionType) rank() int {
+	for i, vt := range VersionTypes {
+		if vt == t {
+			return i
+		}
+	}
+	return -1
+}
+
+// ParseVersionType parses the name of a version type
+// "pre" and "rc" are accepted as short names and "beta" is the same
+// as "preview"
+func ParseVersionType(s string) (VersionType, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "release", "":
+		return VersionRelease, nil
+	case "snapshot":
+		return VersionSnapshot, nil
+	case "pre-release", "prerelease", "pre":
+		return VersionPreRelease, nil
+	case "release-candidate", "releasecandidate", "rc":
+		return VersionReleaseCandidate, nil
+	case "preview", "beta":
+		return VersionPreview, nil
+	}
+	return 0, errors.Errorf("unknown version type %q, use release, snapshot, pre-release, release-candidate or preview", s)
+}
+
+// VersionNumber is the number of a version such as 1.14.2, 1.16-pre3,
+// 20w14a or 1.16.100.56
+type VersionNumber struct {
+	// Major is the major version
+	Major int
+	// Minor is the minor version
+	Minor int
+	// Patch is the patch version
+	Patch int
+	// Revision is the fourth component e.g. 56 in 1.16.100.56
+	Revision int
+	// HasPatch indicates whether the patch version was given
+	// "1.14" has no patch version, where "1.14.0" does
+	HasPatch bool
+	// HasRevision indicates whether the fourth component was given
+	HasRevision bool
+
+	// Type is the type of release
+	Type VersionType
+	// PreRelease is the number of a pre-release or release candidate
+	PreRelease int
+	// Snapshot is the name of a snapshot e.g. 20w14a
+	// A snapshot parsed from its name alone has no release numbers
+	Snapshot string
+}
+
+// VersionError is returned when a version number can't be parsed
+type VersionError struct {
+	// Text is the text that couldn't be parsed
+	Text string
+}
+
+// Error describes the version that couldn't be parsed
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("%q isn't a version, use a version such as 1.14.2, 1.16-pre3 or 20w14a", e.Text)
+}
+
+// snapshotPattern matches the names of java snapshots such as 20w14a
+var snapshotPattern = regexp.MustCompile(`^\d{2}w\d{2}[a-z]$`)
+
+// preReleaseSuffixes are the suffixes of pre-releases and release
+// candidates, with spaces and dashes removed, longest first
+var preReleaseSuffixes = []struct {
+	prefix string
+	t      VersionType
+}{
+	{"releasecandidate", VersionReleaseCandidate},
+	{"prerelease", VersionPreRelease},
+	{"rc", VersionReleaseCandidate},
+	{"pre", VersionPreRelease},
+}
+
+// ParseVersionNumber parses a version number
+// Releases have two to four components such as "1.14", "1.14.2" or
+// "1.16.100.56" and may start with "v". Pre-releases and release
+// candidates are written "1.16-pre3", "1.16 Pre-release 3" or "1.16-rc1".
+// Snapshots are written by name such as "20w14a"
+func ParseVersionNumber(s string) (VersionNumber, error) {
+	text := strings.ToLower(strings.TrimSpace(s))
+	if snapshotPattern.MatchString(text) {
+		return VersionNumber{Type: VersionSnapshot, Snapshot: text}, nil
+	}
+	text = strings.TrimPrefix(text, "v")
+	n := VersionNumber{Type: VersionRelease}
+	if i := strings.IndexAny(text, "- "); i >= 0 {
+		suffix := strings.NewReplacer("-", "", " ", "").Replace(text[i+1:])
+		text = text[:i]
+		found := false
+		for _, prs := range preReleaseSuffixes {
+			if !strings.HasPrefix(suffix, prs.prefix) {
+				continue
+			}
+			number, err := strconv.Atoi(suffix[len(prs.prefix):])
+			if err != nil || number < 1 {
+				return VersionNumber{}, &VersionError{Text: s}
+			}
+			n.Type, n.PreRelease, found = prs.t, number, true
+			break
+		}
+		if !found {
+			return VersionNumber{}, &VersionError{Text: s}
+		}
+	}
+	parts := strings.Split(text, ".")
+	if len(parts) < 2 || len(parts) > 4 {
+		return VersionNumber{}, &VersionError{Text: s}
+	}
+	numbers := make([]int, len(parts))
+	for i, p := range parts {
+		number, err := strconv.Atoi(p)
+		if err != nil || number < 0 || strings.HasPrefix(p, "+") {
+			return VersionNumber{}, &VersionError{Text: s}
+		}
+		numbers[i] = number
+	}
+	n.Major, n.Minor = numbers[0], numbers[1]
+	if len(numbers) >= 3 {
+		n.Patch, n.HasPatch = numbers[2], true
+	}
+	if len(numbers) == 4 {
+		n.Revision, n.HasRevision = numbers[3], true
+	}
+	return n, nil
+}
+
+// String formats the version number, leaving out a patch version of 0
+// Snapshots are formatted by name
+func (n VersionNumber) String() string {
+	if n.Type == VersionSnapshot && n.Snapshot != "" {
+		return n.Snapshot
+	}
+	s := fmt.Sprintf("%d.%d", n.Major, n.Minor)
+	if n.Patch != 0 || n.Revision != 0 {
+		s += fmt.Sprintf(".%d", n.Patch)
+	}
+	if n.Revision != 0 {
+		s += fmt.Sprintf(".%d", n.Revision)
+	}
+	switch n.Type {
+	case VersionSnapshot:
+		s += "-snapshot"
+	case VersionPreRelease:
+		s += fmt.Sprintf("-pre%d", n.PreRelease)
+	case VersionReleaseCandidate:
+		s += fmt.Sprintf("-rc%d", n.PreRelease)
+	}
+	return s
+}
+
+// Compare compares two version numbers
+// The result is negative if n is before o, positive if n is after o and
+// zero if they're the same. Missing components count as 0
+// Versions are ordered by their numbers, so 1.16.100 comes before
+// 1.16.100.56. Snapshots, pre-releases and release candidates have the
+// numbers of the release they lead up to and come before it in that
+// order, then snapshots are ordered by name and the others by number
+func (n VersionNumber) Compare(o VersionNumber) int {
+	switch {
+	case n.Major != o.Major:
+		return compareInts(n.Major, o.Major)
+	case n.Minor != o.Minor:
+		return compareInts(n.Minor, o.Minor)
+	case n.Patch != o.Patch:
+		return compareInts(n.Patch, o.Patch)
+	case n.Revision != o.Revision:
+		return compareInts(n.Revision, o.Revision)
+	case n.Type != o.Type:
+		return compareInts(n.Type.rank(), o.Type.rank())
+	case n.Type == VersionSnapshot:
+		// Snapshot names are the year, week and a letter so
+		// names of the same length are ordered alphabetically
+		return strings.Compare(n.Snapshot, o.Snapshot)
+	}
+	return compareInts(n.PreRelease, o.PreRelease)
+}
+
+// covers indicates whether a version number is within the release that
+// n describes, where components n doesn't have match anything
+// "1.16" covers 1.16.5 and 1.16-pre3, where "1.16.0" covers neither
+// Only releases cover other versions
+func (n VersionNumber) covers(o VersionNumber) bool {
+	return n.Type == VersionRelease && n.Major == o.Major && n.Minor == o.Minor &&
+		(!n.HasPatch || n.Patch == o.Patch) &&
+		(!n.HasRevision || n.Revision == o.Revision)
+}
+
+// matches indicates whether a version number parsed from text refers
+// to the version number of a stored version
+// Snapshots parsed by name only match by name. Previews are written
+// like releases so a release number also matches a preview
+func (n VersionNumber) matches(o VersionNumber) bool {
+	if n.Type == VersionSnapshot && n.Snapshot != "" {
+		return o.Type == VersionSnapshot && n.Snapshot == strings.ToLower(o.Snapshot)
+	}
+	if n.Type == VersionRelease && o.Type == VersionPreview {
+		n.Type = VersionPreview
+	}
+	return n.Compare(o) == 0
+}
+
+// compareInts compares two ints in the same way as VersionNumber.Compare
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Number gets the version number of the version
+func (v Version) Number() VersionNumber {
+	return VersionNumber{
+		Major:       v.MajorVersion,
+		Minor:       v.MinorVersion,
+		Patch:       v.Patch,
+		Revision:    v.Revision,
+		HasPatch:    true,
+		HasRevision: v.Revision != 0,
+		Type:        v.Type,
+		PreRelease:  v.PreRelease,
+		Snapshot:    v.Snapshot,
+	}
+}
+
+// SetNumber sets the version number of the version
+func (v *Version) SetNumber(n VersionNumber) {
+	v.MajorVersion = n.Major
+	v.MinorVersion = n.Minor
+	v.Patch = n.Patch
+	v.Revision = n.Revision
+	v.Type = n.Type
+	v.PreRelease = n.PreRelease
+	v.Snapshot = n.Snapshot
+}
+
+// Compare compares the version numbers of two versions
+// in the same way as VersionNumber.Compare
+func (v Version) Compare(o Version) int {
+	return v.Number().Compare(o.Number())
+}
+
+// SortVersions sorts versions from oldest to newest
+func SortVersions(versions []Version) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Compare(versions[j]) < 0
+	})
+}
+
+// ParseVersion finds the version of an edition with a version number
+// such as "1.14.2", "1.16-pre3" or "20w14a". "1.14" is the same as "1.14.0"
+// A *VersionError is returned if the version number can't be parsed
+func (d *Database) ParseVersion(editionID, s string) (Version, bool, error) {
+	n, err := ParseVersionNumber(s)
+	if err != nil {
+		return Version{}, false, err
+	}
+	versions, err := d.Versions()
+	if err != nil {
+		return Version{}, false, errors.Wrap(err, "failed to get versions")
+	}
+	for _, v := range versions {
+		if v.EditionID == editionID && n.matches(v.Number()) {
+			return v, true, nil
+		}
+	}
+	return Version{}, false, nil
+}
+
+// VersionsBetween gets the versions of an edition from one version
+// number to another, including both, from oldest to newest
+// An upper bound covers every version with the components it's given,
+// so "1.13" to "1.16" includes 1.16.5 and "1.16.100" includes
+// 1.16.100.56. An empty bound isn't limited
+// A *VersionError is returned if a bound can't be parsed or is
+// a snapshot the edition doesn't have
+func (d *Database) VersionsBetween(editionID, from, to string) ([]Version, error) {
+	all, err := d.Versions()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get versions")
+	}
+	versions := []Version{}
+	for _, v := range all {
+		if v.EditionID == editionID {
+			versions = append(versions, v)
+		}
+	}
+	// Snapshots given by name are found to get their release numbers
+	// and previews are found as they're written like releases
+	bound := func(s string) (VersionNumber, error) {
+		n, err := ParseVersionNumber(s)
+		if err != nil || (n.Type != VersionSnapshot && n.Type != VersionRelease) {
+			return n, err
+		}
+		for _, v := range versions {
+			if vn := v.Number(); n.matches(vn) && (n.Type == VersionSnapshot || vn.Type == VersionPreview) {
+				return vn, nil
+			}
+		}
+		if n.Type == VersionSnapshot {
+			return VersionNumber{}, &VersionError{Text: s}
+		}
+		return n, nil
+	}
+	var low, high VersionNumber
+	if from != "" {
+		if low, err = bound(from); err != nil {
+			return nil, err
+		}
+	}
+	if to != "" {
+		if high, err = bound(to); err != nil {
+			return nil, err
+		}
+	}
+	results := []Version{}
+	for _, v := range versions {
+		n := v.Number()
+		if from != "" && n.Compare(low) < 0 {
+			continue
+		}
+		if to != "" && n.Compare(high) > 0 && !high.covers(n) {
+			continue
+		}
+		results = append(results, v)
+	}
+	SortVersions(results)
+	return results, nil
+}
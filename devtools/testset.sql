@@ -1,6 +1,6 @@
-INSERT INTO BuildClasses VALUES (1, "Piston Door", "...", "#0000ff", "05-02-2020", "05-02-2020");
-INSERT INTO BuildClasses VALUES (2, "Logic", "...", "#00ff00", "05-02-2020", "05-02-2020");
-INSERT INTO BuildClasses VALUES (3, "Farms", "...", "#ff0000", "05-02-2020", "05-02-2020");
+INSERT INTO BuildClasses VALUES (1, "Piston Door", "...", "#0000ff", "05-02-2020", "05-02-2020", "door");
+INSERT INTO BuildClasses VALUES (2, "Logic", "...", "#00ff00", "05-02-2020", "05-02-2020", "");
+INSERT INTO BuildClasses VALUES (3, "Farms", "...", "#ff0000", "05-02-2020", "05-02-2020", "");
 
 INSERT INTO Editions VALUES (1, "Minecraft Java Edition", "...", "05-02-2020", "05-02-2020");
 INSERT INTO Editions VALUES (2, "Minecraft Bedrock Edition", "...", "05-02-2020", "05-02-2020");
@@ -26,8 +26,13 @@ INSERT INTO UserStrikes VALUES (9378276281, 1, "...", 7635726387, "05-02-2020",
 INSERT INTO UserStrikes VALUES (9378276281, 2, "...", 9367464235, "05-02-2020", "05-02-2020");
 INSERT INTO UserStrikes VALUES (8372671628, 1, "...", 3874982635, "05-02-2020", "05-02-2020");
 
-INSERT INTO GuildSettings VALUES (8374652635, 3746857263, 8736543337, "05-02-2020", "05-02-2020");
-INSERT INTO GuildSettings VALUES (9987369290, 8847256790, 8749885748, "05-02-2020", "05-02-2020");
+INSERT INTO GuildSettings VALUES (8374652635, "05-02-2020", "05-02-2020");
+INSERT INTO GuildSettings VALUES (9987369290, "05-02-2020", "05-02-2020");
+
+INSERT INTO GuildSettingValues VALUES (8374652635, "build_channel", "3746857263", "05-02-2020", "05-02-2020");
+INSERT INTO GuildSettingValues VALUES (8374652635, "ticket_category", "8736543337", "05-02-2020", "05-02-2020");
+INSERT INTO GuildSettingValues VALUES (9987369290, "build_channel", "8847256790", "05-02-2020", "05-02-2020");
+INSERT INTO GuildSettingValues VALUES (9987369290, "ticket_category", "8749885748", "05-02-2020", "05-02-2020");
 
 INSERT INTO GuildTicketChannels VALUES (8374652635, 8764763888, 1, 1, 4876377628, "05-02-2020");
 INSERT INTO GuildTicketChannels VALUES (8374652635, 8376487367, 1, 2, 0980980980, "05-02-2020");
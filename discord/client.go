@@ -0,0 +1,207 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/Kappeh/RecordBot/embed"
+	"github.com/pkg/errors"
+)
+
+// DefaultBaseURL is the base url of the discord api
+const DefaultBaseURL = "https://discord.com/api/v10"
+
+// Doer sends http requests
+// It's implemented by *http.Client
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client makes requests to the discord rest api as a bot
+type Client struct {
+	// Doer sends the requests
+	Doer Doer
+	// BaseURL is the base url of the discord api
+	BaseURL string
+	// Token is the bot's token
+	Token string
+}
+
+// APIError is an error response from the discord api
+type APIError struct {
+	// StatusCode is the http status code of the response
+	StatusCode int
+	// Body is the body of the response
+	Body string
+}
+
+// Error describes the error response
+func (e *APIError) Error() string {
+	return fmt.Sprintf("discord responded with status %d: %s", e.StatusCode, e.Body)
+}
+
+// NewClient creates a client which uses the default http client
+func NewClient(token string) *Client {
+	return &Client{
+		Doer:    http.DefaultClient,
+		BaseURL: DefaultBaseURL,
+		Token:   token,
+	}
+}
+
+// Message is a message sent to a channel
+type Message struct {
+	// ID is the id of the message
+	ID string `json:"id,omitempty"`
+	// Content is the text of the message
+	Content string `json:"content,omitempty"`
+	// Embeds are the embeds of the message
+	Embeds []embed.Embed `json:"embeds,omitempty"`
+}
+
+// Channel is a guild channel
+type Channel struct {
+	// ID is the id of the channel
+	ID string `json:"id,omitempty"`
+	// Name is the name of the channel
+	Name string `json:"name"`
+	// Type is the type of channel, 0 for text channels
+	Type int `json:"type"`
+	// ParentID is the id of the category the channel is in
+	ParentID string `json:"parent_id,omitempty"`
+	// PermissionOverwrites are the permissions of specific
+	// roles and members within the channel
+	PermissionOverwrites []PermissionOverwrite `json:"permission_overwrites,omitempty"`
+}
+
+// PermissionOverwrite changes the permissions of a role or member
+// within a channel
+type PermissionOverwrite struct {
+	// ID is the id of the role or member
+	ID string `json:"id"`
+	// Type is 0 for roles and 1 for members
+	Type int `json:"type"`
+	// Allow is the bit set of allowed permissions
+	Allow string `json:"allow"`
+	// Deny is the bit set of denied permissions
+	Deny string `json:"deny"`
+}
+
+// Guild is a discord guild
+type Guild struct {
+	// ID is the id of the guild
+	ID string `json:"id"`
+	// OwnerID is the id of the user that owns the guild
+	OwnerID string `json:"owner_id"`
+}
+
+// Member is a member of a guild
+type Member struct {
+	// Roles are the ids of the member's roles
+	Roles []string `json:"roles"`
+}
+
+// Role is a role within a guild
+type Role struct {
+	// ID is the id of the role
+	ID string `json:"id"`
+	// Permissions is the bit set of the role's permissions
+	Permissions string `json:"permissions"`
+}
+
+// CreateMessage sends a message to a channel and returns its id
+func (c *Client) CreateMessage(channelID string, m Message) (string, error) {
+	var created Message
+	if err := c.do(http.MethodPost, "/channels/"+channelID+"/messages", m, &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// EditMessage replaces the content of a message
+func (c *Client) EditMessage(channelID, messageID string, m Message) error {
+	return c.do(http.MethodPatch, "/channels/"+channelID+"/messages/"+messageID, m, nil)
+}
+
+// DeleteMessage deletes a message
+func (c *Client) DeleteMessage(channelID, messageID string) error {
+	return c.do(http.MethodDelete, "/channels/"+channelID+"/messages/"+messageID, nil, nil)
+}
+
+// CreateChannel creates a channel within a guild and returns its id
+func (c *Client) CreateChannel(guildID string, ch Channel) (string, error) {
+	var created Channel
+	if err := c.do(http.MethodPost, "/guilds/"+guildID+"/channels", ch, &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// DeleteChannel deletes a channel
+func (c *Client) DeleteChannel(channelID string) error {
+	return c.do(http.MethodDelete, "/channels/"+channelID, nil, nil)
+}
+
+// Guild gets a guild
+func (c *Client) Guild(guildID string) (Guild, error) {
+	var g Guild
+	err := c.do(http.MethodGet, "/guilds/"+guildID, nil, &g)
+	return g, err
+}
+
+// GuildMember gets a member of a guild
+func (c *Client) GuildMember(guildID, userID string) (Member, error) {
+	var m Member
+	err := c.do(http.MethodGet, "/guilds/"+guildID+"/members/"+userID, nil, &m)
+	return m, err
+}
+
+// GuildRoles gets the roles of a guild
+func (c *Client) GuildRoles(guildID string) ([]Role, error) {
+	var roles []Role
+	err := c.do(http.MethodGet, "/guilds/"+guildID+"/roles", nil, &roles)
+	return roles, err
+}
+
+// do makes a request with a json body and decodes the json response
+// into out. body and out may be nil
+func (c *Client) do(method, path string, body, out interface{}) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode request")
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.BaseURL+path, r)
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Authorization", "Bot "+c.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := c.Doer.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "request failed")
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read response")
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return &APIError{StatusCode: res.StatusCode, Body: string(data)}
+	}
+	if out != nil && len(data) > 0 {
+		if err = json.Unmarshal(data, out); err != nil {
+			return errors.Wrap(err, "failed to decode response")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,500 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Priority is how urgently a request should be sent
+// Waiting requests with a higher priority are sent first
+type Priority int
+
+const (
+	// PriorityBackground is for requests nobody is waiting on,
+	// such as syncing build and record messages
+	PriorityBackground Priority = iota
+	// PriorityInteractive is for requests a user is waiting on,
+	// such as the replies to commands
+	PriorityInteractive
+)
+
+// String gets the name of the priority
+func (p Priority) String() string {
+	switch p {
+	case PriorityBackground:
+		return "background"
+	case PriorityInteractive:
+		return "interactive"
+	}
+	return "unknown"
+}
+
+// Defaults used by dispatchers made with NewDispatcher
+const (
+	// DefaultMaxRetries is the number of times a rate limited
+	// request is sent again before its response is returned
+	DefaultMaxRetries = 3
+	// DefaultGlobalLimit is the most requests sent each second,
+	// which is discord's global rate limit for bots
+	DefaultGlobalLimit = 50
+)
+
+// Dispatcher sends requests to the discord api without exceeding
+// its rate limits
+// Requests wait in a queue until the rate limit bucket of their route
+// has requests remaining. Buckets are learnt from the X-RateLimit
+// headers of responses, and rate limited responses are retried after
+// the time discord asks for
+type Dispatcher struct {
+	// Doer sends the requests
+	Doer Doer
+	// MaxRetries is the number of times a rate limited request
+	// is sent again before its response is returned
+	MaxRetries int
+	// GlobalLimit is the most requests sent each second
+	// There is no limit if it's zero
+	GlobalLimit int
+
+	mu sync.Mutex
+	// routes are the keys of the buckets of routes that have been learnt
+	routes map[string]string
+	// buckets are the buckets by key, which is the bucket's hash and
+	// major parameter, or the route if the hash isn't known yet
+	buckets map[string]*bucket
+	// queue are the waiting requests in the order they should be sent
+	queue []*waiter
+	// seq is the sequence number of the last request
+	seq uint64
+	// globalReset is when a global rate limit ends
+	globalReset time.Time
+	// window is when the current second of the global limit started
+	window time.Time
+	// windowCount is the number of requests sent within window
+	windowCount int
+	// timer wakes the queue when a bucket or the global limit resets
+	timer   *time.Timer
+	timerAt time.Time
+	metrics Metrics
+}
+
+// Metrics describe the requests that have passed through a dispatcher
+type Metrics struct {
+	// Queued is the number of requests waiting of each priority
+	Queued map[Priority]int
+	// InFlight is the number of requests that have been sent
+	// and haven't had a response yet
+	InFlight int
+	// Sent is the number of requests sent, including retries
+	Sent int
+	// Retried is the number of requests sent again after being rate limited
+	Retried int
+	// RateLimited is the number of responses rate limited by a bucket
+	RateLimited int
+	// GlobalRateLimited is the number of responses rate limited globally
+	GlobalRateLimited int
+	// Buckets is the number of rate limit buckets being tracked
+	Buckets int
+	// Waited is the total time requests have waited in the queue
+	Waited time.Duration
+}
+
+// bucket is the rate limit state shared by one or more routes
+type bucket struct {
+	// limit is the number of requests allowed each reset
+	// It's zero when it isn't known
+	limit int
+	// remaining is the number of requests that can be sent before
+	// reset, or -1 when it isn't known
+	remaining int
+	// reset is when remaining goes back to limit
+	reset time.Time
+	// inFlight is the number of requests sent that haven't had a response
+	inFlight int
+}
+
+// available indicates whether a request can be sent in the bucket
+// A bucket that isn't known only lets one request through at a time
+// so that the response can tell the dispatcher about the bucket
+// A bucket that has reset is refilled
+func (b *bucket) available(now time.Time) bool {
+	if !b.reset.IsZero() && !now.Before(b.reset) {
+		b.remaining = -1
+		if b.limit > 0 {
+			b.remaining = b.limit
+		}
+		b.reset = time.Time{}
+	}
+	if b.remaining > 0 {
+		return true
+	}
+	// When it isn't known when the bucket resets, wait for
+	// the responses of the requests already sent
+	if b.remaining < 0 || b.reset.IsZero() {
+		return b.inFlight == 0
+	}
+	return false
+}
+
+// waiter is a request waiting in the queue
+type waiter struct {
+	priority Priority
+	seq      uint64
+	route    string
+	major    string
+	queued   time.Time
+	// bucket is the bucket the request was sent in
+	bucket *bucket
+	// ready is closed when the request can be sent
+	ready chan struct{}
+}
+
+// NewDispatcher creates a dispatcher with the default settings
+// which sends requests with doer
+func NewDispatcher(doer Doer) *Dispatcher {
+	return &Dispatcher{
+		Doer:        doer,
+		MaxRetries:  DefaultMaxRetries,
+		GlobalLimit: DefaultGlobalLimit,
+		routes:      map[string]string{},
+		buckets:     map[string]*bucket{},
+	}
+}
+
+// Do sends a request with interactive priority
+func (d *Dispatcher) Do(req *http.Request) (*http.Response, error) {
+	return d.send(req, PriorityInteractive)
+}
+
+// WithPriority gets a doer which sends requests through
+// the dispatcher with a priority
+func (d *Dispatcher) WithPriority(p Priority) Doer {
+	return priorityDoer{d: d, p: p}
+}
+
+// priorityDoer sends requests through a dispatcher with a priority
+type priorityDoer struct {
+	d *Dispatcher
+	p Priority
+}
+
+// Do sends a request
+func (pd priorityDoer) Do(req *http.Request) (*http.Response, error) {
+	return pd.d.send(req, pd.p)
+}
+
+// Metrics gets a snapshot of the dispatcher's metrics
+func (d *Dispatcher) Metrics() Metrics {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	m := d.metrics
+	m.Queued = map[Priority]int{}
+	for _, w := range d.queue {
+		m.Queued[w.priority]++
+	}
+	m.Buckets = len(d.buckets)
+	return m
+}
+
+// send sends a request once its bucket allows it, retrying
+// it if it's rate limited
+func (d *Dispatcher) send(req *http.Request, p Priority) (*http.Response, error) {
+	route, major := routeOf(req.Method, req.URL.Path)
+	d.mu.Lock()
+	d.seq++
+	seq := d.seq
+	d.mu.Unlock()
+	for attempt := 0; ; attempt++ {
+		// Retries keep their place in the queue
+		w := &waiter{
+			priority: p,
+			seq:      seq,
+			route:    route,
+			major:    major,
+			queued:   time.Now(),
+			ready:    make(chan struct{}),
+		}
+		d.mu.Lock()
+		d.enqueue(w)
+		d.schedule()
+		d.mu.Unlock()
+		select {
+		case <-w.ready:
+		case <-req.Context().Done():
+			d.mu.Lock()
+			if !d.dequeue(w) {
+				// The request was let through as it was cancelled
+				d.finish(w)
+			}
+			d.mu.Unlock()
+			return nil, req.Context().Err()
+		}
+
+		res, err := d.Doer.Do(req)
+		if err != nil {
+			d.mu.Lock()
+			d.finish(w)
+			d.mu.Unlock()
+			return nil, err
+		}
+		limited := rateLimit{}
+		if res.StatusCode == http.StatusTooManyRequests {
+			data, err := ioutil.ReadAll(res.Body)
+			res.Body.Close()
+			if err != nil {
+				d.mu.Lock()
+				d.finish(w)
+				d.mu.Unlock()
+				return nil, errors.Wrap(err, "failed to read rate limit response")
+			}
+			res.Body = ioutil.NopCloser(bytes.NewReader(data))
+			limited = parseRateLimit(res.Header, data)
+		}
+		d.mu.Lock()
+		d.update(w, res.Header, limited)
+		d.finish(w)
+		d.mu.Unlock()
+
+		if res.StatusCode != http.StatusTooManyRequests || attempt >= d.MaxRetries {
+			return res, nil
+		}
+		// The body has to be sent again
+		if req.Body != nil {
+			if req.GetBody == nil {
+				return res, nil
+			}
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, errors.Wrap(err, "failed to reset request body")
+			}
+		}
+		res.Body.Close()
+		d.mu.Lock()
+		d.metrics.Retried++
+		d.mu.Unlock()
+	}
+}
+
+// enqueue adds a request to the queue, keeping it ordered by
+// priority and then by the order requests were made
+func (d *Dispatcher) enqueue(w *waiter) {
+	i := sort.Search(len(d.queue), func(i int) bool {
+		q := d.queue[i]
+		if q.priority != w.priority {
+			return q.priority < w.priority
+		}
+		return q.seq > w.seq
+	})
+	d.queue = append(d.queue, nil)
+	copy(d.queue[i+1:], d.queue[i:])
+	d.queue[i] = w
+}
+
+// dequeue removes a request from the queue
+// False is returned if it had already been let through
+func (d *Dispatcher) dequeue(w *waiter) bool {
+	for i, q := range d.queue {
+		if q == w {
+			d.queue = append(d.queue[:i], d.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// finish records that a request that was let through
+// has finished and lets waiting requests through
+func (d *Dispatcher) finish(w *waiter) {
+	w.bucket.inFlight--
+	d.metrics.InFlight--
+	d.schedule()
+}
+
+// schedule lets through every waiting request that its bucket and
+// the global limit allow, and sets a timer for when more can go
+// Requests in a bucket that's used up stay waiting, and so do the
+// requests after them in the same bucket so that order is kept
+func (d *Dispatcher) schedule() {
+	now := time.Now()
+	wake := time.Time{}
+	later := func(t time.Time) {
+		if wake.IsZero() || t.Before(wake) {
+			wake = t
+		}
+	}
+	blocked := map[*bucket]bool{}
+	waiting := []*waiter{}
+	for _, w := range d.queue {
+		if now.Before(d.globalReset) {
+			later(d.globalReset)
+			waiting = append(waiting, w)
+			continue
+		}
+		if d.GlobalLimit > 0 {
+			if now.Sub(d.window) >= time.Second {
+				d.window = now
+				d.windowCount = 0
+			}
+			if d.windowCount >= d.GlobalLimit {
+				later(d.window.Add(time.Second))
+				waiting = append(waiting, w)
+				continue
+			}
+		}
+		b := d.bucketOf(w.route, w.major)
+		if blocked[b] || !b.available(now) {
+			blocked[b] = true
+			// Buckets waiting on a response are woken by the response
+			if !b.reset.IsZero() {
+				later(b.reset)
+			}
+			waiting = append(waiting, w)
+			continue
+		}
+		// Let the request through
+		if b.remaining > 0 {
+			b.remaining--
+		}
+		b.inFlight++
+		d.windowCount++
+		w.bucket = b
+		d.metrics.InFlight++
+		d.metrics.Sent++
+		d.metrics.Waited += now.Sub(w.queued)
+		close(w.ready)
+	}
+	d.queue = waiting
+	if wake.IsZero() || (d.timer != nil && d.timerAt.Equal(wake)) {
+		return
+	}
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.timerAt = wake
+	d.timer = time.AfterFunc(wake.Sub(now), func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.timer = nil
+		d.schedule()
+	})
+}
+
+// bucketOf gets the bucket of a route, creating it if it doesn't exist
+func (d *Dispatcher) bucketOf(route, major string) *bucket {
+	key, ok := d.routes[route]
+	if !ok {
+		key = route
+	}
+	b, ok := d.buckets[key]
+	if !ok {
+		b = &bucket{remaining: -1}
+		d.buckets[key] = b
+	}
+	return b
+}
+
+// rateLimit is the body of a rate limited response
+type rateLimit struct {
+	// RetryAfter is the number of seconds to wait before trying again
+	RetryAfter float64 `json:"retry_after"`
+	// Global indicates whether the global rate limit was exceeded
+	Global bool `json:"global"`
+}
+
+// parseRateLimit reads a rate limited response
+// The body is preferred as it has more precision than the headers
+func parseRateLimit(h http.Header, body []byte) rateLimit {
+	rl := rateLimit{}
+	if err := json.Unmarshal(body, &rl); err != nil || rl.RetryAfter <= 0 {
+		rl.RetryAfter, _ = strconv.ParseFloat(h.Get("Retry-After"), 64)
+	}
+	rl.Global = rl.Global || h.Get("X-RateLimit-Global") == "true" || h.Get("X-RateLimit-Scope") == "global"
+	// Wait at least a moment so that a response without a retry
+	// after doesn't cause requests to be sent again straight away
+	if rl.RetryAfter <= 0 {
+		rl.RetryAfter = 1
+	}
+	return rl
+}
+
+// update updates the bucket of a request from the headers
+// of its response
+func (d *Dispatcher) update(w *waiter, h http.Header, limited rateLimit) {
+	now := time.Now()
+	b := w.bucket
+	if hash := h.Get("X-RateLimit-Bucket"); hash != "" {
+		key := hash + ":" + w.major
+		if d.routes[w.route] != key {
+			d.routes[w.route] = key
+			if _, ok := d.buckets[key]; !ok {
+				d.buckets[key] = &bucket{remaining: -1}
+			}
+		}
+		b = d.buckets[key]
+	}
+	if limit, err := strconv.Atoi(h.Get("X-RateLimit-Limit")); err == nil {
+		b.limit = limit
+	}
+	if remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining")); err == nil {
+		b.remaining = remaining
+	}
+	if after, err := strconv.ParseFloat(h.Get("X-RateLimit-Reset-After"), 64); err == nil {
+		b.reset = now.Add(seconds(after))
+	}
+	if limited.RetryAfter == 0 {
+		return
+	}
+	reset := now.Add(seconds(limited.RetryAfter))
+	if limited.Global {
+		d.globalReset = reset
+		d.metrics.GlobalRateLimited++
+		return
+	}
+	b.remaining = 0
+	if reset.After(b.reset) {
+		b.reset = reset
+	}
+	d.metrics.RateLimited++
+}
+
+// seconds converts a number of seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// majorResources are the resources whose ids are major parameters
+// Routes with different major parameters have separate buckets
+var majorResources = []string{"channels", "guilds", "webhooks"}
+
+// routeOf gets the route of a request, which identifies its bucket
+// until the bucket's hash is known, and its major parameter
+// Ids other than the major parameter are replaced, so that
+// "/channels/1/messages/2" becomes "/channels/1/messages/:id"
+func routeOf(method, path string) (string, string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	major := ""
+	for i, part := range parts {
+		if _, err := strconv.ParseUint(part, 10, 64); err != nil || i == 0 {
+			continue
+		}
+		if major == "" {
+			for _, r := range majorResources {
+				if parts[i-1] == r {
+					major = r + "/" + part
+				}
+			}
+			if major != "" {
+				continue
+			}
+		}
+		parts[i] = ":id"
+	}
+	return method + " /" + strings.Join(parts, "/"), major
+}
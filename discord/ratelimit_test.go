@@ -0,0 +1,286 @@
+package discord
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder is a server which records the requests made to it
+// and responds with respond
+type recorder struct {
+	mu       sync.Mutex
+	requests []string
+	respond  func(w http.ResponseWriter, r *http.Request, n int)
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec.mu.Lock()
+	rec.requests = append(rec.requests, r.URL.RequestURI())
+	n := len(rec.requests)
+	rec.mu.Unlock()
+	rec.respond(w, r, n)
+}
+
+// received gets the requests made to the server in order
+func (rec *recorder) received() []string {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]string{}, rec.requests...)
+}
+
+// newTestDispatcher creates a dispatcher which sends requests
+// to a recorder
+// The server has to be closed once the test is done
+func newTestDispatcher(respond func(w http.ResponseWriter, r *http.Request, n int)) (*Dispatcher, *recorder, *httptest.Server) {
+	rec := &recorder{respond: respond}
+	server := httptest.NewServer(rec)
+	return NewDispatcher(server.Client()), rec, server
+}
+
+// get sends a get request through a doer
+func get(t *testing.T, d Doer, url string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Errorf("failed to create request: %v", err)
+		return nil
+	}
+	res, err := d.Do(req)
+	if err != nil {
+		t.Errorf("request failed: %v", err)
+		return nil
+	}
+	res.Body.Close()
+	return res
+}
+
+// waitQueued waits until the dispatcher has n requests of a priority queued
+func waitQueued(t *testing.T, d *Dispatcher, p Priority, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for d.Metrics().Queued[p] != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d %s requests queued, got %d", n, p, d.Metrics().Queued[p])
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDispatcherRetriesRateLimited(t *testing.T) {
+	d, rec, server := newTestDispatcher(func(w http.ResponseWriter, r *http.Request, n int) {
+		if n == 1 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"message": "You are being rate limited.", "retry_after": 0.1, "global": false}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+	url := server.URL
+	start := time.Now()
+	res := get(t, d, url+"/channels/1/messages")
+	if res == nil {
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, res.StatusCode)
+	}
+	if waited := time.Since(start); waited < 100*time.Millisecond {
+		t.Errorf("expected the retry to wait for retry_after, waited %v", waited)
+	}
+	if got := len(rec.received()); got != 2 {
+		t.Errorf("expected 2 requests, got %d", got)
+	}
+	m := d.Metrics()
+	if m.Retried != 1 || m.RateLimited != 1 || m.Sent != 2 || m.InFlight != 0 {
+		t.Errorf("unexpected metrics %+v", m)
+	}
+}
+
+func TestDispatcherGivesUpAfterMaxRetries(t *testing.T) {
+	d, rec, server := newTestDispatcher(func(w http.ResponseWriter, r *http.Request, n int) {
+		w.Header().Set("Retry-After", "0.01")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	defer server.Close()
+	url := server.URL
+	d.MaxRetries = 2
+	res := get(t, d, url+"/channels/1/messages")
+	if res == nil {
+		return
+	}
+	if res.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected status %d, got %d", http.StatusTooManyRequests, res.StatusCode)
+	}
+	if got := len(rec.received()); got != 3 {
+		t.Errorf("expected 3 requests, got %d", got)
+	}
+}
+
+func TestDispatcherGlobalRateLimit(t *testing.T) {
+	d, rec, server := newTestDispatcher(func(w http.ResponseWriter, r *http.Request, n int) {
+		if n == 1 {
+			w.Header().Set("X-RateLimit-Global", "true")
+			w.Header().Set("Retry-After", "0.2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+	url := server.URL
+	d.MaxRetries = 0
+	start := time.Now()
+	if res := get(t, d, url+"/channels/1/messages"); res == nil || res.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected the first request to be rate limited")
+	}
+	// A different route has to wait for the global limit too
+	if res := get(t, d, url+"/guilds/2/members"); res == nil || res.StatusCode != http.StatusOK {
+		t.Fatalf("expected the second request to succeed")
+	}
+	if waited := time.Since(start); waited < 200*time.Millisecond {
+		t.Errorf("expected requests to wait for the global limit, waited %v", waited)
+	}
+	if got := len(rec.received()); got != 2 {
+		t.Errorf("expected 2 requests, got %d", got)
+	}
+	if m := d.Metrics(); m.GlobalRateLimited != 1 || m.RateLimited != 0 {
+		t.Errorf("unexpected metrics %+v", m)
+	}
+}
+
+func TestDispatcherGlobalLimit(t *testing.T) {
+	d, rec, server := newTestDispatcher(func(w http.ResponseWriter, r *http.Request, n int) {
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+	url := server.URL
+	d.GlobalLimit = 2
+	start := time.Now()
+	var wg sync.WaitGroup
+	for _, path := range []string{"/channels/1/messages", "/channels/2/messages", "/channels/3/messages"} {
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			get(t, d, url+path)
+		}(path)
+	}
+	wg.Wait()
+	if waited := time.Since(start); waited < time.Second {
+		t.Errorf("expected the third request to wait for the next second, waited %v", waited)
+	}
+	if got := len(rec.received()); got != 3 {
+		t.Errorf("expected 3 requests, got %d", got)
+	}
+}
+
+func TestDispatcherLearnsBuckets(t *testing.T) {
+	var mu sync.Mutex
+	sent := map[string]time.Time{}
+	d, _, server := newTestDispatcher(func(w http.ResponseWriter, r *http.Request, n int) {
+		mu.Lock()
+		sent[r.URL.RequestURI()] = time.Now()
+		mu.Unlock()
+		w.Header().Set("X-RateLimit-Bucket", "abcd")
+		w.Header().Set("X-RateLimit-Limit", "1")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset-After", "0.2")
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+	url := server.URL
+	start := time.Now()
+	get(t, d, url+"/channels/1/messages/10")
+	// Routes are bucketed with their major parameter, so another
+	// channel isn't limited by the first
+	get(t, d, url+"/channels/2/messages/20")
+	// Other ids don't separate buckets, so this waits for the reset
+	get(t, d, url+"/channels/1/messages/11")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if other := sent["/channels/2/messages/20"].Sub(start); other >= 200*time.Millisecond {
+		t.Errorf("expected a different channel to be sent straight away, waited %v", other)
+	}
+	if same := sent["/channels/1/messages/11"].Sub(start); same < 200*time.Millisecond {
+		t.Errorf("expected the same bucket to wait for its reset, waited %v", same)
+	}
+	m := d.Metrics()
+	if m.RateLimited != 0 || m.Sent != 3 {
+		t.Errorf("unexpected metrics %+v", m)
+	}
+	// Each channel's route had a bucket until the hash was learnt,
+	// then each channel has its own bucket with the hash
+	if m.Buckets != 4 {
+		t.Errorf("expected 4 buckets, got %d", m.Buckets)
+	}
+}
+
+func TestDispatcherPriority(t *testing.T) {
+	d, rec, server := newTestDispatcher(func(w http.ResponseWriter, r *http.Request, n int) {
+		w.Header().Set("X-RateLimit-Bucket", "abcd")
+		w.Header().Set("X-RateLimit-Limit", "1")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset-After", "0.2")
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+	url := server.URL
+	// Use up the bucket so that the next requests have to wait
+	get(t, d, url+"/channels/1/messages?n=first")
+
+	var wg sync.WaitGroup
+	send := func(p Priority, name string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			get(t, d.WithPriority(p), url+"/channels/1/messages?n="+name)
+		}()
+	}
+	send(PriorityBackground, "background1")
+	waitQueued(t, d, PriorityBackground, 1)
+	send(PriorityBackground, "background2")
+	waitQueued(t, d, PriorityBackground, 2)
+	send(PriorityInteractive, "interactive")
+	waitQueued(t, d, PriorityInteractive, 1)
+	wg.Wait()
+
+	want := []string{
+		"/channels/1/messages?n=first",
+		"/channels/1/messages?n=interactive",
+		"/channels/1/messages?n=background1",
+		"/channels/1/messages?n=background2",
+	}
+	got := rec.received()
+	if len(got) != len(want) {
+		t.Fatalf("expected requests %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected requests %v, got %v", want, got)
+		}
+	}
+}
+
+func TestRouteOf(t *testing.T) {
+	tests := []struct {
+		method, path string
+		route, major string
+	}{
+		{"GET", "/channels/1/messages/2", "GET /channels/1/messages/:id", "channels/1"},
+		{"POST", "/channels/1/messages", "POST /channels/1/messages", "channels/1"},
+		{"GET", "/guilds/3/members/4", "GET /guilds/3/members/:id", "guilds/3"},
+		{"GET", "/users/5", "GET /users/:id", ""},
+		{"GET", "/gateway/bot", "GET /gateway/bot", ""},
+	}
+	for _, tt := range tests {
+		route, major := routeOf(tt.method, tt.path)
+		if route != tt.route || major != tt.major {
+			t.Errorf("routeOf(%q, %q) = %q, %q, expected %q, %q", tt.method, tt.path, route, major, tt.route, tt.major)
+		}
+	}
+}
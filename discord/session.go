@@ -0,0 +1,160 @@
+package discord
+
+import (
+	"strconv"
+
+	"github.com/Kappeh/RecordBot/command"
+	"github.com/Kappeh/RecordBot/embed"
+	"github.com/Kappeh/RecordBot/messagesync"
+	"github.com/pkg/errors"
+)
+
+// Permission bits used to decide permission levels
+// https://discord.com/developers/docs/topics/permissions
+const (
+	permissionAdministrator      = 1 << 3
+	permissionManageGuild        = 1 << 5
+	permissionViewChannel        = 1 << 10
+	permissionSendMessages       = 1 << 11
+	permissionManageMessages     = 1 << 13
+	permissionReadMessageHistory = 1 << 16
+)
+
+// Session runs commands through the discord rest api
+type Session struct {
+	// Client makes the requests
+	Client *Client
+	// OwnerIDs are the ids of the users that own the bot
+	OwnerIDs []string
+}
+
+// Send sends a message to a channel
+func (s *Session) Send(channelID, content string) error {
+	_, err := s.Client.CreateMessage(channelID, Message{Content: content})
+	return err
+}
+
+// SendEmbed sends an embed to a channel
+func (s *Session) SendEmbed(channelID string, e embed.Embed) error {
+	_, err := s.Client.CreateMessage(channelID, Message{Embeds: []embed.Embed{e}})
+	return err
+}
+
+// Permission gets the permission level of a user
+// Members that can manage the guild are administrators and
+// members that can manage messages are moderators
+func (s *Session) Permission(guildID, userID string) (command.Permission, error) {
+	for _, id := range s.OwnerIDs {
+		if id == userID {
+			return command.PermissionOwner, nil
+		}
+	}
+	if guildID == "" {
+		return command.PermissionEveryone, nil
+	}
+	g, err := s.Client.Guild(guildID)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get guild")
+	}
+	if g.OwnerID == userID {
+		return command.PermissionAdministrator, nil
+	}
+	m, err := s.Client.GuildMember(guildID, userID)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get guild member")
+	}
+	roles, err := s.Client.GuildRoles(guildID)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get guild roles")
+	}
+	// Members have the permissions of @everyone, whose id
+	// is the guild's id, and of each of their roles
+	has := map[string]bool{guildID: true}
+	for _, id := range m.Roles {
+		has[id] = true
+	}
+	var permissions uint64
+	for _, r := range roles {
+		if has[r.ID] {
+			p, _ := strconv.ParseUint(r.Permissions, 10, 64)
+			permissions |= p
+		}
+	}
+	switch {
+	case permissions&(permissionAdministrator|permissionManageGuild) != 0:
+		return command.PermissionAdministrator, nil
+	case permissions&permissionManageMessages != 0:
+		return command.PermissionModerator, nil
+	}
+	return command.PermissionEveryone, nil
+}
+
+// Roles gets the ids of a member's roles within a guild
+func (s *Session) Roles(guildID, userID string) ([]string, error) {
+	m, err := s.Client.GuildMember(guildID, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get guild member")
+	}
+	return m.Roles, nil
+}
+
+// CreateTicketChannel creates a channel within a category which can
+// only be seen by the creator and roles that can manage messages
+func (s *Session) CreateTicketChannel(guildID, categoryID, name, creatorID string) (string, error) {
+	roles, err := s.Client.GuildRoles(guildID)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get guild roles")
+	}
+	visible := strconv.Itoa(permissionViewChannel | permissionSendMessages | permissionReadMessageHistory)
+	overwrites := []PermissionOverwrite{
+		{ID: guildID, Type: 0, Allow: "0", Deny: strconv.Itoa(permissionViewChannel)},
+		{ID: creatorID, Type: 1, Allow: visible, Deny: "0"},
+	}
+	for _, r := range roles {
+		p, _ := strconv.ParseUint(r.Permissions, 10, 64)
+		if r.ID != guildID && p&permissionManageMessages != 0 {
+			overwrites = append(overwrites, PermissionOverwrite{ID: r.ID, Type: 0, Allow: visible, Deny: "0"})
+		}
+	}
+	return s.Client.CreateChannel(guildID, Channel{
+		Name:                 name,
+		ParentID:             categoryID,
+		PermissionOverwrites: overwrites,
+	})
+}
+
+// DeleteChannel deletes a channel
+func (s *Session) DeleteChannel(channelID string) error {
+	return s.Client.DeleteChannel(channelID)
+}
+
+// Messenger sends build and record messages through the discord rest api
+type Messenger struct {
+	// Client makes the requests
+	Client *Client
+}
+
+// Send sends an embed to a channel and returns the message's id
+func (m *Messenger) Send(channelID string, e embed.Embed) (string, error) {
+	return m.Client.CreateMessage(channelID, Message{Embeds: []embed.Embed{e}})
+}
+
+// Edit replaces the embed of a message
+// messagesync.ErrNotFound is returned if the message has been deleted
+func (m *Messenger) Edit(channelID, messageID string, e embed.Embed) error {
+	err := m.Client.EditMessage(channelID, messageID, Message{Embeds: []embed.Embed{e}})
+	if apiErr, ok := errors.Cause(err).(*APIError); ok && apiErr.StatusCode == 404 {
+		return messagesync.ErrNotFound
+	}
+	return err
+}
+
+// Delete deletes a message
+// Messages that have already been deleted are ignored
+func (m *Messenger) Delete(channelID, messageID string) error {
+	err := m.Client.DeleteMessage(channelID, messageID)
+	if apiErr, ok := errors.Cause(err).(*APIError); ok && apiErr.StatusCode == 404 {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,142 @@
+package embed
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Kappeh/RecordBot/database"
+)
+
+// VersionStatus is the status of a build within a version
+type VersionStatus struct {
+	// Version is the version the status applies to
+	Version database.Version
+	// Status is the status of the build within the version
+	Status database.Status
+	// Notes describes the status in more detail
+	Notes string
+}
+
+// BuildData is the information needed to render a build
+type BuildData struct {
+	// Build is the build being rendered
+	Build database.Build
+	// Edition is the edition of the build
+	Edition database.Edition
+	// BuildClass is the build class of the build
+	BuildClass database.BuildClass
+	// Versions are the statuses of the build in each version
+	// that it has been tested in
+	Versions []VersionStatus
+}
+
+// Build renders a build as an embed
+func Build(d BuildData) Embed {
+	b := d.Build
+	e := Embed{
+		Title:       b.Name,
+		Description: b.Description,
+	}
+	if c, err := ParseColour(d.BuildClass.EmbedColour); err == nil {
+		e.Colour = c
+	}
+	e.addField("Creators", b.Creators, false)
+	e.addField("Edition", d.Edition.Name, true)
+	e.addField("Class", d.BuildClass.Name, true)
+	if size := b.Dimensions(); size != (database.Dimensions{}) {
+		e.addField("Dimensions", fmt.Sprintf(
+			"%s (%d blocks)", size, size.Volume(),
+		), true)
+	}
+	e.addField("Door Timings", join(
+		durationLine("Normal close", b.NormalCloseDuration),
+		durationLine("Normal open", b.NormalOpenDuration),
+		durationLine("Visible close", b.VisibleCloseDuration),
+		durationLine("Visible open", b.VisibleOpenDuration),
+		durationLine("Close delay", b.DelayCloseDuration),
+		durationLine("Open delay", b.DelayOpenDuration),
+		durationLine("Close reset", b.ResetCloseDuration),
+		durationLine("Open reset", b.ResetOpenDuration),
+	), true)
+	e.addField("Extender Timings", join(
+		durationLine("Extension", b.ExtensionDuration),
+		durationLine("Retraction", b.RetractionDuration),
+		durationLine("Extension delay", b.ExtensionDelayDuration),
+		durationLine("Retraction delay", b.RetractionDelayDuration),
+	), true)
+	e.addField("Version Compatibility", versionLines(d.Versions), false)
+	links := []string{}
+	for _, l := range []struct{ name, url string }{
+		{"Image", b.ImageURL},
+		{"Video", b.YoutubeURL},
+		{"World Download", b.WorldDownloadURL},
+	} {
+		if l.url != "" {
+			links = append(links, fmt.Sprintf("[%s](%s)", l.name, l.url))
+		}
+	}
+	e.addField("Links", strings.Join(links, " | "), false)
+	if b.ServerIPAddress != "" {
+		e.addField("Server", join(
+			"IP: "+b.ServerIPAddress,
+			prefixed("Coordinates: ", b.ServerCoordinates),
+			prefixed("Command: ", code(b.ServerCommand)),
+		), false)
+	}
+	if b.ImageURL != "" {
+		e.Image = &Image{URL: b.ImageURL}
+	}
+	state := "Unverified"
+	if b.Reported {
+		state = "Reported"
+	} else if b.Verified {
+		state = "Verified"
+	}
+	e.Footer = &Footer{Text: fmt.Sprintf("Build %s • %s", b.ID, state)}
+	return e.Limit()
+}
+
+// durationLine formats a duration with a label
+// An empty string is returned for durations that aren't set
+func durationLine(label string, t database.Ticks) string {
+	if t == 0 {
+		return ""
+	}
+	return label + ": " + t.String()
+}
+
+// versionLines lists the status of a build in each version
+// ordered from oldest to newest version
+func versionLines(versions []VersionStatus) string {
+	sorted := make([]VersionStatus, len(versions))
+	copy(sorted, versions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Version.Compare(sorted[j].Version) < 0
+	})
+	lines := []string{}
+	for _, v := range sorted {
+		line := v.Version.Number().String() + ": " + v.Status.Name
+		if v.Notes != "" {
+			line += " (" + v.Notes + ")"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// prefixed prefixes s if s isn't empty
+func prefixed(prefix, s string) string {
+	if s == "" {
+		return ""
+	}
+	return prefix + s
+}
+
+// code formats s as inline code if s isn't empty
+func code(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + strings.Replace(s, "`", "'", -1) + "`"
+}
@@ -0,0 +1,177 @@
+package embed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// Limits imposed by discord on embeds
+// https://discord.com/developers/docs/resources/channel#embed-limits
+const (
+	// TitleLimit is the maximum length of an embed title
+	TitleLimit = 256
+	// DescriptionLimit is the maximum length of an embed description
+	DescriptionLimit = 4096
+	// FieldCountLimit is the maximum number of fields in an embed
+	FieldCountLimit = 25
+	// FieldNameLimit is the maximum length of a field name
+	FieldNameLimit = 256
+	// FieldValueLimit is the maximum length of a field value
+	FieldValueLimit = 1024
+	// FooterLimit is the maximum length of footer text
+	FooterLimit = 2048
+	// TotalLimit is the maximum combined length of the title,
+	// description, field names, field values and footer text
+	TotalLimit = 6000
+)
+
+// ellipsis is appended to text that has been shortened
+const ellipsis = "…"
+
+// Embed is a discord message embed
+// It is encoded to json in the form expected by the discord api
+type Embed struct {
+	// Title is the title of the embed
+	Title string `json:"title,omitempty"`
+	// Description is the main text of the embed
+	Description string `json:"description,omitempty"`
+	// URL is the link the title points to
+	URL string `json:"url,omitempty"`
+	// Colour is the colour of the side bar of the embed
+	Colour int `json:"color,omitempty"`
+	// Fields are the fields of the embed
+	Fields []Field `json:"fields,omitempty"`
+	// Image is an image shown in the embed
+	Image *Image `json:"image,omitempty"`
+	// Footer is the footer of the embed
+	Footer *Footer `json:"footer,omitempty"`
+}
+
+// Field is a field of an embed
+type Field struct {
+	// Name is the title of the field
+	Name string `json:"name"`
+	// Value is the text of the field
+	Value string `json:"value"`
+	// Inline indicates whether the field can be shown
+	// alongside other inline fields
+	Inline bool `json:"inline,omitempty"`
+}
+
+// Image is an image within an embed
+type Image struct {
+	// URL is the link to the image
+	URL string `json:"url"`
+}
+
+// Footer is the footer of an embed
+type Footer struct {
+	// Text is the text of the footer
+	Text string `json:"text"`
+}
+
+// ParseColour parses a hex colour such as #0000ff, 0x0000ff or 0000ff
+func ParseColour(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "#")
+	s = strings.TrimPrefix(strings.ToLower(s), "0x")
+	if len(s) != 6 {
+		return 0, errors.Errorf("%q isn't a six digit hex colour", s)
+	}
+	c, err := strconv.ParseInt(s, 16, 32)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to parse colour")
+	}
+	return int(c), nil
+}
+
+// Hash gets a hash of the content of the embed
+// Embeds with the same content have the same hash
+func (e Embed) Hash() string {
+	// Encoding an embed can't fail as it only contains
+	// strings, ints and bools
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// addField adds a field to the embed if the value isn't empty
+func (e *Embed) addField(name, value string, inline bool) {
+	if value == "" {
+		return
+	}
+	e.Fields = append(e.Fields, Field{Name: name, Value: value, Inline: inline})
+}
+
+// Length gets the combined length of the text in the embed
+// that counts towards TotalLimit
+func (e Embed) Length() int {
+	n := utf8.RuneCountInString(e.Title) + utf8.RuneCountInString(e.Description)
+	for _, f := range e.Fields {
+		n += utf8.RuneCountInString(f.Name) + utf8.RuneCountInString(f.Value)
+	}
+	if e.Footer != nil {
+		n += utf8.RuneCountInString(e.Footer.Text)
+	}
+	return n
+}
+
+// Limit shortens the embed so that it is within discord's limits
+// Text that is too long is truncated and fields that don't fit
+// are removed from the end of the embed
+func (e Embed) Limit() Embed {
+	e.Title = truncate(e.Title, TitleLimit)
+	e.Description = truncate(e.Description, DescriptionLimit)
+	if e.Footer != nil {
+		e.Footer = &Footer{Text: truncate(e.Footer.Text, FooterLimit)}
+	}
+	fields := make([]Field, 0, len(e.Fields))
+	for i, f := range e.Fields {
+		if i >= FieldCountLimit {
+			break
+		}
+		fields = append(fields, Field{
+			Name:   truncate(f.Name, FieldNameLimit),
+			Value:  truncate(f.Value, FieldValueLimit),
+			Inline: f.Inline,
+		})
+	}
+	e.Fields = fields
+	// Remove fields from the end until the total length fits
+	for e.Length() > TotalLimit && len(e.Fields) > 0 {
+		e.Fields = e.Fields[:len(e.Fields)-1]
+	}
+	if over := e.Length() - TotalLimit; over > 0 {
+		e.Description = truncate(e.Description, utf8.RuneCountInString(e.Description)-over)
+	}
+	return e
+}
+
+// truncate shortens s to at most limit characters
+func truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	if limit <= 0 {
+		return ""
+	}
+	runes := []rune(s)
+	return string(runes[:limit-1]) + ellipsis
+}
+
+// join joins lines of text leaving out empty lines
+func join(lines ...string) string {
+	nonEmpty := []string{}
+	for _, l := range lines {
+		if l != "" {
+			nonEmpty = append(nonEmpty, l)
+		}
+	}
+	return strings.Join(nonEmpty, "\n")
+}
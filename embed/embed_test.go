@@ -0,0 +1,185 @@
+package embed
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseColour(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+		err  bool
+	}{
+		{text: "#0000ff", want: 0x0000ff},
+		{text: "0x00FF00", want: 0x00ff00},
+		{text: " ff0000 ", want: 0xff0000},
+		{text: "#FFFFFF", want: 0xffffff},
+		{text: "", err: true},
+		{text: "#fff", err: true},
+		{text: "#0000ff0", err: true},
+		{text: "#gggggg", err: true},
+		{text: "blue", err: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseColour(tt.text)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParseColour(%q) = %#x, expected an error", tt.text, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseColour(%q) = %#x, %v, expected %#x", tt.text, got, err, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s     string
+		limit int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"exactly", 7, "exactly"},
+		{"too long", 5, "too …"},
+		// Limits count characters rather than bytes
+		{"ééééé", 5, "ééééé"},
+		{"éééééé", 5, "éééé…"},
+		{"anything", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.s, tt.limit); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, expected %q", tt.s, tt.limit, got, tt.want)
+		}
+	}
+}
+
+// fields creates n fields each with a name and value of the given lengths
+func fields(n, nameLength, valueLength int) []Field {
+	fs := []Field{}
+	for i := 0; i < n; i++ {
+		fs = append(fs, Field{Name: strings.Repeat("n", nameLength), Value: strings.Repeat("v", valueLength)})
+	}
+	return fs
+}
+
+func TestLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		embed Embed
+		check func(t *testing.T, e Embed)
+	}{
+		{
+			name:  "within the limits",
+			embed: Embed{Title: "Title", Description: "Description", Fields: fields(3, 5, 5), Footer: &Footer{Text: "Footer"}},
+			check: func(t *testing.T, e Embed) {
+				if e.Title != "Title" || e.Description != "Description" || len(e.Fields) != 3 || e.Footer.Text != "Footer" {
+					t.Errorf("expected the embed not to change, got %+v", e)
+				}
+			},
+		},
+		{
+			name: "long text",
+			embed: Embed{
+				Title:       strings.Repeat("t", TitleLimit+1),
+				Description: strings.Repeat("d", DescriptionLimit+1),
+				Fields:      fields(1, FieldNameLimit+1, FieldValueLimit+1),
+			},
+			check: func(t *testing.T, e Embed) {
+				lengths := []struct {
+					name  string
+					text  string
+					limit int
+				}{
+					{"title", e.Title, TitleLimit},
+					{"description", e.Description, DescriptionLimit},
+					{"field name", e.Fields[0].Name, FieldNameLimit},
+					{"field value", e.Fields[0].Value, FieldValueLimit},
+				}
+				for _, l := range lengths {
+					if n := utf8.RuneCountInString(l.text); n != l.limit || !strings.HasSuffix(l.text, ellipsis) {
+						t.Errorf("expected the %s to be truncated to %d characters, got %d", l.name, l.limit, n)
+					}
+				}
+			},
+		},
+		{
+			name:  "long footer",
+			embed: Embed{Footer: &Footer{Text: strings.Repeat("f", FooterLimit+1)}},
+			check: func(t *testing.T, e Embed) {
+				if n := utf8.RuneCountInString(e.Footer.Text); n != FooterLimit {
+					t.Errorf("expected the footer to be truncated to %d characters, got %d", FooterLimit, n)
+				}
+			},
+		},
+		{
+			name:  "too many fields",
+			embed: Embed{Fields: fields(FieldCountLimit+5, 1, 1)},
+			check: func(t *testing.T, e Embed) {
+				if len(e.Fields) != FieldCountLimit {
+					t.Errorf("expected %d fields, got %d", FieldCountLimit, len(e.Fields))
+				}
+			},
+		},
+		{
+			name: "too long in total",
+			// 24 fields of 256 + 1024 characters are too long together
+			embed: Embed{Title: "Title", Fields: fields(24, FieldNameLimit, FieldValueLimit)},
+			check: func(t *testing.T, e Embed) {
+				if e.Length() > TotalLimit {
+					t.Errorf("expected at most %d characters, got %d", TotalLimit, e.Length())
+				}
+				// Fields are removed from the end until the rest fit
+				if want := (TotalLimit - 5) / (FieldNameLimit + FieldValueLimit); len(e.Fields) != want {
+					t.Errorf("expected %d fields, got %d", want, len(e.Fields))
+				}
+			},
+		},
+		{
+			name: "too long in total without fields",
+			embed: Embed{
+				Title:       strings.Repeat("t", TitleLimit),
+				Description: strings.Repeat("d", DescriptionLimit),
+				Footer:      &Footer{Text: strings.Repeat("f", FooterLimit)},
+			},
+			check: func(t *testing.T, e Embed) {
+				if e.Length() != TotalLimit {
+					t.Errorf("expected %d characters, got %d", TotalLimit, e.Length())
+				}
+				// The description is shortened to make up the difference
+				want := TotalLimit - TitleLimit - FooterLimit
+				if n := utf8.RuneCountInString(e.Description); n != want || !strings.HasSuffix(e.Description, ellipsis) {
+					t.Errorf("expected the description to be truncated to %d characters, got %d", want, n)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, tt.embed.Limit())
+		})
+	}
+}
+
+func TestLimitDoesntChangeOriginal(t *testing.T) {
+	e := Embed{Fields: fields(FieldCountLimit+1, FieldNameLimit+1, 1), Footer: &Footer{Text: strings.Repeat("f", FooterLimit+1)}}
+	e.Limit()
+	if len(e.Fields) != FieldCountLimit+1 || len(e.Fields[0].Name) != FieldNameLimit+1 || len(e.Footer.Text) != FooterLimit+1 {
+		t.Fatalf("expected the original embed to be unchanged")
+	}
+}
+
+func TestHash(t *testing.T) {
+	a := Embed{Title: "Piston Tower", Fields: []Field{{Name: "Creators", Value: "player"}}}
+	b := Embed{Title: "Piston Tower", Fields: []Field{{Name: "Creators", Value: "player"}}}
+	if a.Hash() != b.Hash() {
+		t.Errorf("expected embeds with the same content to have the same hash")
+	}
+	b.Fields[0].Inline = true
+	if a.Hash() == b.Hash() {
+		t.Errorf("expected embeds with different content to have different hashes")
+	}
+}
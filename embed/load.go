@@ -0,0 +1,75 @@
+package embed
+
+import (
+	"sort"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// LoadBuild gets the information needed to render a build
+// from the database
+func LoadBuild(db *database.Database, b database.Build) (BuildData, error) {
+	d := BuildData{Build: b}
+	var err error
+	if d.Edition, _, err = db.Edition(b.EditionID); err != nil {
+		return BuildData{}, errors.Wrap(err, "failed to get edition")
+	}
+	if d.BuildClass, _, err = db.BuildClass(b.BuildClassID); err != nil {
+		return BuildData{}, errors.Wrap(err, "failed to get build class")
+	}
+	bvs, err := b.BuildVersions()
+	if err != nil {
+		return BuildData{}, errors.Wrap(err, "failed to get build versions")
+	}
+	for _, bv := range bvs {
+		v, ok, err := db.Version(bv.VersionID)
+		if err != nil {
+			return BuildData{}, errors.Wrap(err, "failed to get version")
+		} else if !ok {
+			continue
+		}
+		s, _, err := db.Status(bv.StatusID)
+		if err != nil {
+			return BuildData{}, errors.Wrap(err, "failed to get status")
+		}
+		d.Versions = append(d.Versions, VersionStatus{Version: v, Status: s, Notes: bv.Notes})
+	}
+	return d, nil
+}
+
+// LoadRecord gets the information needed to render a record
+// from the database
+func LoadRecord(db *database.Database, r database.Record) (RecordData, error) {
+	d := RecordData{Record: r}
+	var err error
+	if d.Edition, _, err = db.Edition(r.EditionID); err != nil {
+		return RecordData{}, errors.Wrap(err, "failed to get edition")
+	}
+	if d.BuildClass, _, err = db.BuildClass(r.BuildClassID); err != nil {
+		return RecordData{}, errors.Wrap(err, "failed to get build class")
+	}
+	if d.RecordType, _, err = db.RecordType(r.RecordTypeID); err != nil {
+		return RecordData{}, errors.Wrap(err, "failed to get record type")
+	}
+	brs, err := r.CurrentBuildRecords()
+	if err != nil {
+		return RecordData{}, errors.Wrap(err, "failed to get current build records")
+	}
+	for _, br := range brs {
+		b, ok, err := db.Build(br.BuildID)
+		if err != nil {
+			return RecordData{}, errors.Wrap(err, "failed to get build")
+		} else if !ok {
+			continue
+		}
+		d.Holders = append(d.Holders, Holder{BuildRecord: br, Build: b})
+	}
+	// Holders of smallest records are listed from smallest to biggest
+	if d.RecordType.Smallest() {
+		sort.SliceStable(d.Holders, func(i, j int) bool {
+			return database.CompareSmallest(d.Holders[i].Build, d.Holders[j].Build) < 0
+		})
+	}
+	return d, nil
+}
@@ -0,0 +1,76 @@
+package embed
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Kappeh/RecordBot/database"
+)
+
+// Holder is a build which holds a record
+type Holder struct {
+	// BuildRecord is the build record linking the build to the record
+	BuildRecord database.BuildRecord
+	// Build is the build which holds the record
+	Build database.Build
+}
+
+// RecordData is the information needed to render a record
+type RecordData struct {
+	// Record is the record being rendered
+	Record database.Record
+	// Edition is the edition of the record
+	Edition database.Edition
+	// BuildClass is the build class of the record
+	BuildClass database.BuildClass
+	// RecordType is the record type of the record
+	RecordType database.RecordType
+	// Holders are the builds which currently hold the record
+	Holders []Holder
+}
+
+// Record renders a record as an embed
+func Record(d RecordData) Embed {
+	r := d.Record
+	e := Embed{
+		Title:       r.Name,
+		Description: r.Description,
+	}
+	if c, err := ParseColour(d.BuildClass.EmbedColour); err == nil {
+		e.Colour = c
+	}
+	e.addField("Edition", d.Edition.Name, true)
+	e.addField("Class", d.BuildClass.Name, true)
+	e.addField("Record Type", d.RecordType.Name, true)
+	if len(d.Holders) == 0 {
+		e.addField("Current Holder", "Nobody holds this record yet", false)
+	} else {
+		name := "Current Holder"
+		if len(d.Holders) > 1 {
+			name = "Current Holders (Tied)"
+		}
+		lines := []string{}
+		for _, h := range d.Holders {
+			lines = append(lines, holderLine(h.Build))
+		}
+		e.addField(name, strings.Join(lines, "\n"), false)
+	}
+	for _, h := range d.Holders {
+		if h.Build.ImageURL != "" {
+			e.Image = &Image{URL: h.Build.ImageURL}
+			break
+		}
+	}
+	e.Footer = &Footer{Text: fmt.Sprintf("Record %s", r.ID)}
+	return e.Limit()
+}
+
+// holderLine describes a build holding a record in a single line
+func holderLine(b database.Build) string {
+	line := "**" + b.Name + "**"
+	if b.Creators != "" {
+		line += " by " + b.Creators
+	}
+	line += " (" + b.Dimensions().String() + ")"
+	return line
+}
@@ -0,0 +1,187 @@
+package embed
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Kappeh/RecordBot/database"
+)
+
+// testDB is the database shared by the tests
+// The database package only allows one database to be opened
+var testDB *database.Database
+
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "embed")
+	if err != nil {
+		panic(err)
+	}
+	testDB, err = database.Open(filepath.Join(dir, "database.db"))
+	if err != nil {
+		os.RemoveAll(dir)
+		panic(err)
+	}
+	code := m.Run()
+	testDB.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// holder creates a holder of a build with dimensions
+func holder(name, creators string, d database.Dimensions, imageURL string) Holder {
+	b := database.Build{Name: name, Creators: creators, ImageURL: imageURL}
+	b.SetDimensions(d)
+	return Holder{Build: b}
+}
+
+// field gets the value of a field of an embed
+func field(e Embed, name string) (string, bool) {
+	for _, f := range e.Fields {
+		if f.Name == name {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+func TestRecordHolders(t *testing.T) {
+	tower := holder("Piston Tower", "player", database.Dimensions{Width: 3, Height: 4, Depth: 5}, "")
+	door := holder("Piston Door", "", database.Dimensions{Width: 2, Height: 3, Depth: 1}, "https://example.com/door.png")
+	tests := []struct {
+		name    string
+		holders []Holder
+		field   string
+		value   string
+		image   string
+	}{
+		{
+			name:  "nobody",
+			field: "Current Holder",
+			value: "Nobody holds this record yet",
+		},
+		{
+			name:    "one holder",
+			holders: []Holder{tower},
+			field:   "Current Holder",
+			value:   "**Piston Tower** by player (3x4x5)",
+		},
+		{
+			name:    "tied holders",
+			holders: []Holder{tower, door},
+			field:   "Current Holders (Tied)",
+			value:   "**Piston Tower** by player (3x4x5)\n**Piston Door** (2x3x1)",
+			image:   "https://example.com/door.png",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Record(RecordData{
+				Record:     database.Record{ID: "7", Name: "Fastest Piston"},
+				BuildClass: database.BuildClass{EmbedColour: "#00ff00"},
+				Holders:    tt.holders,
+			})
+			if value, ok := field(e, tt.field); !ok || value != tt.value {
+				t.Errorf("expected field %q to be %q, got %q", tt.field, tt.value, value)
+			}
+			if tt.image == "" && e.Image != nil {
+				t.Errorf("expected no image, got %q", e.Image.URL)
+			} else if tt.image != "" && (e.Image == nil || e.Image.URL != tt.image) {
+				t.Errorf("expected image %q, got %+v", tt.image, e.Image)
+			}
+			if e.Title != "Fastest Piston" || e.Colour != 0x00ff00 || e.Footer == nil || e.Footer.Text != "Record 7" {
+				t.Errorf("unexpected embed %+v", e)
+			}
+		})
+	}
+}
+
+func TestLoadRecordHolders(t *testing.T) {
+	e, err := testDB.EditionCreate("Java", "Java Edition")
+	if err != nil {
+		t.Fatalf("failed to create edition: %v", err)
+	}
+	c, err := testDB.BuildClassCreate("Piston", "Piston builds", "", "")
+	if err != nil {
+		t.Fatalf("failed to create build class: %v", err)
+	}
+	// createHolders creates a record with two builds tied for it,
+	// the second being smaller than the first
+	createHolders := func(recordType string) database.Record {
+		rt, err := testDB.RecordTypeCreate(recordType, recordType+" builds")
+		if err != nil {
+			t.Fatalf("failed to create record type: %v", err)
+		}
+		r, err := testDB.RecordCreate(database.Record{
+			Verified:              true,
+			VerifierID:            "0",
+			UpdateRequestRecordID: "0",
+			EditionID:             e.ID,
+			BuildClassID:          c.ID,
+			RecordTypeID:          rt.ID,
+			Name:                  recordType + " Piston",
+			SubmitterID:           "0",
+		})
+		if err != nil {
+			t.Fatalf("failed to create record: %v", err)
+		}
+		jointID := "0"
+		for _, d := range []database.Dimensions{{Width: 4, Height: 3, Depth: 5}, {Width: 3, Height: 4, Depth: 5}} {
+			b := database.Build{
+				Verified:             true,
+				VerifierID:           "0",
+				EditionID:            e.ID,
+				BuildClassID:         c.ID,
+				Name:                 d.String(),
+				Creators:             "player",
+				ReporterID:           "0",
+				UpdateRequestBuildID: "0",
+				SubmitterID:          "0",
+			}
+			b.SetDimensions(d)
+			if b, err = testDB.BuildCreate(b); err != nil {
+				t.Fatalf("failed to create build: %v", err)
+			}
+			br, err := testDB.BuildRecordCreate(database.BuildRecord{
+				BuildID:            b.ID,
+				RecordID:           r.ID,
+				Verified:           true,
+				VerifierID:         "0",
+				ReporterID:         "0",
+				JointBuildRecord:   jointID != "0",
+				JointBuildRecordID: jointID,
+				SubmitterID:        "0",
+			})
+			if err != nil {
+				t.Fatalf("failed to create build record: %v", err)
+			}
+			jointID = br.ID
+		}
+		return r
+	}
+	tests := []struct {
+		recordType string
+		want       []string
+	}{
+		// Holders are listed in the order they got the record
+		{"Fastest", []string{"4x3x5", "3x4x5"}},
+		// Unless they're smallest first
+		{"Smallest", []string{"3x4x5", "4x3x5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.recordType, func(t *testing.T) {
+			d, err := LoadRecord(testDB, createHolders(tt.recordType))
+			if err != nil {
+				t.Fatalf("failed to load record: %v", err)
+			}
+			got := []string{}
+			for _, h := range d.Holders {
+				got = append(got, h.Build.Name)
+			}
+			if len(got) != len(tt.want) || got[0] != tt.want[0] || got[1] != tt.want[1] {
+				t.Fatalf("expected holders %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package interaction
+
+import (
+	"strings"
+
+	"github.com/Kappeh/RecordBot/command"
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// complete suggests values of a kind that match what has been typed
+// Builds and records are suggested by name with their id as the value
+func complete(db *database.Database, kind command.Completion, typed string) ([]Choice, error) {
+	typed = strings.ToLower(strings.TrimSpace(typed))
+	choices := []Choice{}
+	add := func(name, value string) {
+		if len(choices) >= ChoiceLimit {
+			return
+		}
+		if !strings.Contains(strings.ToLower(name), typed) && value != typed {
+			return
+		}
+		choices = append(choices, Choice{Name: limit(name, ChoiceNameLimit), Value: value})
+	}
+	switch kind {
+	case command.CompleteBuild:
+		builds, err := db.Builds()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get builds")
+		}
+		for _, b := range builds {
+			add(b.Name+" (#"+b.ID+")"+pending(b.Verified), b.ID)
+		}
+	case command.CompleteRecord:
+		records, err := db.Records()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get records")
+		}
+		for _, r := range records {
+			add(r.Name+" (#"+r.ID+")"+pending(r.Verified), r.ID)
+		}
+	case command.CompleteEdition:
+		editions, err := db.Editions()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get editions")
+		}
+		for _, e := range editions {
+			add(e.Name, e.Name)
+		}
+	case command.CompleteBuildClass:
+		classes, err := db.BuildClasses()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get build classes")
+		}
+		for _, c := range classes {
+			add(c.Name, c.Name)
+		}
+	case command.CompleteRecordType:
+		types, err := db.RecordTypes()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get record types")
+		}
+		for _, t := range types {
+			add(t.Name, t.Name)
+		}
+	}
+	return choices, nil
+}
+
+// pending marks unverified builds and records in suggestions
+func pending(verified bool) string {
+	if verified {
+		return ""
+	}
+	return " [pending]"
+}
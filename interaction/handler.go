@@ -0,0 +1,265 @@
+package interaction
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Kappeh/RecordBot/command"
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/Kappeh/RecordBot/embed"
+	"github.com/pkg/errors"
+)
+
+// Handler routes interactions to the commands of a router
+type Handler struct {
+	// Router has the commands that interactions are routed to
+	Router *command.Router
+	// DB is the database commands act on
+	DB *database.Database
+	// Session is used by commands for anything other than
+	// replying to the interaction
+	Session command.Session
+
+	// PublicKey is the application's public key used to verify
+	// that requests to ServeHTTP were sent by discord
+	PublicKey ed25519.PublicKey
+	// OnError is called with errors that occur in ServeHTTP
+	// after a response has been made. It may be nil
+	OnError func(err error)
+}
+
+// Handle responds to an interaction
+// A response is returned along with any error from a command so that
+// the user is always told when something goes wrong
+func (h *Handler) Handle(i Interaction) (Response, error) {
+	switch i.Type {
+	case TypePing:
+		return Response{Type: ResponsePong}, nil
+	case TypeApplicationCommand:
+		return h.handleCommand(i)
+	case TypeAutocomplete:
+		return h.handleAutocomplete(i)
+	}
+	return Response{}, errors.Errorf("unsupported interaction type %d", i.Type)
+}
+
+// HandleJSON responds to a json encoded interaction
+// with a json encoded response
+func (h *Handler) HandleJSON(data []byte) ([]byte, error) {
+	var i Interaction
+	if err := json.Unmarshal(data, &i); err != nil {
+		return nil, errors.Wrap(err, "failed to decode interaction")
+	}
+	res, handleErr := h.Handle(i)
+	if res.Type == 0 {
+		return nil, handleErr
+	}
+	out, err := json.Marshal(res)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode response")
+	}
+	return out, handleErr
+}
+
+// ServeHTTP responds to interactions sent to an interactions endpoint
+// Requests that aren't signed with the application's key are rejected
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if !h.verify(req, body) {
+		http.Error(w, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+	out, err := h.HandleJSON(body)
+	if err != nil && h.OnError != nil {
+		h.OnError(err)
+	}
+	if out == nil {
+		http.Error(w, "failed to handle interaction", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out)
+}
+
+// verify checks the signature of a request
+func (h *Handler) verify(req *http.Request, body []byte) bool {
+	sig, err := hex.DecodeString(req.Header.Get("X-Signature-Ed25519"))
+	if err != nil || len(sig) != ed25519.SignatureSize || len(h.PublicKey) != ed25519.PublicKeySize {
+		return false
+	}
+	msg := append([]byte(req.Header.Get("X-Signature-Timestamp")), body...)
+	return ed25519.Verify(h.PublicKey, msg, sig)
+}
+
+// handleCommand runs the command used in an interaction
+func (h *Handler) handleCommand(i Interaction) (Response, error) {
+	if i.Data == nil {
+		return Response{}, errors.New("application command interaction has no data")
+	}
+	path, options, ok := h.resolve(i.Data)
+	if !ok {
+		return message("That command doesn't exist anymore.", true), nil
+	}
+	args := command.Args{}
+	c := path[len(path)-1]
+	for _, a := range c.Args {
+		o, ok := findOption(options, a.Name)
+		if !ok {
+			continue
+		}
+		v, err := command.ParseArg(a, optionString(o))
+		if err != nil {
+			return message("Invalid "+a.Name+": "+err.Error(), true), nil
+		}
+		args[a.Name] = v
+	}
+	rs := &responder{Session: h.Session, channelID: i.ChannelID}
+	if i.Member != nil {
+		// The member's roles are sent with the interaction
+		rs.roles = i.Member.Roles
+		rs.userID = i.UserID()
+	}
+	m := command.Message{GuildID: i.GuildID, ChannelID: i.ChannelID, AuthorID: i.UserID()}
+	err := h.Router.Execute(rs, h.DB, m, path, args)
+	return rs.response(), err
+}
+
+// handleAutocomplete suggests values for the option being typed
+func (h *Handler) handleAutocomplete(i Interaction) (Response, error) {
+	res := Response{Type: ResponseAutocompleteResult, Data: &ResponseData{Choices: []Choice{}}}
+	if i.Data == nil {
+		return res, nil
+	}
+	path, options, ok := h.resolve(i.Data)
+	if !ok {
+		return res, nil
+	}
+	for _, o := range options {
+		if !o.Focused {
+			continue
+		}
+		for _, a := range path[len(path)-1].Args {
+			if a.Name != o.Name {
+				continue
+			}
+			choices, err := complete(h.DB, a.Complete, optionString(o))
+			if err != nil {
+				return res, err
+			}
+			res.Data.Choices = choices
+		}
+	}
+	return res, nil
+}
+
+// resolve finds the command used in an interaction
+// The command and its parents are returned along with its options
+func (h *Handler) resolve(d *Data) ([]*command.Command, []DataOption, bool) {
+	words := []string{d.Name}
+	options := d.Options
+	for len(options) == 1 && (options[0].Type == OptionSubCommand || options[0].Type == OptionSubCommandGroup) {
+		words = append(words, options[0].Name)
+		options = options[0].Options
+	}
+	path, n := h.Router.Find(words)
+	if n != len(words) {
+		return nil, nil, false
+	}
+	return path, options, true
+}
+
+// findOption finds an option by name
+func findOption(options []DataOption, name string) (DataOption, bool) {
+	for _, o := range options {
+		if o.Name == name {
+			return o, true
+		}
+	}
+	return DataOption{}, false
+}
+
+// optionString gets the value of an option as it would be typed
+func optionString(o DataOption) string {
+	var s string
+	if err := json.Unmarshal(o.Value, &s); err == nil {
+		return s
+	}
+	var f float64
+	if err := json.Unmarshal(o.Value, &f); err == nil {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return strings.Trim(string(o.Value), `"`)
+}
+
+// message creates a response containing a message
+func message(content string, ephemeral bool) Response {
+	d := &ResponseData{Content: limit(content, ContentLimit)}
+	if ephemeral {
+		d.Flags = FlagEphemeral
+	}
+	return Response{Type: ResponseChannelMessageWithSource, Data: d}
+}
+
+// responder is a session which collects the replies to an interaction
+// so that they can be sent as the interaction's response
+// Messages sent to other channels are sent through the wrapped session
+type responder struct {
+	command.Session
+	channelID string
+	// userID and roles are the interaction's member and their roles
+	userID string
+	roles  []string
+
+	lines  []string
+	embeds []embed.Embed
+}
+
+// Send collects a reply or sends a message to another channel
+func (r *responder) Send(channelID, content string) error {
+	if channelID != r.channelID {
+		return r.Session.Send(channelID, content)
+	}
+	r.lines = append(r.lines, content)
+	return nil
+}
+
+// SendEmbed collects a reply or sends an embed to another channel
+func (r *responder) SendEmbed(channelID string, e embed.Embed) error {
+	if channelID != r.channelID {
+		return r.Session.SendEmbed(channelID, e)
+	}
+	r.embeds = append(r.embeds, e)
+	return nil
+}
+
+// Roles gets the roles of the interaction's member without a request
+// or gets the roles of other members through the wrapped session
+func (r *responder) Roles(guildID, userID string) ([]string, error) {
+	if r.roles != nil && userID == r.userID {
+		return r.roles, nil
+	}
+	return r.Session.Roles(guildID, userID)
+}
+
+// response gets the response containing the collected replies
+func (r *responder) response() Response {
+	res := message(strings.Join(r.lines, "\n"), false)
+	if len(r.lines) == 0 && len(r.embeds) == 0 {
+		res.Data.Content = "Done."
+		res.Data.Flags = FlagEphemeral
+	}
+	res.Data.Embeds = r.embeds
+	if len(res.Data.Embeds) > EmbedLimit {
+		res.Data.Embeds = res.Data.Embeds[:EmbedLimit]
+	}
+	return res
+}
@@ -0,0 +1,269 @@
+package interaction
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Kappeh/RecordBot/command"
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/Kappeh/RecordBot/embed"
+)
+
+// testDB is the database shared by the tests
+// The database package only allows one database to be opened
+var testDB *database.Database
+
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "interaction")
+	if err != nil {
+		panic(err)
+	}
+	testDB, err = database.Open(filepath.Join(dir, "database.db"))
+	if err != nil {
+		os.RemoveAll(dir)
+		panic(err)
+	}
+	if err = seed(testDB); err != nil {
+		testDB.Close()
+		os.RemoveAll(dir)
+		panic(err)
+	}
+	code := m.Run()
+	testDB.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// seed adds the build that the recorded interactions refer to
+func seed(db *database.Database) error {
+	e, err := db.EditionCreate("Java", "Java Edition")
+	if err != nil {
+		return err
+	}
+	c, err := db.BuildClassCreate("Piston", "Piston builds", "", "")
+	if err != nil {
+		return err
+	}
+	_, err = db.BuildCreate(database.Build{
+		EditionID:            e.ID,
+		BuildClassID:         c.ID,
+		Name:                 "Piston Tower",
+		Creators:             "player",
+		VerifierID:           "0",
+		ReporterID:           "0",
+		UpdateRequestBuildID: "0",
+		SubmitterID:          "0",
+	})
+	return err
+}
+
+// fakeSession is a session where every user has the same permission
+// Messages sent through it are recorded
+type fakeSession struct {
+	permission command.Permission
+	sent       []string
+}
+
+func (s *fakeSession) Send(channelID, content string) error {
+	s.sent = append(s.sent, channelID+": "+content)
+	return nil
+}
+
+func (s *fakeSession) SendEmbed(channelID string, e embed.Embed) error {
+	s.sent = append(s.sent, channelID+": "+e.Title)
+	return nil
+}
+
+func (s *fakeSession) Permission(guildID, userID string) (command.Permission, error) {
+	return s.permission, nil
+}
+
+func (s *fakeSession) Roles(guildID, userID string) ([]string, error) {
+	return nil, nil
+}
+
+func (s *fakeSession) CreateTicketChannel(guildID, categoryID, name, creatorID string) (string, error) {
+	return "", nil
+}
+
+func (s *fakeSession) DeleteChannel(channelID string) error {
+	return nil
+}
+
+// newHandler creates a handler with all of the bot's commands
+func newHandler(t *testing.T, s command.Session) *Handler {
+	t.Helper()
+	r, err := command.NewDefaultRouter("!")
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+	return &Handler{Router: r, DB: testDB, Session: s}
+}
+
+// fixture reads a recorded interaction from testdata
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	return data
+}
+
+// handleFixture feeds a recorded interaction to a handler
+// and decodes the response
+func handleFixture(t *testing.T, h *Handler, name string) Response {
+	t.Helper()
+	out, err := h.HandleJSON(fixture(t, name))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var res Response
+	if err = json.Unmarshal(out, &res); err != nil {
+		t.Fatalf("failed to decode response %s: %v", out, err)
+	}
+	return res
+}
+
+func TestHandlePing(t *testing.T) {
+	res := handleFixture(t, newHandler(t, &fakeSession{}), "ping.json")
+	if res.Type != ResponsePong || res.Data != nil {
+		t.Fatalf("expected pong, got %+v", res)
+	}
+}
+
+func TestHandleCommand(t *testing.T) {
+	tests := []struct {
+		name       string
+		fixture    string
+		permission command.Permission
+		// content is text the response's content must contain
+		content   string
+		embeds    int
+		ephemeral bool
+	}{
+		{
+			name:    "subcommand",
+			fixture: "build_info.json",
+			embeds:  1,
+		},
+		{
+			name:    "missing build",
+			fixture: "build_info_missing.json",
+			content: "there isn't a build with id 99",
+		},
+		{
+			name:      "invalid argument",
+			fixture:   "build_info_invalid.json",
+			content:   "Invalid build: ",
+			ephemeral: true,
+		},
+		{
+			name:      "unknown command",
+			fixture:   "unknown_command.json",
+			content:   "That command doesn't exist anymore.",
+			ephemeral: true,
+		},
+		{
+			name:       "without permission",
+			fixture:    "build_verify.json",
+			permission: command.PermissionEveryone,
+			content:    "you don't have permission to use this command",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &fakeSession{permission: tt.permission}
+			res := handleFixture(t, newHandler(t, s), tt.fixture)
+			if res.Type != ResponseChannelMessageWithSource || res.Data == nil {
+				t.Fatalf("expected a message, got %+v", res)
+			}
+			if !strings.Contains(res.Data.Content, tt.content) {
+				t.Errorf("expected content containing %q, got %q", tt.content, res.Data.Content)
+			}
+			if len(res.Data.Embeds) != tt.embeds {
+				t.Errorf("expected %d embeds, got %d", tt.embeds, len(res.Data.Embeds))
+			}
+			if ephemeral := res.Data.Flags&FlagEphemeral != 0; ephemeral != tt.ephemeral {
+				t.Errorf("expected ephemeral %v, got %v", tt.ephemeral, ephemeral)
+			}
+			if len(s.sent) != 0 {
+				t.Errorf("expected replies in the response, got %v sent", s.sent)
+			}
+		})
+	}
+}
+
+func TestHandleAutocomplete(t *testing.T) {
+	res := handleFixture(t, newHandler(t, &fakeSession{}), "build_info_autocomplete.json")
+	if res.Type != ResponseAutocompleteResult || res.Data == nil {
+		t.Fatalf("expected autocomplete results, got %+v", res)
+	}
+	want := []Choice{{Name: "Piston Tower (#1) [pending]", Value: "1"}}
+	if len(res.Data.Choices) != len(want) || res.Data.Choices[0] != want[0] {
+		t.Fatalf("expected choices %v, got %v", want, res.Data.Choices)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	h := newHandler(t, &fakeSession{})
+	h.PublicKey = public
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	body := fixture(t, "ping.json")
+	timestamp := "1600000000"
+	signature := hex.EncodeToString(ed25519.Sign(private, append([]byte(timestamp), body...)))
+	tests := []struct {
+		name      string
+		signature string
+		timestamp string
+		status    int
+	}{
+		{name: "signed", signature: signature, timestamp: timestamp, status: http.StatusOK},
+		{name: "unsigned", status: http.StatusUnauthorized},
+		{name: "wrong timestamp", signature: signature, timestamp: "1600000001", status: http.StatusUnauthorized},
+		{name: "malformed signature", signature: "zz", timestamp: timestamp, status: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, server.URL, bytes.NewReader(body))
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			req.Header.Set("X-Signature-Ed25519", tt.signature)
+			req.Header.Set("X-Signature-Timestamp", tt.timestamp)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, resp.StatusCode)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			out, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read response: %v", err)
+			}
+			if strings.TrimSpace(string(out)) != `{"type":1}` {
+				t.Fatalf("expected pong, got %s", out)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package interaction
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// DefaultBaseURL is the base url of the discord api
+const DefaultBaseURL = "https://discord.com/api/v10"
+
+// Doer sends http requests
+// It's implemented by *http.Client
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Registrar registers slash commands with discord
+type Registrar struct {
+	// Client sends requests to discord
+	Client Doer
+	// BaseURL is the base url of the discord api
+	BaseURL string
+	// Token is the bot's token
+	Token string
+	// ApplicationID is the id of the bot's application
+	ApplicationID string
+}
+
+// NewRegistrar creates a registrar which uses the default http client
+func NewRegistrar(token, applicationID string) *Registrar {
+	return &Registrar{
+		Client:        http.DefaultClient,
+		BaseURL:       DefaultBaseURL,
+		Token:         token,
+		ApplicationID: applicationID,
+	}
+}
+
+// RegisterGuild replaces the slash commands of a guild
+func (r *Registrar) RegisterGuild(guildID string, cmds []ApplicationCommand) error {
+	body, err := json.Marshal(cmds)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode commands")
+	}
+	url := fmt.Sprintf("%s/applications/%s/guilds/%s/commands", r.BaseURL, r.ApplicationID, guildID)
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Authorization", "Bot "+r.Token)
+	req.Header.Set("Content-Type", "application/json")
+	res, err := r.Client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "request failed")
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(res.Body)
+		return errors.Errorf("discord responded with %s: %s", res.Status, msg)
+	}
+	return nil
+}
+
+// RegisterGuilds replaces the slash commands of every guild
+// that has guild settings
+func (r *Registrar) RegisterGuilds(db *database.Database, cmds []ApplicationCommand) error {
+	settings, err := db.GuildSettings()
+	if err != nil {
+		return errors.Wrap(err, "failed to get guild settings")
+	}
+	for _, gs := range settings {
+		if err = r.RegisterGuild(gs.GuildID, cmds); err != nil {
+			return errors.Wrapf(err, "failed to register commands in guild %s", gs.GuildID)
+		}
+	}
+	return nil
+}
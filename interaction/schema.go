@@ -0,0 +1,100 @@
+package interaction
+
+import (
+	"unicode/utf8"
+
+	"github.com/Kappeh/RecordBot/command"
+)
+
+// Schemas gets the slash command schemas of a router's commands
+func Schemas(r *command.Router) []ApplicationCommand {
+	cmds := []ApplicationCommand{}
+	for _, c := range r.Commands() {
+		ac := ApplicationCommand{
+			Name:        c.Name,
+			Description: description(c.Description, c.Name),
+			Options:     commandOptions(c, 0),
+		}
+		if c.GuildOnly {
+			dm := false
+			ac.DMPermission = &dm
+		}
+		cmds = append(cmds, ac)
+	}
+	return cmds
+}
+
+// commandOptions gets the options of a command, which are its
+// subcommands if it has any and otherwise its arguments
+// depth is how deeply the command is nested
+func commandOptions(c *command.Command, depth int) []Option {
+	if len(c.Subcommands) == 0 {
+		options := []Option{}
+		for _, a := range c.Args {
+			options = append(options, argOption(a))
+		}
+		return options
+	}
+	options := []Option{}
+	for _, sub := range c.Subcommands {
+		o := Option{
+			Type:        OptionSubCommand,
+			Name:        sub.Name,
+			Description: description(sub.Description, sub.Name),
+			Options:     commandOptions(sub, depth+1),
+		}
+		// Discord allows subcommands to be grouped only once
+		if len(sub.Subcommands) > 0 {
+			if depth > 0 {
+				continue
+			}
+			o.Type = OptionSubCommandGroup
+		}
+		options = append(options, o)
+	}
+	return options
+}
+
+// argOption gets the option of an argument
+func argOption(a command.Arg) Option {
+	o := Option{
+		Type:        OptionString,
+		Name:        a.Name,
+		Description: description(a.Description, a.Name),
+		Required:    !a.Optional,
+	}
+	switch a.Type {
+	case command.ArgInt:
+		o.Type = OptionInteger
+	case command.ArgUser:
+		o.Type = OptionUser
+	case command.ArgRole:
+		o.Type = OptionRole
+	case command.ArgDuration:
+		o.Description = description(a.Description+" (e.g. 27gt or 1.35s)", a.Name)
+	case command.ArgDimensions:
+		o.Description = description(a.Description+" (e.g. 3x4x5)", a.Name)
+	}
+	for _, c := range a.Choices {
+		o.Choices = append(o.Choices, Choice{Name: c, Value: c})
+	}
+	o.Autocomplete = a.Complete != command.CompleteNone && len(o.Choices) == 0
+	return o
+}
+
+// description gets a description within discord's limits
+// Discord requires a description so the name is used if it's empty
+func description(s, name string) string {
+	if s == "" {
+		s = name
+	}
+	return limit(s, DescriptionLimit)
+}
+
+// limit shortens s to at most n characters
+func limit(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
@@ -0,0 +1,197 @@
+package interaction
+
+import (
+	"encoding/json"
+
+	"github.com/Kappeh/RecordBot/embed"
+)
+
+// Limits imposed by discord on application commands
+// https://discord.com/developers/docs/interactions/application-commands
+const (
+	// DescriptionLimit is the maximum length of a command or option description
+	DescriptionLimit = 100
+	// ChoiceLimit is the maximum number of choices of an option
+	// and of autocomplete results
+	ChoiceLimit = 25
+	// ChoiceNameLimit is the maximum length of a choice name
+	ChoiceNameLimit = 100
+	// EmbedLimit is the maximum number of embeds in a response
+	EmbedLimit = 10
+	// ContentLimit is the maximum length of the content of a response
+	ContentLimit = 2000
+)
+
+// ApplicationCommand is the schema of a slash command
+type ApplicationCommand struct {
+	// Name is the name of the command
+	Name string `json:"name"`
+	// Description is the description of the command
+	Description string `json:"description"`
+	// Options are the subcommands or arguments of the command
+	Options []Option `json:"options,omitempty"`
+	// DMPermission indicates whether the command can be used
+	// in direct messages
+	DMPermission *bool `json:"dm_permission,omitempty"`
+}
+
+// OptionType is the type of an application command option
+type OptionType int
+
+// Types of application command options
+const (
+	OptionSubCommand      OptionType = 1
+	OptionSubCommandGroup OptionType = 2
+	OptionString          OptionType = 3
+	OptionInteger         OptionType = 4
+	OptionBoolean         OptionType = 5
+	OptionUser            OptionType = 6
+	OptionChannel         OptionType = 7
+	OptionRole            OptionType = 8
+	OptionMentionable     OptionType = 9
+	OptionNumber          OptionType = 10
+)
+
+// Option is a subcommand or argument of an application command
+type Option struct {
+	// Type is the type of the option
+	Type OptionType `json:"type"`
+	// Name is the name of the option
+	Name string `json:"name"`
+	// Description is the description of the option
+	Description string `json:"description"`
+	// Required indicates whether the option must be given
+	Required bool `json:"required,omitempty"`
+	// Choices are the values the option can take
+	Choices []Choice `json:"choices,omitempty"`
+	// Options are the options of a subcommand or subcommand group
+	Options []Option `json:"options,omitempty"`
+	// Autocomplete indicates whether the option is autocompleted
+	Autocomplete bool `json:"autocomplete,omitempty"`
+}
+
+// Choice is a value an option can take
+type Choice struct {
+	// Name is the name of the choice shown to the user
+	Name string `json:"name"`
+	// Value is the value of the option when the choice is picked
+	Value interface{} `json:"value"`
+}
+
+// Type is the type of an interaction
+type Type int
+
+// Types of interactions
+const (
+	TypePing               Type = 1
+	TypeApplicationCommand Type = 2
+	TypeMessageComponent   Type = 3
+	TypeAutocomplete       Type = 4
+)
+
+// Interaction is an interaction sent by discord
+type Interaction struct {
+	// ID is the id of the interaction
+	ID string `json:"id"`
+	// ApplicationID is the id of the application the interaction is for
+	ApplicationID string `json:"application_id"`
+	// Type is the type of the interaction
+	Type Type `json:"type"`
+	// Data is the command that was used
+	Data *Data `json:"data,omitempty"`
+	// GuildID is the id of the guild the interaction was sent from
+	// It's empty for direct messages
+	GuildID string `json:"guild_id,omitempty"`
+	// ChannelID is the id of the channel the interaction was sent from
+	ChannelID string `json:"channel_id,omitempty"`
+	// Member is the member that sent the interaction within a guild
+	Member *Member `json:"member,omitempty"`
+	// User is the user that sent the interaction in direct messages
+	User *User `json:"user,omitempty"`
+	// Token is the token used to follow up the interaction
+	Token string `json:"token"`
+}
+
+// UserID gets the id of the user that sent the interaction
+func (i Interaction) UserID() string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
+
+// Data is the command used in an interaction
+type Data struct {
+	// ID is the id of the command
+	ID string `json:"id"`
+	// Name is the name of the command
+	Name string `json:"name"`
+	// Options are the subcommand or arguments given
+	Options []DataOption `json:"options,omitempty"`
+}
+
+// DataOption is a subcommand or argument given in an interaction
+type DataOption struct {
+	// Name is the name of the option
+	Name string `json:"name"`
+	// Type is the type of the option
+	Type OptionType `json:"type"`
+	// Value is the json encoded value of the option
+	Value json.RawMessage `json:"value,omitempty"`
+	// Options are the options of a subcommand or subcommand group
+	Options []DataOption `json:"options,omitempty"`
+	// Focused indicates whether the option is being autocompleted
+	Focused bool `json:"focused,omitempty"`
+}
+
+// Member is a member of a guild
+type Member struct {
+	// User is the user of the member
+	User *User `json:"user,omitempty"`
+	// Roles are the ids of the member's roles
+	Roles []string `json:"roles,omitempty"`
+}
+
+// User is a discord user
+type User struct {
+	// ID is the id of the user
+	ID string `json:"id"`
+	// Username is the name of the user
+	Username string `json:"username,omitempty"`
+}
+
+// ResponseType is the type of a response to an interaction
+type ResponseType int
+
+// Types of interaction responses
+const (
+	ResponsePong                     ResponseType = 1
+	ResponseChannelMessageWithSource ResponseType = 4
+	ResponseAutocompleteResult       ResponseType = 8
+)
+
+// FlagEphemeral makes a response only visible to the user
+const FlagEphemeral = 1 << 6
+
+// Response is a response to an interaction
+type Response struct {
+	// Type is the type of the response
+	Type ResponseType `json:"type"`
+	// Data is the content of the response
+	Data *ResponseData `json:"data,omitempty"`
+}
+
+// ResponseData is the content of a response
+type ResponseData struct {
+	// Content is the text of the message
+	Content string `json:"content,omitempty"`
+	// Embeds are the embeds of the message
+	Embeds []embed.Embed `json:"embeds,omitempty"`
+	// Flags are the message flags such as FlagEphemeral
+	Flags int `json:"flags,omitempty"`
+	// Choices are the results of autocompletion
+	Choices []Choice `json:"choices,omitempty"`
+}
@@ -0,0 +1,158 @@
+package manifest
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// errDryRun rolls back the import transaction of a dry run
+var errDryRun = errors.New("dry run")
+
+// Options change how a version list is imported
+type Options struct {
+	// DryRun reports what would be imported without
+	// changing the database
+	DryRun bool
+	// ReleasesOnly leaves out snapshots, pre-releases, release
+	// candidates, betas and previews
+	ReleasesOnly bool
+}
+
+// Skipped is a listed version that wasn't imported
+type Skipped struct {
+	// ID is the name of the version in the list
+	ID string
+	// Reason is why the version wasn't imported
+	Reason string
+}
+
+// Report is the outcome of importing a version list
+type Report struct {
+	// Added are the versions that were created, oldest first
+	Added []database.Version
+	// Updated are the versions that already existed without
+	// a release time and were given the listed one
+	Updated []database.Version
+	// Unchanged is the number of listed versions that already existed
+	Unchanged int
+	// Skipped are the listed versions that weren't imported
+	Skipped []Skipped
+}
+
+// listed is a version of a list with its version number
+type listed struct {
+	Entry
+	number database.VersionNumber
+}
+
+// Import creates the versions of a list that an edition doesn't have yet
+// Versions are matched by version number, or by name for snapshots,
+// so importing a list more than once doesn't create duplicates.
+// Everything is imported in a single transaction from the oldest to the
+// newest version, so version hooks see them in the order they came out
+// Snapshots are given the release numbers of the release that came
+// after them, e.g. 20w14a is part of 1.16, so that they're ordered
+// amongst the releases
+func Import(db *database.Database, editionID string, entries []Entry, opts Options) (Report, error) {
+	report := Report{Added: []database.Version{}, Updated: []database.Version{}, Skipped: []Skipped{}}
+	// Parse the version numbers before changing anything
+	versions := []listed{}
+	for _, e := range entries {
+		if e.Kind == KindOld {
+			report.Skipped = append(report.Skipped, Skipped{e.ID, "old alphas and betas aren't imported"})
+			continue
+		}
+		if e.Kind == KindSnapshot && opts.ReleasesOnly {
+			report.Skipped = append(report.Skipped, Skipped{e.ID, "only releases are imported"})
+			continue
+		}
+		n, err := database.ParseVersionNumber(e.ID)
+		if err != nil {
+			report.Skipped = append(report.Skipped, Skipped{e.ID, err.Error()})
+			continue
+		}
+		if e.Kind == KindRelease && n.Type != database.VersionRelease {
+			report.Skipped = append(report.Skipped, Skipped{e.ID, "it's listed as a release but isn't numbered like one"})
+			continue
+		}
+		// Bedrock betas and previews are numbered like releases
+		if e.Kind == KindSnapshot && n.Type == database.VersionRelease {
+			n.Type = database.VersionPreview
+		}
+		versions = append(versions, listed{e, n})
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Released.Before(versions[j].Released)
+	})
+	numberSnapshots(versions)
+
+	err := db.Transaction(func(tx *database.Database) error {
+		if _, ok, err := tx.Edition(editionID); err != nil {
+			return errors.Wrap(err, "failed to get edition")
+		} else if !ok {
+			return errors.Errorf("edition %s doesn't exist", editionID)
+		}
+		for _, p := range versions {
+			existing, ok, err := tx.ParseVersion(editionID, p.ID)
+			if err != nil {
+				return errors.Wrapf(err, "failed to find version %s", p.ID)
+			}
+			if ok {
+				// Versions without a release time are stored with the zero time
+				if t := time.Time(existing.VersionTimestamp); t.Year() > 1 || p.Released.IsZero() {
+					report.Unchanged++
+					continue
+				}
+				existing.VersionTimestamp = database.Timestamp(p.Released)
+				if existing, _, err = tx.VersionEdit(existing.ID, existing); err != nil {
+					return errors.Wrapf(err, "failed to update version %s", p.ID)
+				}
+				report.Updated = append(report.Updated, existing)
+				continue
+			}
+			v := database.Version{EditionID: editionID, VersionTimestamp: database.Timestamp(p.Released)}
+			v.SetNumber(p.number)
+			if v, err = tx.VersionCreate(v); err != nil {
+				return errors.Wrapf(err, "failed to create version %s", p.ID)
+			}
+			report.Added = append(report.Added, v)
+		}
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && err != errDryRun {
+		return Report{}, err
+	}
+	return report, nil
+}
+
+// numberSnapshots gives snapshots that only have a name the release
+// numbers of the next release. Snapshots after the last release are
+// part of the minor release after it
+// The versions must be sorted from oldest to newest
+func numberSnapshots(versions []listed) {
+	var next database.VersionNumber
+	found := false
+	for i := len(versions) - 1; i >= 0; i-- {
+		if n := versions[i].number; n.Type == database.VersionRelease {
+			next = database.VersionNumber{Major: n.Major, Minor: n.Minor + 1}
+			found = true
+			break
+		}
+	}
+	for i := len(versions) - 1; i >= 0; i-- {
+		n := &versions[i].number
+		switch {
+		case n.Type == database.VersionRelease:
+			next = *n
+		case found && n.Type == database.VersionSnapshot && n.Major == 0 && n.Minor == 0:
+			n.Major, n.Minor, n.Patch, n.HasPatch = next.Major, next.Minor, next.Patch, next.HasPatch
+			n.Revision, n.HasRevision = next.Revision, next.HasRevision
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package manifest
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Format is the layout of a version list
+type Format int
+
+const (
+	// FormatJava is the layout of Mojang's version_manifest.json
+	//
+	//	{
+	//		"latest": {"release": "1.16.5", "snapshot": "21w08b"},
+	//		"versions": [
+	//			{"id": "21w08b", "type": "snapshot", "releaseTime": "2021-02-24T14:54:23+00:00"},
+	//			{"id": "1.16.5", "type": "release", "releaseTime": "2021-01-14T16:05:32+00:00"}
+	//		]
+	//	}
+	FormatJava Format = iota
+	// FormatBedrock is a list of Bedrock versions where the channel
+	// is release, beta or preview and the release time is a date
+	// or a time like those of FormatJava
+	//
+	//	{
+	//		"versions": [
+	//			{"version": "1.16.210.53", "channel": "beta", "released": "2021-02-03"},
+	//			{"version": "1.16.201", "channel": "release", "released": "2021-01-14"}
+	//		]
+	//	}
+	FormatBedrock
+)
+
+// Formats are the names of the formats
+var Formats = map[string]Format{
+	"java":    FormatJava,
+	"bedrock": FormatBedrock,
+}
+
+// dateLayout is the layout of release dates without a time
+const dateLayout = "2006-01-02"
+
+// Kind is the kind of a listed version
+type Kind int
+
+const (
+	// KindRelease is a full release
+	KindRelease Kind = iota
+	// KindSnapshot is a development version such as a snapshot,
+	// pre-release, release candidate, beta or preview
+	KindSnapshot
+	// KindOld is an old alpha or beta of Java Edition
+	// They aren't imported
+	KindOld
+)
+
+// String gets the name of the kind
+func (k Kind) String() string {
+	switch k {
+	case KindRelease:
+		return "release"
+	case KindSnapshot:
+		return "snapshot"
+	case KindOld:
+		return "old"
+	}
+	return "unknown"
+}
+
+// Entry is a version in a version list
+type Entry struct {
+	// ID is the name of the version such as 1.16.5, 20w14a or 1.16-pre3
+	ID string
+	// Kind is the kind of version
+	Kind Kind
+	// Released is the time the version was released
+	// It's zero if the list doesn't give it
+	Released time.Time
+}
+
+// javaManifest is the content of version_manifest.json
+type javaManifest struct {
+	Versions []struct {
+		ID          string `json:"id"`
+		Type        string `json:"type"`
+		Time        string `json:"time"`
+		ReleaseTime string `json:"releaseTime"`
+	} `json:"versions"`
+}
+
+// bedrockList is the content of a FormatBedrock file
+type bedrockList struct {
+	Versions []struct {
+		Version  string `json:"version"`
+		Channel  string `json:"channel"`
+		Released string `json:"released"`
+	} `json:"versions"`
+}
+
+// Read reads the entries of a version list in the order they're listed
+func Read(r io.Reader, f Format) ([]Entry, error) {
+	entries := []Entry{}
+	switch f {
+	case FormatJava:
+		var m javaManifest
+		if err := json.NewDecoder(r).Decode(&m); err != nil {
+			return nil, errors.Wrap(err, "failed to parse version manifest")
+		}
+		for i, v := range m.Versions {
+			e := Entry{ID: strings.TrimSpace(v.ID)}
+			switch v.Type {
+			case "release":
+				e.Kind = KindRelease
+			case "snapshot":
+				e.Kind = KindSnapshot
+			case "old_alpha", "old_beta":
+				e.Kind = KindOld
+			default:
+				return nil, errors.Errorf("version %d (%s) has unknown type %q", i+1, v.ID, v.Type)
+			}
+			released := v.ReleaseTime
+			if released == "" {
+				released = v.Time
+			}
+			var err error
+			if e.Released, err = parseTime(released); err != nil {
+				return nil, errors.Wrapf(err, "version %d (%s) has an invalid release time", i+1, v.ID)
+			}
+			entries = append(entries, e)
+		}
+	case FormatBedrock:
+		var l bedrockList
+		if err := json.NewDecoder(r).Decode(&l); err != nil {
+			return nil, errors.Wrap(err, "failed to parse version list")
+		}
+		for i, v := range l.Versions {
+			e := Entry{ID: strings.TrimSpace(v.Version)}
+			switch v.Channel {
+			case "release", "":
+				e.Kind = KindRelease
+			case "beta", "preview":
+				e.Kind = KindSnapshot
+			default:
+				return nil, errors.Errorf("version %d (%s) has unknown channel %q", i+1, v.Version, v.Channel)
+			}
+			var err error
+			if e.Released, err = parseTime(v.Released); err != nil {
+				return nil, errors.Wrapf(err, "version %d (%s) has an invalid release time", i+1, v.Version)
+			}
+			entries = append(entries, e)
+		}
+	default:
+		return nil, errors.Errorf("unknown format %d", f)
+	}
+	return entries, nil
+}
+
+// parseTime parses a release time, which can be a date,
+// a time or empty if it isn't known
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse(dateLayout, s)
+}
@@ -0,0 +1,232 @@
+package messagesync
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/Kappeh/RecordBot/embed"
+	"github.com/pkg/errors"
+)
+
+// Messenger sends, edits and deletes discord messages
+type Messenger interface {
+	// Send sends an embed to a channel and returns the message's id
+	Send(channelID string, e embed.Embed) (string, error)
+	// Edit replaces the embed of a message
+	// ErrNotFound is returned if the message doesn't exist
+	Edit(channelID, messageID string, e embed.Embed) error
+	// Delete deletes a message
+	Delete(channelID, messageID string) error
+}
+
+// ErrNotFound is returned by a Messenger when a message doesn't exist,
+// such as when it's been deleted by hand
+var ErrNotFound = errors.New("message not found")
+
+// ApplyError is returned by Apply when operations of a plan fail
+type ApplyError struct {
+	// Errors are the errors of the operations that failed
+	Errors []error
+}
+
+// Error gets the messages of the errors
+func (e *ApplyError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Apply carries out the operations of a plan and records the
+// resulting messages in the database
+// An operation that fails doesn't stop the ones after it, and the errors
+// of every operation that failed are returned as an *ApplyError. As plans
+// are made from the database, planning again and applying the new plan
+// retries only the operations that failed
+func Apply(db *database.Database, m Messenger, p Plan) error {
+	ae := &ApplyError{}
+	for _, op := range p.Ops {
+		if err := applyOp(db, m, op); err != nil {
+			ae.Errors = append(ae.Errors, errors.Wrapf(err, "failed to %s %s %s message in guild %s", op.Kind, op.Target, op.ID, op.GuildID))
+		}
+	}
+	if len(ae.Errors) > 0 {
+		return ae
+	}
+	return nil
+}
+
+// guildLocks serializes the changes made to the messages of each guild
+// so that a sync and a backfill can't both post the same record
+var guildLocks = struct {
+	sync.Mutex
+	guilds map[string]*sync.Mutex
+}{guilds: map[string]*sync.Mutex{}}
+
+// lockGuild locks the messages of a guild and returns
+// the function which unlocks them
+func lockGuild(guildID string) func() {
+	guildLocks.Lock()
+	l, ok := guildLocks.guilds[guildID]
+	if !ok {
+		l = &sync.Mutex{}
+		guildLocks.guilds[guildID] = l
+	}
+	guildLocks.Unlock()
+	l.Lock()
+	return l.Unlock
+}
+
+// applyOp carries out a single operation while
+// holding the lock of the operation's guild
+func applyOp(db *database.Database, m Messenger, op Op) error {
+	unlock := lockGuild(op.GuildID)
+	defer unlock()
+	return applyLocked(db, m, op)
+}
+
+// applyLocked carries out a single operation
+// The lock of the operation's guild must be held
+func applyLocked(db *database.Database, m Messenger, op Op) error {
+	switch op.Kind {
+	case OpCreate:
+		// The message may have been sent by a backfill since planning,
+		// in which case it's updated rather than sent again
+		channelID, messageID, ok, err := recordedMessage(db, op)
+		if err != nil {
+			return err
+		} else if ok {
+			op.Kind, op.OldChannelID, op.MessageID = OpEdit, channelID, messageID
+			if channelID != op.ChannelID {
+				op.Kind = OpMove
+			}
+			return applyLocked(db, m, op)
+		}
+		messageID, err = m.Send(op.ChannelID, op.Embed)
+		if err != nil {
+			return errors.Wrap(err, "failed to send message")
+		}
+		if err = saveMessage(db, op, messageID, true); err != nil {
+			return err
+		}
+		return saveHash(db, op.GuildID, messageID, op.Hash)
+	case OpEdit:
+		err := m.Edit(op.ChannelID, op.MessageID, op.Embed)
+		if errors.Cause(err) == ErrNotFound {
+			// The message was deleted by someone else so it's
+			// forgotten and sent again
+			if _, _, err = db.GuildMessageHashDelete(op.MessageID); err != nil {
+				return errors.Wrap(err, "failed to delete guild message hash")
+			}
+			if err = deleteMessage(db, op); err != nil {
+				return err
+			}
+			op.Kind, op.OldChannelID, op.MessageID = OpCreate, "", ""
+			return applyLocked(db, m, op)
+		} else if err != nil {
+			return errors.Wrap(err, "failed to edit message")
+		}
+		if err := saveMessage(db, op, op.MessageID, false); err != nil {
+			return err
+		}
+		return saveHash(db, op.GuildID, op.MessageID, op.Hash)
+	case OpMove:
+		if err := m.Delete(op.OldChannelID, op.MessageID); err != nil {
+			return errors.Wrap(err, "failed to delete old message")
+		}
+		if _, _, err := db.GuildMessageHashDelete(op.MessageID); err != nil {
+			return errors.Wrap(err, "failed to delete guild message hash")
+		}
+		messageID, err := m.Send(op.ChannelID, op.Embed)
+		if err != nil {
+			return errors.Wrap(err, "failed to send message")
+		}
+		if err = saveMessage(db, op, messageID, false); err != nil {
+			return err
+		}
+		return saveHash(db, op.GuildID, messageID, op.Hash)
+	case OpDelete:
+		if err := m.Delete(op.OldChannelID, op.MessageID); err != nil {
+			return errors.Wrap(err, "failed to delete message")
+		}
+		if _, _, err := db.GuildMessageHashDelete(op.MessageID); err != nil {
+			return errors.Wrap(err, "failed to delete guild message hash")
+		}
+		return deleteMessage(db, op)
+	}
+	return errors.Errorf("unknown operation kind %d", op.Kind)
+}
+
+// recordedMessage gets the channel and id of the message recorded as
+// displaying the operation's build or record
+// false is returned if there isn't one
+func recordedMessage(db *database.Database, op Op) (string, string, bool, error) {
+	switch op.Target {
+	case TargetBuild:
+		gbm, ok, err := db.GuildBuildMessage(op.GuildID, op.ID)
+		if err != nil {
+			return "", "", false, errors.Wrap(err, "failed to get guild build message")
+		}
+		return gbm.ChannelID, gbm.MessageID, ok, nil
+	case TargetRecord:
+		grm, ok, err := db.GuildRecordMessage(op.GuildID, op.ID)
+		if err != nil {
+			return "", "", false, errors.Wrap(err, "failed to get guild record message")
+		}
+		return grm.ChannelID, grm.MessageID, ok, nil
+	}
+	return "", "", false, errors.Errorf("unknown target %d", op.Target)
+}
+
+// deleteMessage deletes the row recording the message
+// that displays the operation's build or record
+// The row is kept if it records a different message, such as when
+// the operation deletes a duplicate of the recorded message
+func deleteMessage(db *database.Database, op Op) error {
+	_, messageID, ok, err := recordedMessage(db, op)
+	if err != nil {
+		return err
+	} else if !ok || messageID != op.MessageID {
+		return nil
+	}
+	switch op.Target {
+	case TargetBuild:
+		_, _, err = db.GuildBuildMessageDelete(op.GuildID, op.ID)
+	case TargetRecord:
+		_, _, err = db.GuildRecordMessageDelete(op.GuildID, op.ID)
+	}
+	return errors.Wrap(err, "failed to delete message row")
+}
+
+// saveMessage creates or edits the row recording the message
+// that displays the operation's build or record
+func saveMessage(db *database.Database, op Op, messageID string, create bool) error {
+	var err error
+	switch {
+	case op.Target == TargetBuild && create:
+		_, _, err = db.GuildBuildMessageCreate(op.GuildID, op.ID, op.ChannelID, messageID)
+	case op.Target == TargetBuild:
+		_, _, err = db.GuildBuildMessageEdit(op.GuildID, op.ID, op.ChannelID, messageID)
+	case op.Target == TargetRecord && create:
+		_, _, err = db.GuildRecordMessageCreate(op.GuildID, op.ID, op.ChannelID, messageID)
+	case op.Target == TargetRecord:
+		_, _, err = db.GuildRecordMessageEdit(op.GuildID, op.ID, op.ChannelID, messageID)
+	}
+	return errors.Wrap(err, "failed to save message row")
+}
+
+// saveHash creates or edits the content hash of a message
+func saveHash(db *database.Database, guildID, messageID, hash string) error {
+	_, ok, err := db.GuildMessageHashEdit(messageID, hash)
+	if err != nil {
+		return errors.Wrap(err, "failed to edit guild message hash")
+	} else if ok {
+		return nil
+	}
+	if _, _, err = db.GuildMessageHashCreate(messageID, guildID, hash); err != nil {
+		return errors.Wrap(err, "failed to create guild message hash")
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package messagesync
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/Kappeh/RecordBot/embed"
+)
+
+// messenger is a fake messenger which records the messages it has
+type messenger struct {
+	mu       sync.Mutex
+	next     int
+	messages map[string]string
+	deleted  []string
+}
+
+func newMessenger() *messenger {
+	return &messenger{next: 1000, messages: map[string]string{}}
+}
+
+func (m *messenger) Send(channelID string, e embed.Embed) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.next++
+	messageID := strconv.Itoa(m.next)
+	m.messages[messageID] = e.Title
+	return messageID, nil
+}
+
+func (m *messenger) Edit(channelID, messageID string, e embed.Embed) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.messages[messageID]; !ok {
+		return ErrNotFound
+	}
+	m.messages[messageID] = e.Title
+	return nil
+}
+
+func (m *messenger) Delete(channelID, messageID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.messages, messageID)
+	m.deleted = append(m.deleted, messageID)
+	return nil
+}
+
+func TestApplyDeleteDuplicate(t *testing.T) {
+	const guildID, buildID = "1", "900"
+	if _, _, err := testDB.GuildBuildMessageCreate(guildID, buildID, "10", "100"); err != nil {
+		t.Fatalf("failed to create guild build message: %v", err)
+	}
+	m := newMessenger()
+	op := Op{Kind: OpDelete, Target: TargetBuild, GuildID: guildID, ID: buildID, OldChannelID: "10"}
+
+	// Deleting a duplicate keeps the row of the recorded message
+	op.MessageID = "101"
+	if err := Apply(testDB, m, Plan{Ops: []Op{op}}); err != nil {
+		t.Fatalf("failed to apply plan: %v", err)
+	}
+	if gbm, ok, err := testDB.GuildBuildMessage(guildID, buildID); err != nil || !ok || gbm.MessageID != "100" {
+		t.Fatalf("expected the recorded message to be kept, got %+v, %v, %v", gbm, ok, err)
+	}
+
+	op.MessageID = "100"
+	if err := Apply(testDB, m, Plan{Ops: []Op{op}}); err != nil {
+		t.Fatalf("failed to apply plan: %v", err)
+	}
+	if _, ok, err := testDB.GuildBuildMessage(guildID, buildID); err != nil || ok {
+		t.Fatalf("expected the recorded message to be deleted, got %v, %v", ok, err)
+	}
+	if len(m.deleted) != 2 || m.deleted[0] != "101" || m.deleted[1] != "100" {
+		t.Errorf("expected both messages to be deleted, got %v", m.deleted)
+	}
+}
@@ -0,0 +1,215 @@
+package messagesync
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/Kappeh/RecordBot/embed"
+	"github.com/pkg/errors"
+)
+
+// Defaults used by backfillers made with NewBackfiller
+const (
+	// DefaultSendInterval is the least time between messages sent by a
+	// backfill, which keeps it well within discord's rate limits
+	DefaultSendInterval = 2 * time.Second
+	// DefaultCheckInterval is how often guilds are checked for
+	// records that haven't been posted
+	DefaultCheckInterval = time.Minute
+)
+
+// Backfiller posts the verified records that existed before a guild
+// configured its record type channels
+// Progress is kept in the GuildRecordMessages table, each row being
+// saved as soon as its message is sent, so a backfill that's stopped
+// continues from where it stopped when it's run again
+type Backfiller struct {
+	// SendInterval is the least time between messages being sent
+	SendInterval time.Duration
+	// CheckInterval is how often Run checks guilds for
+	// records that haven't been posted
+	CheckInterval time.Duration
+	// OnError is called when backfilling a guild fails during Run
+	// The guild is tried again at the next check
+	OnError func(guildID string, err error)
+
+	db       *database.Database
+	m        Messenger
+	lastSend time.Time
+}
+
+// BackfillResult is the outcome of backfilling a guild
+type BackfillResult struct {
+	// Posted is the number of records that were posted
+	Posted int
+	// Remaining is the number of records that still need posting
+	// It's only above zero when the backfill was stopped
+	Remaining int
+}
+
+// NewBackfiller creates a backfiller with the default settings
+func NewBackfiller(db *database.Database, m Messenger) *Backfiller {
+	return &Backfiller{
+		SendInterval:  DefaultSendInterval,
+		CheckInterval: DefaultCheckInterval,
+		db:            db,
+		m:             m,
+	}
+}
+
+// Run backfills every guild that has auto posting of records turned
+// on until ctx is done
+// Errors getting the guilds stop the backfiller, errors backfilling
+// a guild are passed to OnError
+func (b *Backfiller) Run(ctx context.Context) error {
+	ticker := time.NewTicker(b.CheckInterval)
+	defer ticker.Stop()
+	for {
+		settings, err := b.db.GuildSettings()
+		if err != nil {
+			return errors.Wrap(err, "failed to get guild settings")
+		}
+		for _, gs := range settings {
+			autoPost, err := b.db.GuildSettingGet(gs.GuildID, database.SettingAutoPostRecords)
+			if err != nil {
+				return errors.Wrap(err, "failed to get auto post setting")
+			}
+			if !autoPost.Bool() {
+				continue
+			}
+			if _, err = b.RunGuild(ctx, gs.GuildID); ctx.Err() != nil {
+				return nil
+			} else if err != nil && b.OnError != nil {
+				b.OnError(gs.GuildID, err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunGuild posts every verified record of a guild that doesn't have a
+// message yet, waiting SendInterval between messages
+// When ctx is done the backfill stops and ctx's error is returned
+// along with how far the backfill got
+func (b *Backfiller) RunGuild(ctx context.Context, guildID string) (BackfillResult, error) {
+	ops, err := PlanBackfill(b.db, guildID)
+	if err != nil {
+		return BackfillResult{}, err
+	}
+	r := BackfillResult{Remaining: len(ops)}
+	for _, op := range ops {
+		if wait := b.SendInterval - time.Since(b.lastSend); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return r, ctx.Err()
+			case <-timer.C:
+			}
+		} else if ctx.Err() != nil {
+			return r, ctx.Err()
+		}
+		posted, err := b.post(op)
+		if err != nil {
+			return r, errors.Wrapf(err, "failed to post record %s in guild %s", op.ID, guildID)
+		} else if posted {
+			r.Posted++
+		}
+		r.Remaining--
+	}
+	return r, nil
+}
+
+// post sends the message of a record unless it's been posted
+// by a sync since the backfill was planned
+// The guild is locked between checking and recording the message so
+// that a sync can't post the record at the same time
+func (b *Backfiller) post(op Op) (bool, error) {
+	unlock := lockGuild(op.GuildID)
+	defer unlock()
+	if _, ok, err := b.db.GuildRecordMessage(op.GuildID, op.ID); err != nil {
+		return false, errors.Wrap(err, "failed to get guild record message")
+	} else if ok {
+		return false, nil
+	}
+	b.lastSend = time.Now()
+	if err := applyLocked(b.db, b.m, op); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// PlanBackfill plans the creation of messages for every verified record
+// of a guild that has a record type channel but no message
+// Records are ordered by record type, edition, build class and then id
+// so that backfills post records in the same order every time
+func PlanBackfill(db *database.Database, guildID string) ([]Op, error) {
+	grtcs, err := db.GuildRecordTypeChannels(guildID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get guild record type channels")
+	}
+	channels := map[string]string{}
+	for _, grtc := range grtcs {
+		channels[grtc.RecordTypeID] = grtc.ChannelID
+	}
+	if len(channels) == 0 {
+		return []Op{}, nil
+	}
+	grms, err := db.GuildRecordMessages(guildID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get guild record messages")
+	}
+	posted := map[string]bool{}
+	for _, grm := range grms {
+		posted[grm.RecordID] = true
+	}
+	records, err := db.Records()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get records")
+	}
+	missing := []database.Record{}
+	for _, r := range records {
+		if _, ok := channels[r.RecordTypeID]; !ok || !r.Verified || r.UpdateRequest || posted[r.ID] {
+			continue
+		}
+		missing = append(missing, r)
+	}
+	sort.SliceStable(missing, func(i, j int) bool {
+		a, b := missing[i], missing[j]
+		if a.RecordTypeID != b.RecordTypeID {
+			return lessID(a.RecordTypeID, b.RecordTypeID)
+		}
+		if a.EditionID != b.EditionID {
+			return lessID(a.EditionID, b.EditionID)
+		}
+		if a.BuildClassID != b.BuildClassID {
+			return lessID(a.BuildClassID, b.BuildClassID)
+		}
+		return lessID(a.ID, b.ID)
+	})
+	ops := []Op{}
+	for _, r := range missing {
+		// Loading the record includes the builds holding it
+		d, err := embed.LoadRecord(db, r)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load record")
+		}
+		e := embed.Record(d)
+		ops = append(ops, Op{
+			Kind:      OpCreate,
+			Target:    TargetRecord,
+			GuildID:   guildID,
+			ID:        r.ID,
+			ChannelID: channels[r.RecordTypeID],
+			Embed:     e,
+			Hash:      e.Hash(),
+		})
+	}
+	return ops, nil
+}
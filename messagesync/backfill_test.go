@@ -0,0 +1,141 @@
+package messagesync
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/Kappeh/RecordBot/database"
+)
+
+// setupGuild creates a guild which posts the records of a new record type
+// in a channel, along with records of that type
+func setupGuild(t *testing.T, guildID string, records int) (database.RecordType, []database.Record) {
+	t.Helper()
+	rt, err := testDB.RecordTypeCreate("Fastest "+guildID, "The fastest builds")
+	if err != nil {
+		t.Fatalf("failed to create record type: %v", err)
+	}
+	if _, _, err = testDB.GuildSettingCreate(guildID); err != nil {
+		t.Fatalf("failed to create guild settings: %v", err)
+	}
+	if _, _, err = testDB.GuildRecordTypeChannelCreate(guildID, rt.ID, "50"); err != nil {
+		t.Fatalf("failed to create guild record type channel: %v", err)
+	}
+	rs := []database.Record{}
+	for i := 0; i < records; i++ {
+		r, err := testDB.RecordCreate(database.Record{
+			Verified:              true,
+			VerifierID:            "0",
+			UpdateRequestRecordID: "0",
+			EditionID:             testEdition.ID,
+			BuildClassID:          testBuildClass.ID,
+			RecordTypeID:          rt.ID,
+			Name:                  "Record " + strconv.Itoa(i),
+			SubmitterID:           "0",
+		})
+		if err != nil {
+			t.Fatalf("failed to create record: %v", err)
+		}
+		rs = append(rs, r)
+	}
+	return rt, rs
+}
+
+// syncRecord plans and applies the messages of a record
+// in the same way as the outbox's sync handler
+func syncRecord(db *database.Database, m Messenger, recordID string) error {
+	s := NewScope()
+	s.Records[recordID] = true
+	plan, err := PlanScope(db, s)
+	if err != nil {
+		return err
+	}
+	return Apply(db, m, plan)
+}
+
+// checkPosted checks that each record has exactly one message
+func checkPosted(t *testing.T, m *messenger, guildID string, records []database.Record) {
+	t.Helper()
+	grms, err := testDB.GuildRecordMessages(guildID)
+	if err != nil {
+		t.Fatalf("failed to get guild record messages: %v", err)
+	}
+	if len(grms) != len(records) {
+		t.Errorf("expected %d guild record messages, got %d", len(records), len(grms))
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.messages) != len(records) {
+		t.Errorf("expected %d messages, got %d: %v", len(records), len(m.messages), m.messages)
+	}
+	for _, grm := range grms {
+		if _, ok := m.messages[grm.MessageID]; !ok {
+			t.Errorf("expected message %s of record %s to exist", grm.MessageID, grm.RecordID)
+		}
+	}
+}
+
+func TestSyncAfterBackfill(t *testing.T) {
+	const guildID = "20"
+	_, records := setupGuild(t, guildID, 1)
+	r := records[0]
+	m := newMessenger()
+
+	// A sync plans to post the record
+	s := NewScope()
+	s.Records[r.ID] = true
+	plan, err := PlanScope(testDB, s)
+	if err != nil {
+		t.Fatalf("failed to plan sync: %v", err)
+	}
+	// Then a backfill posts it first
+	b := NewBackfiller(testDB, m)
+	b.SendInterval = 0
+	if res, err := b.RunGuild(context.Background(), guildID); err != nil || res.Posted != 1 {
+		t.Fatalf("expected the backfill to post the record, got %+v, %v", res, err)
+	}
+	// The sync updates the backfill's message instead of posting again
+	if err = Apply(testDB, m, plan); err != nil {
+		t.Fatalf("failed to apply sync: %v", err)
+	}
+	checkPosted(t, m, guildID, records)
+	if len(m.deleted) != 0 {
+		t.Errorf("expected the message to be edited in place, got %v deleted", m.deleted)
+	}
+}
+
+func TestBackfillRace(t *testing.T) {
+	const guildID = "21"
+	_, records := setupGuild(t, guildID, 20)
+	m := newMessenger()
+	b := NewBackfiller(testDB, m)
+	b.SendInterval = 0
+
+	// The backfill and syncs of every record race to post them
+	var wg sync.WaitGroup
+	errs := make(chan error, len(records)+1)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if _, err := b.RunGuild(context.Background(), guildID); err != nil {
+			errs <- err
+		}
+	}()
+	for _, r := range records {
+		wg.Add(1)
+		go func(recordID string) {
+			defer wg.Done()
+			if err := syncRecord(testDB, m, recordID); err != nil {
+				errs <- err
+			}
+		}(r.ID)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+	checkPosted(t, m, guildID, records)
+}
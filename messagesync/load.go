@@ -0,0 +1,187 @@
+package messagesync
+
+import (
+	"time"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/Kappeh/RecordBot/embed"
+	"github.com/pkg/errors"
+)
+
+// PlanGuild plans the operations which make the build and record
+// messages of a guild match the database
+// Verified builds that aren't update requests or reported are posted
+// in the guild's build channel. Verified records that aren't update
+// requests are posted in the guild's channel for their record type
+// Guilds which have turned off auto posting for builds or records
+// have existing messages kept up to date but no new messages sent
+func PlanGuild(db *database.Database, guildID string) (Plan, error) {
+	return planGuild(db, guildID, nil)
+}
+
+// planGuild plans the operations which make the messages of a guild
+// for the builds and records within a scope match the database
+// Every build and record is planned if the scope is nil
+func planGuild(db *database.Database, guildID string, s *Scope) (Plan, error) {
+	buildChannel, err := db.GuildSettingGet(guildID, database.SettingBuildChannel)
+	if err != nil {
+		return Plan{}, errors.Wrap(err, "failed to get build channel")
+	}
+	autoPost := map[Target]bool{}
+	for target, key := range map[Target]string{
+		TargetBuild:  database.SettingAutoPostBuilds,
+		TargetRecord: database.SettingAutoPostRecords,
+	} {
+		v, err := db.GuildSettingGet(guildID, key)
+		if err != nil {
+			return Plan{}, errors.Wrap(err, "failed to get auto post setting")
+		}
+		autoPost[target] = v.Bool()
+	}
+	want := []desired{}
+	// Builds
+	if buildChannel.ID() != "" {
+		builds, err := s.builds(db)
+		if err != nil {
+			return Plan{}, err
+		}
+		for _, b := range builds {
+			if !b.Verified || b.UpdateRequest || b.Reported {
+				continue
+			}
+			d, err := embed.LoadBuild(db, b)
+			if err != nil {
+				return Plan{}, errors.Wrap(err, "failed to load build")
+			}
+			want = append(want, desired{
+				target:    TargetBuild,
+				id:        b.ID,
+				channelID: buildChannel.ID(),
+				embed:     embed.Build(d),
+				edited:    time.Time(b.EditedTimestamp),
+			})
+		}
+	}
+	// Records
+	grtcs, err := db.GuildRecordTypeChannels(guildID)
+	if err != nil {
+		return Plan{}, errors.Wrap(err, "failed to get guild record type channels")
+	}
+	channels := map[string]string{}
+	for _, grtc := range grtcs {
+		channels[grtc.RecordTypeID] = grtc.ChannelID
+	}
+	if len(channels) > 0 {
+		records, err := s.records(db)
+		if err != nil {
+			return Plan{}, err
+		}
+		for _, r := range records {
+			channelID, ok := channels[r.RecordTypeID]
+			if !ok || !r.Verified || r.UpdateRequest {
+				continue
+			}
+			d, err := embed.LoadRecord(db, r)
+			if err != nil {
+				return Plan{}, errors.Wrap(err, "failed to load record")
+			}
+			want = append(want, desired{
+				target:    TargetRecord,
+				id:        r.ID,
+				channelID: channelID,
+				embed:     embed.Record(d),
+				edited:    time.Time(r.EditedTimestamp),
+			})
+		}
+	}
+	// Existing messages
+	have := []existing{}
+	gbms, err := db.GuildBuildMessages(guildID)
+	if err != nil {
+		return Plan{}, errors.Wrap(err, "failed to get guild build messages")
+	}
+	for _, gbm := range gbms {
+		if !s.hasBuild(gbm.BuildID) {
+			continue
+		}
+		e := existing{
+			target:    TargetBuild,
+			id:        gbm.BuildID,
+			channelID: gbm.ChannelID,
+			messageID: gbm.MessageID,
+			edited:    time.Time(gbm.EditedTimestamp),
+		}
+		if e.hash, err = messageHash(db, gbm.MessageID); err != nil {
+			return Plan{}, err
+		}
+		have = append(have, e)
+	}
+	grms, err := db.GuildRecordMessages(guildID)
+	if err != nil {
+		return Plan{}, errors.Wrap(err, "failed to get guild record messages")
+	}
+	for _, grm := range grms {
+		if !s.hasRecord(grm.RecordID) {
+			continue
+		}
+		e := existing{
+			target:    TargetRecord,
+			id:        grm.RecordID,
+			channelID: grm.ChannelID,
+			messageID: grm.MessageID,
+			edited:    time.Time(grm.EditedTimestamp),
+		}
+		if e.hash, err = messageHash(db, grm.MessageID); err != nil {
+			return Plan{}, err
+		}
+		have = append(have, e)
+	}
+	plan := diff(guildID, want, have)
+	ops := []Op{}
+	for _, op := range plan.Ops {
+		if op.Kind != OpCreate || autoPost[op.Target] {
+			ops = append(ops, op)
+		}
+	}
+	plan.Ops = ops
+	return plan, nil
+}
+
+// PlanAll plans the operations which make the messages of every
+// guild with guild settings match the database
+func PlanAll(db *database.Database) (Plan, error) {
+	return PlanScope(db, nil)
+}
+
+// PlanScope plans the operations which make the messages of every
+// guild with guild settings match the database for the builds and
+// records within a scope
+// Every build and record is planned if the scope is nil
+func PlanScope(db *database.Database, s *Scope) (Plan, error) {
+	settings, err := db.GuildSettings()
+	if err != nil {
+		return Plan{}, errors.Wrap(err, "failed to get guild settings")
+	}
+	plan := Plan{Ops: []Op{}}
+	for _, gs := range settings {
+		p, err := planGuild(db, gs.GuildID, s)
+		if err != nil {
+			return Plan{}, errors.Wrapf(err, "failed to plan guild %s", gs.GuildID)
+		}
+		plan.Ops = append(plan.Ops, p.Ops...)
+	}
+	sortOps(plan.Ops)
+	return plan, nil
+}
+
+// messageHash gets the stored content hash of a message
+// An empty string is returned if it isn't known
+func messageHash(db *database.Database, messageID string) (string, error) {
+	gmh, ok, err := db.GuildMessageHash(messageID)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get guild message hash")
+	} else if !ok {
+		return "", nil
+	}
+	return gmh.Hash, nil
+}
@@ -0,0 +1,241 @@
+package messagesync
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Kappeh/RecordBot/embed"
+)
+
+// OpKind is the kind of change an operation makes to a message
+type OpKind int
+
+const (
+	// OpDelete deletes a message that should no longer exist
+	OpDelete OpKind = iota
+	// OpMove deletes a message and sends it to a different channel
+	OpMove
+	// OpEdit edits the content of a message
+	OpEdit
+	// OpCreate sends a message that doesn't exist yet
+	OpCreate
+)
+
+// String gets the name of the operation kind
+func (k OpKind) String() string {
+	switch k {
+	case OpDelete:
+		return "delete"
+	case OpMove:
+		return "move"
+	case OpEdit:
+		return "edit"
+	case OpCreate:
+		return "create"
+	}
+	return "unknown"
+}
+
+// Target is the type of row a message displays
+type Target int
+
+const (
+	// TargetBuild is a message displaying a build
+	TargetBuild Target = iota
+	// TargetRecord is a message displaying a record
+	TargetRecord
+)
+
+// String gets the name of the target
+func (t Target) String() string {
+	switch t {
+	case TargetBuild:
+		return "build"
+	case TargetRecord:
+		return "record"
+	}
+	return "unknown"
+}
+
+// Op is a single change to be made to a message within a guild
+type Op struct {
+	// Kind is the kind of change
+	Kind OpKind
+	// Target is the type of row the message displays
+	Target Target
+
+	// GuildID is the id of the guild containing the message
+	GuildID string
+	// ID is the id of the build or record the message displays
+	ID string
+
+	// ChannelID is the id of the channel the message should be in
+	// This is empty for deletions
+	ChannelID string
+	// OldChannelID is the id of the channel the message is currently in
+	// This is empty for creations
+	OldChannelID string
+	// MessageID is the id of the existing message
+	// This is empty for creations
+	MessageID string
+
+	// Embed is the content the message should have
+	// This is empty for deletions
+	Embed embed.Embed
+	// Hash is the hash of Embed
+	Hash string
+}
+
+// Plan is an ordered list of operations which make the messages
+// within guilds match the database
+type Plan struct {
+	// Ops are the operations in the order they should be applied
+	Ops []Op
+}
+
+// Counts gets the number of operations of each kind in the plan
+func (p Plan) Counts() map[OpKind]int {
+	counts := map[OpKind]int{}
+	for _, op := range p.Ops {
+		counts[op.Kind]++
+	}
+	return counts
+}
+
+// desired is a message that should exist within a guild
+type desired struct {
+	target    Target
+	id        string
+	channelID string
+	embed     embed.Embed
+	// edited is when the build or record was last edited
+	edited time.Time
+}
+
+// existing is a message that has been sent to a guild
+type existing struct {
+	target    Target
+	id        string
+	channelID string
+	messageID string
+	// hash is the hash of the message's content if it's known
+	hash string
+	// edited is when the message was last sent or edited
+	edited time.Time
+}
+
+// key identifies the build or record a message displays
+type key struct {
+	target Target
+	id     string
+}
+
+// diff plans the operations that make the existing messages
+// of a guild match the desired messages
+// When the hash of an existing message is known it's compared with
+// the desired content, otherwise the message is edited if the build
+// or record was edited after the message
+// Only one message is kept for each build or record, any others
+// such as those left by an interrupted sync are deleted
+func diff(guildID string, want []desired, have []existing) Plan {
+	haveByKey := map[key][]existing{}
+	for _, e := range have {
+		k := key{e.target, e.id}
+		haveByKey[k] = append(haveByKey[k], e)
+	}
+	// kept are the ids of the existing messages which aren't deleted
+	kept := map[string]bool{}
+	ops := []Op{}
+	for _, w := range want {
+		hash := w.embed.Hash()
+		op := Op{
+			Target:    w.target,
+			GuildID:   guildID,
+			ID:        w.id,
+			ChannelID: w.channelID,
+			Embed:     w.embed,
+			Hash:      hash,
+		}
+		e, ok := pick(haveByKey[key{w.target, w.id}], w.channelID)
+		if ok {
+			kept[e.messageID] = true
+		}
+		switch {
+		case !ok:
+			op.Kind = OpCreate
+		case e.channelID != w.channelID:
+			op.Kind = OpMove
+		case e.hash != "" && e.hash != hash:
+			op.Kind = OpEdit
+		case e.hash == "" && w.edited.After(e.edited):
+			op.Kind = OpEdit
+		default:
+			continue
+		}
+		if ok {
+			op.OldChannelID = e.channelID
+			op.MessageID = e.messageID
+		}
+		ops = append(ops, op)
+	}
+	for _, e := range have {
+		if kept[e.messageID] {
+			continue
+		}
+		ops = append(ops, Op{
+			Kind:         OpDelete,
+			Target:       e.target,
+			GuildID:      guildID,
+			ID:           e.id,
+			OldChannelID: e.channelID,
+			MessageID:    e.messageID,
+		})
+	}
+	sortOps(ops)
+	return Plan{Ops: ops}
+}
+
+// pick chooses which of the existing messages of a build or record
+// is kept, preferring one in the channel it should be in
+// false is returned if there aren't any
+func pick(es []existing, channelID string) (existing, bool) {
+	if len(es) == 0 {
+		return existing{}, false
+	}
+	for _, e := range es {
+		if e.channelID == channelID {
+			return e, true
+		}
+	}
+	return es[0], true
+}
+
+// sortOps orders operations by kind, then by target and then by the id
+// of the build or record so that plans are deterministic and
+// messages are created in the order the rows were created
+func sortOps(ops []Op) {
+	sort.SliceStable(ops, func(i, j int) bool {
+		a, b := ops[i], ops[j]
+		if a.GuildID != b.GuildID {
+			return lessID(a.GuildID, b.GuildID)
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Target != b.Target {
+			return a.Target < b.Target
+		}
+		return lessID(a.ID, b.ID)
+	})
+}
+
+// lessID compares two numeric ids
+func lessID(a, b string) bool {
+	ai, aErr := strconv.Atoi(a)
+	bi, bErr := strconv.Atoi(b)
+	if aErr != nil || bErr != nil {
+		return a < b
+	}
+	return ai < bi
+}
@@ -0,0 +1,137 @@
+package messagesync
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Kappeh/RecordBot/embed"
+)
+
+// summary describes an operation without its embed
+func summary(op Op) string {
+	return fmt.Sprintf("%s %s %s %s->%s %s", op.Kind, op.Target, op.ID, op.OldChannelID, op.ChannelID, op.MessageID)
+}
+
+func TestDiff(t *testing.T) {
+	old := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := old.Add(time.Hour)
+	tower := embed.Embed{Title: "Piston Tower"}
+	door := embed.Embed{Title: "Piston Door"}
+	build := func(id, channelID string, e embed.Embed, edited time.Time) desired {
+		return desired{target: TargetBuild, id: id, channelID: channelID, embed: e, edited: edited}
+	}
+	message := func(target Target, id, channelID, messageID, hash string, edited time.Time) existing {
+		return existing{target: target, id: id, channelID: channelID, messageID: messageID, hash: hash, edited: edited}
+	}
+	tests := []struct {
+		name string
+		want []desired
+		have []existing
+		ops  []string
+	}{
+		{
+			name: "create",
+			want: []desired{build("2", "10", door, now), build("1", "10", tower, now)},
+			ops:  []string{"create build 1 ->10 ", "create build 2 ->10 "},
+		},
+		{
+			name: "unchanged",
+			want: []desired{build("1", "10", tower, old)},
+			have: []existing{
+				message(TargetBuild, "1", "10", "100", tower.Hash(), now),
+			},
+		},
+		{
+			name: "edit when the hash differs",
+			want: []desired{build("1", "10", tower, old)},
+			have: []existing{
+				message(TargetBuild, "1", "10", "100", door.Hash(), now),
+			},
+			ops: []string{"edit build 1 10->10 100"},
+		},
+		{
+			name: "edit when edited after an unhashed message",
+			want: []desired{build("1", "10", tower, now), build("2", "10", door, old)},
+			have: []existing{
+				message(TargetBuild, "1", "10", "100", "", old),
+				message(TargetBuild, "2", "10", "200", "", now),
+			},
+			ops: []string{"edit build 1 10->10 100"},
+		},
+		{
+			name: "move",
+			want: []desired{build("1", "20", tower, old)},
+			have: []existing{
+				message(TargetBuild, "1", "10", "100", tower.Hash(), now),
+			},
+			ops: []string{"move build 1 10->20 100"},
+		},
+		{
+			name: "delete",
+			have: []existing{
+				message(TargetRecord, "3", "30", "300", "", now),
+				message(TargetBuild, "1", "10", "100", "", now),
+			},
+			ops: []string{"delete build 1 10-> 100", "delete record 3 30-> 300"},
+		},
+		{
+			name: "duplicates",
+			want: []desired{build("1", "10", tower, old)},
+			have: []existing{
+				message(TargetBuild, "1", "20", "101", tower.Hash(), now),
+				message(TargetBuild, "1", "10", "100", tower.Hash(), now),
+				message(TargetBuild, "1", "10", "102", tower.Hash(), now),
+			},
+			// The message in the right channel is kept
+			ops: []string{"delete build 1 20-> 101", "delete build 1 10-> 102"},
+		},
+		{
+			name: "duplicates in the wrong channel",
+			want: []desired{build("1", "30", tower, old)},
+			have: []existing{
+				message(TargetBuild, "1", "10", "100", tower.Hash(), now),
+				message(TargetBuild, "1", "20", "101", tower.Hash(), now),
+			},
+			ops: []string{"delete build 1 20-> 101", "move build 1 10->30 100"},
+		},
+		{
+			name: "duplicates of a deleted build",
+			have: []existing{
+				message(TargetBuild, "1", "10", "100", "", now),
+				message(TargetBuild, "1", "10", "101", "", now),
+			},
+			ops: []string{"delete build 1 10-> 100", "delete build 1 10-> 101"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := diff("1", tt.want, tt.have)
+			ops := []string{}
+			for _, op := range plan.Ops {
+				if op.GuildID != "1" {
+					t.Errorf("expected operations in guild 1, got %s", op.GuildID)
+				}
+				ops = append(ops, summary(op))
+			}
+			if tt.ops == nil {
+				tt.ops = []string{}
+			}
+			if !reflect.DeepEqual(ops, tt.ops) {
+				t.Fatalf("expected operations %q, got %q", tt.ops, ops)
+			}
+		})
+	}
+}
+
+func TestDiffKeepsContent(t *testing.T) {
+	e := embed.Embed{Title: "Piston Tower"}
+	plan := diff("1", []desired{{target: TargetBuild, id: "1", channelID: "10", embed: e}}, nil)
+	if len(plan.Ops) != 1 {
+		t.Fatalf("expected one operation, got %d", len(plan.Ops))
+	}
+	if op := plan.Ops[0]; op.Embed.Title != e.Title || op.Hash != e.Hash() {
+		t.Fatalf("expected the embed and its hash, got %+v", op)
+	}
+}
@@ -0,0 +1,109 @@
+package messagesync
+
+import (
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// Scope is the builds and records whose messages a plan is limited to
+type Scope struct {
+	// Builds are the ids of the builds within the scope
+	Builds map[string]bool
+	// Records are the ids of the records within the scope
+	Records map[string]bool
+}
+
+// NewScope creates a scope without any builds or records
+func NewScope() *Scope {
+	return &Scope{Builds: map[string]bool{}, Records: map[string]bool{}}
+}
+
+// EntryScope gets the builds and records whose messages may change
+// because of the change an outbox entry is for
+// Build messages show the build and record messages show the builds
+// holding the record, so a change to a build is within the scope
+// of the records it holds
+// Rows that no longer exist can't be looked up, so the records
+// a deletion affected are taken from the entry instead
+func EntryScope(db *database.Database, e database.OutboxEntry) (*Scope, error) {
+	s := NewScope()
+	switch e.Kind {
+	case database.OutboxBuild:
+		// The build's messages are deleted if it no longer exists
+		s.Builds[e.EntityID] = true
+		b, ok, err := db.Build(e.EntityID)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get build")
+		} else if !ok {
+			break
+		}
+		brs, err := b.BuildRecords()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get build records")
+		}
+		for _, br := range brs {
+			s.Records[br.RecordID] = true
+		}
+	case database.OutboxRecord:
+		s.Records[e.EntityID] = true
+	case database.OutboxBuildRecord:
+		br, ok, err := db.BuildRecord(e.EntityID)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get build record")
+		} else if ok {
+			s.Records[br.RecordID] = true
+		}
+	default:
+		return nil, errors.Errorf("unknown outbox kind %d", e.Kind)
+	}
+	for _, recordID := range e.RecordIDs {
+		s.Records[recordID] = true
+	}
+	return s, nil
+}
+
+// hasBuild determines whether a build is within the scope
+func (s *Scope) hasBuild(buildID string) bool {
+	return s == nil || s.Builds[buildID]
+}
+
+// hasRecord determines whether a record is within the scope
+func (s *Scope) hasRecord(recordID string) bool {
+	return s == nil || s.Records[recordID]
+}
+
+// builds gets the builds within the scope
+func (s *Scope) builds(db *database.Database) ([]database.Build, error) {
+	if s == nil {
+		builds, err := db.Builds()
+		return builds, errors.Wrap(err, "failed to get builds")
+	}
+	builds := []database.Build{}
+	for id := range s.Builds {
+		b, ok, err := db.Build(id)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get build")
+		} else if ok {
+			builds = append(builds, b)
+		}
+	}
+	return builds, nil
+}
+
+// records gets the records within the scope
+func (s *Scope) records(db *database.Database) ([]database.Record, error) {
+	if s == nil {
+		records, err := db.Records()
+		return records, errors.Wrap(err, "failed to get records")
+	}
+	records := []database.Record{}
+	for id := range s.Records {
+		r, ok, err := db.Record(id)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get record")
+		} else if ok {
+			records = append(records, r)
+		}
+	}
+	return records, nil
+}
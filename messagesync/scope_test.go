@@ -0,0 +1,193 @@
+package messagesync
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Kappeh/RecordBot/database"
+)
+
+// testDB is the database shared by the tests
+// The database package only allows one database to be opened
+var testDB *database.Database
+
+// The edition, build class and record type which
+// the builds and records of the tests are in
+var (
+	testEdition    database.Edition
+	testBuildClass database.BuildClass
+	testRecordType database.RecordType
+)
+
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "messagesync")
+	if err != nil {
+		panic(err)
+	}
+	testDB, err = database.Open(filepath.Join(dir, "database.db"))
+	if err != nil {
+		os.RemoveAll(dir)
+		panic(err)
+	}
+	if err = seed(testDB); err != nil {
+		testDB.Close()
+		os.RemoveAll(dir)
+		panic(err)
+	}
+	code := m.Run()
+	testDB.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// seed creates the edition, build class and record type the tests use
+func seed(db *database.Database) (err error) {
+	if testEdition, err = db.EditionCreate("Java", "Java Edition"); err != nil {
+		return err
+	}
+	if testBuildClass, err = db.BuildClassCreate("Piston", "Piston builds", "", ""); err != nil {
+		return err
+	}
+	testRecordType, err = db.RecordTypeCreate("Fastest", "The fastest builds")
+	return err
+}
+
+// createBuild creates a verified build
+func createBuild(t *testing.T, name string) database.Build {
+	t.Helper()
+	b, err := testDB.BuildCreate(database.Build{
+		Verified:             true,
+		VerifierID:           "0",
+		EditionID:            testEdition.ID,
+		BuildClassID:         testBuildClass.ID,
+		Name:                 name,
+		Creators:             "player",
+		ReporterID:           "0",
+		UpdateRequestBuildID: "0",
+		SubmitterID:          "0",
+	})
+	if err != nil {
+		t.Fatalf("failed to create build: %v", err)
+	}
+	return b
+}
+
+// createRecord creates a verified record held by a build
+func createRecord(t *testing.T, name string, b database.Build) (database.Record, database.BuildRecord) {
+	t.Helper()
+	r, err := testDB.RecordCreate(database.Record{
+		Verified:              true,
+		VerifierID:            "0",
+		UpdateRequestRecordID: "0",
+		EditionID:             testEdition.ID,
+		BuildClassID:          testBuildClass.ID,
+		RecordTypeID:          testRecordType.ID,
+		Name:                  name,
+		SubmitterID:           "0",
+	})
+	if err != nil {
+		t.Fatalf("failed to create record: %v", err)
+	}
+	br, err := testDB.BuildRecordCreate(database.BuildRecord{
+		BuildID:            b.ID,
+		RecordID:           r.ID,
+		Verified:           true,
+		VerifierID:         "0",
+		ReporterID:         "0",
+		JointBuildRecordID: "0",
+		SubmitterID:        "0",
+	})
+	if err != nil {
+		t.Fatalf("failed to create build record: %v", err)
+	}
+	return r, br
+}
+
+// lastEntry gets the newest outbox entry
+func lastEntry(t *testing.T) database.OutboxEntry {
+	t.Helper()
+	oes, err := testDB.OutboxEntries(false)
+	if err != nil || len(oes) == 0 {
+		t.Fatalf("failed to get outbox entries: %v", err)
+	}
+	return oes[len(oes)-1]
+}
+
+// ids makes a set of ids
+func ids(ids ...string) map[string]bool {
+	set := map[string]bool{}
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+func TestEntryScope(t *testing.T) {
+	b := createBuild(t, "Scope Tower")
+	r1, _ := createRecord(t, "Fastest Scope Tower", b)
+	r2, br2 := createRecord(t, "Fastest Scope Tower Extension", b)
+
+	tests := []struct {
+		name    string
+		change  func() error
+		builds  map[string]bool
+		records map[string]bool
+	}{
+		{
+			name: "edited record",
+			change: func() error {
+				_, _, err := testDB.RecordEdit(r1.ID, r1)
+				return err
+			},
+			builds:  ids(),
+			records: ids(r1.ID),
+		},
+		{
+			name: "edited build",
+			change: func() error {
+				_, _, err := testDB.BuildEdit(b.ID, b)
+				return err
+			},
+			builds:  ids(b.ID),
+			records: ids(r1.ID, r2.ID),
+		},
+		{
+			name: "deleted build record",
+			change: func() error {
+				_, _, err := testDB.BuildRecordDelete(br2.ID)
+				return err
+			},
+			builds:  ids(),
+			records: ids(r2.ID),
+		},
+		{
+			name: "deleted build",
+			change: func() error {
+				_, _, err := testDB.BuildDelete(b.ID)
+				return err
+			},
+			builds:  ids(b.ID),
+			records: ids(r1.ID),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.change(); err != nil {
+				t.Fatalf("failed to make change: %v", err)
+			}
+			s, err := EntryScope(testDB, lastEntry(t))
+			if err != nil {
+				t.Fatalf("failed to get scope: %v", err)
+			}
+			if s == nil {
+				t.Fatalf("expected a scope, got every build and record")
+			}
+			if !reflect.DeepEqual(s.Builds, tt.builds) || !reflect.DeepEqual(s.Records, tt.records) {
+				t.Fatalf("expected builds %v and records %v, got %v and %v", tt.builds, tt.records, s.Builds, s.Records)
+			}
+		})
+	}
+}
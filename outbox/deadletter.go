@@ -0,0 +1,60 @@
+package outbox
+
+import (
+	"time"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// DeadLetters gets the entries that failed too many times
+// and won't be attempted again unless they're replayed
+func DeadLetters(db *database.Database) ([]database.OutboxEntry, error) {
+	oes, err := db.OutboxEntries(true)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get dead outbox entries")
+	}
+	return oes, nil
+}
+
+// Replay returns a dead letter to the outbox so that it's attempted
+// again straight away with a fresh set of attempts
+// The error from its last attempt is kept until it's next attempted
+// False is returned if the entry doesn't exist or isn't dead
+func Replay(db *database.Database, outboxEntryID string) (database.OutboxEntry, bool, error) {
+	oe, ok, err := db.OutboxEntry(outboxEntryID)
+	if err != nil {
+		return database.OutboxEntry{}, false, errors.Wrap(err, "failed to get outbox entry")
+	} else if !ok || !oe.Dead {
+		return database.OutboxEntry{}, false, nil
+	}
+	oe, ok, err = db.OutboxEntryEdit(oe.ID, 0, database.Timestamp(time.Now()), oe.LastError, false)
+	if err != nil {
+		return database.OutboxEntry{}, false, errors.Wrap(err, "failed to edit outbox entry")
+	}
+	return oe, ok, nil
+}
+
+// ReplayAll replays every dead letter and returns how many were replayed
+func ReplayAll(db *database.Database) (int, error) {
+	oes, err := DeadLetters(db)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	err = db.Transaction(func(tx *database.Database) error {
+		for _, oe := range oes {
+			_, ok, err := Replay(tx, oe.ID)
+			if err != nil {
+				return errors.Wrapf(err, "failed to replay outbox entry %s", oe.ID)
+			} else if ok {
+				n++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,30 @@
+package outbox
+
+import (
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/Kappeh/RecordBot/messagesync"
+	"github.com/pkg/errors"
+)
+
+// SyncHandler creates a handler which brings the messages of every
+// guild for the entry's row up to date with the database
+// A change to a build can change the messages of the records it holds
+// and so they're planned too, see messagesync.EntryScope.
+// Planning is idempotent, so handling an entry more than once
+// doesn't send duplicate messages
+func SyncHandler(db *database.Database, m messagesync.Messenger) Handler {
+	return func(e database.OutboxEntry) error {
+		scope, err := messagesync.EntryScope(db, e)
+		if err != nil {
+			return errors.Wrap(err, "failed to get message sync scope")
+		}
+		plan, err := messagesync.PlanScope(db, scope)
+		if err != nil {
+			return errors.Wrap(err, "failed to plan message sync")
+		}
+		if err = messagesync.Apply(db, m, plan); err != nil {
+			return errors.Wrap(err, "failed to apply message sync")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,169 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// Defaults used by workers made with NewWorker
+const (
+	// DefaultMaxAttempts is the number of failed attempts after which
+	// an entry becomes a dead letter
+	DefaultMaxAttempts = 8
+	// DefaultBaseDelay is the delay before the first retry
+	DefaultBaseDelay = 5 * time.Second
+	// DefaultMaxDelay is the longest delay between retries
+	DefaultMaxDelay = time.Hour
+	// DefaultBatchSize is the most entries attempted at once
+	DefaultBatchSize = 50
+	// DefaultInterval is how often the outbox is checked for due entries
+	DefaultInterval = time.Second
+)
+
+// Handler carries out the side effects of an outbox entry
+// An entry may be handled more than once, for example if the bot stops
+// after handling it but before it's removed from the outbox, so handlers
+// must be idempotent or use the entry's idempotency key to skip repeats
+type Handler func(e database.OutboxEntry) error
+
+// Worker drains the outbox by handling due entries
+// Failed entries are retried with exponential backoff until they have
+// failed MaxAttempts times, at which point they become dead letters
+type Worker struct {
+	// MaxAttempts is the number of failed attempts after which
+	// an entry becomes a dead letter
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry
+	// Each following retry waits twice as long as the last
+	BaseDelay time.Duration
+	// MaxDelay is the longest delay between retries
+	MaxDelay time.Duration
+	// BatchSize is the most entries attempted by each call to RunOnce
+	BatchSize int
+	// Interval is how often Run checks for due entries
+	Interval time.Duration
+
+	db      *database.Database
+	handler Handler
+	// now gets the current time, which tests replace
+	// to step through backoff without waiting
+	now func() time.Time
+}
+
+// Result is the outcome of a call to RunOnce
+type Result struct {
+	// Handled is the number of entries handled successfully
+	Handled int
+	// Retried is the number of entries that failed and will be retried
+	Retried int
+	// Dead is the number of entries that failed and became dead letters
+	Dead int
+}
+
+// NewWorker creates a worker with the default settings
+func NewWorker(db *database.Database, h Handler) *Worker {
+	return &Worker{
+		MaxAttempts: DefaultMaxAttempts,
+		BaseDelay:   DefaultBaseDelay,
+		MaxDelay:    DefaultMaxDelay,
+		BatchSize:   DefaultBatchSize,
+		Interval:    DefaultInterval,
+		db:          db,
+		handler:     h,
+		now:         time.Now,
+	}
+}
+
+// Run drains the outbox until ctx is done
+// Errors accessing the database stop the worker, errors from the
+// handler are recorded on the entry that caused them
+func (w *Worker) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		// Keep going without waiting while there are full batches
+		for {
+			r, err := w.RunOnce()
+			if err != nil {
+				return err
+			}
+			if r.Handled+r.Retried+r.Dead < w.BatchSize {
+				break
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// entity identifies the row an outbox entry is for
+type entity struct {
+	kind database.OutboxKind
+	id   string
+}
+
+// RunOnce attempts a batch of due entries
+// Handled entries are removed from the outbox
+// Entries for the same row are coalesced, so the row is handled once
+// and its result counts for each of its entries in the batch
+func (w *Worker) RunOnce() (Result, error) {
+	now := w.now()
+	oes, err := w.db.OutboxDueEntries(now, w.BatchSize)
+	if err != nil {
+		return Result{}, errors.Wrap(err, "failed to get due outbox entries")
+	}
+	r := Result{}
+	results := map[entity]error{}
+	for _, oe := range oes {
+		e := entity{kind: oe.Kind, id: oe.EntityID}
+		handleErr, ok := results[e]
+		if !ok {
+			handleErr = w.handler(oe)
+			results[e] = handleErr
+		}
+		if handleErr == nil {
+			if _, _, err = w.db.OutboxEntryDelete(oe.ID); err != nil {
+				return r, errors.Wrap(err, "failed to delete outbox entry")
+			}
+			r.Handled++
+			continue
+		}
+		attempts := oe.Attempts + 1
+		dead := attempts >= w.MaxAttempts
+		next := now.Add(w.backoff(attempts))
+		if _, _, err = w.db.OutboxEntryEdit(oe.ID, attempts, database.Timestamp(next), handleErr.Error(), dead); err != nil {
+			return r, errors.Wrap(err, "failed to edit outbox entry")
+		}
+		if dead {
+			r.Dead++
+		} else {
+			r.Retried++
+		}
+	}
+	return r, nil
+}
+
+// backoff gets the delay before retrying an entry
+// that has failed the specified number of times
+func (w *Worker) backoff(attempts int) time.Duration {
+	delay := w.BaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= w.MaxDelay {
+			return w.MaxDelay
+		}
+	}
+	if delay > w.MaxDelay {
+		return w.MaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,311 @@
+package outbox
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// testDB is the database shared by the tests
+// The database package only allows one database to be opened
+var testDB *database.Database
+
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "outbox")
+	if err != nil {
+		panic(err)
+	}
+	testDB, err = database.Open(filepath.Join(dir, "database.db"))
+	if err != nil {
+		os.RemoveAll(dir)
+		panic(err)
+	}
+	code := m.Run()
+	testDB.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// clock is a fake clock which only moves when it's advanced
+// It starts at the current time, to the second, so that entries
+// created by the test are already due
+type clock struct {
+	t time.Time
+}
+
+func newClock() *clock {
+	return &clock{t: time.Now().Truncate(time.Second)}
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+// at gets the wall clock time in d from now
+// Timestamps are stored without a time zone and read back as UTC,
+// so they're compared with the wall clock of the fake clock
+func (c *clock) at(d time.Duration) string {
+	return c.t.Add(d).Format("2006-01-02 15:04:05")
+}
+
+// wall gets the wall clock time of a timestamp
+func wall(ts database.Timestamp) string {
+	return time.Time(ts).Format("2006-01-02 15:04:05")
+}
+
+// handler is a handler which fails a number of times
+// before handling entries and records what it was given
+type handler struct {
+	// failures is the number of attempts which fail
+	// Every attempt fails if it's negative
+	failures int
+	calls    []database.OutboxEntry
+}
+
+func (h *handler) handle(e database.OutboxEntry) error {
+	h.calls = append(h.calls, e)
+	if h.failures < 0 || len(h.calls) <= h.failures {
+		return errors.New("failed to handle entry")
+	}
+	return nil
+}
+
+// newTestWorker creates a worker which uses a fake clock
+// The outbox is emptied so that tests don't see each other's entries
+func newTestWorker(t *testing.T, h *handler) (*Worker, *clock) {
+	t.Helper()
+	for _, dead := range []bool{false, true} {
+		oes, err := testDB.OutboxEntries(dead)
+		if err != nil {
+			t.Fatalf("failed to get outbox entries: %v", err)
+		}
+		for _, oe := range oes {
+			if _, _, err = testDB.OutboxEntryDelete(oe.ID); err != nil {
+				t.Fatalf("failed to delete outbox entry: %v", err)
+			}
+		}
+	}
+	c := newClock()
+	w := NewWorker(testDB, h.handle)
+	w.MaxAttempts = 3
+	w.BaseDelay = time.Minute
+	w.MaxDelay = 3 * time.Minute
+	w.now = c.now
+	return w, c
+}
+
+// createEntry creates an outbox entry for a build
+func createEntry(t *testing.T, buildID, key string) database.OutboxEntry {
+	t.Helper()
+	oe, ok, err := testDB.OutboxEntryCreate(database.OutboxBuild, buildID, key, nil)
+	if err != nil || !ok {
+		t.Fatalf("failed to create outbox entry: %v", err)
+	}
+	return oe
+}
+
+// getEntry gets an outbox entry which has to exist
+func getEntry(t *testing.T, id string) database.OutboxEntry {
+	t.Helper()
+	oe, ok, err := testDB.OutboxEntry(id)
+	if err != nil || !ok {
+		t.Fatalf("failed to get outbox entry %s: %v", id, err)
+	}
+	return oe
+}
+
+// runOnce attempts a batch of entries and checks the result
+func runOnce(t *testing.T, w *Worker, want Result) {
+	t.Helper()
+	r, err := w.RunOnce()
+	if err != nil {
+		t.Fatalf("failed to run worker: %v", err)
+	}
+	if r != want {
+		t.Fatalf("expected %+v, got %+v", want, r)
+	}
+}
+
+func TestWorkerHandlesEntries(t *testing.T) {
+	h := &handler{}
+	w, _ := newTestWorker(t, h)
+	createEntry(t, "1", "build:1:a")
+	createEntry(t, "2", "build:2:a")
+	runOnce(t, w, Result{Handled: 2})
+	if len(h.calls) != 2 || h.calls[0].EntityID != "1" || h.calls[1].EntityID != "2" {
+		t.Errorf("expected both entries to be handled in order, got %+v", h.calls)
+	}
+	if oes, err := testDB.OutboxEntries(false); err != nil || len(oes) != 0 {
+		t.Errorf("expected handled entries to be removed, got %+v, %v", oes, err)
+	}
+	runOnce(t, w, Result{})
+}
+
+func TestWorkerRetriesWithBackoff(t *testing.T) {
+	h := &handler{failures: 2}
+	w, c := newTestWorker(t, h)
+	oe := createEntry(t, "1", "build:1:a")
+
+	runOnce(t, w, Result{Retried: 1})
+	got := getEntry(t, oe.ID)
+	if got.Attempts != 1 || got.LastError != "failed to handle entry" || got.Dead {
+		t.Fatalf("expected one failed attempt, got %+v", got)
+	}
+	if next := wall(got.NextAttemptTimestamp); next != c.at(time.Minute) {
+		t.Fatalf("expected the next attempt at %s, got %s", c.at(time.Minute), next)
+	}
+
+	// The entry isn't attempted again until its delay has passed
+	c.advance(59 * time.Second)
+	runOnce(t, w, Result{})
+	c.advance(time.Second)
+	runOnce(t, w, Result{Retried: 1})
+	// Each retry waits twice as long as the last
+	got = getEntry(t, oe.ID)
+	if next := wall(got.NextAttemptTimestamp); got.Attempts != 2 || next != c.at(2*time.Minute) {
+		t.Fatalf("expected a second attempt at %s, got %+v", c.at(2*time.Minute), got)
+	}
+
+	c.advance(2 * time.Minute)
+	runOnce(t, w, Result{Handled: 1})
+	if len(h.calls) != 3 {
+		t.Errorf("expected 3 attempts, got %d", len(h.calls))
+	}
+	if _, ok, err := testDB.OutboxEntry(oe.ID); err != nil || ok {
+		t.Errorf("expected the entry to be removed, got %v, %v", ok, err)
+	}
+}
+
+func TestWorkerBackoff(t *testing.T) {
+	w := NewWorker(testDB, nil)
+	w.BaseDelay = 5 * time.Second
+	w.MaxDelay = time.Minute
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{3, 20 * time.Second},
+		{4, 40 * time.Second},
+		{5, time.Minute},
+		{6, time.Minute},
+		// Doubling this many times would overflow
+		{100, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := w.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, expected %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestWorkerDeadLetters(t *testing.T) {
+	h := &handler{failures: -1}
+	w, c := newTestWorker(t, h)
+	oe := createEntry(t, "1", "build:1:a")
+
+	runOnce(t, w, Result{Retried: 1})
+	c.advance(w.MaxDelay)
+	runOnce(t, w, Result{Retried: 1})
+	c.advance(w.MaxDelay)
+	runOnce(t, w, Result{Dead: 1})
+	got := getEntry(t, oe.ID)
+	if !got.Dead || got.Attempts != w.MaxAttempts {
+		t.Fatalf("expected a dead letter after %d attempts, got %+v", w.MaxAttempts, got)
+	}
+
+	// Dead letters aren't attempted again
+	c.advance(time.Hour)
+	runOnce(t, w, Result{})
+	if len(h.calls) != w.MaxAttempts {
+		t.Errorf("expected %d attempts, got %d", w.MaxAttempts, len(h.calls))
+	}
+	dead, err := DeadLetters(testDB)
+	if err != nil {
+		t.Fatalf("failed to get dead letters: %v", err)
+	}
+	if len(dead) != 1 || dead[0].ID != oe.ID || dead[0].LastError != "failed to handle entry" {
+		t.Errorf("expected the entry to be a dead letter, got %+v", dead)
+	}
+}
+
+func TestWorkerIdempotencyKeys(t *testing.T) {
+	h := &handler{}
+	w, _ := newTestWorker(t, h)
+	createEntry(t, "1", "build:1:a")
+	// An entry with the same key isn't created
+	if _, ok, err := testDB.OutboxEntryCreate(database.OutboxBuild, "1", "build:1:a", nil); err != nil || ok {
+		t.Fatalf("expected a repeated key to be ignored, got %v, %v", ok, err)
+	}
+	// Different changes to the same row are handled once
+	createEntry(t, "1", "build:1:b")
+	createEntry(t, "2", "build:2:a")
+	runOnce(t, w, Result{Handled: 3})
+	if len(h.calls) != 2 || h.calls[0].EntityID != "1" || h.calls[1].EntityID != "2" {
+		t.Errorf("expected each build to be handled once, got %+v", h.calls)
+	}
+	// The key of a handled entry can be used again
+	createEntry(t, "1", "build:1:a")
+}
+
+// killEntry makes an entry a dead letter
+func killEntry(t *testing.T, id string) {
+	t.Helper()
+	if _, _, err := testDB.OutboxEntryEdit(id, 3, database.Timestamp(time.Now()), "failed to handle entry", true); err != nil {
+		t.Fatalf("failed to edit outbox entry: %v", err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	h := &handler{}
+	w, _ := newTestWorker(t, h)
+	oe := createEntry(t, "1", "build:1:a")
+	killEntry(t, oe.ID)
+	runOnce(t, w, Result{})
+
+	got, ok, err := Replay(testDB, oe.ID)
+	if err != nil || !ok {
+		t.Fatalf("failed to replay entry: %v", err)
+	}
+	if got.Dead || got.Attempts != 0 || got.LastError != "failed to handle entry" {
+		t.Fatalf("expected a fresh set of attempts keeping the last error, got %+v", got)
+	}
+	// Entries which aren't dead or don't exist aren't replayed
+	for _, id := range []string{oe.ID, "999"} {
+		if _, ok, err = Replay(testDB, id); err != nil || ok {
+			t.Errorf("Replay(%s) = %v, %v, expected it not to be replayed", id, ok, err)
+		}
+	}
+	runOnce(t, w, Result{Handled: 1})
+}
+
+func TestReplayAll(t *testing.T) {
+	h := &handler{}
+	w, _ := newTestWorker(t, h)
+	for _, id := range []string{"1", "2"} {
+		killEntry(t, createEntry(t, id, "build:"+id+":a").ID)
+	}
+	createEntry(t, "3", "build:3:a")
+
+	n, err := ReplayAll(testDB)
+	if err != nil {
+		t.Fatalf("failed to replay entries: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 entries to be replayed, got %d", n)
+	}
+	if dead, err := DeadLetters(testDB); err != nil || len(dead) != 0 {
+		t.Errorf("expected no dead letters, got %+v, %v", dead, err)
+	}
+	runOnce(t, w, Result{Handled: 3})
+}
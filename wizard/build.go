@@ -0,0 +1,210 @@
+package wizard
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	register(&Wizard{
+		TicketType: database.TicketSubmitBuild,
+		Steps:      buildSteps(),
+		Init:       initBuild,
+		Finish:     finishBuild,
+	})
+}
+
+// buildSteps are the steps which fill in the details of a build
+func buildSteps() []Step {
+	return []Step{
+		{
+			Name:   "edition",
+			Prompt: "Which edition of Minecraft is the build in?",
+			Apply: func(db *database.Database, d *Draft, input string) error {
+				e, err := findEdition(db, input)
+				if err != nil {
+					return err
+				}
+				d.Build.EditionID = e.ID
+				return nil
+			},
+		},
+		{
+			Name:   "class",
+			Prompt: "What class of build is it? (e.g. Piston Door)",
+			Apply: func(db *database.Database, d *Draft, input string) error {
+				b, err := findBuildClass(db, input)
+				if err != nil {
+					return err
+				}
+				d.Build.BuildClassID = b.ID
+				return nil
+			},
+		},
+		{
+			Name:   "name",
+			Prompt: "What is the name of the build?",
+			Apply: func(db *database.Database, d *Draft, input string) error {
+				name, err := parseName(input)
+				if err != nil {
+					return err
+				}
+				d.Build.Name = name
+				return nil
+			},
+		},
+		{
+			Name:     "description",
+			Prompt:   "Give a short description of the build.",
+			Optional: true,
+			Apply: func(db *database.Database, d *Draft, input string) error {
+				d.Build.Description = strings.TrimSpace(input)
+				return nil
+			},
+		},
+		{
+			Name:   "creators",
+			Prompt: "Who created the build? Give a comma separated list of in game names.",
+			Apply: func(db *database.Database, d *Draft, input string) error {
+				creators, err := parseCreators(input)
+				if err != nil {
+					return err
+				}
+				d.Build.Creators = creators
+				return nil
+			},
+		},
+		{
+			Name:   "dimensions",
+			Prompt: "What are the dimensions of the build? (width x height x depth e.g. 3x4x5)",
+			Apply: func(db *database.Database, d *Draft, input string) error {
+				size, err := database.ParseDimensions(input)
+				if err != nil {
+					return inputErrorf("%s", err)
+				}
+				d.Build.SetDimensions(size)
+				return nil
+			},
+		},
+		durationStep("normal", "What are the normal closing and opening times in gameticks? (2.1.2.3 - b)",
+			func(b *database.Build) (*database.Ticks, *database.Ticks) {
+				return &b.NormalCloseDuration, &b.NormalOpenDuration
+			}),
+		durationStep("visible", "What are the visible closing and opening times in gameticks? (2.1.2.3 - a)",
+			func(b *database.Build) (*database.Ticks, *database.Ticks) {
+				return &b.VisibleCloseDuration, &b.VisibleOpenDuration
+			}),
+		durationStep("delay", "What are the closing and opening input delays in gameticks? (2.1.2.3 - c)",
+			func(b *database.Build) (*database.Ticks, *database.Ticks) {
+				return &b.DelayCloseDuration, &b.DelayOpenDuration
+			}),
+		durationStep("reset", "What are the closing and opening reset times in gameticks? (2.1.2.3 - d)",
+			func(b *database.Build) (*database.Ticks, *database.Ticks) {
+				return &b.ResetCloseDuration, &b.ResetOpenDuration
+			}),
+		durationStep("extension", "What are the extension and retraction times in gameticks? (2.3.2.2 - a)",
+			func(b *database.Build) (*database.Ticks, *database.Ticks) {
+				return &b.ExtensionDuration, &b.RetractionDuration
+			}),
+		durationStep("extension delay", "What are the extension and retraction input delays in gameticks? (2.3.2.2 - b)",
+			func(b *database.Build) (*database.Ticks, *database.Ticks) {
+				return &b.ExtensionDelayDuration, &b.RetractionDelayDuration
+			}),
+		urlStep("image", "Give a link to an image of the build.",
+			func(b *database.Build) *string { return &b.ImageURL }),
+		urlStep("video", "Give a link to a youtube video of the build.",
+			func(b *database.Build) *string { return &b.YoutubeURL }),
+		urlStep("world download", "Give a link to a world download containing the build.",
+			func(b *database.Build) *string { return &b.WorldDownloadURL }),
+		{
+			Name:     "server",
+			Prompt:   "Is the build on a server? Give the server ip, the build's coordinates and a command to get there separated by |",
+			Optional: true,
+			Apply: func(db *database.Database, d *Draft, input string) error {
+				parts := strings.Split(input, "|")
+				if len(parts) > 3 {
+					return inputErrorf("give at most an ip, coordinates and a command separated by |")
+				}
+				for len(parts) < 3 {
+					parts = append(parts, "")
+				}
+				ip := strings.TrimSpace(parts[0])
+				if ip == "" {
+					return inputErrorf("the server ip can't be empty")
+				}
+				d.Build.ServerIPAddress = ip
+				d.Build.ServerCoordinates = strings.TrimSpace(parts[1])
+				d.Build.ServerCommand = strings.TrimSpace(parts[2])
+				return nil
+			},
+		},
+	}
+}
+
+// durationStep creates an optional step which sets a pair of
+// duration fields of a build
+func durationStep(name, prompt string, fields func(b *database.Build) (*database.Ticks, *database.Ticks)) Step {
+	return Step{
+		Name:     name + " durations",
+		Prompt:   prompt,
+		Optional: true,
+		Apply: func(db *database.Database, d *Draft, input string) error {
+			first, second, err := parseDurationPair(input)
+			if err != nil {
+				return err
+			}
+			f, s := fields(&d.Build)
+			*f, *s = first, second
+			return nil
+		},
+	}
+}
+
+// urlStep creates an optional step which sets a url field of a build
+func urlStep(name, prompt string, field func(b *database.Build) *string) Step {
+	return Step{
+		Name:     name,
+		Prompt:   prompt,
+		Optional: true,
+		Apply: func(db *database.Database, d *Draft, input string) error {
+			u, err := parseURL(input)
+			if err != nil {
+				return err
+			}
+			*field(&d.Build) = u
+			return nil
+		},
+	}
+}
+
+// initBuild prepares a new build submitted by the ticket's creator
+func initBuild(db *database.Database, d *Draft, ticket database.GuildTicketChannel) error {
+	d.Build = database.Build{
+		VerifierID:           "0",
+		ReporterID:           "0",
+		UpdateRequestBuildID: "0",
+		CreationTimestamp:    database.Timestamp(time.Now()),
+		SubmitterID:          ticket.CreatorID,
+	}
+	return nil
+}
+
+// finishBuild creates the build as an unverified submission
+func finishBuild(db *database.Database, d Draft) (Draft, error) {
+	b := d.Build
+	b.Verified = false
+	b.VerifierID = "0"
+	b.Reported = false
+	b.ReporterID = "0"
+	b, err := db.BuildCreate(b)
+	if ve, ok := errors.Cause(err).(*database.ValidationError); ok {
+		return Draft{}, inputErrorf("the build can't be submitted: %s", ve.Error())
+	} else if err != nil {
+		return Draft{}, errors.Wrap(err, "failed to create build")
+	}
+	d.Build = b
+	return d, nil
+}
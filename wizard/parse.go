@@ -0,0 +1,189 @@
+package wizard
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// findEdition finds an edition by its id or name
+func findEdition(db *database.Database, input string) (database.Edition, error) {
+	editions, err := db.Editions()
+	if err != nil {
+		return database.Edition{}, errors.Wrap(err, "failed to get editions")
+	}
+	input = strings.TrimSpace(input)
+	for _, e := range editions {
+		if e.ID == input || strings.EqualFold(e.Name, input) {
+			return e, nil
+		}
+	}
+	return database.Edition{}, inputErrorf("there is no edition called %q", input)
+}
+
+// findBuildClass finds a build class by its id or name
+func findBuildClass(db *database.Database, input string) (database.BuildClass, error) {
+	buildClasses, err := db.BuildClasses()
+	if err != nil {
+		return database.BuildClass{}, errors.Wrap(err, "failed to get build classes")
+	}
+	input = strings.TrimSpace(input)
+	for _, b := range buildClasses {
+		if b.ID == input || strings.EqualFold(b.Name, input) {
+			return b, nil
+		}
+	}
+	return database.BuildClass{}, inputErrorf("there is no build class called %q", input)
+}
+
+// parseName parses a name or title that must not be empty
+func parseName(input string) (string, error) {
+	name := strings.TrimSpace(input)
+	if name == "" {
+		return "", inputErrorf("the name can't be empty")
+	}
+	if len(name) > 100 {
+		return "", inputErrorf("the name can't be longer than 100 characters")
+	}
+	return name, nil
+}
+
+// parseCreators parses a comma separated list of
+// in game names into a normalised list
+func parseCreators(input string) (string, error) {
+	creators := []string{}
+	for _, c := range strings.Split(input, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			creators = append(creators, c)
+		}
+	}
+	if len(creators) == 0 {
+		return "", inputErrorf("at least one creator is needed")
+	}
+	return strings.Join(creators, ", "), nil
+}
+
+// parseDurationPair parses two durations such as 27gt or 1.35s
+// separated by whitespace, a comma or a slash
+func parseDurationPair(input string) (database.Ticks, database.Ticks, error) {
+	parts := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == ',' || r == '/'
+	})
+	if len(parts) != 2 {
+		return 0, 0, inputErrorf("two durations are needed e.g. 12 14")
+	}
+	values := [2]database.Ticks{}
+	for i, p := range parts {
+		v, err := database.ParseTicks(p)
+		if err != nil {
+			return 0, 0, inputErrorf("%s", err)
+		}
+		values[i] = v
+	}
+	return values[0], values[1], nil
+}
+
+// parseURL parses a http or https url
+func parseURL(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	u, err := url.Parse(input)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", inputErrorf("%q isn't a valid link", input)
+	}
+	return u.String(), nil
+}
+
+// findRecordType finds a record type by its id or name
+func findRecordType(db *database.Database, input string) (database.RecordType, error) {
+	recordTypes, err := db.RecordTypes()
+	if err != nil {
+		return database.RecordType{}, errors.Wrap(err, "failed to get record types")
+	}
+	input = strings.TrimSpace(input)
+	for _, rt := range recordTypes {
+		if rt.ID == input || strings.EqualFold(rt.Name, input) {
+			return rt, nil
+		}
+	}
+	return database.RecordType{}, inputErrorf("there is no record type called %q", input)
+}
+
+// findBuild finds a verified build that isn't an update request
+// by its id or name
+func findBuild(db *database.Database, input string) (database.Build, error) {
+	builds, err := db.Builds()
+	if err != nil {
+		return database.Build{}, errors.Wrap(err, "failed to get builds")
+	}
+	input = strings.TrimSpace(input)
+	matches := []database.Build{}
+	for _, b := range builds {
+		if !b.Verified || b.UpdateRequest {
+			continue
+		}
+		if b.ID == input {
+			return b, nil
+		}
+		if strings.EqualFold(b.Name, input) {
+			matches = append(matches, b)
+		}
+	}
+	if len(matches) == 0 {
+		return database.Build{}, inputErrorf("there is no build called %q", input)
+	} else if len(matches) > 1 {
+		return database.Build{}, inputErrorf("there is more than one build called %q, use the build's id instead", input)
+	}
+	return matches[0], nil
+}
+
+// findRecord finds a verified record that isn't an update request
+// by its id or name
+func findRecord(db *database.Database, input string) (database.Record, error) {
+	records, err := db.Records()
+	if err != nil {
+		return database.Record{}, errors.Wrap(err, "failed to get records")
+	}
+	input = strings.TrimSpace(input)
+	matches := []database.Record{}
+	for _, r := range records {
+		if !r.Verified || r.UpdateRequest {
+			continue
+		}
+		if r.ID == input {
+			return r, nil
+		}
+		if strings.EqualFold(r.Name, input) {
+			matches = append(matches, r)
+		}
+	}
+	if len(matches) == 0 {
+		return database.Record{}, inputErrorf("there is no record called %q", input)
+	} else if len(matches) > 1 {
+		return database.Record{}, inputErrorf("there is more than one record called %q, use the record's id instead", input)
+	}
+	return matches[0], nil
+}
+
+// findDuplicateRecord finds an existing record with the same edition,
+// build class, record type and name as a record
+// Update requests and the record with the id ignoreID are ignored
+func findDuplicateRecord(db *database.Database, r database.Record, ignoreID string) (database.Record, bool, error) {
+	records, err := db.Records()
+	if err != nil {
+		return database.Record{}, false, errors.Wrap(err, "failed to get records")
+	}
+	for _, existing := range records {
+		if existing.UpdateRequest || existing.ID == ignoreID {
+			continue
+		}
+		if existing.EditionID == r.EditionID &&
+			existing.BuildClassID == r.BuildClassID &&
+			existing.RecordTypeID == r.RecordTypeID &&
+			strings.EqualFold(existing.Name, r.Name) {
+			return existing, true, nil
+		}
+	}
+	return database.Record{}, false, nil
+}
@@ -0,0 +1,129 @@
+package wizard
+
+import (
+	"strings"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	register(&Wizard{
+		TicketType: database.TicketSubmitRecord,
+		Steps:      recordSteps(),
+		Init:       initRecord,
+		Finish:     finishRecord,
+	})
+}
+
+// recordSteps are the steps which fill in the details of a record
+func recordSteps() []Step {
+	return []Step{
+		{
+			Name:   "edition",
+			Prompt: "Which edition of Minecraft is the record for?",
+			Apply: func(db *database.Database, d *Draft, input string) error {
+				e, err := findEdition(db, input)
+				if err != nil {
+					return err
+				}
+				d.Record.EditionID = e.ID
+				return nil
+			},
+		},
+		{
+			Name:   "class",
+			Prompt: "Which class of build is the record for? (e.g. Piston Door)",
+			Apply: func(db *database.Database, d *Draft, input string) error {
+				b, err := findBuildClass(db, input)
+				if err != nil {
+					return err
+				}
+				d.Record.BuildClassID = b.ID
+				return nil
+			},
+		},
+		{
+			Name:   "record type",
+			Prompt: "What type of record is it? (e.g. Smallest)",
+			Apply: func(db *database.Database, d *Draft, input string) error {
+				rt, err := findRecordType(db, input)
+				if err != nil {
+					return err
+				}
+				d.Record.RecordTypeID = rt.ID
+				return nil
+			},
+		},
+		{
+			Name:   "name",
+			Prompt: "What is the name of the record? (e.g. 3x3 Piston Door)",
+			Apply: func(db *database.Database, d *Draft, input string) error {
+				name, err := parseName(input)
+				if err != nil {
+					return err
+				}
+				r := d.Record
+				r.Name = name
+				if err := checkDuplicateRecord(db, r); err != nil {
+					return err
+				}
+				d.Record.Name = name
+				return nil
+			},
+		},
+		{
+			Name:     "description",
+			Prompt:   "Give a short description of the record.",
+			Optional: true,
+			Apply: func(db *database.Database, d *Draft, input string) error {
+				d.Record.Description = strings.TrimSpace(input)
+				return nil
+			},
+		},
+	}
+}
+
+// checkDuplicateRecord returns an InputError if a record already
+// exists with the same edition, build class, record type and name
+// A record being updated isn't counted as a duplicate of itself
+func checkDuplicateRecord(db *database.Database, r database.Record) error {
+	ignoreID := ""
+	if r.UpdateRequest {
+		ignoreID = r.UpdateRequestRecordID
+	}
+	existing, ok, err := findDuplicateRecord(db, r, ignoreID)
+	if err != nil {
+		return err
+	} else if ok {
+		return inputErrorf("the record %q (id %s) already exists", existing.Name, existing.ID)
+	}
+	return nil
+}
+
+// initRecord prepares a new record submitted by the ticket's creator
+func initRecord(db *database.Database, d *Draft, ticket database.GuildTicketChannel) error {
+	d.Record = database.Record{
+		VerifierID:            "0",
+		UpdateRequestRecordID: "0",
+		SubmitterID:           ticket.CreatorID,
+	}
+	return nil
+}
+
+// finishRecord creates the record as an unverified submission
+func finishRecord(db *database.Database, d Draft) (Draft, error) {
+	r := d.Record
+	r.Verified = false
+	r.VerifierID = "0"
+	// Another record may have been made since the name was entered
+	if err := checkDuplicateRecord(db, r); err != nil {
+		return Draft{}, err
+	}
+	r, err := db.RecordCreate(r)
+	if err != nil {
+		return Draft{}, errors.Wrap(err, "failed to create record")
+	}
+	d.Record = r
+	return d, nil
+}
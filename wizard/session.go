@@ -0,0 +1,220 @@
+package wizard
+
+import (
+	"encoding/json"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// Session is a wizard being ran within a ticket channel
+// Progress is saved to the database after every change
+// so that a session can be resumed after a restart
+type Session struct {
+	db     *database.Database
+	wizard *Wizard
+
+	// GuildID is the id of the guild containing the ticket
+	GuildID string
+	// ChannelID is the id of the ticket channel
+	ChannelID string
+
+	// Step is the index of the current step
+	// It is equal to the number of steps once the wizard is complete
+	Step int
+	// History is the indexes of previously visited steps
+	// with the most recent last
+	History []int
+	// Draft is the information entered so far
+	Draft Draft
+}
+
+// Start starts the wizard for a ticket channel
+// false is returned if the channel isn't a ticket, the ticket's
+// type doesn't have a wizard or the wizard has already been started
+func Start(db *database.Database, guildID, channelID string) (*Session, bool, error) {
+	// Get the ticket the wizard is being started in
+	ticket, ok, err := db.GuildTicketChannel(guildID, channelID)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to determine if guild ticket channel exists")
+	} else if !ok {
+		return nil, false, nil
+	}
+	w, ok := For(ticket.TicketType)
+	if !ok {
+		return nil, false, nil
+	}
+	// Create the session
+	s := &Session{
+		db:        db,
+		wizard:    w,
+		GuildID:   guildID,
+		ChannelID: channelID,
+		History:   []int{},
+	}
+	if w.Init != nil {
+		if err := w.Init(db, &s.Draft, ticket); err != nil {
+			return nil, false, errors.Wrap(err, "failed to initialise draft")
+		}
+	}
+	history, draft, err := s.encode()
+	if err != nil {
+		return nil, false, err
+	}
+	if _, ok, err = db.TicketWizardCreate(guildID, channelID, ticket.TicketType, history, draft); err != nil {
+		return nil, false, errors.Wrap(err, "failed to save wizard")
+	} else if !ok {
+		// Wizard already started
+		return nil, false, nil
+	}
+	return s, true, nil
+}
+
+// Resume loads the saved session for a ticket channel
+// false is returned if no wizard has been started in the channel
+func Resume(db *database.Database, guildID, channelID string) (*Session, bool, error) {
+	tw, ok, err := db.TicketWizard(guildID, channelID)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to determine if ticket wizard exists")
+	} else if !ok {
+		return nil, false, nil
+	}
+	w, ok := For(tw.TicketType)
+	if !ok {
+		return nil, false, errors.Errorf("no wizard for ticket type %d", tw.TicketType)
+	}
+	s := &Session{
+		db:        db,
+		wizard:    w,
+		GuildID:   guildID,
+		ChannelID: channelID,
+		Step:      tw.Step,
+	}
+	if err = json.Unmarshal([]byte(tw.History), &s.History); err != nil {
+		return nil, false, errors.Wrap(err, "failed to decode history")
+	}
+	if err = json.Unmarshal([]byte(tw.Draft), &s.Draft); err != nil {
+		return nil, false, errors.Wrap(err, "failed to decode draft")
+	}
+	// The wizard may have fewer steps than when the
+	// session was saved
+	if s.Step > len(w.Steps) {
+		s.Step = len(w.Steps)
+	}
+	return s, true, nil
+}
+
+// Current gets the step the session is on
+// false is returned if all steps have been completed
+func (s *Session) Current() (Step, bool) {
+	if s.Complete() {
+		return Step{}, false
+	}
+	return s.wizard.Steps[s.Step], true
+}
+
+// Complete indicates whether all steps have been completed
+func (s *Session) Complete() bool {
+	return s.Step >= len(s.wizard.Steps)
+}
+
+// Submit gives input to the current step and moves onto the
+// next step if the input is valid. An InputError is returned
+// if the input is invalid
+func (s *Session) Submit(input string) error {
+	step, ok := s.Current()
+	if !ok {
+		return inputErrorf("all steps have been completed")
+	}
+	// Apply to a copy so invalid input doesn't change the draft
+	d := s.Draft
+	if err := step.Apply(s.db, &d, input); err != nil {
+		return err
+	}
+	s.Draft = d
+	s.advance()
+	return s.save()
+}
+
+// Skip moves onto the next step without giving any input
+// An InputError is returned if the current step isn't optional
+func (s *Session) Skip() error {
+	step, ok := s.Current()
+	if !ok {
+		return inputErrorf("all steps have been completed")
+	}
+	if !step.Optional {
+		return inputErrorf("the %s step can't be skipped", step.Name)
+	}
+	s.advance()
+	return s.save()
+}
+
+// Back returns to the previously visited step
+// An InputError is returned if there is no previous step
+func (s *Session) Back() error {
+	if len(s.History) == 0 {
+		return inputErrorf("there is no previous step")
+	}
+	s.Step = s.History[len(s.History)-1]
+	s.History = s.History[:len(s.History)-1]
+	return s.save()
+}
+
+// Finish submits the draft once all steps have been completed
+// and removes the saved session
+func (s *Session) Finish() (Draft, error) {
+	if !s.Complete() {
+		step, _ := s.Current()
+		return Draft{}, inputErrorf("the %s step hasn't been completed", step.Name)
+	}
+	d, err := s.wizard.Finish(s.db, s.Draft)
+	if err != nil {
+		return Draft{}, err
+	}
+	if _, _, err = s.db.TicketWizardDelete(s.GuildID, s.ChannelID); err != nil {
+		return Draft{}, errors.Wrap(err, "failed to remove wizard")
+	}
+	return d, nil
+}
+
+// Cancel removes the saved session without submitting the draft
+func (s *Session) Cancel() error {
+	if _, _, err := s.db.TicketWizardDelete(s.GuildID, s.ChannelID); err != nil {
+		return errors.Wrap(err, "failed to remove wizard")
+	}
+	return nil
+}
+
+// advance moves onto the next step
+func (s *Session) advance() {
+	s.History = append(s.History, s.Step)
+	s.Step++
+}
+
+// save saves the progress of the session to the database
+func (s *Session) save() error {
+	history, draft, err := s.encode()
+	if err != nil {
+		return err
+	}
+	if _, ok, err := s.db.TicketWizardEdit(s.GuildID, s.ChannelID, s.Step, history, draft); err != nil {
+		return errors.Wrap(err, "failed to save wizard")
+	} else if !ok {
+		return errors.New("wizard no longer exists")
+	}
+	return nil
+}
+
+// encode json encodes the history and draft of the session
+func (s *Session) encode() (string, string, error) {
+	history, err := json.Marshal(s.History)
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to encode history")
+	}
+	draft, err := json.Marshal(s.Draft)
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to encode draft")
+	}
+	return string(history), string(draft), nil
+}
@@ -0,0 +1,209 @@
+package wizard
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Kappeh/RecordBot/database"
+	"github.com/pkg/errors"
+)
+
+// testDB is the database shared by the tests
+// The database package only allows one database to be opened
+var testDB *database.Database
+
+const testGuildID = "1"
+
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "wizard")
+	if err != nil {
+		panic(err)
+	}
+	testDB, err = database.Open(filepath.Join(dir, "database.db"))
+	if err != nil {
+		os.RemoveAll(dir)
+		panic(err)
+	}
+	if err = seed(testDB); err != nil {
+		testDB.Close()
+		os.RemoveAll(dir)
+		panic(err)
+	}
+	code := m.Run()
+	testDB.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// seed creates the edition and build class builds are submitted in
+func seed(db *database.Database) error {
+	if _, err := db.EditionCreate("Java", "Java Edition"); err != nil {
+		return errors.Wrap(err, "failed to create edition")
+	}
+	if _, err := db.BuildClassCreate("Piston Door", "Piston doors", "", database.BuildClassRuleDoor); err != nil {
+		return errors.Wrap(err, "failed to create build class")
+	}
+	return nil
+}
+
+// openTicket creates a ticket channel of a type made by user 5
+func openTicket(t *testing.T, channelID string, ticketType database.TicketType) {
+	t.Helper()
+	if _, _, err := testDB.GuildTicketChannelCreate(testGuildID, channelID, ticketType, "5"); err != nil {
+		t.Fatalf("failed to create ticket channel: %v", err)
+	}
+}
+
+// resume resumes the session of a channel, which must exist
+func resume(t *testing.T, channelID string) *Session {
+	t.Helper()
+	s, ok, err := Resume(testDB, testGuildID, channelID)
+	if err != nil || !ok {
+		t.Fatalf("failed to resume wizard: %v, %v", ok, err)
+	}
+	return s
+}
+
+// isInputError checks whether an error is shown to the user
+func isInputError(err error) bool {
+	_, ok := errors.Cause(err).(*InputError)
+	return ok
+}
+
+func TestStart(t *testing.T) {
+	openTicket(t, "100", database.TicketGeneral)
+	openTicket(t, "101", database.TicketSubmitBuild)
+	tests := []struct {
+		name      string
+		channelID string
+		ok        bool
+	}{
+		{"not a ticket", "102", false},
+		{"ticket without a wizard", "100", false},
+		{"ticket with a wizard", "101", true},
+		{"already started", "101", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, ok, err := Start(testDB, testGuildID, tt.channelID)
+			if err != nil || ok != tt.ok {
+				t.Fatalf("expected %v, got %v, %v", tt.ok, ok, err)
+			}
+			if !ok {
+				return
+			}
+			if step, ok := s.Current(); !ok || step.Name != "edition" || s.Draft.Build.SubmitterID != "5" {
+				t.Fatalf("expected the draft of user 5 on the first step, got %+v", s)
+			}
+		})
+	}
+}
+
+func TestSession(t *testing.T) {
+	const channelID = "110"
+	openTicket(t, channelID, database.TicketSubmitBuild)
+	if _, ok, err := Start(testDB, testGuildID, channelID); err != nil || !ok {
+		t.Fatalf("failed to start wizard: %v, %v", ok, err)
+	}
+	// Each action is taken on a session resumed from the database,
+	// as if the bot had restarted in between
+	actions := []struct {
+		name    string
+		act     func(s *Session) error
+		invalid bool
+		step    string
+		history []int
+	}{
+		{"edition", func(s *Session) error { return s.Submit("java") }, false, "class", []int{0}},
+		{"unknown class", func(s *Session) error { return s.Submit("nope") }, true, "class", []int{0}},
+		{"class", func(s *Session) error { return s.Submit("piston door") }, false, "name", []int{0, 1}},
+		{"back", func(s *Session) error { return s.Back() }, false, "class", []int{0}},
+		{"class again", func(s *Session) error { return s.Submit("Piston Door") }, false, "name", []int{0, 1}},
+		{"skip a required step", func(s *Session) error { return s.Skip() }, true, "name", []int{0, 1}},
+		{"name", func(s *Session) error { return s.Submit(" Piston Door ") }, false, "description", []int{0, 1, 2}},
+		{"skip an optional step", func(s *Session) error { return s.Skip() }, false, "creators", []int{0, 1, 2, 3}},
+		{"creators", func(s *Session) error { return s.Submit("alice,, bob") }, false, "dimensions", []int{0, 1, 2, 3, 4}},
+		{"bad dimensions", func(s *Session) error { return s.Submit("3x4") }, true, "dimensions", []int{0, 1, 2, 3, 4}},
+		{"dimensions", func(s *Session) error { return s.Submit("3x4x5") }, false, "normal durations", []int{0, 1, 2, 3, 4, 5}},
+		{"finish early", func(s *Session) error { _, err := s.Finish(); return err }, true, "normal durations", []int{0, 1, 2, 3, 4, 5}},
+		{"durations", func(s *Session) error { return s.Submit("1.35s 13.5rt") }, false, "visible durations", []int{0, 1, 2, 3, 4, 5, 6}},
+	}
+	for _, a := range actions {
+		s := resume(t, channelID)
+		err := a.act(s)
+		if a.invalid != isInputError(err) || (!a.invalid && err != nil) {
+			t.Fatalf("%s: expected invalid input to be %v, got %v", a.name, a.invalid, err)
+		}
+		s = resume(t, channelID)
+		if step, _ := s.Current(); step.Name != a.step || !reflect.DeepEqual(s.History, a.history) {
+			t.Fatalf("%s: expected step %q after %v, got %q after %v", a.name, a.step, a.history, step.Name, s.History)
+		}
+	}
+
+	// The draft was saved along with the steps
+	s := resume(t, channelID)
+	b := s.Draft.Build
+	if b.Name != "Piston Door" || b.Creators != "alice, bob" || b.Width != 3 || b.Depth != 5 ||
+		b.NormalCloseDuration != 27 || b.NormalOpenDuration != 27 || b.SubmitterID != "5" {
+		t.Fatalf("unexpected draft %+v", b)
+	}
+	for !s.Complete() {
+		if err := s.Skip(); err != nil {
+			t.Fatalf("failed to skip: %v", err)
+		}
+	}
+	if err := s.Submit("too late"); !isInputError(err) {
+		t.Fatalf("expected input after the last step to be invalid, got %v", err)
+	}
+	d, err := resume(t, channelID).Finish()
+	if err != nil {
+		t.Fatalf("failed to finish: %v", err)
+	}
+	if b, ok, err := testDB.Build(d.Build.ID); err != nil || !ok || b.Name != "Piston Door" || b.Verified {
+		t.Fatalf("expected an unverified build to be submitted, got %+v, %v, %v", b, ok, err)
+	}
+	if _, ok, err := Resume(testDB, testGuildID, channelID); err != nil || ok {
+		t.Fatalf("expected the wizard to be removed, got %v, %v", ok, err)
+	}
+}
+
+func TestFinishInvalid(t *testing.T) {
+	const channelID = "120"
+	openTicket(t, channelID, database.TicketSubmitBuild)
+	s, ok, err := Start(testDB, testGuildID, channelID)
+	if err != nil || !ok {
+		t.Fatalf("failed to start wizard: %v, %v", ok, err)
+	}
+	// Doors need their normal times, which are skipped
+	for _, input := range []string{"java", "piston door", "Piston Door", "", "alice", "3x4x5"} {
+		if input == "" {
+			err = s.Skip()
+		} else {
+			err = s.Submit(input)
+		}
+		if err != nil {
+			t.Fatalf("failed to give %q: %v", input, err)
+		}
+	}
+	for !s.Complete() {
+		if err = s.Skip(); err != nil {
+			t.Fatalf("failed to skip: %v", err)
+		}
+	}
+	if _, err = s.Finish(); !isInputError(err) {
+		t.Fatalf("expected the build to be rejected, got %v", err)
+	}
+	// The wizard is kept so that the steps can be fixed
+	if s = resume(t, channelID); !s.Complete() {
+		t.Fatalf("expected the wizard to be kept")
+	}
+	if err = s.Cancel(); err != nil {
+		t.Fatalf("failed to cancel: %v", err)
+	}
+	if _, ok, err = Resume(testDB, testGuildID, channelID); err != nil || ok {
+		t.Fatalf("expected the wizard to be removed, got %v, %v", ok, err)
+	}
+}
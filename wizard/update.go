@@ -0,0 +1,78 @@
+package wizard
+
+import (
+	"github.com/Kappeh/RecordBot/database"
+)
+
+func init() {
+	register(&Wizard{
+		TicketType: database.TicketSubmitBuildUpdate,
+		Steps: append([]Step{{
+			Name:   "build",
+			Prompt: "Which build would you like to update? Give the build's id or name.",
+			Apply:  selectBuild,
+		}}, optional(buildSteps())...),
+		Init:   initBuild,
+		Finish: finishBuild,
+	})
+	register(&Wizard{
+		TicketType: database.TicketSubmitRecordUpdate,
+		Steps: append([]Step{{
+			Name:   "record",
+			Prompt: "Which record would you like to update? Give the record's id or name.",
+			Apply:  selectRecord,
+		}}, optional(recordSteps())...),
+		Init:   initRecord,
+		Finish: finishRecord,
+	})
+}
+
+// optional makes every step optional so that prefilled
+// information can be kept by skipping the step
+func optional(steps []Step) []Step {
+	for i := range steps {
+		steps[i].Optional = true
+	}
+	return steps
+}
+
+// selectBuild prefills the draft with an existing build
+// as an update request for that build
+func selectBuild(db *database.Database, d *Draft, input string) error {
+	b, err := findBuild(db, input)
+	if err != nil {
+		return err
+	}
+	submitterID := d.Build.SubmitterID
+	d.Build = b
+	d.Build.ID = ""
+	d.Build.Verified = false
+	d.Build.VerifierID = "0"
+	d.Build.VerifiedTimestamp = database.Timestamp{}
+	d.Build.Reported = false
+	d.Build.ReporterID = "0"
+	d.Build.ReportedTimestamp = database.Timestamp{}
+	d.Build.UpdateRequest = true
+	d.Build.UpdateRequestBuildID = b.ID
+	d.Build.SubmitterID = submitterID
+	return nil
+}
+
+// selectRecord prefills the draft with an existing record
+// as an update request for that record
+func selectRecord(db *database.Database, d *Draft, input string) error {
+	r, err := findRecord(db, input)
+	if err != nil {
+		return err
+	}
+	submitterID := d.Record.SubmitterID
+	d.Record = r
+	d.Record.ID = ""
+	d.Record.Verified = false
+	d.Record.VerifierID = "0"
+	d.Record.VerifiedTimestamp = database.Timestamp{}
+	d.Record.UpdateRequest = true
+	d.Record.UpdateRequestRecordID = r.ID
+	d.Record.SubmitterID = submitterID
+	return nil
+}
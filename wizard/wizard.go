@@ -0,0 +1,80 @@
+package wizard
+
+import (
+	"fmt"
+
+	"github.com/Kappeh/RecordBot/database"
+)
+
+// Draft is the information that a wizard builds up
+// as a user progresses through it
+type Draft struct {
+	// Build is the build being submitted or updated
+	Build database.Build
+	// Record is the record being submitted or updated
+	Record database.Record
+}
+
+// Step is a single step of a wizard which asks the user
+// for one piece of information
+type Step struct {
+	// Name is a short name of the step
+	Name string
+	// Prompt is the message shown to the user when
+	// the step is reached
+	Prompt string
+	// Optional indicates whether the step can be skipped
+	Optional bool
+	// Apply validates the user's input for the step
+	// and applies it to the draft. An InputError should be
+	// returned if the input is invalid
+	Apply func(db *database.Database, d *Draft, input string) error
+}
+
+// Wizard is an ordered set of steps which guides a user
+// through a submission within a ticket
+type Wizard struct {
+	// TicketType is the type of ticket the wizard is ran in
+	TicketType database.TicketType
+	// Steps are the steps of the wizard in the order
+	// they are presented to the user
+	Steps []Step
+	// Init prepares the draft for a newly opened ticket
+	Init func(db *database.Database, d *Draft, ticket database.GuildTicketChannel) error
+	// Finish submits a completed draft and returns the
+	// draft containing the rows that were created
+	Finish func(db *database.Database, d Draft) (Draft, error)
+}
+
+// InputError is an error caused by a user giving a wizard
+// input that can't be used. The message is suitable
+// to be shown to the user
+type InputError struct {
+	// Message describes why the input couldn't be used
+	Message string
+}
+
+// Error gets the message of the input error
+func (e *InputError) Error() string {
+	return e.Message
+}
+
+// inputErrorf creates an InputError with a formatted message
+func inputErrorf(format string, args ...interface{}) error {
+	return &InputError{Message: fmt.Sprintf(format, args...)}
+}
+
+// wizards contains the wizard for each ticket type that has one
+var wizards = map[database.TicketType]*Wizard{}
+
+// register adds a wizard so that it is used for tickets
+// of the wizard's ticket type
+func register(w *Wizard) {
+	wizards[w.TicketType] = w
+}
+
+// For gets the wizard used for a type of ticket
+func For(ticketType database.TicketType) (*Wizard, bool) {
+	w, ok := wizards[ticketType]
+	return w, ok
+}